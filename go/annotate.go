@@ -0,0 +1,104 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"path"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// queryCallerKey is the key in context of the caller of a query, found before the query leaves the caller's
+// goroutine, e.g. to be coalesced.
+const queryCallerKey = TContextKey("queryCallerKey")
+
+// driverPackage is the package of the driver, whose frames are skipped to find the caller of a query.
+const driverPackage = "github.com/prequel-co/athenadriver/go."
+
+// withQueryCaller is to remember the caller of a query in ctx, if queries are annotated.
+func (c *Connection) withQueryCaller(ctx context.Context) context.Context {
+	if !c.connector.config.IsQueryAnnotation() || ctx.Value(queryCallerKey) != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, queryCallerKey, queryCaller())
+}
+
+// queryCaller is to get the function which called database/sql or the driver, like `reports.(*Job).Run`.
+func queryCaller() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		skipped := strings.HasPrefix(frame.Function, "runtime.") ||
+			strings.HasPrefix(frame.Function, "database/sql.") ||
+			strings.HasPrefix(frame.Function, driverPackage) && !strings.HasSuffix(frame.File, "_test.go")
+		if !skipped {
+			return path.Base(frame.Function)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// annotateQuery is to prepend the comment of Config.SetQueryAnnotation to a query, unless it would make the
+// query too long.
+func (c *Connection) annotateQuery(ctx context.Context, query string) string {
+	if !c.connector.config.IsQueryAnnotation() {
+		return query
+	}
+	var annotations []string
+	if service := c.connector.config.GetAnnotationService(); service != "" {
+		annotations = append(annotations, "service="+sanitizeAnnotation(service))
+	}
+	if values, ok := ctx.Value(QueryAnnotationsKey).(map[string]string); ok {
+		keys := make([]string, 0, len(values))
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			annotations = append(annotations, sanitizeAnnotation(k)+"="+sanitizeAnnotation(values[k]))
+		}
+	}
+	caller, ok := ctx.Value(queryCallerKey).(string)
+	if !ok {
+		caller = queryCaller()
+	}
+	if caller != "" {
+		annotations = append(annotations, "caller="+sanitizeAnnotation(caller))
+	}
+	if len(annotations) == 0 {
+		return query
+	}
+	annotated := "/* " + strings.Join(annotations, ", ") + " */ " + query
+	if len(annotated) > MAXQueryStringLength {
+		return query
+	}
+	return annotated
+}
+
+// sanitizeAnnotation is to keep an annotation from ending the comment or breaking its format.
+func sanitizeAnnotation(s string) string {
+	return strings.NewReplacer("*/", "* /", "/*", "/ *", ",", " ", "=", " ", "\n", " ", "\r", " ").Replace(s)
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnection_annotateQuery(t *testing.T) {
+	c := createConnectionFixture()
+	ctx := context.Background()
+	assert.Equal(t, "SELECT 1", c.annotateQuery(ctx, "SELECT 1"))
+
+	c.connector.config.SetQueryAnnotation(true)
+	assert.Equal(t, "/* caller=go.TestConnection_annotateQuery */ SELECT 1", c.annotateQuery(ctx, "SELECT 1"))
+
+	c.connector.config.SetAnnotationService("billing")
+	ctx = context.WithValue(ctx, QueryAnnotationsKey, map[string]string{"trace_id": "abc", "team": "x*/, y=z"})
+	ctx = context.WithValue(ctx, queryCallerKey, "reports.(*Job).Run")
+	assert.Equal(t, "/* service=billing, team=x* /  y z, trace_id=abc, caller=reports.(*Job).Run */ SELECT 1",
+		c.annotateQuery(ctx, "SELECT 1"))
+
+	long := "SELECT '" + strings.Repeat("x", MAXQueryStringLength-10) + "'"
+	assert.Equal(t, long, c.annotateQuery(ctx, long))
+}
+
+func TestConnection_QueryContextAnnotation(t *testing.T) {
+	c := createConnectionFixture()
+	c.connector.config.SetQueryAnnotation(true)
+	c.connector.config.SetAnnotationService("billing")
+	_, err := c.QueryContext(context.Background(), "SELECTQueryContext_OK", []driver.NamedValue{})
+	assert.Nil(t, err)
+	m := c.athenaClient.(*mockAthenaClient)
+	assert.Equal(t, "/* service=billing, caller=go.TestConnection_QueryContextAnnotation */ "+
+		"SELECTQueryContext_OK", *m.lastStartInput.QueryString)
+}
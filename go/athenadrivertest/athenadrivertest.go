@@ -0,0 +1,441 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package athenadrivertest provides a fake of the Athena APIs for unit tests of code querying Athena with
+// athenadriver, without an AWS account. Queries are answered with the result sets registered for them:
+//
+//	client := athenadrivertest.NewClient()
+//	client.OnQuery("SELECT id, name FROM users").Return(
+//		[]athenadrivertest.Column{{Name: "id", Type: "integer"}, {Name: "name", Type: "varchar"}},
+//		[]interface{}{1, "alice"},
+//	)
+//	db := client.Open(nil)
+package athenadrivertest
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	drv "github.com/prequel-co/athenadriver/go"
+)
+
+// pageSize is the number of rows per page of GetQueryResults, like Athena.
+const pageSize = 1000
+
+var (
+	// ErrUnexpectedQuery is returned by StartQueryExecution for a query no fixture is registered for.
+	ErrUnexpectedQuery = errors.New("no fixture is registered for the query")
+	// ErrUnknownQueryID is returned for a QID which was not returned by StartQueryExecution.
+	ErrUnknownQueryID = errors.New("unknown query execution ID")
+)
+
+// Column is the name and Athena type of a column of a result set, e.g. {"id", "integer"}.
+type Column struct {
+	Name string
+	Type string
+}
+
+// Execution is a query execution started with the Client.
+type Execution struct {
+	QueryID   string
+	Query     string
+	Params    []string
+	Database  string
+	Catalog   string
+	WorkGroup string
+}
+
+// Fixture is how a Client answers the queries it matches. By default, a query succeeds without rows.
+type Fixture struct {
+	query         string
+	pattern       *regexp.Regexp
+	params        []string
+	columns       []Column
+	rows          [][]*string
+	updateCount   *int64
+	reason        string
+	statementType athenatypes.StatementType
+	times         int
+}
+
+// Return is to answer the query with a result set. Values are rendered like Athena does for the column types,
+// e.g. a time.Time as 2006-01-02 15:04:05.000 for a timestamp column. nil is NULL.
+func (f *Fixture) Return(columns []Column, rows ...[]interface{}) *Fixture {
+	f.columns = columns
+	f.rows = make([][]*string, len(rows))
+	for i, row := range rows {
+		f.rows[i] = make([]*string, len(row))
+		for j, v := range row {
+			if v != nil {
+				columnType := ""
+				if j < len(columns) {
+					columnType = columns[j].Type
+				}
+				f.rows[i][j] = aws.String(formatValue(v, columnType))
+			}
+		}
+	}
+	return f
+}
+
+// ReturnUpdateCount is to answer the query with the number of rows it wrote, like for INSERT INTO, which
+// Result.RowsAffected returns.
+func (f *Fixture) ReturnUpdateCount(n int64) *Fixture {
+	f.updateCount = &n
+	f.columns = []Column{{Name: "rows", Type: "bigint"}}
+	return f
+}
+
+// Fail is to fail the query execution with a reason, like a syntax error.
+func (f *Fixture) Fail(reason string) *Fixture {
+	f.reason = reason
+	return f
+}
+
+// WithParams is to only match the query when it has these execution parameters.
+func (f *Fixture) WithParams(params ...string) *Fixture {
+	f.params = params
+	return f
+}
+
+// WithStatementType is to set the statement type of the query, which is guessed from the first keyword by default.
+func (f *Fixture) WithStatementType(t athenatypes.StatementType) *Fixture {
+	f.statementType = t
+	return f
+}
+
+// Times is to only match the query n times, after which the next matching fixture is used.
+func (f *Fixture) Times(n int) *Fixture {
+	f.times = n
+	return f
+}
+
+func (f *Fixture) matches(query string, params []string) bool {
+	if f.times < 0 {
+		return false
+	}
+	if f.params != nil && strings.Join(f.params, "\x00") != strings.Join(params, "\x00") {
+		return false
+	}
+	if f.pattern != nil {
+		return f.pattern.MatchString(query)
+	}
+	return f.query == normalize(query)
+}
+
+// execution is the state of a query execution.
+type execution struct {
+	Execution
+	fixture *Fixture
+	state   athenatypes.QueryExecutionState
+}
+
+// Client is a fake of the Athena APIs used by athenadriver to run queries: StartQueryExecution, GetQueryExecution,
+// GetQueryResults, StopQueryExecution, GetQueryRuntimeStatistics, GetWorkGroup and CreateWorkGroup. The other
+// APIs are delegated to the embedded AthenaClient, which is nil by default.
+type Client struct {
+	drv.AthenaClient
+
+	mu         sync.Mutex
+	fixtures   []*Fixture
+	executions []*execution
+	byID       map[string]*execution
+}
+
+// NewClient is to create a Client without fixtures.
+func NewClient() *Client {
+	return &Client{byID: map[string]*execution{}}
+}
+
+// OnQuery is to register a fixture for a query, compared after collapsing whitespace. Fixtures are matched in
+// the order they are registered.
+func (c *Client) OnQuery(query string) *Fixture {
+	return c.register(&Fixture{query: normalize(query)})
+}
+
+// OnQueryMatching is to register a fixture for the queries matching a regular expression.
+func (c *Client) OnQueryMatching(pattern string) *Fixture {
+	return c.register(&Fixture{pattern: regexp.MustCompile(pattern)})
+}
+
+func (c *Client) register(f *Fixture) *Fixture {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fixtures = append(c.fixtures, f)
+	return f
+}
+
+// Open is to open a database whose connections query the client, with the config, or a no-op config if it is nil.
+func (c *Client) Open(config *drv.Config) *sql.DB {
+	if config == nil {
+		config = drv.NewNoOpsConfig()
+	}
+	return sql.OpenDB(drv.NewSQLConnectorWithClient(config, c))
+}
+
+// Executions is to get the query executions started so far, in order.
+func (c *Client) Executions() []Execution {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	executions := make([]Execution, len(c.executions))
+	for i, e := range c.executions {
+		executions[i] = e.Execution
+	}
+	return executions
+}
+
+// Reset is to remove the fixtures and the executions.
+func (c *Client) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fixtures = nil
+	c.executions = nil
+	c.byID = map[string]*execution{}
+}
+
+// StartQueryExecution is to start a query execution answered by the first fixture matching the query.
+func (c *Client) StartQueryExecution(_ context.Context, input *athena.StartQueryExecutionInput,
+	_ ...func(*athena.Options)) (*athena.StartQueryExecutionOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	query := aws.ToString(input.QueryString)
+	var fixture *Fixture
+	for _, f := range c.fixtures {
+		if f.matches(query, input.ExecutionParameters) {
+			fixture = f
+			break
+		}
+	}
+	if fixture == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnexpectedQuery, query)
+	}
+	if fixture.times > 0 {
+		if fixture.times--; fixture.times == 0 {
+			fixture.times = -1
+		}
+	}
+	e := &execution{
+		Execution: Execution{
+			QueryID:   fmt.Sprintf("00000000-0000-4000-8000-%012d", len(c.executions)+1),
+			Query:     query,
+			Params:    input.ExecutionParameters,
+			WorkGroup: aws.ToString(input.WorkGroup),
+		},
+		fixture: fixture,
+		state:   athenatypes.QueryExecutionStateSucceeded,
+	}
+	if input.QueryExecutionContext != nil {
+		e.Database = aws.ToString(input.QueryExecutionContext.Database)
+		e.Catalog = aws.ToString(input.QueryExecutionContext.Catalog)
+	}
+	if fixture.reason != "" {
+		e.state = athenatypes.QueryExecutionStateFailed
+	}
+	c.executions = append(c.executions, e)
+	c.byID[e.QueryID] = e
+	return &athena.StartQueryExecutionOutput{QueryExecutionId: aws.String(e.QueryID)}, nil
+}
+
+func (c *Client) get(queryID *string) (*execution, error) {
+	e, ok := c.byID[aws.ToString(queryID)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownQueryID, aws.ToString(queryID))
+	}
+	return e, nil
+}
+
+// GetQueryExecution is to get the status of a query execution, which is final as soon as it is started.
+func (c *Client) GetQueryExecution(_ context.Context, input *athena.GetQueryExecutionInput,
+	_ ...func(*athena.Options)) (*athena.GetQueryExecutionOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, err := c.get(input.QueryExecutionId)
+	if err != nil {
+		return nil, err
+	}
+	status := &athenatypes.QueryExecutionStatus{State: e.state}
+	if e.state == athenatypes.QueryExecutionStateFailed {
+		status.StateChangeReason = aws.String(e.fixture.reason)
+	}
+	return &athena.GetQueryExecutionOutput{QueryExecution: &athenatypes.QueryExecution{
+		QueryExecutionId: aws.String(e.QueryID),
+		Query:            aws.String(e.Query),
+		QueryExecutionContext: &athenatypes.QueryExecutionContext{
+			Database: aws.String(e.Database),
+			Catalog:  aws.String(e.Catalog),
+		},
+		ExecutionParameters: e.Params,
+		StatementType:       e.statementType(),
+		Status:              status,
+		Statistics:          &athenatypes.QueryExecutionStatistics{DataScannedInBytes: aws.Int64(0)},
+		WorkGroup:           aws.String(e.WorkGroup),
+	}}, nil
+}
+
+// GetQueryResults is to get a page of the result set of a query execution. The first page of DML statements
+// starts with a header row, like in Athena.
+func (c *Client) GetQueryResults(_ context.Context, input *athena.GetQueryResultsInput,
+	_ ...func(*athena.Options)) (*athena.GetQueryResultsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, err := c.get(input.QueryExecutionId)
+	if err != nil {
+		return nil, err
+	}
+	if e.state != athenatypes.QueryExecutionStateSucceeded {
+		return nil, fmt.Errorf("query %s has not succeeded", e.QueryID)
+	}
+	f := e.fixture
+	columnInfo := make([]athenatypes.ColumnInfo, len(f.columns))
+	header := make([]athenatypes.Datum, len(f.columns))
+	for i, col := range f.columns {
+		columnInfo[i] = athenatypes.ColumnInfo{Name: aws.String(col.Name), Label: aws.String(col.Name),
+			Type: aws.String(col.Type), Nullable: athenatypes.ColumnNullableUnknown}
+		header[i] = athenatypes.Datum{VarCharValue: aws.String(col.Name)}
+	}
+
+	var rows []athenatypes.Row
+	start := 0
+	if input.NextToken != nil {
+		if start, err = strconv.Atoi(*input.NextToken); err != nil {
+			return nil, fmt.Errorf("invalid token %s", *input.NextToken)
+		}
+	} else if e.statementType() == athenatypes.StatementTypeDml && f.updateCount == nil && len(f.columns) > 0 {
+		rows = append(rows, athenatypes.Row{Data: header})
+	}
+	end := start + pageSize - len(rows)
+	if end > len(f.rows) {
+		end = len(f.rows)
+	}
+	for _, row := range f.rows[start:end] {
+		data := make([]athenatypes.Datum, len(row))
+		for i, v := range row {
+			data[i] = athenatypes.Datum{VarCharValue: v}
+		}
+		rows = append(rows, athenatypes.Row{Data: data})
+	}
+	out := &athena.GetQueryResultsOutput{
+		ResultSet: &athenatypes.ResultSet{
+			ResultSetMetadata: &athenatypes.ResultSetMetadata{ColumnInfo: columnInfo},
+			Rows:              rows,
+		},
+		UpdateCount: f.updateCount,
+	}
+	if end < len(f.rows) {
+		out.NextToken = aws.String(strconv.Itoa(end))
+	}
+	return out, nil
+}
+
+// StopQueryExecution is to cancel a query execution.
+func (c *Client) StopQueryExecution(_ context.Context, input *athena.StopQueryExecutionInput,
+	_ ...func(*athena.Options)) (*athena.StopQueryExecutionOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, err := c.get(input.QueryExecutionId)
+	if err != nil {
+		return nil, err
+	}
+	e.state = athenatypes.QueryExecutionStateCancelled
+	return &athena.StopQueryExecutionOutput{}, nil
+}
+
+// GetQueryRuntimeStatistics is to get the number of rows a query execution wrote, which is its update count.
+func (c *Client) GetQueryRuntimeStatistics(_ context.Context, input *athena.GetQueryRuntimeStatisticsInput,
+	_ ...func(*athena.Options)) (*athena.GetQueryRuntimeStatisticsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, err := c.get(input.QueryExecutionId)
+	if err != nil {
+		return nil, err
+	}
+	rows := &athenatypes.QueryRuntimeStatisticsRows{OutputRows: e.fixture.updateCount}
+	return &athena.GetQueryRuntimeStatisticsOutput{
+		QueryRuntimeStatistics: &athenatypes.QueryRuntimeStatistics{Rows: rows},
+	}, nil
+}
+
+// GetWorkGroup is to get an enabled workgroup of any name.
+func (c *Client) GetWorkGroup(_ context.Context, input *athena.GetWorkGroupInput,
+	_ ...func(*athena.Options)) (*athena.GetWorkGroupOutput, error) {
+	return &athena.GetWorkGroupOutput{WorkGroup: &athenatypes.WorkGroup{
+		Name:  input.WorkGroup,
+		State: athenatypes.WorkGroupStateEnabled,
+	}}, nil
+}
+
+// CreateWorkGroup is to pretend to create a workgroup.
+func (c *Client) CreateWorkGroup(context.Context, *athena.CreateWorkGroupInput,
+	...func(*athena.Options)) (*athena.CreateWorkGroupOutput, error) {
+	return &athena.CreateWorkGroupOutput{}, nil
+}
+
+// statementType is the statement type of the fixture, or the one guessed from the first keyword of the query.
+func (e *execution) statementType() athenatypes.StatementType {
+	if e.fixture.statementType != "" {
+		return e.fixture.statementType
+	}
+	keyword, _, _ := strings.Cut(strings.ToUpper(strings.TrimSpace(e.Query)), " ")
+	switch keyword {
+	case "CREATE", "DROP", "ALTER", "SHOW", "DESCRIBE", "MSCK":
+		return athenatypes.StatementTypeDdl
+	}
+	return athenatypes.StatementTypeDml
+}
+
+// normalize is to collapse the whitespace of a query.
+func normalize(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// formatValue is to render a value the way Athena returns it for a column type.
+func formatValue(v interface{}, columnType string) string {
+	switch vv := v.(type) {
+	case string:
+		return vv
+	case []byte:
+		s := hex.EncodeToString(vv)
+		var sb strings.Builder
+		for i := 0; i < len(s); i += 2 {
+			if i > 0 {
+				sb.WriteByte(' ')
+			}
+			sb.WriteString(s[i : i+2])
+		}
+		return sb.String()
+	case time.Time:
+		if columnType == "date" {
+			return vv.Format(drv.DateUniXFormat)
+		}
+		return vv.Format(drv.TimestampUniXFormat)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadrivertest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	drv "github.com/prequel-co/athenadriver/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_Query(t *testing.T) {
+	client := NewClient()
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 6000000, time.UTC)
+	client.OnQuery("SELECT id, name, created, data FROM users").Return(
+		[]Column{{Name: "id", Type: "integer"}, {Name: "name", Type: "varchar"},
+			{Name: "created", Type: "timestamp"}, {Name: "data", Type: "varbinary"}},
+		[]interface{}{1, "alice", ts, []byte{0x0a, 0xff}},
+		[]interface{}{2, nil, ts, nil},
+	)
+	db := client.Open(nil)
+	defer db.Close()
+
+	rows, err := db.Query("SELECT id, name, created, data\n  FROM users")
+	assert.Nil(t, err)
+	type user struct {
+		ID      int
+		Name    string
+		Created time.Time
+		Data    []byte
+	}
+	users, err := drv.Collect[user](rows)
+	assert.Nil(t, err)
+	assert.Len(t, users, 2)
+	assert.Equal(t, 1, users[0].ID)
+	assert.Equal(t, "alice", users[0].Name)
+	assert.True(t, ts.Equal(users[0].Created))
+	assert.Equal(t, []byte{0x0a, 0xff}, users[0].Data)
+	// NULL is an empty string with the no-op config, see Config.SetMissingAsEmptyString
+	assert.Equal(t, "", users[1].Name)
+
+	executions := client.Executions()
+	assert.Len(t, executions, 1)
+	assert.Equal(t, "SELECT id, name, created, data\n  FROM users", executions[0].Query)
+	assert.Equal(t, drv.DefaultDBName, executions[0].Database)
+	assert.True(t, drv.IsQID(executions[0].QueryID))
+}
+
+func TestClient_Pages(t *testing.T) {
+	client := NewClient()
+	var rows [][]interface{}
+	for i := 0; i < 2500; i++ {
+		rows = append(rows, []interface{}{i})
+	}
+	client.OnQueryMatching(`^SELECT n FROM numbers`).Return([]Column{{Name: "n", Type: "bigint"}}, rows...)
+	db := client.Open(nil)
+	defer db.Close()
+
+	r, err := db.Query("SELECT n FROM numbers ORDER BY n")
+	assert.Nil(t, err)
+	numbers, err := drv.Collect[int64](r)
+	assert.Nil(t, err)
+	assert.Len(t, numbers, 2500)
+	assert.Equal(t, int64(2499), numbers[2499])
+}
+
+func TestClient_Exec(t *testing.T) {
+	client := NewClient()
+	client.OnQuery("INSERT INTO t VALUES (1), (2)").ReturnUpdateCount(2)
+	client.OnQuery("CREATE TABLE t (id int)")
+	db := client.Open(nil)
+	defer db.Close()
+
+	_, err := db.Exec("CREATE TABLE t (id int)")
+	assert.Nil(t, err)
+	result, err := db.Exec("INSERT INTO t VALUES (1), (2)")
+	assert.Nil(t, err)
+	n, err := result.RowsAffected()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), n)
+}
+
+func TestClient_Fixtures(t *testing.T) {
+	client := NewClient()
+	client.OnQuery("SELECT ?").WithParams("1").Return([]Column{{Name: "_col0", Type: "integer"}}, []interface{}{1})
+	client.OnQuery("SELECT ?").Return([]Column{{Name: "_col0", Type: "integer"}}, []interface{}{0})
+	client.OnQuery("SELECT broken").Fail("SYNTAX_ERROR: line 1:8: Column 'broken' cannot be resolved")
+	client.OnQuery("SELECT once").Times(1)
+	db := client.Open(nil)
+	defer db.Close()
+	ctx := context.Background()
+
+	var n int
+	assert.Nil(t, db.QueryRowContext(ctx, "SELECT ?", 1).Scan(&n))
+	assert.Equal(t, 1, n)
+	assert.Nil(t, db.QueryRowContext(ctx, "SELECT ?", 2).Scan(&n))
+	assert.Equal(t, 0, n)
+	assert.Equal(t, []string{"2"}, client.Executions()[1].Params)
+
+	_, err := db.QueryContext(ctx, "SELECT broken")
+	assert.NotNil(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "SYNTAX_ERROR"))
+
+	_, err = db.ExecContext(ctx, "SELECT once")
+	assert.Nil(t, err)
+	_, err = db.ExecContext(ctx, "SELECT once")
+	assert.True(t, errors.Is(err, ErrUnexpectedQuery))
+
+	client.Reset()
+	assert.Empty(t, client.Executions())
+	_, err = db.QueryContext(ctx, "SELECT ?", 1)
+	assert.True(t, errors.Is(err, ErrUnexpectedQuery))
+}
+
+func TestClient_StatementType(t *testing.T) {
+	client := NewClient()
+	client.OnQuery("SHOW TABLES").Return([]Column{{Name: "tab_name", Type: "string"}},
+		[]interface{}{"a"}, []interface{}{"b"})
+	client.OnQuery("EXPLAIN SELECT 1").WithStatementType(athenatypes.StatementTypeUtility).
+		Return([]Column{{Name: "Query Plan", Type: "varchar"}}, []interface{}{"plan"})
+	db := client.Open(nil)
+	defer db.Close()
+
+	rows, err := db.Query("SHOW TABLES")
+	assert.Nil(t, err)
+	tables, err := drv.Collect[string](rows)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b"}, tables)
+
+	rows, err = db.Query("EXPLAIN SELECT 1")
+	assert.Nil(t, err)
+	plan, err := drv.Collect[string](rows)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"plan"}, plan)
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"go.uber.org/zap"
+)
+
+var (
+	auditSinksMu sync.RWMutex
+	auditSinks   = map[string]AuditSink{}
+)
+
+// AuditRecord is the audit record of a query execution which has finished, succeeded or not.
+type AuditRecord struct {
+	Time time.Time `json:"time"`
+	// Principal is the value of PrincipalKey in the context of the query, or else the user of the config.
+	Principal string `json:"principal,omitempty"`
+	QueryID   string `json:"queryId"`
+	// Query is the query text, with its arguments interpolated if it is run by QueryContext or ExecContext, or its
+	// Fingerprint if Config.SetAuditFingerprint is set.
+	Query              string `json:"query"`
+	Workgroup          string `json:"workgroup"`
+	Catalog            string `json:"catalog,omitempty"`
+	Database           string `json:"database"`
+	DataScannedInBytes int64  `json:"dataScannedInBytes"`
+	DurationMillis     int64  `json:"durationMs"`
+	// Status is the final state of the query execution, SUCCEEDED, FAILED or CANCELLED.
+	Status string `json:"status"`
+	// Reason is why the query execution failed, if it did.
+	Reason string `json:"reason,omitempty"`
+}
+
+// AuditSink is given the audit records of the queries of the connections whose Config.SetAuditSink names it.
+// Write is called when the query finishes, before its result is returned, so it should be quick.
+type AuditSink interface {
+	Write(ctx context.Context, record *AuditRecord) error
+}
+
+// RegisterAuditSink is to install an AuditSink under a name.
+func RegisterAuditSink(name string, sink AuditSink) {
+	auditSinksMu.Lock()
+	defer auditSinksMu.Unlock()
+	auditSinks[name] = sink
+}
+
+// UnregisterAuditSink is to remove the AuditSink installed under a name.
+func UnregisterAuditSink(name string) {
+	auditSinksMu.Lock()
+	defer auditSinksMu.Unlock()
+	delete(auditSinks, name)
+}
+
+func getAuditSink(name string) (AuditSink, bool) {
+	auditSinksMu.RLock()
+	defer auditSinksMu.RUnlock()
+	sink, ok := auditSinks[name]
+	return sink, ok
+}
+
+// WithPrincipal is to set the principal, e.g. the end user or the IAM role, the queries run with the returned
+// context are audited for.
+func WithPrincipal(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, PrincipalKey, principal)
+}
+
+// WriterAuditSink is an AuditSink writing the records as JSON lines to an io.Writer, e.g. an append-only file.
+type WriterAuditSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterAuditSink is to create a WriterAuditSink writing to w.
+func NewWriterAuditSink(w io.Writer) *WriterAuditSink {
+	return &WriterAuditSink{w: w}
+}
+
+// Write is to implement interface AuditSink.
+func (s *WriterAuditSink) Write(_ context.Context, record *AuditRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
+
+// writeAudit is to give the audit record of a finished query execution to the AuditSink of the config, if any.
+// queryExecution may be nil if the query execution was stopped before its final status was known.
+func (c *Connection) writeAudit(ctx context.Context, queryID string, query string, wgName string, start time.Time,
+	status athenatypes.QueryExecutionState, queryExecution *athenatypes.QueryExecution) {
+	conf := c.connector.config
+	name := conf.GetAuditSink()
+	if name == "" {
+		return
+	}
+	sink, ok := getAuditSink(name)
+	if !ok {
+		return
+	}
+	namespace := NamespaceFromContext(ctx, conf)
+	record := &AuditRecord{
+		Time:           time.Now().UTC(),
+		Principal:      conf.GetUser(),
+		QueryID:        queryID,
+		Query:          query,
+		Workgroup:      wgName,
+		Catalog:        namespace.Catalog,
+		Database:       namespace.Database,
+		DurationMillis: time.Since(start).Milliseconds(),
+		Status:         string(status),
+	}
+	if principal, ok := ctx.Value(PrincipalKey).(string); ok && principal != "" {
+		record.Principal = principal
+	}
+	if queryExecution != nil {
+		if stats := queryExecution.Statistics; stats != nil {
+			record.DataScannedInBytes = aws.ToInt64(stats.DataScannedInBytes)
+			if stats.TotalExecutionTimeInMillis != nil {
+				record.DurationMillis = *stats.TotalExecutionTimeInMillis
+			}
+		}
+		if queryExecution.Status != nil && queryExecution.Status.StateChangeReason != nil &&
+			status != athenatypes.QueryExecutionStateSucceeded {
+			record.Reason = *queryExecution.Status.StateChangeReason
+		}
+	}
+	if conf.IsAuditFingerprint() {
+		record.Query = Fingerprint(record.Query)
+	}
+	// The records of canceled queries are written too.
+	if err := sink.Write(context.WithoutCancel(ctx), record); err != nil {
+		c.tracer(ctx).Scope().Counter(DriverName + ".failure.audit").Inc(1)
+		c.tracer(ctx).Log(WarnLevel, "writing audit record failed",
+			zap.String("queryID", queryID),
+			zap.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readAuditRecords(t *testing.T, buf *bytes.Buffer) []AuditRecord {
+	var records []AuditRecord
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record AuditRecord
+		assert.Nil(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestConnection_writeAudit(t *testing.T) {
+	var buf bytes.Buffer
+	RegisterAuditSink("TestConnection_writeAudit", NewWriterAuditSink(&buf))
+	defer UnregisterAuditSink("TestConnection_writeAudit")
+
+	c := createConnectionFixture()
+	_, err := c.QueryContext(context.Background(), "SELECTQueryContext_OK", []driver.NamedValue{})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, buf.Len())
+
+	c.connector.config.SetAuditSink("TestConnection_writeAudit")
+	ctx := WithPrincipal(context.Background(), "arn:aws:iam::123456789012:role/reports")
+	_, err = c.QueryContext(ctx, "SELECTQueryContext_OK", []driver.NamedValue{})
+	assert.Nil(t, err)
+	_, err = c.QueryContext(context.Background(), "SELECTQueryContext_AWS_FAIL", []driver.NamedValue{})
+	assert.NotNil(t, err)
+
+	records := readAuditRecords(t, &buf)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "SELECTQueryContext_OK_QID", records[0].QueryID)
+	assert.Equal(t, "arn:aws:iam::123456789012:role/reports", records[0].Principal)
+	assert.Equal(t, "SUCCEEDED", records[0].Status)
+	assert.Equal(t, "SELECTQueryContext_OK", records[0].Query)
+	assert.Equal(t, c.connector.config.GetDB(), records[0].Database)
+	assert.Equal(t, "FAILED", records[1].Status)
+	assert.Equal(t, "something_broken", records[1].Reason)
+	assert.Equal(t, c.connector.config.GetUser(), records[1].Principal)
+}
+
+func TestConnection_writeAuditFingerprint(t *testing.T) {
+	var buf bytes.Buffer
+	RegisterAuditSink("TestConnection_writeAuditFingerprint", NewWriterAuditSink(&buf))
+	defer UnregisterAuditSink("TestConnection_writeAuditFingerprint")
+
+	c := createConnectionFixture()
+	c.connector.config.SetAuditSink("TestConnection_writeAuditFingerprint")
+	c.connector.config.SetAuditFingerprint(true)
+	_, err := c.QueryContext(context.Background(), "SELECTQueryContext_OK", []driver.NamedValue{})
+	assert.Nil(t, err)
+	records := readAuditRecords(t, &buf)
+	assert.Len(t, records, 1)
+	assert.Equal(t, Fingerprint("SELECTQueryContext_OK"), records[0].Query)
+}
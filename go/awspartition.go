@@ -0,0 +1,83 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"regexp"
+	"strings"
+)
+
+// The AWS partitions, each a group of regions with its own ARNs and endpoints.
+const (
+	AWSPartitionCommercial = "aws"
+	AWSPartitionChina      = "aws-cn"
+	AWSPartitionGovCloud   = "aws-us-gov"
+	AWSPartitionISO        = "aws-iso"
+	AWSPartitionISOB       = "aws-iso-b"
+)
+
+// regionPattern matches the names of the regions of every partition, e.g. us-east-1, cn-north-1 and us-gov-west-1.
+var regionPattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-[0-9]+$`)
+
+// awsPartitions are the partitions other than the commercial one, by the prefix of the names of their regions, and
+// the DNS suffix of their endpoints. us-isob- is before us-iso- as it is longer.
+var awsPartitions = []struct {
+	regionPrefix string
+	name         string
+	dnsSuffix    string
+}{
+	{"cn-", AWSPartitionChina, "amazonaws.com.cn"},
+	{"us-gov-", AWSPartitionGovCloud, "amazonaws.com"},
+	{"us-isob-", AWSPartitionISOB, "sc2s.sgov.gov"},
+	{"us-iso-", AWSPartitionISO, "c2s.ic.gov"},
+}
+
+// IsValidRegion is to check if a region name is well-formed, e.g. us-east-1, cn-north-1 or us-gov-west-1. It doesn't
+// check the region exists.
+func IsValidRegion(region string) bool {
+	return regionPattern.MatchString(region)
+}
+
+// AWSPartition is to get the partition of a region, e.g. aws-cn for cn-north-1, which ARNs of resources in the
+// region start with, like arn:aws-cn:athena:cn-north-1:....
+func AWSPartition(region string) string {
+	for _, p := range awsPartitions {
+		if strings.HasPrefix(region, p.regionPrefix) {
+			return p.name
+		}
+	}
+	return AWSPartitionCommercial
+}
+
+// AWSDNSSuffix is to get the DNS suffix of the endpoints of a region, e.g. amazonaws.com.cn for cn-north-1.
+func AWSDNSSuffix(region string) string {
+	for _, p := range awsPartitions {
+		if strings.HasPrefix(region, p.regionPrefix) {
+			return p.dnsSuffix
+		}
+	}
+	return "amazonaws.com"
+}
+
+// AthenaEndpoint is to get the host of the Athena endpoint of a region, e.g. athena.cn-north-1.amazonaws.com.cn.
+func AthenaEndpoint(region string) string {
+	return "athena." + region + "." + AWSDNSSuffix(region)
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidRegion(t *testing.T) {
+	for _, region := range []string{"us-east-1", "ap-southeast-2", "cn-north-1", "cn-northwest-1", "us-gov-west-1",
+		"us-gov-east-1", "us-iso-east-1", "us-isob-east-1"} {
+		assert.True(t, IsValidRegion(region), region)
+	}
+	for _, region := range []string{"", "east", "us-east", "US-EAST-1", "us-east-1a", "us_east_1", DummyRegion} {
+		assert.False(t, IsValidRegion(region), region)
+	}
+}
+
+func TestAWSPartition(t *testing.T) {
+	testCases := []struct {
+		region    string
+		partition string
+		endpoint  string
+	}{
+		{"us-east-1", AWSPartitionCommercial, "athena.us-east-1.amazonaws.com"},
+		{"cn-north-1", AWSPartitionChina, "athena.cn-north-1.amazonaws.com.cn"},
+		{"us-gov-west-1", AWSPartitionGovCloud, "athena.us-gov-west-1.amazonaws.com"},
+		{"us-iso-east-1", AWSPartitionISO, "athena.us-iso-east-1.c2s.ic.gov"},
+		{"us-isob-east-1", AWSPartitionISOB, "athena.us-isob-east-1.sc2s.sgov.gov"},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.partition, AWSPartition(tc.region), tc.region)
+		assert.Equal(t, tc.endpoint, AthenaEndpoint(tc.region), tc.region)
+	}
+}
@@ -1,5 +1,14 @@
 package athenadriver
 
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
 // QIDMetaData is the meta data for QID
 type QIDMetaData struct {
 	QID         string
@@ -18,3 +27,210 @@ type AthenaCache interface {
 	// GetQuery is to get query string from cache by QID
 	GetQuery(QID string) string
 }
+
+// QueryCache maps the fingerprints of queries to the QIDs of the executions which answered them, each for a TTL, so
+// repeated queries read those results instead of being executed again. Unlike an AthenaCache, it can be backed by
+// an external store, e.g. Redis, shared by several service instances.
+type QueryCache interface {
+	// Get is to get the QID cached for a fingerprint, or "" if there is none.
+	Get(ctx context.Context, fingerprint string) (string, error)
+
+	// Put is to cache the QID of a fingerprint for ttl.
+	Put(ctx context.Context, fingerprint string, queryID string, ttl time.Duration) error
+}
+
+var (
+	queryCachesMu sync.RWMutex
+	queryCaches   = map[string]QueryCache{}
+)
+
+// RegisterQueryCache is to install a QueryCache under a name, so the connections whose Config.SetQueryCache names
+// it reuse the QIDs of repeated queries instead of executing them again. An AthenaCache is installed as the
+// QueryCache NewAthenaQueryCache makes of it.
+func RegisterQueryCache(name string, cache QueryCache) {
+	queryCachesMu.Lock()
+	defer queryCachesMu.Unlock()
+	queryCaches[name] = cache
+}
+
+// UnregisterQueryCache is to remove the QueryCache installed under a name.
+func UnregisterQueryCache(name string) {
+	queryCachesMu.Lock()
+	defer queryCachesMu.Unlock()
+	delete(queryCaches, name)
+}
+
+func getQueryCache(name string) (QueryCache, bool) {
+	queryCachesMu.RLock()
+	defer queryCachesMu.RUnlock()
+	cache, ok := queryCaches[name]
+	return cache, ok
+}
+
+// athenaQueryCache is the QueryCache of an AthenaCache, which keeps QIDs for a TTL of its own.
+type athenaQueryCache struct {
+	cache AthenaCache
+}
+
+// NewAthenaQueryCache is to create the QueryCache of an AthenaCache, to install it with RegisterQueryCache. The
+// AthenaCache keeps QIDs for a TTL of its own rather than Config.GetQueryCacheTTL.
+func NewAthenaQueryCache(cache AthenaCache) QueryCache {
+	return athenaQueryCache{cache}
+}
+
+// Get is to get the QID cached for a fingerprint.
+func (c athenaQueryCache) Get(_ context.Context, fingerprint string) (string, error) {
+	return c.cache.GetQID(fingerprint).QID, nil
+}
+
+// Put is to cache the QID of a fingerprint.
+func (c athenaQueryCache) Put(_ context.Context, fingerprint string, queryID string, _ time.Duration) error {
+	c.cache.SetQID(fingerprint, QIDMetaData{QID: queryID, timestamp: time.Now().UnixNano()})
+	return nil
+}
+
+// WithResultReuse is to turn the reuse of earlier results, by the query cache and by query coalescing, on or off for
+// the queries run with the returned context. Off, e.g. for a query whose result must be fresh, a query is always
+// executed, and its result is still cached for the queries which reuse it. On is the default: read-only statements
+// reuse results, and other statements never do, so a write always runs.
+func WithResultReuse(ctx context.Context, reuse bool) context.Context {
+	return context.WithValue(ctx, ResultReuseKey, reuse)
+}
+
+// isResultReuse is to check if a query may reuse an earlier result. Only read-only statements do, and the context
+// can force it off for them.
+func isResultReuse(ctx context.Context, query string, conf *Config) bool {
+	if reuse, ok := ctx.Value(ResultReuseKey).(bool); ok && !reuse {
+		return false
+	}
+	return isReadOnlyStatement(query, conf)
+}
+
+// queryFingerprint is the cache key of a query: a hash of its text normalized by NormalizeQuery,
+// its parameters, database and workgroup.
+func queryFingerprint(query string, executionParams []string, db string, wgName string) string {
+	h := sha256.New()
+	for _, s := range append([]string{wgName, db, NormalizeQuery(query)}, executionParams...) {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// memoryCache is an in-process AthenaCache which keeps the most recently used QIDs.
+type memoryCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	lru     *list.List
+	entries map[string]*list.Element
+	queries map[string]string
+}
+
+type memoryCacheEntry struct {
+	query string
+	data  QIDMetaData
+	// expires is when the entry expires if it was put with a TTL of its own.
+	expires time.Time
+}
+
+// NewMemoryCache is to create an in-process AthenaCache which keeps at most size QIDs, each for at most ttl.
+// Athena itself only keeps query results as long as the output location does, so ttl should not be longer.
+func NewMemoryCache(size int, ttl time.Duration) AthenaCache {
+	return &memoryCache{
+		size:    size,
+		ttl:     ttl,
+		lru:     list.New(),
+		entries: map[string]*list.Element{},
+		queries: map[string]string{},
+	}
+}
+
+// NewMemoryQueryCache is to create an in-process QueryCache which keeps at most size QIDs, each for the TTL it is
+// put with.
+func NewMemoryQueryCache(size int) QueryCache {
+	return NewMemoryCache(size, 0).(*memoryCache)
+}
+
+// SetQID is to put query -> QIDMetaData into cache
+func (m *memoryCache) SetQID(query string, data QIDMetaData) {
+	m.set(query, data, time.Time{})
+}
+
+// Get is to get the QID cached for a fingerprint.
+func (m *memoryCache) Get(_ context.Context, fingerprint string) (string, error) {
+	return m.GetQID(fingerprint).QID, nil
+}
+
+// Put is to cache the QID of a fingerprint for ttl.
+func (m *memoryCache) Put(_ context.Context, fingerprint string, queryID string, ttl time.Duration) error {
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+	m.set(fingerprint, QIDMetaData{QID: queryID}, expires)
+	return nil
+}
+
+func (m *memoryCache) set(query string, data QIDMetaData, expires time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if data.timestamp == 0 {
+		data.timestamp = time.Now().UnixNano()
+	}
+	if e, ok := m.entries[query]; ok {
+		m.remove(e)
+	}
+	m.entries[query] = m.lru.PushFront(&memoryCacheEntry{query: query, data: data, expires: expires})
+	m.queries[data.QID] = query
+	for m.size > 0 && m.lru.Len() > m.size {
+		m.remove(m.lru.Back())
+	}
+}
+
+// GetQID is to get QIDMetaData from cache by query string
+func (m *memoryCache) GetQID(query string) QIDMetaData {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[query]
+	if !ok {
+		return QIDMetaData{}
+	}
+	entry := e.Value.(*memoryCacheEntry)
+	if m.expired(entry) {
+		m.remove(e)
+		return QIDMetaData{}
+	}
+	m.lru.MoveToFront(e)
+	return entry.data
+}
+
+// GetQuery is to get query string from cache by QID
+func (m *memoryCache) GetQuery(QID string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	query, ok := m.queries[QID]
+	if !ok {
+		return ""
+	}
+	if entry := m.entries[query].Value.(*memoryCacheEntry); m.expired(entry) {
+		m.remove(m.entries[query])
+		return ""
+	}
+	return query
+}
+
+func (m *memoryCache) expired(entry *memoryCacheEntry) bool {
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		return true
+	}
+	return m.ttl > 0 && time.Since(time.Unix(0, entry.data.timestamp)) > m.ttl
+}
+
+func (m *memoryCache) remove(e *list.Element) {
+	entry := m.lru.Remove(e).(*memoryCacheEntry)
+	delete(m.entries, entry.query)
+	if m.queries[entry.data.QID] == entry.query {
+		delete(m.queries, entry.data.QID)
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryCache(t *testing.T) {
+	cache := NewMemoryCache(2, time.Minute)
+	cache.SetQID("q1", QIDMetaData{QID: "qid1"})
+	cache.SetQID("q2", QIDMetaData{QID: "qid2"})
+	assert.Equal(t, "qid1", cache.GetQID("q1").QID)
+	assert.Equal(t, "q2", cache.GetQuery("qid2"))
+
+	// q2 is the least recently used one
+	cache.SetQID("q3", QIDMetaData{QID: "qid3"})
+	assert.Equal(t, "", cache.GetQID("q2").QID)
+	assert.Equal(t, "", cache.GetQuery("qid2"))
+	assert.Equal(t, "qid1", cache.GetQID("q1").QID)
+	assert.Equal(t, "qid3", cache.GetQID("q3").QID)
+
+	// replacing the QID of a query forgets the old one
+	cache.SetQID("q1", QIDMetaData{QID: "qid4"})
+	assert.Equal(t, "", cache.GetQuery("qid1"))
+	assert.Equal(t, "q1", cache.GetQuery("qid4"))
+
+	// expired entries
+	cache.SetQID("q5", QIDMetaData{QID: "qid5", timestamp: time.Now().Add(-2 * time.Minute).UnixNano()})
+	assert.Equal(t, "", cache.GetQuery("qid5"))
+	cache.SetQID("q5", QIDMetaData{QID: "qid5", timestamp: time.Now().Add(-2 * time.Minute).UnixNano()})
+	assert.Equal(t, "", cache.GetQID("q5").QID)
+}
+
+func TestQueryFingerprint(t *testing.T) {
+	fp := queryFingerprint("SELECT *\n  FROM t WHERE a = ?;", []string{"1"}, "db", "wg")
+	assert.Equal(t, fp, queryFingerprint(" SELECT * FROM t\tWHERE a = ? ", []string{"1"}, "db", "wg"))
+	assert.NotEqual(t, fp, queryFingerprint("SELECT * FROM t WHERE a = ?", []string{"2"}, "db", "wg"))
+	assert.NotEqual(t, fp, queryFingerprint("SELECT * FROM t WHERE a = ?", []string{"1"}, "db2", "wg"))
+	assert.NotEqual(t, fp, queryFingerprint("SELECT * FROM t WHERE a = ?", []string{"1"}, "db", "wg2"))
+}
+
+func TestConnection_QueryContextCache(t *testing.T) {
+	cache := NewMemoryCache(10, time.Minute)
+	RegisterQueryCache("TestConnection_QueryContextCache", NewAthenaQueryCache(cache))
+	defer UnregisterQueryCache("TestConnection_QueryContextCache")
+	c := createConnectionFixture()
+	nm := c.athenaClient.(*mockAthenaClient)
+	c.connector.config.SetQueryCache("TestConnection_QueryContextCache")
+	// the mock query is a single word, not a SELECT statement
+	c.connector.config.SetStatementReadOnly("SELECTQueryContext_OK", true)
+
+	for i := 0; i < 3; i++ {
+		driverRows, err := c.QueryContext(context.Background(), "SELECTQueryContext_OK", []driver.NamedValue{})
+		assert.Nil(t, err)
+		assert.NotNil(t, driverRows)
+	}
+	assert.Equal(t, 1, nm.callCount("StartQueryExecution"))
+	assert.NotEqual(t, "", cache.GetQuery("SELECTQueryContext_OK_QID"))
+
+	// a cache which is not registered is ignored
+	c.connector.config.SetQueryCache("unknown")
+	_, err := c.QueryContext(context.Background(), "SELECTQueryContext_OK", []driver.NamedValue{})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, nm.callCount("StartQueryExecution"))
+}
+
+func TestConnection_QueryContextResultReuse(t *testing.T) {
+	cache := NewMemoryCache(10, time.Minute)
+	RegisterQueryCache("TestConnection_QueryContextResultReuse", NewAthenaQueryCache(cache))
+	defer UnregisterQueryCache("TestConnection_QueryContextResultReuse")
+	c := createConnectionFixture()
+	nm := c.athenaClient.(*mockAthenaClient)
+	c.connector.config.SetQueryCache("TestConnection_QueryContextResultReuse")
+
+	// the mock query is a single word, not a SELECT statement, so it never reuses results, even if reuse is on
+	reuse := WithResultReuse(context.Background(), true)
+	for _, ctx := range []context.Context{context.Background(), context.Background(), reuse, reuse} {
+		_, err := c.QueryContext(ctx, "SELECTQueryContext_OK", []driver.NamedValue{})
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 4, nm.callCount("StartQueryExecution"))
+	assert.Equal(t, "", cache.GetQuery("SELECTQueryContext_OK_QID"))
+
+	// a read-only statement bypasses the cache when reuse is off, and its fresh result is cached
+	c.connector.config.SetStatementReadOnly("SELECTQueryContext_OK", true)
+	fresh := WithResultReuse(context.Background(), false)
+	for i := 0; i < 2; i++ {
+		_, err := c.QueryContext(fresh, "SELECTQueryContext_OK", []driver.NamedValue{})
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 6, nm.callCount("StartQueryExecution"))
+	assert.NotEqual(t, "", cache.GetQuery("SELECTQueryContext_OK_QID"))
+	for _, ctx := range []context.Context{context.Background(), reuse} {
+		_, err := c.QueryContext(ctx, "SELECTQueryContext_OK", []driver.NamedValue{})
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 6, nm.callCount("StartQueryExecution"))
+}
+
+func TestConnection_QueryContextResultReuseWrite(t *testing.T) {
+	RegisterQueryCache("TestConnection_QueryContextResultReuseWrite", NewMemoryQueryCache(10))
+	defer UnregisterQueryCache("TestConnection_QueryContextResultReuseWrite")
+	c := createConnectionFixture()
+	nm := c.athenaClient.(*mockAthenaClient)
+	c.connector.config.SetQueryCache("TestConnection_QueryContextResultReuseWrite")
+	c.connector.config.SetQueryCoalescing(true)
+
+	// an INSERT runs every time, even with reuse forced on
+	reuse := WithResultReuse(context.Background(), true)
+	for i := 0; i < 2; i++ {
+		_, err := c.QueryContext(reuse, "INSERT INTO t SELECT * FROM s", []driver.NamedValue{})
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 2, nm.callCount("StartQueryExecution"))
+	assert.False(t, isResultReuse(reuse, "INSERT INTO t SELECT * FROM s", c.connector.config))
+	assert.True(t, isResultReuse(reuse, "SELECT 1", c.connector.config))
+	assert.False(t, isResultReuse(WithResultReuse(context.Background(), false), "SELECT 1", c.connector.config))
+}
+
+func TestMemoryQueryCache(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryQueryCache(10)
+	qid, err := cache.Get(ctx, "fp1")
+	assert.Nil(t, err)
+	assert.Equal(t, "", qid)
+
+	assert.Nil(t, cache.Put(ctx, "fp1", "qid1", time.Minute))
+	assert.Nil(t, cache.Put(ctx, "fp2", "qid2", time.Millisecond))
+	qid, _ = cache.Get(ctx, "fp1")
+	assert.Equal(t, "qid1", qid)
+	time.Sleep(2 * time.Millisecond)
+	qid, _ = cache.Get(ctx, "fp2")
+	assert.Equal(t, "", qid)
+}
+
+// failingQueryCache is a QueryCache whose store is unavailable.
+type failingQueryCache struct{}
+
+func (failingQueryCache) Get(context.Context, string) (string, error) {
+	return "", ErrTestMockGeneric
+}
+
+func (failingQueryCache) Put(context.Context, string, string, time.Duration) error {
+	return ErrTestMockGeneric
+}
+
+func TestConnection_QueryContextSharedCache(t *testing.T) {
+	cache := NewMemoryQueryCache(10)
+	RegisterQueryCache("TestConnection_QueryContextSharedCache", cache)
+	defer UnregisterQueryCache("TestConnection_QueryContextSharedCache")
+	c := createConnectionFixture()
+	nm := c.athenaClient.(*mockAthenaClient)
+	c.connector.config.SetQueryCache("TestConnection_QueryContextSharedCache")
+	c.connector.config.SetStatementReadOnly("SELECTQueryContext_OK", true)
+
+	// another instance answered the query already
+	fingerprint := queryFingerprint("SELECTQueryContext_OK", nil, NamespaceFromContext(context.Background(), c.connector.config).String(), "henry_wu")
+	assert.Nil(t, cache.Put(context.Background(), fingerprint, "SELECTQueryContext_OK_QID", time.Minute))
+	_, err := c.QueryContext(context.Background(), "SELECTQueryContext_OK", []driver.NamedValue{})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, nm.callCount("StartQueryExecution"))
+
+	// the query is executed when the cache is unavailable
+	RegisterQueryCache("TestConnection_QueryContextSharedCache", failingQueryCache{})
+	_, err = c.QueryContext(context.Background(), "SELECTQueryContext_OK", []driver.NamedValue{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, nm.callCount("StartQueryExecution"))
+}
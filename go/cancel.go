@@ -0,0 +1,122 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+)
+
+// maxQueryExecutionBatchSize is the maximum number of query execution IDs
+// accepted by ListQueryExecutions and BatchGetQueryExecution in one call.
+const maxQueryExecutionBatchSize = 50
+
+// cancelAllLookback is how far back CancelAll scans the query history. No query runs longer than the Athena service
+// limit, DDLQueryTimeout, whichever client submitted it and whatever timeouts this driver has, and the rest leaves
+// time for it to have been queued.
+const cancelAllLookback = 2 * DDLQueryTimeout * time.Second
+
+// CancelAll stops every RUNNING or QUEUED query execution in the workgroup of
+// this connection and returns the IDs of the stopped executions.
+// It is meant for incident response, e.g. when a runaway dashboard floods Athena.
+// The query history is scanned from the newest execution backwards, and the scan
+// stops once a whole page of executions was submitted before cancelAllLookback, as
+// none of them can still be running. An execution which fails to stop, e.g. because
+// it finished in the meantime, doesn't stop the others, and the errors are returned
+// together at the end.
+func (c *Connection) CancelAll(ctx context.Context) ([]string, error) {
+	var obs = c.tracer(ctx)
+	wgName := c.workgroupName()
+	oldest := time.Now().Add(-cancelAllLookback)
+	stopped := []string{}
+	var stopErrs []error
+	paginator := athena.NewListQueryExecutionsPaginator(c.athenaClient, &athena.ListQueryExecutionsInput{
+		WorkGroup:  aws.String(wgName),
+		MaxResults: aws.Int32(maxQueryExecutionBatchSize),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			obs.Scope().Counter(DriverName + ".failure.cancelall.listqueryexecutions").Inc(1)
+			return stopped, err
+		}
+		if len(page.QueryExecutionIds) == 0 {
+			break
+		}
+		batch, err := c.athenaClient.BatchGetQueryExecution(ctx, &athena.BatchGetQueryExecutionInput{
+			QueryExecutionIds: page.QueryExecutionIds,
+		})
+		if err != nil {
+			obs.Scope().Counter(DriverName + ".failure.cancelall.batchgetqueryexecution").Inc(1)
+			return stopped, err
+		}
+		expired := true
+		for _, qe := range batch.QueryExecutions {
+			if qe.Status == nil || qe.QueryExecutionId == nil {
+				continue
+			}
+			if qe.Status.SubmissionDateTime == nil || qe.Status.SubmissionDateTime.After(oldest) {
+				expired = false
+			}
+			if qe.Status.State != athenatypes.QueryExecutionStateRunning &&
+				qe.Status.State != athenatypes.QueryExecutionStateQueued {
+				continue
+			}
+			_, err := c.athenaClient.StopQueryExecution(ctx, &athena.StopQueryExecutionInput{
+				QueryExecutionId: qe.QueryExecutionId,
+			})
+			if err != nil {
+				obs.Log(ErrorLevel, "StopQueryExecution failed",
+					zap.String("workgroup", wgName),
+					zap.String("queryID", *qe.QueryExecutionId),
+					zap.String("error", err.Error()))
+				obs.Scope().Counter(DriverName + ".failure.cancelall.stopqueryexecution").Inc(1)
+				stopErrs = append(stopErrs, fmt.Errorf("query %s: %w", *qe.QueryExecutionId, err))
+				continue
+			}
+			stopped = append(stopped, *qe.QueryExecutionId)
+		}
+		if expired {
+			break
+		}
+	}
+	obs.Log(WarnLevel, "query executions canceled",
+		zap.String("workgroup", wgName),
+		zap.Strings("queryIDs", stopped))
+	obs.Scope().Counter(DriverName + ".cancelall.stopped").Inc(int64(len(stopped)))
+	return stopped, errors.Join(stopErrs...)
+}
+
+// workgroupName returns the name of the workgroup this connection submits queries to.
+func (c *Connection) workgroupName() string {
+	if name := c.connector.config.GetWorkgroup().Name; name != "" {
+		return name
+	}
+	return DefaultWGName
+}
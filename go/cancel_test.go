@@ -0,0 +1,166 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newMockQueryExecution(qid string, state athenatypes.QueryExecutionState,
+	submitted time.Time) athenatypes.QueryExecution {
+	return athenatypes.QueryExecution{
+		QueryExecutionId: aws.String(qid),
+		Status: &athenatypes.QueryExecutionStatus{
+			State:              state,
+			SubmissionDateTime: aws.Time(submitted),
+		},
+	}
+}
+
+func TestConnection_CancelAll(t *testing.T) {
+	t.Parallel()
+	nm := newMockAthenaClient()
+	now := time.Now()
+	for i := 0; i < 120; i++ {
+		state := athenatypes.QueryExecutionStateSucceeded
+		switch i % 4 {
+		case 0:
+			state = athenatypes.QueryExecutionStateRunning
+		case 1:
+			state = athenatypes.QueryExecutionStateQueued
+		}
+		nm.queryExecutions = append(nm.queryExecutions,
+			newMockQueryExecution("qid_"+strconv.Itoa(i), state, now))
+	}
+	c := &Connection{
+		athenaClient: nm,
+		connector:    NoopsSQLConnector(),
+	}
+	stopped, err := c.CancelAll(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 60, len(stopped))
+	assert.Equal(t, "qid_0", stopped[0])
+	assert.Equal(t, "qid_1", stopped[1])
+	assert.Equal(t, "qid_4", stopped[2])
+}
+
+func TestConnection_CancelAll_StopsAtExpiredPage(t *testing.T) {
+	t.Parallel()
+	nm := newMockAthenaClient()
+	old := time.Now().Add(-cancelAllLookback - time.Hour)
+	for i := 0; i < 50; i++ {
+		nm.queryExecutions = append(nm.queryExecutions,
+			newMockQueryExecution("old_"+strconv.Itoa(i), athenatypes.QueryExecutionStateSucceeded, old))
+	}
+	nm.queryExecutions = append(nm.queryExecutions,
+		newMockQueryExecution("stale", athenatypes.QueryExecutionStateRunning, old))
+	c := &Connection{
+		athenaClient: nm,
+		connector:    NoopsSQLConnector(),
+	}
+	stopped, err := c.CancelAll(context.Background())
+	assert.Nil(t, err)
+	assert.Empty(t, stopped)
+}
+
+func TestConnection_CancelAll_OldRunningQuery(t *testing.T) {
+	t.Parallel()
+	nm := newMockAthenaClient()
+	// submitted by another client, long before the longest timeout of this driver
+	nm.queryExecutions = append(nm.queryExecutions,
+		newMockQueryExecution("old_running", athenatypes.QueryExecutionStateRunning, time.Now().Add(-3*time.Hour)))
+	c := &Connection{
+		athenaClient: nm,
+		connector:    NoopsSQLConnector(),
+	}
+	c.connector.config.SetTimeoutPolicy(TimeoutPolicy{Max: time.Minute})
+	stopped, err := c.CancelAll(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"old_running"}, stopped)
+}
+
+func TestConnection_CancelAll_StopFailed(t *testing.T) {
+	t.Parallel()
+	nm := newMockAthenaClient()
+	now := time.Now()
+	nm.queryExecutions = []athenatypes.QueryExecution{
+		newMockQueryExecution("qid_0", athenatypes.QueryExecutionStateRunning, now),
+		newMockQueryExecution("finished_1", athenatypes.QueryExecutionStateRunning, now),
+		newMockQueryExecution("qid_2", athenatypes.QueryExecutionStateQueued, now),
+	}
+	c := &Connection{
+		athenaClient: nm,
+		connector:    NoopsSQLConnector(),
+	}
+	stopped, err := c.CancelAll(context.Background())
+	assert.Equal(t, []string{"qid_0", "qid_2"}, stopped)
+	var invalid *athenatypes.InvalidRequestException
+	assert.True(t, errors.As(err, &invalid))
+	assert.Contains(t, err.Error(), "finished_1")
+}
+
+func TestConnection_CancelAll_ListFailed(t *testing.T) {
+	t.Parallel()
+	c := &Connection{
+		athenaClient: newMockAthenaClient(),
+		connector:    NoopsSQLConnector(),
+	}
+	testConf := NewNoOpsConfig()
+	_ = testConf.SetWorkGroup(NewDefaultWG("ListQueryExecutions_return_error", nil, nil))
+	c.connector.config = testConf
+	_, err := c.CancelAll(context.Background())
+	assert.Equal(t, ErrTestMockGeneric, err)
+}
+
+func TestConnection_PseudoCommandCancelAll(t *testing.T) {
+	t.Parallel()
+	nm := newMockAthenaClient()
+	nm.queryExecutions = []athenatypes.QueryExecution{
+		newMockQueryExecution("qid_running", athenatypes.QueryExecutionStateRunning, time.Now()),
+		newMockQueryExecution("qid_done", athenatypes.QueryExecutionStateSucceeded, time.Now()),
+	}
+	c := &Connection{
+		athenaClient: nm,
+		connector:    NoopsSQLConnector(),
+	}
+	rows, err := c.QueryContext(context.Background(), "pc:cancel_all", []driver.NamedValue{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"query_id"}, rows.Columns())
+	dest := make([]driver.Value, 1)
+	assert.Nil(t, rows.Next(dest))
+	assert.Equal(t, "qid_running", dest[0])
+	assert.Equal(t, io.EOF, rows.Next(dest))
+
+	c.connector.config.SetReadOnly(true)
+	rows, err = c.QueryContext(context.Background(), "pc:cancel_all", []driver.NamedValue{})
+	assert.NotNil(t, err)
+	assert.Nil(t, rows)
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+)
+
+// CapacityReservation is a wrapper of Athena provisioned capacity reservation.
+// https://docs.aws.amazon.com/athena/latest/ug/capacity-management.html
+type CapacityReservation struct {
+	Name       string
+	TargetDPUs int32
+	Tags       *WGTags
+}
+
+// NewCapacityReservation is to create a new CapacityReservation.
+func NewCapacityReservation(name string, targetDPUs int32, tags *WGTags) *CapacityReservation {
+	if tags == nil {
+		tags = NewWGTags()
+	}
+	return &CapacityReservation{
+		Name:       name,
+		TargetDPUs: targetDPUs,
+		Tags:       tags,
+	}
+}
+
+// CreateCapacityReservationRemotely is to create a capacity reservation remotely.
+func (r *CapacityReservation) CreateCapacityReservationRemotely(ctx context.Context, athenaClient AthenaClient) error {
+	if athenaClient == nil {
+		return ErrAthenaNilClient
+	}
+	input := &athena.CreateCapacityReservationInput{
+		Name:       aws.String(r.Name),
+		TargetDpus: aws.Int32(r.TargetDPUs),
+	}
+	if r.Tags != nil && len(r.Tags.Get()) > 0 {
+		input.Tags = r.Tags.Get()
+	}
+	_, err := athenaClient.CreateCapacityReservation(ctx, input)
+	return err
+}
+
+// AssignWorkgroupRemotely is to assign a workgroup to the capacity reservation.
+// Workgroups already assigned to the reservation are kept.
+func (r *CapacityReservation) AssignWorkgroupRemotely(ctx context.Context, athenaClient AthenaClient,
+	workgroup string) error {
+	if athenaClient == nil {
+		return ErrAthenaNilClient
+	}
+	out, err := athenaClient.GetCapacityAssignmentConfiguration(ctx, &athena.GetCapacityAssignmentConfigurationInput{
+		CapacityReservationName: aws.String(r.Name),
+	})
+	if err != nil {
+		return err
+	}
+	var assignments []athenatypes.CapacityAssignment
+	if out.CapacityAssignmentConfiguration != nil {
+		assignments = out.CapacityAssignmentConfiguration.CapacityAssignments
+	}
+	for _, assignment := range assignments {
+		for _, name := range assignment.WorkGroupNames {
+			if name == workgroup {
+				return nil
+			}
+		}
+	}
+	assignments = append(assignments, athenatypes.CapacityAssignment{
+		WorkGroupNames: []string{workgroup},
+	})
+	_, err = athenaClient.PutCapacityAssignmentConfiguration(ctx, &athena.PutCapacityAssignmentConfigurationInput{
+		CapacityReservationName: aws.String(r.Name),
+		CapacityAssignments:     assignments,
+	})
+	return err
+}
+
+// ListCapacityReservations is to list all capacity reservations in the account and region.
+func ListCapacityReservations(ctx context.Context, athenaClient AthenaClient) ([]athenatypes.CapacityReservation, error) {
+	if athenaClient == nil {
+		return nil, ErrAthenaNilClient
+	}
+	var reservations []athenatypes.CapacityReservation
+	paginator := athena.NewListCapacityReservationsPaginator(athenaClient, &athena.ListCapacityReservationsInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, page.CapacityReservations...)
+	}
+	return reservations, nil
+}
+
+// checkCapacityReservation returns ErrCapacityUnavailable if the capacity reservation is not
+// active or has no DPU allocated.
+func checkCapacityReservation(ctx context.Context, athenaClient AthenaClient, name string) error {
+	out, err := athenaClient.GetCapacityReservation(ctx, &athena.GetCapacityReservationInput{
+		Name: aws.String(name),
+	})
+	if err != nil {
+		return err
+	}
+	reservation := out.CapacityReservation
+	if reservation == nil {
+		return fmt.Errorf("%w: capacity reservation %q is not found", ErrCapacityUnavailable, name)
+	}
+	if reservation.Status != athenatypes.CapacityReservationStatusActive &&
+		reservation.Status != athenatypes.CapacityReservationStatusUpdatePending {
+		return fmt.Errorf("%w: capacity reservation %q is %s", ErrCapacityUnavailable, name, reservation.Status)
+	}
+	if reservation.AllocatedDpus == nil || *reservation.AllocatedDpus <= 0 {
+		return fmt.Errorf("%w: capacity reservation %q has 0 allocated DPUs", ErrCapacityUnavailable, name)
+	}
+	return nil
+}
+
+// capacityCheck is the result of checking a capacity reservation, and when it was checked.
+type capacityCheck struct {
+	err       error
+	checkedAt time.Time
+}
+
+// checkCapacityReservation is to check a capacity reservation like checkCapacityReservation, reusing the result of
+// the last check for CapacityCheckTTL rather than calling GetCapacityReservation for every query. Errors of the call
+// itself are not kept.
+func (c *SQLConnector) checkCapacityReservation(ctx context.Context, athenaClient AthenaClient, name string) error {
+	c.capacityMu.Lock()
+	check, ok := c.capacityChecks[name]
+	c.capacityMu.Unlock()
+	if ok && time.Since(check.checkedAt) < CapacityCheckTTL {
+		return check.err
+	}
+	err := checkCapacityReservation(ctx, athenaClient, name)
+	if err != nil && !errors.Is(err, ErrCapacityUnavailable) {
+		return err
+	}
+	c.capacityMu.Lock()
+	defer c.capacityMu.Unlock()
+	if c.capacityChecks == nil {
+		c.capacityChecks = make(map[string]capacityCheck)
+	}
+	c.capacityChecks[name] = capacityCheck{err: err, checkedAt: time.Now()}
+	return err
+}
+
+// AssignCapacityReservation is to assign the workgroup of this connection to a capacity reservation.
+func (c *Connection) AssignCapacityReservation(ctx context.Context, name string) error {
+	return NewCapacityReservation(name, 0, nil).AssignWorkgroupRemotely(ctx, c.athenaClient, c.workgroupName())
+}
+
+// ListCapacityReservations is to list all capacity reservations visible to this connection.
+func (c *Connection) ListCapacityReservations(ctx context.Context) ([]athenatypes.CapacityReservation, error) {
+	return ListCapacityReservations(ctx, c.athenaClient)
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapacityReservation_CreateAndList(t *testing.T) {
+	nm := newMockAthenaClient()
+	tags := NewWGTags()
+	tags.AddTag("team", "data")
+	r := NewCapacityReservation("reservation_a", 24, tags)
+	assert.Nil(t, r.CreateCapacityReservationRemotely(context.Background(), nm))
+	assert.NotNil(t, r.CreateCapacityReservationRemotely(context.Background(), nm))
+
+	reservations, err := ListCapacityReservations(context.Background(), nm)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(reservations))
+	assert.Equal(t, "reservation_a", *reservations[0].Name)
+	assert.Equal(t, int32(24), *reservations[0].TargetDpus)
+
+	_, err = ListCapacityReservations(context.Background(), nil)
+	assert.Equal(t, ErrAthenaNilClient, err)
+}
+
+func TestCapacityReservation_AssignWorkgroup(t *testing.T) {
+	nm := newMockAthenaClient()
+	r := NewCapacityReservation("reservation_a", 24, nil)
+	assert.Nil(t, r.CreateCapacityReservationRemotely(context.Background(), nm))
+	assert.Nil(t, r.AssignWorkgroupRemotely(context.Background(), nm, "wg_a"))
+	assert.Nil(t, r.AssignWorkgroupRemotely(context.Background(), nm, "wg_b"))
+	assert.Nil(t, r.AssignWorkgroupRemotely(context.Background(), nm, "wg_a"))
+	assert.Equal(t, []athenatypes.CapacityAssignment{
+		{WorkGroupNames: []string{"wg_a"}},
+		{WorkGroupNames: []string{"wg_b"}},
+	}, nm.capacityAssignments["reservation_a"])
+
+	c := &Connection{
+		athenaClient: nm,
+		connector:    NoopsSQLConnector(),
+	}
+	assert.Nil(t, c.AssignCapacityReservation(context.Background(), "reservation_a"))
+	assert.Equal(t, []string{DefaultWGName}, nm.capacityAssignments["reservation_a"][2].WorkGroupNames)
+	assert.NotNil(t, c.AssignCapacityReservation(context.Background(), "reservation_missing"))
+	reservations, err := c.ListCapacityReservations(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(reservations))
+}
+
+func TestCheckCapacityReservation(t *testing.T) {
+	nm := newMockAthenaClient()
+	nm.capacityReservations = map[string]athenatypes.CapacityReservation{
+		"active": {
+			Name:          aws.String("active"),
+			Status:        athenatypes.CapacityReservationStatusActive,
+			AllocatedDpus: aws.Int32(24),
+		},
+		"empty": {
+			Name:          aws.String("empty"),
+			Status:        athenatypes.CapacityReservationStatusActive,
+			AllocatedDpus: aws.Int32(0),
+		},
+		"cancelled": {
+			Name:   aws.String("cancelled"),
+			Status: athenatypes.CapacityReservationStatusCancelled,
+		},
+	}
+	assert.Nil(t, checkCapacityReservation(context.Background(), nm, "active"))
+	err := checkCapacityReservation(context.Background(), nm, "empty")
+	assert.True(t, errors.Is(err, ErrCapacityUnavailable))
+	assert.Contains(t, err.Error(), "0 allocated DPUs")
+	err = checkCapacityReservation(context.Background(), nm, "cancelled")
+	assert.True(t, errors.Is(err, ErrCapacityUnavailable))
+	assert.Contains(t, err.Error(), "CANCELLED")
+	assert.Equal(t, ErrTestMockGeneric, checkCapacityReservation(context.Background(), nm, "missing"))
+}
+
+func TestConnection_QueryContext_CapacityFailFast(t *testing.T) {
+	nm := newMockAthenaClient()
+	nm.capacityReservations = map[string]athenatypes.CapacityReservation{
+		"empty": {
+			Name:          aws.String("empty"),
+			Status:        athenatypes.CapacityReservationStatusActive,
+			AllocatedDpus: aws.Int32(0),
+		},
+	}
+	c := &Connection{
+		athenaClient: nm,
+		connector:    NoopsSQLConnector(),
+	}
+	c.connector.config.SetCapacityReservation("empty")
+	c.connector.config.SetCapacityFailFast(true)
+	rows, err := c.QueryContext(context.Background(), "SELECTQueryContext_OK", []driver.NamedValue{})
+	assert.Nil(t, rows)
+	assert.True(t, errors.Is(err, ErrCapacityUnavailable))
+
+	// the state of the reservation is reused for CapacityCheckTTL
+	r := nm.capacityReservations["empty"]
+	r.AllocatedDpus = aws.Int32(8)
+	nm.capacityReservations["empty"] = r
+	_, err = c.QueryContext(context.Background(), "SELECTQueryContext_OK", []driver.NamedValue{})
+	assert.True(t, errors.Is(err, ErrCapacityUnavailable))
+	assert.Equal(t, 1, nm.callCount("GetCapacityReservation"))
+	check := c.connector.capacityChecks["empty"]
+	check.checkedAt = check.checkedAt.Add(-CapacityCheckTTL)
+	c.connector.capacityChecks["empty"] = check
+	rows, err = c.QueryContext(context.Background(), "SELECTQueryContext_OK", []driver.NamedValue{})
+	assert.Nil(t, err)
+	assert.NotNil(t, rows)
+	assert.Equal(t, 2, nm.callCount("GetCapacityReservation"))
+
+	// errors of the call itself are not kept
+	c.connector.config.SetCapacityReservation("missing")
+	_, err = c.QueryContext(context.Background(), "SELECTQueryContext_OK", []driver.NamedValue{})
+	assert.Equal(t, ErrTestMockGeneric, err)
+	_, err = c.QueryContext(context.Background(), "SELECTQueryContext_OK", []driver.NamedValue{})
+	assert.Equal(t, ErrTestMockGeneric, err)
+	assert.Equal(t, 4, nm.callCount("GetCapacityReservation"))
+
+	c.connector.config.SetCapacityFailFast(false)
+	rows, err = c.QueryContext(context.Background(), "SELECTQueryContext_OK", []driver.NamedValue{})
+	assert.Nil(t, err)
+	assert.NotNil(t, rows)
+}
@@ -0,0 +1,198 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	"go.uber.org/zap"
+)
+
+// CircuitOpenError is returned right away, without calling Athena, while the circuit breaker of a connector is open
+// after consecutive API failures, see Config.SetCircuitBreaker. It is ErrCircuitOpen for errors.Is, and unwraps to
+// the last failure.
+type CircuitOpenError struct {
+	// Failures is the number of consecutive failures which opened the circuit.
+	Failures int
+	// RetryAfter is how long until Athena is probed again.
+	RetryAfter time.Duration
+	Err        error
+}
+
+// Error is to implement interface error.
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%v after %d consecutive failures, retry after %v: %v", ErrCircuitOpen, e.Failures,
+		e.RetryAfter, e.Err)
+}
+
+// Unwrap is to get the last failure.
+func (e *CircuitOpenError) Unwrap() error {
+	return e.Err
+}
+
+// Is is to match ErrCircuitOpen.
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// circuitBreaker opens after threshold consecutive API failures, and then lets a single call through every cooldown
+// to probe whether Athena is back, which closes it if it succeeds.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	lastErr   error
+	openedAt  time.Time
+	probing   bool
+}
+
+// newCircuitBreaker is to create a circuit breaker. A nil circuitBreaker never opens.
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow returns a *CircuitOpenError if a call is not to be made, and whether the call is the probe otherwise.
+func (b *circuitBreaker) allow() (bool, error) {
+	if b == nil {
+		return false, nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return false, nil
+	}
+	if wait := b.cooldown - time.Since(b.openedAt); wait > 0 || b.probing {
+		if wait < 0 {
+			wait = 0
+		}
+		return false, &CircuitOpenError{Failures: b.failures, RetryAfter: wait, Err: b.lastErr}
+	}
+	b.probing = true
+	return true, nil
+}
+
+// record is to count the outcome of a call made with ctx, and returns whether it opened the circuit. Failures which
+// are not Athena's, i.e. client errors and calls whose context is done, neither count nor reset the failures.
+func (b *circuitBreaker) record(ctx context.Context, probe bool, err error) bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if probe {
+		b.probing = false
+	}
+	if err == nil {
+		b.failures = 0
+		b.lastErr = nil
+		return false
+	}
+	if !isAPIOutage(ctx, err) {
+		return false
+	}
+	b.failures++
+	b.lastErr = err
+	if b.failures == b.threshold || probe {
+		// A failed probe opens the circuit for another cooldown.
+		b.openedAt = time.Now()
+		return true
+	}
+	return false
+}
+
+// isAPIOutage is to check if an API call failed because Athena or the network did, rather than because of the
+// request, i.e. with a 5xx status or without a response, while ctx is not done.
+func isAPIOutage(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var re *awshttp.ResponseError
+	if errors.As(err, &re) {
+		return re.HTTPStatusCode() >= 500
+	}
+	return true
+}
+
+// circuitBreakerClient is an AthenaClient which fails fast with a *CircuitOpenError while its circuit breaker,
+// shared by all connections of a connector, is open.
+type circuitBreakerClient struct {
+	AthenaClient
+	breaker *circuitBreaker
+	tracer  *DriverTracer
+}
+
+// call is to make an API call through the circuit breaker.
+func (c *circuitBreakerClient) call(ctx context.Context, api string, f func() error) error {
+	probe, err := c.breaker.allow()
+	if err != nil {
+		c.tracer.Scope().Counter(DriverName + ".failure.circuitbreaker.rejected").Inc(1)
+		return err
+	}
+	err = f()
+	if c.breaker.record(ctx, probe, err) {
+		c.tracer.Scope().Counter(DriverName + ".circuitbreaker.open").Inc(1)
+		c.tracer.Log(ErrorLevel, "circuit breaker opened",
+			zap.String("api", api),
+			zap.Duration("cooldown", c.breaker.cooldown),
+			zap.String("error", err.Error()))
+	}
+	return err
+}
+
+// StartQueryExecution calls AthenaClient.StartQueryExecution through the circuit breaker.
+func (c *circuitBreakerClient) StartQueryExecution(ctx context.Context, input *athena.StartQueryExecutionInput,
+	optFns ...func(*athena.Options)) (out *athena.StartQueryExecutionOutput, err error) {
+	err = c.call(ctx, APIStartQueryExecution, func() error {
+		out, err = c.AthenaClient.StartQueryExecution(ctx, input, optFns...)
+		return err
+	})
+	return out, err
+}
+
+// GetQueryExecution calls AthenaClient.GetQueryExecution through the circuit breaker.
+func (c *circuitBreakerClient) GetQueryExecution(ctx context.Context, input *athena.GetQueryExecutionInput,
+	optFns ...func(*athena.Options)) (out *athena.GetQueryExecutionOutput, err error) {
+	err = c.call(ctx, APIGetQueryExecution, func() error {
+		out, err = c.AthenaClient.GetQueryExecution(ctx, input, optFns...)
+		return err
+	})
+	return out, err
+}
+
+// GetQueryResults calls AthenaClient.GetQueryResults through the circuit breaker.
+func (c *circuitBreakerClient) GetQueryResults(ctx context.Context, input *athena.GetQueryResultsInput,
+	optFns ...func(*athena.Options)) (out *athena.GetQueryResultsOutput, err error) {
+	err = c.call(ctx, APIGetQueryResults, func() error {
+		out, err = c.AthenaClient.GetQueryResults(ctx, input, optFns...)
+		return err
+	})
+	return out, err
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	assert.Nil(t, newCircuitBreaker(0, time.Second))
+	var disabled *circuitBreaker
+	probe, err := disabled.allow()
+	assert.False(t, probe)
+	assert.Nil(t, err)
+
+	b := newCircuitBreaker(2, 20*time.Millisecond)
+	ctx := context.Background()
+	assert.False(t, b.record(ctx, false, ErrTestMockGeneric))
+	// a success resets the failures
+	assert.False(t, b.record(ctx, false, nil))
+	assert.False(t, b.record(ctx, false, ErrTestMockGeneric))
+	assert.True(t, b.record(ctx, false, ErrTestMockGeneric))
+
+	_, err = b.allow()
+	var openErr *CircuitOpenError
+	assert.True(t, errors.As(err, &openErr))
+	assert.Equal(t, 2, openErr.Failures)
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.True(t, errors.Is(err, ErrTestMockGeneric))
+
+	// a single probe is let through after the cooldown, and a failed one opens the circuit again
+	time.Sleep(25 * time.Millisecond)
+	probe, err = b.allow()
+	assert.True(t, probe)
+	assert.Nil(t, err)
+	_, err = b.allow()
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.True(t, b.record(ctx, true, ErrTestMockGeneric))
+	_, err = b.allow()
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+
+	// a successful probe closes it
+	time.Sleep(25 * time.Millisecond)
+	probe, err = b.allow()
+	assert.True(t, probe)
+	assert.Nil(t, err)
+	assert.False(t, b.record(ctx, true, nil))
+	probe, err = b.allow()
+	assert.False(t, probe)
+	assert.Nil(t, err)
+}
+
+func TestIsAPIOutage(t *testing.T) {
+	responseError := func(status int) error {
+		return &awshttp.ResponseError{ResponseError: &smithyhttp.ResponseError{
+			Response: &smithyhttp.Response{Response: &http.Response{StatusCode: status}},
+			Err:      ErrTestMockGeneric,
+		}}
+	}
+	ctx := context.Background()
+	assert.True(t, isAPIOutage(ctx, ErrTestMockGeneric))
+	assert.True(t, isAPIOutage(ctx, responseError(503)))
+	assert.False(t, isAPIOutage(ctx, responseError(400)))
+	assert.False(t, isAPIOutage(ctx, context.Canceled))
+
+	done, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.False(t, isAPIOutage(done, ErrTestMockGeneric))
+}
+
+func TestSQLConnector_WithCircuitBreaker(t *testing.T) {
+	mock := newMockAthenaClient()
+	connector := NoopsSQLConnector()
+	assert.Equal(t, AthenaClient(mock), connector.withCircuitBreaker(mock))
+
+	connector = NoopsSQLConnector()
+	connector.config.SetCircuitBreaker(2, time.Hour)
+	client := connector.withCircuitBreaker(mock)
+	// the breaker is shared by the connections of the connector
+	assert.Equal(t, client.(*circuitBreakerClient).breaker, connector.withCircuitBreaker(mock).(*circuitBreakerClient).breaker)
+
+	failing := &athena.GetQueryExecutionInput{QueryExecutionId: aws.String(
+		"When_StartQueryExecution_Succeed_but_GetQueryExecutionWithContext_return_nil_and_error_QID")}
+	for i := 0; i < 2; i++ {
+		_, err := client.GetQueryExecution(context.Background(), failing)
+		assert.Equal(t, ErrTestMockGeneric, err)
+	}
+	_, err := client.GetQueryResults(context.Background(),
+		&athena.GetQueryResultsInput{QueryExecutionId: aws.String("SELECT_OK")})
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	_, err = client.StartQueryExecution(context.Background(),
+		&athena.StartQueryExecutionInput{QueryString: aws.String("SELECTQueryContext_OK")})
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.Equal(t, 2, mock.callCount("GetQueryExecution"))
+	assert.Equal(t, 0, mock.callCount("GetQueryResults")+mock.callCount("StartQueryExecution"))
+}
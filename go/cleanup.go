@@ -0,0 +1,92 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"go.uber.org/zap"
+)
+
+// resultCleanupTimeout bounds the deletion of the result objects of a query, which is not tied to any context.
+const resultCleanupTimeout = time.Minute
+
+// s3ObjectDeleter is the part of the S3 client used to delete query results.
+type s3ObjectDeleter interface {
+	DeleteObjects(context.Context, *s3.DeleteObjectsInput, ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error)
+}
+
+// scheduleResultCleanup is to delete the result objects of a query execution when rows are closed, unless onClose
+// is false, and/or after the TTL, as enabled by Config.SetResultCleanupOnClose and Config.SetResultCleanupTTL. The
+// objects are kept for at least keep, e.g. as long as the query cache may hand out the QID.
+func (c *Connection) scheduleResultCleanup(rows *Rows, queryExecution *athenatypes.QueryExecution, onClose bool,
+	keep time.Duration) {
+	if c.s3Deleter == nil {
+		return
+	}
+	if onClose && c.connector.config.IsResultCleanupOnClose() {
+		rows.onClose = func() { c.deleteResult(queryExecution) }
+	}
+	if ttl := c.connector.config.GetResultCleanupTTL(); ttl > 0 {
+		if ttl < keep {
+			ttl = keep
+		}
+		time.AfterFunc(ttl, func() { c.deleteResult(queryExecution) })
+	}
+}
+
+// deleteResult is to delete the result file of a query execution and its metadata file.
+func (c *Connection) deleteResult(queryExecution *athenatypes.QueryExecution) {
+	if queryExecution.ResultConfiguration == nil || queryExecution.ResultConfiguration.OutputLocation == nil {
+		return
+	}
+	obs := c.connector.tracer
+	bucket, key, ok := parseS3Location(*queryExecution.ResultConfiguration.OutputLocation)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), resultCleanupTimeout)
+	defer cancel()
+	out, err := c.s3Deleter.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+		Bucket: aws.String(bucket),
+		Delete: &s3types.Delete{
+			Objects: []s3types.ObjectIdentifier{{Key: aws.String(key)}, {Key: aws.String(key + ".metadata")}},
+			Quiet:   aws.Bool(true),
+		},
+	})
+	if err == nil && len(out.Errors) > 0 {
+		err = fmt.Errorf("%s: %s", aws.ToString(out.Errors[0].Key), aws.ToString(out.Errors[0].Message))
+	}
+	if err != nil {
+		obs.Scope().Counter(DriverName + ".failure.resultcleanup").Inc(1)
+		obs.Log(WarnLevel, "deleting query result failed",
+			zap.String("queryID", aws.ToString(queryExecution.QueryExecutionId)),
+			zap.String("error", err.Error()))
+		return
+	}
+	obs.Scope().Counter(DriverName + ".resultcleanup").Inc(1)
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockS3Deleter struct {
+	mu      sync.Mutex
+	deleted []string
+	errors  []s3types.Error
+}
+
+func (m *mockS3Deleter) DeleteObjects(_ context.Context, input *s3.DeleteObjectsInput,
+	_ ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, obj := range input.Delete.Objects {
+		m.deleted = append(m.deleted, *input.Bucket+"/"+*obj.Key)
+	}
+	return &s3.DeleteObjectsOutput{Errors: m.errors}, nil
+}
+
+func (m *mockS3Deleter) deletedObjects() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.deleted...)
+}
+
+func resultCleanupFixture() (*Connection, *mockS3Deleter, *athenatypes.QueryExecution) {
+	deleter := &mockS3Deleter{}
+	c := createConnectionFixture()
+	c.s3Deleter = deleter
+	queryExecution := &athenatypes.QueryExecution{
+		QueryExecutionId: aws.String("QID"),
+		ResultConfiguration: &athenatypes.ResultConfiguration{
+			OutputLocation: aws.String("s3://results/prefix/QID.csv"),
+		},
+	}
+	return c, deleter, queryExecution
+}
+
+func TestConnection_scheduleResultCleanupOnClose(t *testing.T) {
+	c, deleter, queryExecution := resultCleanupFixture()
+	rows := &Rows{tracer: c.connector.tracer}
+	c.scheduleResultCleanup(rows, queryExecution, true, 0)
+	assert.Nil(t, rows.onClose)
+
+	c.connector.config.SetResultCleanupOnClose(true)
+	c.scheduleResultCleanup(rows, queryExecution, false, 0)
+	assert.Nil(t, rows.onClose)
+	c.scheduleResultCleanup(rows, queryExecution, true, 0)
+	assert.Empty(t, deleter.deletedObjects())
+	assert.Nil(t, rows.Close())
+	assert.Nil(t, rows.Close())
+	assert.Equal(t, []string{"results/prefix/QID.csv", "results/prefix/QID.csv.metadata"}, deleter.deletedObjects())
+}
+
+func TestConnection_scheduleResultCleanupTTL(t *testing.T) {
+	c, deleter, queryExecution := resultCleanupFixture()
+	c.connector.config.SetResultCleanupTTL(10 * time.Millisecond)
+	c.scheduleResultCleanup(&Rows{}, queryExecution, false, 0)
+	assert.Eventually(t, func() bool { return len(deleter.deletedObjects()) == 2 }, time.Second, 5*time.Millisecond)
+}
+
+func TestConnection_deleteResult(t *testing.T) {
+	c, deleter, queryExecution := resultCleanupFixture()
+	deleter.errors = []s3types.Error{{Key: aws.String("prefix/QID.csv"), Message: aws.String("Access Denied")}}
+	c.deleteResult(queryExecution)
+	c.deleteResult(&athenatypes.QueryExecution{QueryExecutionId: aws.String("DDL")})
+	assert.Len(t, deleter.deletedObjects(), 2)
+}
+
+func TestConnection_QueryContextCachedResultCleanup(t *testing.T) {
+	RegisterQueryCache("TestConnection_QueryContextCachedResultCleanup", NewMemoryQueryCache(10))
+	defer UnregisterQueryCache("TestConnection_QueryContextCachedResultCleanup")
+	c, deleter, _ := resultCleanupFixture()
+	m := c.athenaClient.(*mockAthenaClient)
+	c.connector.config.SetQueryCache("TestConnection_QueryContextCachedResultCleanup")
+	c.connector.config.SetResultCleanupOnClose(true)
+	c.connector.config.SetStatementReadOnly("SELECTQueryContext_OK", true)
+	ctx := context.Background()
+
+	rows, err := c.QueryContext(ctx, "SELECTQueryContext_OK", []driver.NamedValue{})
+	assert.Nil(t, err)
+	assert.Nil(t, rows.Close())
+	assert.Empty(t, deleter.deletedObjects())
+
+	// the cached QID is still readable after the first rows are closed
+	rows, err = c.QueryContext(ctx, "SELECTQueryContext_OK", []driver.NamedValue{})
+	assert.Nil(t, err)
+	assert.Nil(t, rows.Close())
+	assert.Equal(t, 1, m.callCount("StartQueryExecution"))
+	assert.Empty(t, deleter.deletedObjects())
+
+	// without the cache, the result is deleted on close
+	c.connector.config.SetQueryCache("")
+	rows, err = c.QueryContext(ctx, "SELECTQueryContext_OK", []driver.NamedValue{})
+	assert.Nil(t, err)
+	assert.Nil(t, rows.Close())
+	assert.Equal(t, []string{"results/SELECTQueryContext_OK_QID.csv", "results/SELECTQueryContext_OK_QID.csv.metadata"},
+		deleter.deletedObjects())
+}
+
+func TestConnection_scheduleResultCleanupKeep(t *testing.T) {
+	c, deleter, queryExecution := resultCleanupFixture()
+	c.connector.config.SetResultCleanupTTL(time.Millisecond)
+	c.scheduleResultCleanup(&Rows{}, queryExecution, false, 50*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, deleter.deletedObjects())
+	assert.Eventually(t, func() bool { return len(deleter.deletedObjects()) == 2 }, time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+)
+
+// queryCoalescer lets concurrent submissions of the same query share one query execution, like singleflight.
+// The shared execution is not bound to the context of the submission which started it: it is only stopped when
+// every submission waiting for it has given up. Each SQLConnector has its own, as executions can only be shared by
+// connections with the same region and credentials.
+type queryCoalescer struct {
+	mu    sync.Mutex
+	calls map[string]*coalescedCall
+}
+
+type coalescedCall struct {
+	done           chan struct{}
+	cancel         context.CancelFunc
+	waiters        int
+	queryExecution *athenatypes.QueryExecution
+	err            error
+}
+
+// coalescingKey is the key of a query execution of a connector: the same normalized query, parameters, catalog and
+// database, output location and workgroup.
+func coalescingKey(query string, executionParams []string, db string, outputBucket string, wgName string) string {
+	return strings.Join(append([]string{wgName, db, outputBucket, NormalizeQuery(query)}, executionParams...), "\x00")
+}
+
+// do is to run fn for key, or wait for the run of fn already in flight for key, and return its result.
+// A finished run keeps being shared for window after it finishes. shared is true if the result is from a run
+// started by another caller.
+func (g *queryCoalescer) do(ctx context.Context, key string, window time.Duration,
+	fn func(ctx context.Context) (*athenatypes.QueryExecution, error)) (queryExecution *athenatypes.QueryExecution,
+	shared bool, err error) {
+	g.mu.Lock()
+	call, shared := g.calls[key]
+	if !shared {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		call = &coalescedCall{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = call
+		go g.run(callCtx, key, call, window, fn)
+	}
+	call.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.queryExecution, shared, call.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		call.waiters--
+		if call.waiters == 0 && !isDone(call.done) {
+			g.forget(key, call)
+			call.cancel()
+		}
+		g.mu.Unlock()
+		return nil, shared, ctx.Err()
+	}
+}
+
+func (g *queryCoalescer) run(ctx context.Context, key string, call *coalescedCall, window time.Duration,
+	fn func(ctx context.Context) (*athenatypes.QueryExecution, error)) {
+	call.queryExecution, call.err = fn(ctx)
+	call.cancel()
+	close(call.done)
+	if call.err == nil && window > 0 {
+		time.AfterFunc(window, func() {
+			g.mu.Lock()
+			g.forget(key, call)
+			g.mu.Unlock()
+		})
+		return
+	}
+	g.mu.Lock()
+	g.forget(key, call)
+	g.mu.Unlock()
+}
+
+// forget is to remove call from g, unless a newer call has replaced it. g.mu must be held.
+func (g *queryCoalescer) forget(key string, call *coalescedCall) {
+	if g.calls[key] == call {
+		delete(g.calls, key)
+	}
+}
+
+func isDone(done chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func waitForWaiters(g *queryCoalescer, key string, n int) {
+	for {
+		g.mu.Lock()
+		waiters := 0
+		if call := g.calls[key]; call != nil {
+			waiters = call.waiters
+		}
+		g.mu.Unlock()
+		if waiters == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestQueryCoalescer_Do(t *testing.T) {
+	g := &queryCoalescer{calls: map[string]*coalescedCall{}}
+	var runs int32
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (*athenatypes.QueryExecution, error) {
+		atomic.AddInt32(&runs, 1)
+		<-release
+		return &athenatypes.QueryExecution{QueryExecutionId: aws.String("qid")}, nil
+	}
+
+	var wg sync.WaitGroup
+	var shared int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			qe, s, err := g.do(context.Background(), "key", 0, fn)
+			assert.Nil(t, err)
+			assert.Equal(t, "qid", *qe.QueryExecutionId)
+			if s {
+				atomic.AddInt32(&shared, 1)
+			}
+		}()
+	}
+	waitForWaiters(g, "key", 10)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&runs))
+	assert.Equal(t, int32(9), atomic.LoadInt32(&shared))
+
+	// without a window, the next submission starts a new execution
+	_, s, err := g.do(context.Background(), "key", 0, fn)
+	assert.Nil(t, err)
+	assert.False(t, s)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&runs))
+
+	// within the window, the finished execution is shared
+	_, s, err = g.do(context.Background(), "windowed", time.Minute, fn)
+	assert.Nil(t, err)
+	assert.False(t, s)
+	_, s, err = g.do(context.Background(), "windowed", time.Minute, fn)
+	assert.Nil(t, err)
+	assert.True(t, s)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&runs))
+}
+
+func TestQueryCoalescer_Cancel(t *testing.T) {
+	g := &queryCoalescer{calls: map[string]*coalescedCall{}}
+	stopped := make(chan struct{})
+	fn := func(ctx context.Context) (*athenatypes.QueryExecution, error) {
+		<-ctx.Done()
+		close(stopped)
+		return nil, ctx.Err()
+	}
+
+	// the execution keeps running while a submission is still waiting for it
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	errs := make(chan error, 2)
+	go func() {
+		_, _, err := g.do(ctx1, "key", 0, fn)
+		errs <- err
+	}()
+	go func() {
+		_, _, err := g.do(ctx2, "key", 0, fn)
+		errs <- err
+	}()
+	waitForWaiters(g, "key", 2)
+	cancel1()
+	assert.Equal(t, context.Canceled, <-errs)
+	select {
+	case <-stopped:
+		t.Fatal("execution stopped while a submission is waiting")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	// and is stopped once every submission has given up
+	cancel2()
+	assert.Equal(t, context.Canceled, <-errs)
+	<-stopped
+}
+
+func TestConnection_QueryContextCoalescing(t *testing.T) {
+	c := createConnectionFixture()
+	nm := c.athenaClient.(*mockAthenaClient)
+	c.connector.config.SetQueryCoalescing(true)
+	c.connector.config.SetQueryCoalescingWindow(time.Minute)
+	// the mock query is a single word, not a SELECT statement
+	c.connector.config.SetStatementReadOnly("SELECTQueryContext_OK", true)
+
+	for i := 0; i < 3; i++ {
+		driverRows, err := c.QueryContext(context.Background(), "SELECTQueryContext_OK", []driver.NamedValue{})
+		assert.Nil(t, err)
+		assert.NotNil(t, driverRows)
+	}
+	assert.Equal(t, 1, nm.callCount("StartQueryExecution"))
+
+	// connections of another connector, e.g. with other credentials or another region, don't share executions
+	other := createConnectionFixture()
+	other.connector.config = c.connector.config
+	otherClient := other.athenaClient.(*mockAthenaClient)
+	_, err := other.QueryContext(context.Background(), "SELECTQueryContext_OK", []driver.NamedValue{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, otherClient.callCount("StartQueryExecution"))
+	assert.Equal(t, 1, nm.callCount("StartQueryExecution"))
+}
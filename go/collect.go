@@ -0,0 +1,156 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// CollectTagName is the struct tag used by Collect to map a column to a struct field, e.g. `athena:"created_at"`.
+// A field tagged with `athena:"-"` is never mapped.
+const CollectTagName = "athena"
+
+// Collect scans all rows into a slice of T and closes rows.
+// If T is a struct, or a pointer to a struct, each column is scanned into the exported field whose
+// `athena` tag, name or snake_case name matches the column name case-insensitively. Columns without
+// a matching field are skipped, but at least one column must match. Otherwise, including structs
+// without exported fields like netip.Addr, rows must have exactly one column, which is scanned into T.
+func Collect[T any](rows *sql.Rows) ([]T, error) {
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var zero T
+	targetType := reflect.TypeOf(&zero).Elem()
+	structType, isPtr := targetType, false
+	if structType.Kind() == reflect.Ptr {
+		structType, isPtr = structType.Elem(), true
+	}
+
+	var fieldIndexes [][]int
+	if structType.Kind() == reflect.Struct && !isScannerType(structType) {
+		if fields := collectFields(structType); len(fields) > 0 {
+			fieldIndexes = make([][]int, len(columns))
+			mapped := false
+			for i, column := range columns {
+				fieldIndexes[i] = fields[strings.ToLower(column)]
+				mapped = mapped || fieldIndexes[i] != nil
+			}
+			if !mapped {
+				return nil, fmt.Errorf("no column of %v maps to a field of %s", columns, targetType)
+			}
+		}
+	}
+	if fieldIndexes == nil && len(columns) != 1 {
+		return nil, fmt.Errorf("cannot collect %d columns into %s", len(columns), targetType)
+	}
+
+	result := []T{}
+	for rows.Next() {
+		var item T
+		dest := make([]interface{}, len(columns))
+		if fieldIndexes == nil {
+			dest[0] = &item
+		} else {
+			value := reflect.ValueOf(&item).Elem()
+			if isPtr {
+				value.Set(reflect.New(structType))
+				value = value.Elem()
+			}
+			for i, index := range fieldIndexes {
+				if index == nil {
+					dest[i] = new(interface{})
+					continue
+				}
+				dest[i] = value.FieldByIndex(index).Addr().Interface()
+			}
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		result = append(result, item)
+	}
+	return result, rows.Err()
+}
+
+// isScannerType returns true if a struct type is scanned as a whole, like time.Time or sql.NullString.
+func isScannerType(t reflect.Type) bool {
+	return t == reflect.TypeOf(YearMonthInterval{}) || t.PkgPath() == "time" ||
+		reflect.PtrTo(t).Implements(reflect.TypeOf((*sql.Scanner)(nil)).Elem())
+}
+
+// collectFields returns the field index of every lowercased column name a struct type can hold.
+// Fields of embedded structs are promoted, unless they are shadowed.
+func collectFields(t reflect.Type) map[string][]int {
+	fields := map[string][]int{}
+	var embedded []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get(CollectTagName)
+		if tag == "-" {
+			continue
+		}
+		if f.Anonymous && tag == "" && f.Type.Kind() == reflect.Struct && !isScannerType(f.Type) {
+			embedded = append(embedded, f)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		names := []string{strings.ToLower(f.Name), toSnakeCase(f.Name)}
+		if tag != "" {
+			names = []string{strings.ToLower(tag)}
+		}
+		for _, name := range names {
+			fields[name] = f.Index
+		}
+	}
+	for _, f := range embedded {
+		for name, index := range collectFields(f.Type) {
+			if _, ok := fields[name]; !ok {
+				fields[name] = append([]int{f.Index[0]}, index...)
+			}
+		}
+	}
+	return fields
+}
+
+// toSnakeCase converts a field name like `CreatedAt` or `UserID` to `created_at` or `user_id`.
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"database/sql"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type collectBase struct {
+	ID int64
+}
+
+type collectItem struct {
+	collectBase
+	Name      string
+	CreatedAt time.Time
+	Score     sql.NullFloat64 `athena:"total_score"`
+	Ignored   string          `athena:"-"`
+	hidden    string
+}
+
+func TestCollect(t *testing.T) {
+	now := time.Now().UTC()
+	sqlRows := sqlmock.NewRows([]string{"id", "NAME", "created_at", "total_score", "ignored", "hidden", "extra"})
+	sqlRows.AddRow(int32(1), "a", now, 1.5, "x", "y", "z")
+	sqlRows.AddRow(int64(2), "b", now, nil, "x", "y", "z")
+
+	items, err := Collect[collectItem](mockRowsToSQLRows(sqlRows))
+	assert.Nil(t, err)
+	assert.Equal(t, []collectItem{
+		{collectBase: collectBase{ID: 1}, Name: "a", CreatedAt: now, Score: sql.NullFloat64{Float64: 1.5, Valid: true}},
+		{collectBase: collectBase{ID: 2}, Name: "b", CreatedAt: now},
+	}, items)
+
+	sqlRows = sqlmock.NewRows([]string{"id", "name"})
+	sqlRows.AddRow(int32(3), "c")
+	ptrs, err := Collect[*collectItem](mockRowsToSQLRows(sqlRows))
+	assert.Nil(t, err)
+	assert.Equal(t, []*collectItem{{collectBase: collectBase{ID: 3}, Name: "c"}}, ptrs)
+
+	sqlRows = sqlmock.NewRows([]string{"id"})
+	sqlRows.AddRow("x")
+	_, err = Collect[collectItem](mockRowsToSQLRows(sqlRows))
+	assert.NotNil(t, err)
+}
+
+func TestCollect_SingleColumn(t *testing.T) {
+	sqlRows := sqlmock.NewRows([]string{"n"})
+	sqlRows.AddRow(int32(1)).AddRow(int32(2))
+	ns, err := Collect[int](mockRowsToSQLRows(sqlRows))
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2}, ns)
+
+	sqlRows = sqlmock.NewRows([]string{"t"})
+	_, err = Collect[time.Time](mockRowsToSQLRows(sqlRows))
+	assert.Nil(t, err)
+
+	sqlRows = sqlmock.NewRows([]string{"a", "b"})
+	_, err = Collect[string](mockRowsToSQLRows(sqlRows))
+	assert.NotNil(t, err)
+
+	// a struct without exported fields is a single value, not a row
+	sqlRows = sqlmock.NewRows([]string{"ip", "host"})
+	_, err = Collect[netip.Addr](mockRowsToSQLRows(sqlRows))
+	assert.NotNil(t, err)
+}
+
+func TestCollect_ValueStruct(t *testing.T) {
+	m := newMockAthenaClient()
+	m.queryToResultsGenMap["EXPLAIN_QID"] = func(_ string) (*athena.GetQueryResultsOutput, error) {
+		return &athena.GetQueryResultsOutput{
+			ResultSet: &athenatypes.ResultSet{
+				ResultSetMetadata: &athenatypes.ResultSetMetadata{
+					ColumnInfo: []athenatypes.ColumnInfo{newColumnInfo("ip", "ipaddress")},
+				},
+				Rows: []athenatypes.Row{newRow(1, []string{"10.0.0.1"}), newRow(1, []string{"2001:db8::1"})},
+			},
+		}, nil
+	}
+	conf := NewNoOpsConfig()
+	conf.SetTypedIPAddressAndUUID(true)
+	db := sql.OpenDB(NewSQLConnectorWithClient(conf, m))
+	defer db.Close()
+
+	rows, err := db.Query("EXPLAIN SELECT ip")
+	assert.Nil(t, err)
+	addrs, err := Collect[netip.Addr](rows)
+	assert.Nil(t, err)
+	assert.Equal(t, []netip.Addr{netip.MustParseAddr("10.0.0.1"), netip.MustParseAddr("2001:db8::1")}, addrs)
+}
+
+func TestCollect_NoMappedColumn(t *testing.T) {
+	sqlRows := sqlmock.NewRows([]string{"request_timestamp"})
+	sqlRows.AddRow("2024-07-01")
+	_, err := Collect[collectItem](mockRowsToSQLRows(sqlRows))
+	assert.EqualError(t, err, "no column of [request_timestamp] maps to a field of athenadriver.collectItem")
+}
+
+func TestToSnakeCase(t *testing.T) {
+	for name, expected := range map[string]string{
+		"ID":        "id",
+		"Name":      "name",
+		"CreatedAt": "created_at",
+		"UserID":    "user_id",
+		"HTTPCode":  "http_code",
+	} {
+		assert.Equal(t, expected, toSnakeCase(name))
+	}
+}
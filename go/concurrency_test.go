@@ -0,0 +1,102 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The tests in this file check the concurrency contract of Connection, and are meant to be run with -race.
+
+func TestConnection_ConcurrentUse(t *testing.T) {
+	c := createConnectionFixture()
+	c.connector.config.SetMaxConcurrentQueries(4)
+	c.connector.config.SetQueryCoalescing(true)
+	c.connector.config.SetQueryCoalescingWindow(time.Second)
+	c.connector.config.SetStatementReadOnly("SELECTQueryContext_OK", true)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rows, err := c.QueryContext(context.Background(), "SELECTQueryContext_OK", nil)
+			if assert.Nil(t, err) {
+				dest := make([]driver.Value, len(rows.Columns()))
+				for err == nil {
+					err = rows.Next(dest)
+				}
+				assert.Equal(t, io.EOF, err)
+				assert.Nil(t, rows.Close())
+			}
+
+			_, err = c.QueryContext(context.Background(), "SELECTQueryContext_?",
+				[]driver.NamedValue{{Ordinal: 1, Value: int64(i)}})
+			assert.Nil(t, err)
+
+			_, err = c.ExecContext(context.Background(), "SELECTExecContext_OK", nil)
+			assert.Nil(t, err)
+
+			stmt, err := c.Prepare("SELECT * FROM t WHERE a = ? AND b = ?")
+			if assert.Nil(t, err) {
+				assert.Equal(t, 2, stmt.NumInput())
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestConnection_UseAfterClose(t *testing.T) {
+	c := createConnectionFixture()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// racing with Close, so it may or may not succeed, but it must not panic
+			_, err := c.QueryContext(context.Background(), "SELECTQueryContext_OK", nil)
+			if err != nil {
+				assert.Equal(t, driver.ErrBadConn, err)
+			}
+		}()
+	}
+	assert.Nil(t, c.Close())
+	wg.Wait()
+
+	_, err := c.QueryContext(context.Background(), "SELECTQueryContext_OK", nil)
+	assert.Equal(t, driver.ErrBadConn, err)
+	_, err = c.ExecContext(context.Background(), "SELECTExecContext_OK", nil)
+	assert.Equal(t, driver.ErrBadConn, err)
+	_, err = c.Prepare("SELECT 1")
+	assert.Equal(t, driver.ErrBadConn, err)
+	_, err = c.ResumeRows(context.Background(), Cursor{QueryID: "SELECT_OK"})
+	assert.Equal(t, driver.ErrBadConn, err)
+	assert.Equal(t, driver.ErrBadConn, c.Ping(context.Background()))
+	assert.Nil(t, c.Close())
+}
@@ -96,21 +96,53 @@ func NewNoOpsConfig() *Config {
 	return &a
 }
 
-// NewConfig is to create Config from a string.
+// NewEmulatorConfig is to create a driver Config for an emulator of the AWS APIs listening at endpoint, e.g.
+// http://localhost:4566 for LocalStack, with the dummy credentials emulators accept.
+func NewEmulatorConfig(endpoint string, outputBucket string) (*Config, error) {
+	a := NewNoOpsConfig()
+	if err := a.SetOutputBucket(outputBucket); err != nil {
+		return nil, err
+	}
+	a.SetEndpoint(endpoint)
+	_ = a.SetAccessID("test")
+	_ = a.SetSecretAccessKey("test")
+	return a, nil
+}
+
+// NewConfig is to create Config from a string, see ParseDSN.
 func NewConfig(s string) (*Config, error) {
-	u, err := url.Parse(s)
+	return ParseDSN(s)
+}
+
+// ParseDSN is to parse a DSN into a Config, e.g. for tools to read or change the settings of a connection string.
+// It fails with ErrConfigInvalidConfig when the DSN is not an s3 URL with a region. Every setting of a Config
+// survives its DSN, so ParseDSN(conf.Stringify()) has the same settings as conf, and its Stringify is the same.
+// The connection properties of the Athena JDBC and ODBC drivers, like AwsRegion, S3OutputLocation and Workgroup, are
+// accepted in the DSN as well, and so are JDBC connection URLs like jdbc:awsathena://AwsRegion=us-east-1;..., so
+// connection strings can be shared with Java services.
+func ParseDSN(dsn string) (*Config, error) {
+	if strings.HasPrefix(strings.ToLower(dsn), jdbcURLPrefix) {
+		dsn = parseJDBCURL(dsn)
+	}
+	u, err := url.Parse(dsn)
 	if err != nil {
 		return nil, err
 	}
+	values, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return nil, err
+	}
+	if normalizeJDBCProperties(u, values) {
+		u.RawQuery = values.Encode()
+	}
 	a := Config{
-		dsn: *u,
+		dsn:    *u,
+		values: values,
 	}
-
-	a.values, err = url.ParseQuery(u.RawQuery)
 	if !a.isValid() {
 		return nil, ErrConfigInvalidConfig
 	}
-	return &a, err
+	return &a, nil
 }
 
 func (c *Config) isValid() bool {
@@ -143,6 +175,9 @@ func (c *Config) SafeStringify() string {
 // the naming conventions that we use in all other worldwide AWS Regions.
 // Amazon S3 no longer supports creating bucket names that contain uppercase letters or underscores.
 // https://docs.aws.amazon.com/AmazonS3/latest/dev/BucketRestrictions.html#bucketnamingrules
+// The location can be a template like s3://bucket/results/{yyyy}/{MM}/{dd}/{workgroup}/, expanded for every query
+// with the UTC date and hour ({yyyy}, {MM}, {dd}, {HH}) when it starts, its {workgroup}, {database} and {user}, so
+// results can be organized for lifecycle policies.
 func (c *Config) SetOutputBucket(o string) error {
 	if !strings.HasPrefix(o, "s3://") {
 		return ErrConfigOutputLocation
@@ -194,6 +229,17 @@ func (c *Config) GetDB() string {
 	return DefaultDBName
 }
 
+// SetCatalog is to set the data catalog queries run in, e.g. a federated catalog backed by a Lambda connector to
+// DynamoDB or RDS. Tables of other catalogs can still be referenced as catalog.db.table.
+func (c *Config) SetCatalog(o string) {
+	c.values.Set("catalog", o)
+}
+
+// GetCatalog is to get the data catalog queries run in. It is empty for the default AwsDataCatalog.
+func (c *Config) GetCatalog() string {
+	return c.values.Get("catalog")
+}
+
 // SetResultPollIntervalSeconds is a setter of Overriding poll interval.
 func (c *Config) SetResultPollIntervalSeconds(n int) {
 	c.values.Set("resultPollIntervalSeconds", strconv.Itoa(n))
@@ -211,6 +257,34 @@ func (c *Config) GetResultPollIntervalSeconds() time.Duration {
 	return time.Duration(PoolInterval) * time.Second
 }
 
+// SetInitialPollInterval is to set the interval between two status checks while a query has been running for
+// less than the fast poll period, so quick queries return without waiting a whole poll interval. After that,
+// the interval doubles up to the poll interval. 0 checks every poll interval from the start.
+func (c *Config) SetInitialPollInterval(d time.Duration) {
+	c.values.Set("initialPollInterval", d.String())
+}
+
+// GetInitialPollInterval is getter of initialPollInterval.
+func (c *Config) GetInitialPollInterval() time.Duration {
+	if d, err := time.ParseDuration(c.values.Get("initialPollInterval")); err == nil {
+		return d
+	}
+	return InitialPollInterval
+}
+
+// SetFastPollPeriod is to set how long the status of a query is checked every initial poll interval.
+func (c *Config) SetFastPollPeriod(d time.Duration) {
+	c.values.Set("fastPollPeriod", d.String())
+}
+
+// GetFastPollPeriod is getter of fastPollPeriod.
+func (c *Config) GetFastPollPeriod() time.Duration {
+	if d, err := time.ParseDuration(c.values.Get("fastPollPeriod")); err == nil {
+		return d
+	}
+	return FastPollPeriod
+}
+
 // SetWorkGroup is a setter of WorkGroup.
 func (c *Config) SetWorkGroup(w *Workgroup) error {
 	if w == nil {
@@ -287,7 +361,7 @@ func (c *Config) GetUser() string {
 	return c.dsn.User.Username()
 }
 
-// GetOutputBucket is getter of OutputBucket.
+// GetOutputBucket is getter of OutputBucket. It is the template with its placeholders, see SetOutputBucket.
 func (c *Config) GetOutputBucket() string {
 	if strings.HasPrefix(c.dsn.Path, "/") {
 		return c.dsn.Scheme + "://" + c.dsn.Host + c.dsn.Path
@@ -295,6 +369,25 @@ func (c *Config) GetOutputBucket() string {
 	return c.dsn.Scheme + "://" + c.dsn.Host + "/" + c.dsn.Path
 }
 
+// expandOutputLocation is to get the output location of a query started at t in a workgroup, expanding the
+// placeholders of the template, see SetOutputBucket.
+func (c *Config) expandOutputLocation(wgName string, t time.Time) string {
+	location := c.GetOutputBucket()
+	if !strings.Contains(location, "{") {
+		return location
+	}
+	t = t.UTC()
+	return strings.NewReplacer(
+		"{yyyy}", t.Format("2006"),
+		"{MM}", t.Format("01"),
+		"{dd}", t.Format("02"),
+		"{HH}", t.Format("15"),
+		"{workgroup}", wgName,
+		"{database}", c.GetDB(),
+		"{user}", c.GetUser(),
+	).Replace(location)
+}
+
 // GetWorkgroup is getter of Workgroup.
 func (c *Config) GetWorkgroup() Workgroup {
 	tagString := c.values.Get("tag")
@@ -458,6 +551,8 @@ func (c *Config) GetAWSProfile() string {
 }
 
 // SetServiceLimitOverride is to set values from a ServiceLimitOverride
+//
+// Deprecated: use SetTimeoutPolicy, which overrides these timeouts.
 func (c *Config) SetServiceLimitOverride(serviceLimitOverride ServiceLimitOverride) {
 	for k, v := range serviceLimitOverride.GetAsStringMap() {
 		c.values.Set(k, v)
@@ -465,8 +560,837 @@ func (c *Config) SetServiceLimitOverride(serviceLimitOverride ServiceLimitOverri
 }
 
 // GetServiceLimitOverride is to get the ServiceLimitOverride manually set by a user
+//
+// Deprecated: use GetTimeoutPolicy, which includes these timeouts.
 func (c *Config) GetServiceLimitOverride() *ServiceLimitOverride {
 	serviceLimitOverride := NewServiceLimitOverride()
 	serviceLimitOverride.SetFromValues(c.values)
 	return serviceLimitOverride
 }
+
+// SetCapacityReservation is to set the name of the capacity reservation the workgroup is assigned to.
+func (c *Config) SetCapacityReservation(name string) {
+	c.values.Set("capacityReservation", name)
+}
+
+// GetCapacityReservation is to get the name of the capacity reservation the workgroup is assigned to.
+func (c *Config) GetCapacityReservation() string {
+	return c.values.Get("capacityReservation")
+}
+
+// SetCapacityFailFast is to set if a query fails immediately when its capacity reservation has no capacity.
+// The state of the reservation is checked at most every CapacityCheckTTL per connector.
+func (c *Config) SetCapacityFailFast(b bool) {
+	if b {
+		c.values.Set("capacityFailFast", "true")
+	} else {
+		c.values.Set("capacityFailFast", "false")
+	}
+}
+
+// IsCapacityFailFast is to check if a query fails immediately when its capacity reservation has no capacity.
+func (c *Config) IsCapacityFailFast() bool {
+	return c.values.Get("capacityFailFast") == "true"
+}
+
+// SetPrestoEscaping is to set if interpolated string arguments are escaped the way Athena (Presto) expects,
+// i.e. single quotes are doubled and backslashes are kept literally, and []byte arguments are rendered as
+// X'..' varbinary literals. When it is off, the deprecated MySQL style backslash escaping is used.
+func (c *Config) SetPrestoEscaping(b bool) {
+	if b {
+		c.values.Set("prestoEscaping", "true")
+	} else {
+		c.values.Set("prestoEscaping", "false")
+	}
+}
+
+// IsPrestoEscaping is to check if interpolated arguments are escaped the way Athena (Presto) expects.
+func (c *Config) IsPrestoEscaping() bool {
+	return c.values.Get("prestoEscaping") == "true"
+}
+
+// SetDecimalMode is to set how DECIMAL values are returned: DecimalAsString, DecimalAsRat, DecimalAsFloat64 or
+// the name of a converter registered with RegisterDecimalConverter.
+func (c *Config) SetDecimalMode(mode string) {
+	c.values.Set("decimalMode", mode)
+}
+
+// GetDecimalMode is to get how DECIMAL values are returned.
+func (c *Config) GetDecimalMode() string {
+	if val := c.values.Get("decimalMode"); val != "" {
+		return val
+	}
+	return DecimalAsString
+}
+
+// SetNullAsNil is to set if NULL cells are returned as nil, so they can be scanned into sql.NullString,
+// sql.NullInt64 etc. for every column type. It takes precedence over the missing value options, and an empty
+// cell of a column type that cannot hold an empty string, e.g. integer or timestamp, is also treated as NULL.
+func (c *Config) SetNullAsNil(b bool) {
+	if b {
+		c.values.Set("nullAsNil", "true")
+	} else {
+		c.values.Set("nullAsNil", "false")
+	}
+}
+
+// IsNullAsNil is to check if NULL cells are returned as nil.
+func (c *Config) IsNullAsNil() bool {
+	return c.values.Get("nullAsNil") == "true"
+}
+
+// SetJSONAsRawMessage is to set if JSON values are returned as json.RawMessage instead of string.
+func (c *Config) SetJSONAsRawMessage(b bool) {
+	if b {
+		c.values.Set("jsonAsRawMessage", "true")
+	} else {
+		c.values.Set("jsonAsRawMessage", "false")
+	}
+}
+
+// IsJSONAsRawMessage is to check if JSON values are returned as json.RawMessage.
+func (c *Config) IsJSONAsRawMessage() bool {
+	return c.values.Get("jsonAsRawMessage") == "true"
+}
+
+// SetTypedIPAddressAndUUID is to set if ipaddress values are returned as netip.Addr and uuid values as UUID. Off by
+// default, they are returned as the validated strings Athena sent, so they can be scanned into *string.
+func (c *Config) SetTypedIPAddressAndUUID(b bool) {
+	if b {
+		c.values.Set("typedIPAddressAndUUID", "true")
+	} else {
+		c.values.Set("typedIPAddressAndUUID", "false")
+	}
+}
+
+// IsTypedIPAddressAndUUID is to check if ipaddress and uuid values are returned as netip.Addr and UUID.
+func (c *Config) IsTypedIPAddressAndUUID() bool {
+	return c.values.Get("typedIPAddressAndUUID") == "true"
+}
+
+// SetRawStringMode is to set if every value is returned as the string Athena sent, without any type conversion,
+// and NULL as nil, so columns can be scanned into *string.
+func (c *Config) SetRawStringMode(b bool) {
+	if b {
+		c.values.Set("rawStringMode", "true")
+	} else {
+		c.values.Set("rawStringMode", "false")
+	}
+}
+
+// IsRawStringMode is to check if every value is returned as the string Athena sent.
+func (c *Config) IsRawStringMode() bool {
+	return c.values.Get("rawStringMode") == "true"
+}
+
+// SetGeometryMode is to set how geospatial values are returned: GeometryAsBytes, GeometryAsWKT or
+// GeometryAsGeometry. Varbinary values which are not WKB, e.g. from ST_AsBinary, are still returned as []byte.
+func (c *Config) SetGeometryMode(mode string) {
+	c.values.Set("geometryMode", mode)
+}
+
+// GetGeometryMode is to get how geospatial values are returned.
+func (c *Config) GetGeometryMode() string {
+	if val := c.values.Get("geometryMode"); val != "" {
+		return val
+	}
+	return GeometryAsBytes
+}
+
+// SetPrefetchPages is to set how many result set pages are fetched ahead of Rows.Next() in the background.
+// 0, the default, fetches a page only when the previous one has been read.
+func (c *Config) SetPrefetchPages(n int) {
+	c.values.Set("prefetchPages", strconv.Itoa(n))
+}
+
+// GetPrefetchPages is to get how many result set pages are fetched ahead of Rows.Next().
+func (c *Config) GetPrefetchPages() int {
+	n, err := strconv.Atoi(c.values.Get("prefetchPages"))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// SetQueryCoalescing is to set if concurrent submissions of the same read-only query, with the same parameters,
+// database, output location and workgroup, share a single query execution, e.g. for dashboards which fan out
+// identical widgets. Only the connections of the same connector share executions. Every submission still gets its
+// own Rows.
+func (c *Config) SetQueryCoalescing(b bool) {
+	if b {
+		c.values.Set("queryCoalescing", "true")
+	} else {
+		c.values.Set("queryCoalescing", "false")
+	}
+}
+
+// IsQueryCoalescing is to check if concurrent submissions of the same query share a single query execution.
+func (c *Config) IsQueryCoalescing() bool {
+	return c.values.Get("queryCoalescing") == "true"
+}
+
+// SetQueryCoalescingWindow is to set how long a succeeded query execution keeps being shared with submissions
+// of the same query after it finishes. It only applies if query coalescing is on, and is 0 by default.
+func (c *Config) SetQueryCoalescingWindow(d time.Duration) {
+	c.values.Set("queryCoalescingWindow", d.String())
+}
+
+// GetQueryCoalescingWindow is to get how long a succeeded query execution keeps being shared after it finishes.
+func (c *Config) GetQueryCoalescingWindow() time.Duration {
+	d, err := time.ParseDuration(c.values.Get("queryCoalescingWindow"))
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
+
+// SetQueryCache is to set the name of the QueryCache, installed with RegisterQueryCache, which maps repeated
+// read-only queries to the QID of their last execution, so their results are read again instead of executing them.
+func (c *Config) SetQueryCache(name string) {
+	c.values.Set("queryCache", name)
+}
+
+// GetQueryCache is to get the name of the QueryCache repeated queries are looked up in.
+func (c *Config) GetQueryCache() string {
+	return c.values.Get("queryCache")
+}
+
+// SetQueryCacheTTL is to set how long the QIDs are kept in a QueryCache. Athena only keeps query results as long
+// as the output location does, so it should not be longer. An AthenaCache installed with NewAthenaQueryCache keeps
+// QIDs for a TTL of its own.
+func (c *Config) SetQueryCacheTTL(d time.Duration) {
+	c.values.Set("queryCacheTTL", d.String())
+}
+
+// GetQueryCacheTTL is to get how long the QIDs are kept in a QueryCache. It defaults to 1 hour.
+func (c *Config) GetQueryCacheTTL() time.Duration {
+	if d, err := time.ParseDuration(c.values.Get("queryCacheTTL")); err == nil && d > 0 {
+		return d
+	}
+	return time.Hour
+}
+
+// SetMaxConcurrentQueries is to set how many query executions the connections of a connector run at once, to stay
+// within the account's concurrent query quota. Queries over the limit wait for a slot, or until their context is
+// done. 0, the default, is no limit. It takes effect when the connector runs its first query.
+func (c *Config) SetMaxConcurrentQueries(n int) {
+	c.values.Set("maxConcurrentQueries", strconv.Itoa(n))
+}
+
+// GetMaxConcurrentQueries is to get how many query executions the connections of a connector run at once.
+func (c *Config) GetMaxConcurrentQueries() int {
+	n, err := strconv.Atoi(c.values.Get("maxConcurrentQueries"))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// SetAPIRateLimit is to set how many requests per second the connections of a connector make to an Athena API:
+// APIStartQueryExecution, APIGetQueryExecution or APIGetQueryResults, so many goroutines polling at once do not get
+// the account throttled. Bursts of the rate rounded up are allowed. 0, the default, is no limit.
+func (c *Config) SetAPIRateLimit(api string, requestsPerSecond float64) {
+	c.values.Set("apiRateLimit."+api, strconv.FormatFloat(requestsPerSecond, 'g', -1, 64))
+}
+
+// GetAPIRateLimit is to get how many requests per second the connections of a connector make to an Athena API.
+func (c *Config) GetAPIRateLimit(api string) float64 {
+	rate, err := strconv.ParseFloat(c.values.Get("apiRateLimit."+api), 64)
+	if err != nil || rate < 0 {
+		return 0
+	}
+	return rate
+}
+
+// SetS3ResultThreshold is to set the size in bytes of a query result CSV file above which the rows after the first
+// page are streamed from the file in S3 instead of paged through GetQueryResults, which is much faster for huge
+// results. It needs s3:GetObject on the output location. 0, the default, always uses GetQueryResults.
+func (c *Config) SetS3ResultThreshold(bytes int64) {
+	c.values.Set("s3ResultThreshold", strconv.FormatInt(bytes, 10))
+}
+
+// GetS3ResultThreshold is to get the size in bytes of a query result above which it is read from S3.
+func (c *Config) GetS3ResultThreshold() int64 {
+	n, err := strconv.ParseInt(c.values.Get("s3ResultThreshold"), 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// SetSpillToDisk is to set if all result set pages after the first one are fetched ahead of Rows.Next() in the
+// background, keeping up to the spill memory limit of them in memory and spooling the rest to a temporary file,
+// so huge results are fetched at full speed however slowly they are iterated. It takes precedence over
+// SetPrefetchPages. The file is removed when the rows are closed.
+func (c *Config) SetSpillToDisk(b bool) {
+	if b {
+		c.values.Set("spillToDisk", "true")
+	} else {
+		c.values.Set("spillToDisk", "false")
+	}
+}
+
+// IsSpillToDisk is to check if prefetched pages are spooled to a temporary file over the spill memory limit.
+func (c *Config) IsSpillToDisk() bool {
+	return c.values.Get("spillToDisk") == "true"
+}
+
+// SetSpillMemoryLimit is to set how many bytes of prefetched pages are kept in memory when spilling to disk.
+func (c *Config) SetSpillMemoryLimit(bytes int64) {
+	c.values.Set("spillMemoryLimit", strconv.FormatInt(bytes, 10))
+}
+
+// GetSpillMemoryLimit is to get how many bytes of prefetched pages are kept in memory when spilling to disk.
+func (c *Config) GetSpillMemoryLimit() int64 {
+	n, err := strconv.ParseInt(c.values.Get("spillMemoryLimit"), 10, 64)
+	if err != nil || n < 0 {
+		return DefaultSpillMemoryLimit
+	}
+	return n
+}
+
+// SetSpillDir is to set the directory of the temporary files pages are spilled to. It is the default directory
+// for temporary files if empty.
+func (c *Config) SetSpillDir(dir string) {
+	c.values.Set("spillDir", dir)
+}
+
+// GetSpillDir is to get the directory of the temporary files pages are spilled to.
+func (c *Config) GetSpillDir() string {
+	return c.values.Get("spillDir")
+}
+
+// SetStatementReadOnly is to override whether the statements with a keyword, e.g. MSCK or SHOW, are read-only,
+// which decides if they are allowed in read-only mode and if their results are cached or coalesced.
+func (c *Config) SetStatementReadOnly(keyword string, readOnly bool) {
+	c.values.Set("statementReadOnly."+strings.ToUpper(keyword), strconv.FormatBool(readOnly))
+}
+
+// GetStatementReadOnly is to get whether the statements with a keyword are read-only, and if it is overridden.
+func (c *Config) GetStatementReadOnly(keyword string) (readOnly bool, ok bool) {
+	v := c.values.Get("statementReadOnly." + strings.ToUpper(keyword))
+	return v == "true", v != ""
+}
+
+// SetDetachOnCancel is to set if a query keeps running when its context is done, instead of being stopped with
+// StopQueryExecution. The query then fails with a *DetachedQueryError holding its QID, which is useful to only
+// submit queries. It can be overridden for a query with a bool value of DetachOnCancelKey in its context.
+func (c *Config) SetDetachOnCancel(detach bool) {
+	if detach {
+		c.values.Set("detachOnCancel", "true")
+	} else {
+		c.values.Set("detachOnCancel", "false")
+	}
+}
+
+// IsDetachOnCancel is to check if a query keeps running when its context is done.
+func (c *Config) IsDetachOnCancel() bool {
+	return c.values.Get("detachOnCancel") == "true"
+}
+
+// SetTimeoutPolicy is to set how long the driver waits for queries to finish by the type of their statements, and
+// for each phase of a query.
+func (c *Config) SetTimeoutPolicy(policy TimeoutPolicy) {
+	c.values.Set("dmlTimeout", policy.DML.String())
+	c.values.Set("ddlTimeout", policy.DDL.String())
+	c.values.Set("utilityTimeout", policy.Utility.String())
+	c.values.Set("maxTimeout", policy.Max.String())
+	c.values.Set("connectTimeout", policy.Connect.String())
+	c.values.Set("queuedTimeout", policy.Queued.String())
+	c.values.Set("runningTimeout", policy.Running.String())
+	c.values.Set("fetchTimeout", policy.Fetch.String())
+}
+
+// GetTimeoutPolicy is to get how long the driver waits for queries to finish. The timeouts which are not set fall
+// back to the ones set with SetServiceLimitOverride.
+func (c *Config) GetTimeoutPolicy() TimeoutPolicy {
+	var policy TimeoutPolicy
+	for key, timeout := range map[string]*time.Duration{
+		"dmlTimeout":     &policy.DML,
+		"ddlTimeout":     &policy.DDL,
+		"utilityTimeout": &policy.Utility,
+		"maxTimeout":     &policy.Max,
+		"connectTimeout": &policy.Connect,
+		"queuedTimeout":  &policy.Queued,
+		"runningTimeout": &policy.Running,
+		"fetchTimeout":   &policy.Fetch,
+	} {
+		if d, err := time.ParseDuration(c.values.Get(key)); err == nil && d > 0 {
+			*timeout = d
+		}
+	}
+	return c.GetServiceLimitOverride().timeoutPolicy().merge(policy)
+}
+
+// SetZeroTimeMode is to set how zero time.Time arguments are bound: ZeroTimeAsLiteral, ZeroTimeAsNull or
+// ZeroTimeAsError.
+func (c *Config) SetZeroTimeMode(mode string) {
+	c.values.Set("zeroTimeMode", mode)
+}
+
+// GetZeroTimeMode is to get how zero time.Time arguments are bound.
+func (c *Config) GetZeroTimeMode() string {
+	if val := c.values.Get("zeroTimeMode"); val != "" {
+		return val
+	}
+	return ZeroTimeAsLiteral
+}
+
+// SetBooleanLiterals is to set if bool arguments are bound as the true and false literals, which compare with
+// BOOLEAN columns, instead of 1 and 0. It defaults to whether Presto escaping is enabled, see SetPrestoEscaping.
+func (c *Config) SetBooleanLiterals(b bool) {
+	if b {
+		c.values.Set("booleanLiterals", "true")
+	} else {
+		c.values.Set("booleanLiterals", "false")
+	}
+}
+
+// IsBooleanLiterals is to check if bool arguments are bound as the true and false literals.
+func (c *Config) IsBooleanLiterals() bool {
+	if val := c.values.Get("booleanLiterals"); val != "" {
+		return val == "true"
+	}
+	return c.IsPrestoEscaping()
+}
+
+// SetValidateOutputLocation is to set if Connect checks the bucket of the output location exists, is in the
+// region and is writable, so that a misconfigured bucket fails with an OutputLocationError instead of on the first
+// query. It is checked once per connector, and needs s3:PutObject on the output location.
+func (c *Config) SetValidateOutputLocation(b bool) {
+	if b {
+		c.values.Set("validateOutputLocation", "true")
+	} else {
+		c.values.Set("validateOutputLocation", "false")
+	}
+}
+
+// IsValidateOutputLocation is to check if Connect validates the output location.
+func (c *Config) IsValidateOutputLocation() bool {
+	return c.values.Get("validateOutputLocation") == "true"
+}
+
+// SetStrictParams is to set if the string and []byte arguments of parameterized queries must be quoted by
+// QuoteLiteral or QuoteBinary, or be a RawParam, as they are passed to Athena as they are. Other arguments fail the
+// query with an UnformattedParamError, instead of letting an unquoted value change the query.
+func (c *Config) SetStrictParams(b bool) {
+	if b {
+		c.values.Set("strictParams", "true")
+	} else {
+		c.values.Set("strictParams", "false")
+	}
+}
+
+// IsStrictParams is to check if the arguments of parameterized queries are checked to be formatted.
+func (c *Config) IsStrictParams() bool {
+	return c.values.Get("strictParams") == "true"
+}
+
+// SetParseUtilityOutput is to set if the text output of utility statements is parsed into rows. The output of
+// MSCK REPAIR TABLE, a single _col0 column of tab separated partitions, is returned as a row per partition with the
+// table, partition and status columns, where status is one of PartitionNotInMetastore,
+// PartitionMissingFromFilesystem, PartitionAdded and PartitionDropped.
+func (c *Config) SetParseUtilityOutput(b bool) {
+	if b {
+		c.values.Set("parseUtilityOutput", "true")
+	} else {
+		c.values.Set("parseUtilityOutput", "false")
+	}
+}
+
+// IsParseUtilityOutput is to check if the text output of utility statements is parsed into rows.
+func (c *Config) IsParseUtilityOutput() bool {
+	return c.values.Get("parseUtilityOutput") == "true"
+}
+
+// SetMetadataCacheTTL is to set how long the results of the Metadata of a connector are cached.
+func (c *Config) SetMetadataCacheTTL(d time.Duration) {
+	c.values.Set("metadataCacheTTL", d.String())
+}
+
+// GetMetadataCacheTTL is to get how long the results of the Metadata of a connector are cached. It defaults to
+// 10 minutes, like workgroups.
+func (c *Config) GetMetadataCacheTTL() time.Duration {
+	if d, err := time.ParseDuration(c.values.Get("metadataCacheTTL")); err == nil {
+		return d
+	}
+	return 10 * time.Minute
+}
+
+// SetEndpoint is to set the URL the AWS API calls are sent to instead of the AWS endpoints, e.g. the endpoint of
+// LocalStack or another emulator. S3 buckets are then addressed by path rather than by host name.
+func (c *Config) SetEndpoint(endpoint string) {
+	c.values.Set("endpoint", endpoint)
+}
+
+// GetEndpoint is to get the URL the AWS API calls are sent to. It is empty for the AWS endpoints.
+func (c *Config) GetEndpoint() string {
+	return c.values.Get("endpoint")
+}
+
+// SetCompletionNotifier is to set the name of the CompletionNotifier, installed by RegisterCompletionNotifier, which
+// tells when queries are done, so their status is not polled at every poll interval.
+func (c *Config) SetCompletionNotifier(name string) {
+	c.values.Set("completionNotifier", name)
+}
+
+// GetCompletionNotifier is to get the name of the CompletionNotifier which tells when queries are done.
+func (c *Config) GetCompletionNotifier() string {
+	return c.values.Get("completionNotifier")
+}
+
+// SetCompletionNotifierFallback is to set how long to wait for the CompletionNotifier before checking the status of
+// a query anyway, in case its notification is lost.
+func (c *Config) SetCompletionNotifierFallback(d time.Duration) {
+	c.values.Set("completionNotifierFallback", d.String())
+}
+
+// GetCompletionNotifierFallback is to get how long to wait for the CompletionNotifier before checking the status of
+// a query anyway. It defaults to 1 minute.
+func (c *Config) GetCompletionNotifierFallback() time.Duration {
+	if d, err := time.ParseDuration(c.values.Get("completionNotifierFallback")); err == nil && d > 0 {
+		return d
+	}
+	return time.Minute
+}
+
+// SetLineageEmitter is to set the name of the LineageEmitter, installed by RegisterLineageEmitter, which is given
+// an OpenLineage run event when a query starts and when it finishes.
+func (c *Config) SetLineageEmitter(name string) {
+	c.values.Set("lineageEmitter", name)
+}
+
+// GetLineageEmitter is to get the name of the LineageEmitter which is given the OpenLineage run events of queries.
+func (c *Config) GetLineageEmitter() string {
+	return c.values.Get("lineageEmitter")
+}
+
+// SetLineageNamespace is to set the OpenLineage namespace of the jobs of the queries, e.g. the name of the service.
+func (c *Config) SetLineageNamespace(namespace string) {
+	c.values.Set("lineageNamespace", namespace)
+}
+
+// GetLineageNamespace is to get the OpenLineage namespace of the jobs of the queries. It defaults to athenadriver.
+func (c *Config) GetLineageNamespace() string {
+	if val := c.values.Get("lineageNamespace"); val != "" {
+		return val
+	}
+	return "athenadriver"
+}
+
+// SetWGCacheTTL is to set how long the state of an enabled workgroup is cached before it is checked again. 0 checks
+// it for every query.
+func (c *Config) SetWGCacheTTL(d time.Duration) {
+	c.values.Set("wgCacheTTL", d.String())
+}
+
+// GetWGCacheTTL is to get how long the state of an enabled workgroup is cached. It defaults to 10 minutes.
+func (c *Config) GetWGCacheTTL() time.Duration {
+	if d, err := time.ParseDuration(c.values.Get("wgCacheTTL")); err == nil && d >= 0 {
+		return d
+	}
+	return 10 * time.Minute
+}
+
+// SetSkipWGVerification is to set if the workgroup is used without checking it exists and is enabled with
+// GetWorkGroup, e.g. when the IAM policy doesn't allow athena:GetWorkGroup. StartQueryExecution then fails if the
+// workgroup can't be used, and the workgroup is never created remotely.
+func (c *Config) SetSkipWGVerification(b bool) {
+	if b {
+		c.values.Set("skipWGVerification", "true")
+	} else {
+		c.values.Set("skipWGVerification", "false")
+	}
+}
+
+// IsSkipWGVerification is to check if the workgroup is used without checking it with GetWorkGroup.
+func (c *Config) IsSkipWGVerification() bool {
+	return c.values.Get("skipWGVerification") == "true"
+}
+
+// SetResultCleanupOnClose is to set if the result file of a query and its metadata file are deleted from S3 when
+// its rows are closed, so the output location doesn't accumulate results. The result can't be read again by QID,
+// cursor or the QID cache afterwards. Results stored in a query cache are left to the TTL.
+func (c *Config) SetResultCleanupOnClose(b bool) {
+	if b {
+		c.values.Set("resultCleanupOnClose", "true")
+	} else {
+		c.values.Set("resultCleanupOnClose", "false")
+	}
+}
+
+// IsResultCleanupOnClose is to check if the result files of a query are deleted from S3 when its rows are closed.
+func (c *Config) IsResultCleanupOnClose() bool {
+	return c.values.Get("resultCleanupOnClose") == "true"
+}
+
+// SetResultCleanupTTL is to set how long after a query succeeds its result file and metadata file are deleted
+// from S3, whether or not its rows are closed. 0 keeps them.
+func (c *Config) SetResultCleanupTTL(d time.Duration) {
+	c.values.Set("resultCleanupTTL", d.String())
+}
+
+// GetResultCleanupTTL is to get how long after a query succeeds its result files are deleted from S3. It defaults
+// to 0, which keeps them.
+func (c *Config) GetResultCleanupTTL() time.Duration {
+	if d, err := time.ParseDuration(c.values.Get("resultCleanupTTL")); err == nil && d > 0 {
+		return d
+	}
+	return 0
+}
+
+// SetQueryAnnotation is to set if a comment like `/* service=x, trace_id=y, caller=z */` is prepended to the
+// queries, so they can be attributed in the query history of Athena. It has the service of SetAnnotationService,
+// the annotations of QueryAnnotationsKey in the context, and the function which called the driver.
+func (c *Config) SetQueryAnnotation(b bool) {
+	if b {
+		c.values.Set("queryAnnotation", "true")
+	} else {
+		c.values.Set("queryAnnotation", "false")
+	}
+}
+
+// IsQueryAnnotation is to check if a comment attributing the queries is prepended to them.
+func (c *Config) IsQueryAnnotation() bool {
+	return c.values.Get("queryAnnotation") == "true"
+}
+
+// SetAnnotationService is to set the service named in the comment of SetQueryAnnotation.
+func (c *Config) SetAnnotationService(service string) {
+	c.values.Set("annotationService", service)
+}
+
+// GetAnnotationService is to get the service named in the comment of SetQueryAnnotation.
+func (c *Config) GetAnnotationService() string {
+	return c.values.Get("annotationService")
+}
+
+// SetDryRun is to set how the queries are previewed instead of being executed, e.g. by tools checking the cost of
+// queries. SELECT statements are executed as EXPLAIN, or EXPLAIN ANALYZE, and the plan is returned as rows. SHOW,
+// DESCRIBE and EXPLAIN statements are executed as usual, and any other statement fails with ErrDryRunUnsupported.
+// DryRunKey in the context of a query overrides it.
+func (c *Config) SetDryRun(mode DryRunMode) {
+	c.values.Set("dryRun", string(mode))
+}
+
+// GetDryRun is to get how the queries are previewed instead of being executed. It defaults to DryRunOff.
+func (c *Config) GetDryRun() DryRunMode {
+	return DryRunMode(c.values.Get("dryRun"))
+}
+
+// SetAPIMiddlewares is to name the APIMiddlewares, installed with RegisterAPIMiddleware, which the Athena clients of
+// the connections add to the middleware stack of their API calls, in order.
+func (c *Config) SetAPIMiddlewares(names ...string) {
+	c.values.Del("apiMiddleware")
+	for _, name := range names {
+		c.values.Add("apiMiddleware", name)
+	}
+}
+
+// GetAPIMiddlewares is to get the names of the APIMiddlewares of the Athena clients.
+func (c *Config) GetAPIMiddlewares() []string {
+	return c.values["apiMiddleware"]
+}
+
+// SetQueryRetries is to set how many times a read-only query which Athena fails transiently, e.g. with an internal
+// error or HIVE_CURSOR_ERROR, is executed again. 0, the default, does not execute failed queries again.
+func (c *Config) SetQueryRetries(n int) {
+	c.values.Set("queryRetries", strconv.Itoa(n))
+}
+
+// GetQueryRetries is to get how many times a read-only query which fails transiently is executed again.
+func (c *Config) GetQueryRetries() int {
+	n, err := strconv.Atoi(c.values.Get("queryRetries"))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// SetQueryRetryBackoff is to set how long to wait before executing a failed query again. It is doubled for every
+// further retry.
+func (c *Config) SetQueryRetryBackoff(d time.Duration) {
+	c.values.Set("queryRetryBackoff", d.String())
+}
+
+// GetQueryRetryBackoff is to get how long to wait before executing a failed query again. It defaults to 1 second.
+func (c *Config) GetQueryRetryBackoff() time.Duration {
+	if d, err := time.ParseDuration(c.values.Get("queryRetryBackoff")); err == nil && d >= 0 {
+		return d
+	}
+	return time.Second
+}
+
+// SetCircuitBreaker is to make the connections of a connector fail fast with a *CircuitOpenError once threshold
+// consecutive Athena API calls have failed with a 5xx status or without a response, e.g. during a regional outage,
+// instead of piling up calls to a broken endpoint. A single call is let through every cooldown to probe if Athena is
+// back. A threshold of 0, the default, disables it.
+func (c *Config) SetCircuitBreaker(threshold int, cooldown time.Duration) {
+	c.values.Set("circuitBreakerThreshold", strconv.Itoa(threshold))
+	c.values.Set("circuitBreakerCooldown", cooldown.String())
+}
+
+// GetCircuitBreaker is to get after how many consecutive failures the circuit breaker opens, 0 if it is disabled,
+// and how often it probes Athena while it is open, 30 seconds by default.
+func (c *Config) GetCircuitBreaker() (int, time.Duration) {
+	threshold, err := strconv.Atoi(c.values.Get("circuitBreakerThreshold"))
+	if err != nil || threshold < 0 {
+		threshold = 0
+	}
+	cooldown, err := time.ParseDuration(c.values.Get("circuitBreakerCooldown"))
+	if err != nil || cooldown <= 0 {
+		cooldown = 30 * time.Second
+	}
+	return threshold, cooldown
+}
+
+// SetResultTransport is to name the ResultTransport which reads query results, ResultTransportAPI,
+// ResultTransportS3CSV or one installed with RegisterResultTransport. Without it, results are paged through
+// GetQueryResults, or streamed from S3 when Config.SetS3ResultThreshold is set.
+func (c *Config) SetResultTransport(name string) {
+	c.values.Set("resultTransport", name)
+}
+
+// GetResultTransport is to get the name of the ResultTransport which reads query results.
+func (c *Config) GetResultTransport() string {
+	return c.values.Get("resultTransport")
+}
+
+// SetMaxSliceParamLength is to set how many elements a slice argument, expanded into a parenthesized IN list, may
+// have, DefaultMaxSliceParamLength by default. A longer slice fails the query rather than making it too long.
+func (c *Config) SetMaxSliceParamLength(n int) {
+	c.values.Set("maxSliceParamLength", strconv.Itoa(n))
+}
+
+// GetMaxSliceParamLength is to get how many elements a slice argument may have.
+func (c *Config) GetMaxSliceParamLength() int {
+	n, err := strconv.Atoi(c.values.Get("maxSliceParamLength"))
+	if err != nil || n <= 0 {
+		return DefaultMaxSliceParamLength
+	}
+	return n
+}
+
+// SetExecutionParamOverflow is to set what happens to a parameterized query with an execution parameter longer than
+// Athena allows, or empty: ExecutionParamOverflowError, the default, fails it with an *ExecutionParamLimitError
+// before it is submitted, and ExecutionParamOverflowInterpolate runs it with the parameters bound into the query.
+func (c *Config) SetExecutionParamOverflow(mode string) {
+	c.values.Set("executionParamOverflow", mode)
+}
+
+// GetExecutionParamOverflow is to get what happens to a query with an execution parameter out of the limits.
+func (c *Config) GetExecutionParamOverflow() string {
+	if val := c.values.Get("executionParamOverflow"); val != "" {
+		return val
+	}
+	return ExecutionParamOverflowError
+}
+
+// SetIcebergTransactions is to let Begin start pseudo-transactions for writing to Iceberg tables, for ORMs which
+// insist on transactions. The INSERT, UPDATE, DELETE and MERGE statements executed in such a transaction are queued,
+// with 0 rows affected, and run one after another on Commit, each atomically. Other statements fail, and queries
+// don't see the queued statements. Commit stops at the first statement which fails with a *TxCommitError, and the
+// statements before it stay committed. Rollback discards the queued statements.
+func (c *Config) SetIcebergTransactions(b bool) {
+	if b {
+		c.values.Set("icebergTransactions", "true")
+	} else {
+		c.values.Set("icebergTransactions", "false")
+	}
+}
+
+// IsIcebergTransactions is to check if Begin starts pseudo-transactions for Iceberg tables.
+func (c *Config) IsIcebergTransactions() bool {
+	return c.values.Get("icebergTransactions") == "true"
+}
+
+// SetNoOpTransactions is to let Begin start no-op transactions, for frameworks which refuse to run without
+// transactions: every statement executed in one is committed right away, Commit does nothing, and Rollback returns a
+// *TxRollbackWarning if statements were executed. Config.SetIcebergTransactions takes precedence over it.
+func (c *Config) SetNoOpTransactions(b bool) {
+	if b {
+		c.values.Set("noOpTransactions", "true")
+	} else {
+		c.values.Set("noOpTransactions", "false")
+	}
+}
+
+// IsNoOpTransactions is to check if Begin starts no-op transactions.
+func (c *Config) IsNoOpTransactions() bool {
+	return c.values.Get("noOpTransactions") == "true"
+}
+
+// SetNamespace is to name a catalog and database pair, e.g. "sales" for the catalog and database of the sales data
+// in the environment, so the code which runs queries in it with WithNamespace doesn't embed their names.
+func (c *Config) SetNamespace(name string, n Namespace) {
+	c.values.Set("namespace."+name+".catalog", n.Catalog)
+	c.values.Set("namespace."+name+".database", n.Database)
+}
+
+// GetNamespace is to get a catalog and database pair named by SetNamespace, and if it is set.
+func (c *Config) GetNamespace(name string) (Namespace, bool) {
+	if !c.values.Has("namespace." + name + ".database") {
+		return Namespace{}, false
+	}
+	return Namespace{
+		Catalog:  c.values.Get("namespace." + name + ".catalog"),
+		Database: c.values.Get("namespace." + name + ".database"),
+	}, true
+}
+
+// SetAuditSink is to set the name of the AuditSink, installed by RegisterAuditSink, which is given an AuditRecord
+// when a query execution finishes.
+func (c *Config) SetAuditSink(name string) {
+	c.values.Set("auditSink", name)
+}
+
+// GetAuditSink is to get the name of the AuditSink which is given the audit records of queries.
+func (c *Config) GetAuditSink() string {
+	return c.values.Get("auditSink")
+}
+
+// SetAuditFingerprint is to set if audit records have the Fingerprint of the query rather than its text, which may
+// have sensitive values in its literals.
+func (c *Config) SetAuditFingerprint(b bool) {
+	if b {
+		c.values.Set("auditFingerprint", "true")
+	} else {
+		c.values.Set("auditFingerprint", "false")
+	}
+}
+
+// IsAuditFingerprint is to check if audit records have the Fingerprint of the query rather than its text.
+func (c *Config) IsAuditFingerprint() bool {
+	return c.values.Get("auditFingerprint") == "true"
+}
+
+// SetStatementCacheSize is to set how many prepared queries each connection keeps by query text, so that preparing
+// the same query again, as some frameworks do for every execution, skips analyzing it. The least recently used
+// ones are evicted first. 0 disables the cache, and the default is DefaultStatementCacheSize.
+func (c *Config) SetStatementCacheSize(n int) {
+	c.values.Set("statementCacheSize", strconv.Itoa(n))
+}
+
+// GetStatementCacheSize is to get how many prepared queries each connection keeps.
+func (c *Config) GetStatementCacheSize() int {
+	n, err := strconv.Atoi(c.values.Get("statementCacheSize"))
+	if err != nil || n < 0 {
+		return DefaultStatementCacheSize
+	}
+	return n
+}
+
+// SetMaxConnectionAge is to set how long a connection is used for, after which database/sql replaces it, like
+// sql.DB.SetConnMaxLifetime, but enforced by the driver for every pool of the connector. Connections whose
+// credentials expire, e.g. STS sessions, expire shortly before them in any case. 0, the default, is no limit.
+func (c *Config) SetMaxConnectionAge(d time.Duration) {
+	c.values.Set("maxConnectionAge", d.String())
+}
+
+// GetMaxConnectionAge is to get how long a connection is used for.
+func (c *Config) GetMaxConnectionAge() time.Duration {
+	if d, err := time.ParseDuration(c.values.Get("maxConnectionAge")); err == nil && d > 0 {
+		return d
+	}
+	return 0
+}
@@ -21,6 +21,7 @@
 package athenadriver
 
 import (
+	"context"
 	"net/url"
 	"testing"
 	"time"
@@ -210,6 +211,50 @@ func TestConfig_NewConfig(t *testing.T) {
 	assert.Nil(t, x)
 }
 
+func TestParseDSN(t *testing.T) {
+	wgTags := NewWGTags()
+	wgTags.AddTag("Uber User", "henry.wu@uber.com")
+	testConf := NewNoOpsConfig()
+	assert.Nil(t, testConf.SetOutputBucket("s3://results/{yyyy}/{MM}/{workgroup} logs/"))
+	assert.Nil(t, testConf.SetRegion("us-west-2"))
+	assert.Nil(t, testConf.SetWorkGroup(NewDefaultWG("henry_wu", nil, wgTags)))
+	assert.Nil(t, testConf.SetAccessID("AKIA/+EXAMPLE"))
+	assert.Nil(t, testConf.SetSecretAccessKey("se+cr/et=&?#"))
+	testConf.SetSessionToken("token with spaces")
+	testConf.SetUser("henry.wu@uber.com")
+	testConf.SetDB("sales db")
+	testConf.SetEndpoint("http://localhost:4566/")
+	testConf.SetStatementReadOnly("MSCK", true)
+	testConf.SetTimeoutPolicy(TimeoutPolicy{DML: time.Minute, Fetch: time.Second})
+	testConf.SetAPIRateLimit("GetQueryExecution", 2.5)
+	testConf.SetDryRun(DryRunExplain)
+
+	dsn := testConf.Stringify()
+	parsed, err := ParseDSN(dsn)
+	assert.Nil(t, err)
+	assert.Equal(t, dsn, parsed.Stringify())
+	assert.Equal(t, testConf.values, parsed.values)
+	assert.Equal(t, testConf.GetOutputBucket(), parsed.GetOutputBucket())
+	assert.Equal(t, testConf.GetUser(), parsed.GetUser())
+	assert.Equal(t, testConf.GetSecretAccessKey(), parsed.GetSecretAccessKey())
+	assert.Equal(t, testConf.GetWorkgroup(), parsed.GetWorkgroup())
+	assert.Equal(t, testConf.GetTimeoutPolicy(), parsed.GetTimeoutPolicy())
+
+	// a parsed DSN round-trips as well
+	again, err := ParseDSN(parsed.Stringify())
+	assert.Nil(t, err)
+	assert.Equal(t, dsn, again.Stringify())
+
+	_, err = ParseDSN("s3://results/")
+	assert.Equal(t, ErrConfigInvalidConfig, err)
+	_, err = ParseDSN("https://results/?region=us-east-1")
+	assert.Equal(t, ErrConfigInvalidConfig, err)
+	_, err = ParseDSN("s3://results/?region=us-east-1&db=%zz")
+	assert.NotNil(t, err)
+	_, err = ParseDSN("s3://results/?region=us-east-1")
+	assert.Nil(t, err)
+}
+
 func TestConfig_GetWorkgroup(t *testing.T) {
 	wg := NewDefaultWG("henry_wu", nil, nil)
 	testConf := NewNoOpsConfig()
@@ -333,3 +378,479 @@ func TestConfig_ResultPollIntervalDefault(t *testing.T) {
 	interval := testConf.GetResultPollIntervalSeconds()
 	assert.Equal(t, time.Second*time.Duration(PoolInterval), interval)
 }
+
+func TestConfig_CapacityReservation(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.Equal(t, "", testConf.GetCapacityReservation())
+	assert.False(t, testConf.IsCapacityFailFast())
+	testConf.SetCapacityReservation("reservation_a")
+	testConf.SetCapacityFailFast(true)
+	assert.Equal(t, "reservation_a", testConf.GetCapacityReservation())
+	assert.True(t, testConf.IsCapacityFailFast())
+	testConf.SetCapacityFailFast(false)
+	assert.False(t, testConf.IsCapacityFailFast())
+}
+
+func TestConfig_SetPrestoEscaping(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.False(t, testConf.IsPrestoEscaping())
+	testConf.SetPrestoEscaping(true)
+	assert.True(t, testConf.IsPrestoEscaping())
+	testConf.SetPrestoEscaping(false)
+	assert.False(t, testConf.IsPrestoEscaping())
+}
+
+func TestConfig_SetNullAsNil(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.False(t, testConf.IsNullAsNil())
+	testConf.SetNullAsNil(true)
+	assert.True(t, testConf.IsNullAsNil())
+	testConf.SetNullAsNil(false)
+	assert.False(t, testConf.IsNullAsNil())
+}
+
+func TestConfig_SetTypedIPAddressAndUUID(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.False(t, testConf.IsTypedIPAddressAndUUID())
+	testConf.SetTypedIPAddressAndUUID(true)
+	assert.True(t, testConf.IsTypedIPAddressAndUUID())
+	testConf.SetTypedIPAddressAndUUID(false)
+	assert.False(t, testConf.IsTypedIPAddressAndUUID())
+}
+
+func TestConfig_SetJSONAsRawMessage(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.False(t, testConf.IsJSONAsRawMessage())
+	testConf.SetJSONAsRawMessage(true)
+	assert.True(t, testConf.IsJSONAsRawMessage())
+	testConf.SetJSONAsRawMessage(false)
+	assert.False(t, testConf.IsJSONAsRawMessage())
+}
+
+func TestConfig_SetRawStringMode(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.False(t, testConf.IsRawStringMode())
+	testConf.SetRawStringMode(true)
+	assert.True(t, testConf.IsRawStringMode())
+	testConf.SetRawStringMode(false)
+	assert.False(t, testConf.IsRawStringMode())
+}
+
+func TestConfig_SetGeometryMode(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.Equal(t, GeometryAsBytes, testConf.GetGeometryMode())
+	testConf.SetGeometryMode(GeometryAsWKT)
+	assert.Equal(t, GeometryAsWKT, testConf.GetGeometryMode())
+}
+
+func TestConfig_SetPrefetchPages(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.Equal(t, 0, testConf.GetPrefetchPages())
+	testConf.SetPrefetchPages(4)
+	assert.Equal(t, 4, testConf.GetPrefetchPages())
+	testConf.SetPrefetchPages(-1)
+	assert.Equal(t, 0, testConf.GetPrefetchPages())
+}
+
+func TestConfig_SetQueryCoalescing(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.False(t, testConf.IsQueryCoalescing())
+	assert.Equal(t, time.Duration(0), testConf.GetQueryCoalescingWindow())
+	testConf.SetQueryCoalescing(true)
+	assert.True(t, testConf.IsQueryCoalescing())
+	testConf.SetQueryCoalescingWindow(2 * time.Second)
+	assert.Equal(t, 2*time.Second, testConf.GetQueryCoalescingWindow())
+}
+
+func TestConfig_SetQueryCache(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.Equal(t, "", testConf.GetQueryCache())
+	testConf.SetQueryCache("memory")
+	assert.Equal(t, "memory", testConf.GetQueryCache())
+}
+
+func TestConfig_SetInitialPollInterval(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.Equal(t, InitialPollInterval, testConf.GetInitialPollInterval())
+	assert.Equal(t, FastPollPeriod, testConf.GetFastPollPeriod())
+	testConf.SetInitialPollInterval(50 * time.Millisecond)
+	assert.Equal(t, 50*time.Millisecond, testConf.GetInitialPollInterval())
+	testConf.SetFastPollPeriod(2 * time.Second)
+	assert.Equal(t, 2*time.Second, testConf.GetFastPollPeriod())
+}
+
+func TestConfig_SetMaxConcurrentQueries(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.Equal(t, 0, testConf.GetMaxConcurrentQueries())
+	testConf.SetMaxConcurrentQueries(20)
+	assert.Equal(t, 20, testConf.GetMaxConcurrentQueries())
+}
+
+func TestConfig_SetAPIRateLimit(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.Equal(t, 0.0, testConf.GetAPIRateLimit(APIGetQueryExecution))
+	testConf.SetAPIRateLimit(APIGetQueryExecution, 2.5)
+	assert.Equal(t, 2.5, testConf.GetAPIRateLimit(APIGetQueryExecution))
+	assert.Equal(t, 0.0, testConf.GetAPIRateLimit(APIGetQueryResults))
+}
+
+func TestConfig_SetS3ResultThreshold(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.Equal(t, int64(0), testConf.GetS3ResultThreshold())
+	testConf.SetS3ResultThreshold(64 << 20)
+	assert.Equal(t, int64(64<<20), testConf.GetS3ResultThreshold())
+}
+
+func TestConfig_SetSpillToDisk(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.False(t, testConf.IsSpillToDisk())
+	assert.Equal(t, int64(DefaultSpillMemoryLimit), testConf.GetSpillMemoryLimit())
+	assert.Equal(t, "", testConf.GetSpillDir())
+	testConf.SetSpillToDisk(true)
+	testConf.SetSpillMemoryLimit(1 << 20)
+	testConf.SetSpillDir("/var/tmp")
+	assert.True(t, testConf.IsSpillToDisk())
+	assert.Equal(t, int64(1<<20), testConf.GetSpillMemoryLimit())
+	assert.Equal(t, "/var/tmp", testConf.GetSpillDir())
+}
+
+func TestConfig_SetStatementReadOnly(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	_, ok := testConf.GetStatementReadOnly("MSCK")
+	assert.False(t, ok)
+	testConf.SetStatementReadOnly("msck", true)
+	readOnly, ok := testConf.GetStatementReadOnly("MSCK")
+	assert.True(t, ok)
+	assert.True(t, readOnly)
+	testConf.SetStatementReadOnly("MSCK", false)
+	readOnly, ok = testConf.GetStatementReadOnly("msck")
+	assert.True(t, ok)
+	assert.False(t, readOnly)
+}
+
+func TestConfig_SetDetachOnCancel(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.False(t, testConf.IsDetachOnCancel())
+	testConf.SetDetachOnCancel(true)
+	assert.True(t, testConf.IsDetachOnCancel())
+	testConf.SetDetachOnCancel(false)
+	assert.False(t, testConf.IsDetachOnCancel())
+}
+
+func TestConfig_SetTimeoutPolicy(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.Equal(t, TimeoutPolicy{}, testConf.GetTimeoutPolicy())
+
+	serviceLimitOverride := NewServiceLimitOverride()
+	_ = serviceLimitOverride.SetDMLQueryTimeout(60 * 60)
+	_ = serviceLimitOverride.SetDDLQueryTimeout(2 * 60 * 60)
+	testConf.SetServiceLimitOverride(*serviceLimitOverride)
+	assert.Equal(t, TimeoutPolicy{DML: time.Hour, DDL: 2 * time.Hour}, testConf.GetTimeoutPolicy())
+
+	testConf.SetTimeoutPolicy(TimeoutPolicy{DML: 5 * time.Minute, Max: time.Hour})
+	assert.Equal(t, TimeoutPolicy{DML: 5 * time.Minute, DDL: 2 * time.Hour, Max: time.Hour},
+		testConf.GetTimeoutPolicy())
+
+	testConf.SetTimeoutPolicy(TimeoutPolicy{Connect: 5 * time.Second, Queued: time.Minute, Running: 30 * time.Minute,
+		Fetch: 10 * time.Minute})
+	policy := testConf.GetTimeoutPolicy()
+	assert.Equal(t, 5*time.Second, policy.Connect)
+	assert.Equal(t, time.Minute, policy.Queued)
+	assert.Equal(t, 30*time.Minute, policy.Running)
+	assert.Equal(t, 10*time.Minute, policy.Fetch)
+	assert.Equal(t, time.Hour, policy.DML)
+}
+
+func TestConfig_SetZeroTimeMode(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.Equal(t, ZeroTimeAsLiteral, testConf.GetZeroTimeMode())
+	testConf.SetZeroTimeMode(ZeroTimeAsNull)
+	assert.Equal(t, ZeroTimeAsNull, testConf.GetZeroTimeMode())
+}
+
+func TestConfig_SetBooleanLiterals(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.False(t, testConf.IsBooleanLiterals())
+	testConf.SetPrestoEscaping(true)
+	assert.True(t, testConf.IsBooleanLiterals())
+	testConf.SetBooleanLiterals(false)
+	assert.False(t, testConf.IsBooleanLiterals())
+	testConf.SetPrestoEscaping(false)
+	testConf.SetBooleanLiterals(true)
+	assert.True(t, testConf.IsBooleanLiterals())
+}
+
+func TestConfig_SetValidateOutputLocation(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.False(t, testConf.IsValidateOutputLocation())
+	testConf.SetValidateOutputLocation(true)
+	assert.True(t, testConf.IsValidateOutputLocation())
+	testConf.SetValidateOutputLocation(false)
+	assert.False(t, testConf.IsValidateOutputLocation())
+}
+
+func TestConfig_SetStrictParams(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.False(t, testConf.IsStrictParams())
+	testConf.SetStrictParams(true)
+	assert.True(t, testConf.IsStrictParams())
+	testConf.SetStrictParams(false)
+	assert.False(t, testConf.IsStrictParams())
+}
+
+func TestConfig_SetParseUtilityOutput(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.False(t, testConf.IsParseUtilityOutput())
+	testConf.SetParseUtilityOutput(true)
+	assert.True(t, testConf.IsParseUtilityOutput())
+	testConf.SetParseUtilityOutput(false)
+	assert.False(t, testConf.IsParseUtilityOutput())
+}
+
+func TestConfig_SetMetadataCacheTTL(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.Equal(t, 10*time.Minute, testConf.GetMetadataCacheTTL())
+	testConf.SetMetadataCacheTTL(time.Minute)
+	assert.Equal(t, time.Minute, testConf.GetMetadataCacheTTL())
+	testConf.SetMetadataCacheTTL(0)
+	assert.Equal(t, time.Duration(0), testConf.GetMetadataCacheTTL())
+}
+
+func TestConfig_SetCatalog(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.Equal(t, "", testConf.GetCatalog())
+	assert.Equal(t, testConf.GetDB(), NamespaceFromContext(context.Background(), testConf).String())
+	testConf.SetCatalog("dynamo")
+	assert.Equal(t, "dynamo", testConf.GetCatalog())
+	assert.Equal(t, "dynamo."+testConf.GetDB(), NamespaceFromContext(context.Background(), testConf).String())
+}
+
+func TestConfig_SetEndpoint(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.Equal(t, "", testConf.GetEndpoint())
+	testConf.SetEndpoint("http://localhost:4566")
+	assert.Equal(t, "http://localhost:4566", testConf.GetEndpoint())
+
+	testConf, err := NewEmulatorConfig("http://localhost:4566", "s3://results/prefix")
+	assert.Nil(t, err)
+	assert.Equal(t, "http://localhost:4566", testConf.GetEndpoint())
+	assert.Equal(t, "s3://results/prefix", testConf.GetOutputBucket())
+	assert.Equal(t, "test", testConf.GetAccessID())
+	assert.Equal(t, "test", testConf.GetSecretAccessKey())
+
+	_, err = NewEmulatorConfig("http://localhost:4566", "results")
+	assert.Equal(t, ErrConfigOutputLocation, err)
+}
+
+func TestConfig_SetCompletionNotifier(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.Equal(t, "", testConf.GetCompletionNotifier())
+	assert.Equal(t, time.Minute, testConf.GetCompletionNotifierFallback())
+	testConf.SetCompletionNotifier("sqs")
+	testConf.SetCompletionNotifierFallback(5 * time.Minute)
+	assert.Equal(t, "sqs", testConf.GetCompletionNotifier())
+	assert.Equal(t, 5*time.Minute, testConf.GetCompletionNotifierFallback())
+}
+
+func TestConfig_SetLineageEmitter(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.Equal(t, "", testConf.GetLineageEmitter())
+	assert.Equal(t, "athenadriver", testConf.GetLineageNamespace())
+	testConf.SetLineageEmitter("marquez")
+	testConf.SetLineageNamespace("reports")
+	assert.Equal(t, "marquez", testConf.GetLineageEmitter())
+	assert.Equal(t, "reports", testConf.GetLineageNamespace())
+}
+
+func TestConfig_SetWGCacheTTL(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.Equal(t, 10*time.Minute, testConf.GetWGCacheTTL())
+	testConf.SetWGCacheTTL(0)
+	assert.Equal(t, time.Duration(0), testConf.GetWGCacheTTL())
+	testConf.SetWGCacheTTL(time.Hour)
+	assert.Equal(t, time.Hour, testConf.GetWGCacheTTL())
+}
+
+func TestConfig_SetSkipWGVerification(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.False(t, testConf.IsSkipWGVerification())
+	testConf.SetSkipWGVerification(true)
+	assert.True(t, testConf.IsSkipWGVerification())
+	testConf.SetSkipWGVerification(false)
+	assert.False(t, testConf.IsSkipWGVerification())
+}
+
+func TestConfig_SetResultCleanup(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.False(t, testConf.IsResultCleanupOnClose())
+	assert.Equal(t, time.Duration(0), testConf.GetResultCleanupTTL())
+	testConf.SetResultCleanupOnClose(true)
+	testConf.SetResultCleanupTTL(24 * time.Hour)
+	assert.True(t, testConf.IsResultCleanupOnClose())
+	assert.Equal(t, 24*time.Hour, testConf.GetResultCleanupTTL())
+	testConf.SetResultCleanupOnClose(false)
+	assert.False(t, testConf.IsResultCleanupOnClose())
+}
+
+func TestConfig_expandOutputLocation(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.Nil(t, testConf.SetOutputBucket("s3://results/prefix/"))
+	start := time.Date(2024, 3, 9, 7, 30, 0, 0, time.FixedZone("PST", -8*3600))
+	assert.Equal(t, "s3://results/prefix/", testConf.expandOutputLocation("primary", start))
+
+	assert.Nil(t, testConf.SetOutputBucket("s3://results/{yyyy}/{MM}/{dd}/{HH}/{workgroup}/{database}/{user}/"))
+	testConf.SetDB("sales")
+	testConf.SetUser("henry.wu")
+	assert.Equal(t, "s3://results/2024/03/09/15/primary/sales/henry.wu/", testConf.expandOutputLocation("primary", start))
+
+	// the template survives the DSN
+	parsed, err := NewConfig(testConf.Stringify())
+	assert.Nil(t, err)
+	assert.Equal(t, testConf.GetOutputBucket(), parsed.GetOutputBucket())
+}
+
+func TestConfig_SetQueryAnnotation(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.False(t, testConf.IsQueryAnnotation())
+	assert.Equal(t, "", testConf.GetAnnotationService())
+	testConf.SetQueryAnnotation(true)
+	testConf.SetAnnotationService("billing")
+	assert.True(t, testConf.IsQueryAnnotation())
+	assert.Equal(t, "billing", testConf.GetAnnotationService())
+	testConf.SetQueryAnnotation(false)
+	assert.False(t, testConf.IsQueryAnnotation())
+}
+
+func TestConfig_SetDryRun(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.Equal(t, DryRunOff, testConf.GetDryRun())
+	testConf.SetDryRun(DryRunExplainAnalyze)
+	assert.Equal(t, DryRunExplainAnalyze, testConf.GetDryRun())
+	testConf.SetDryRun(DryRunOff)
+	assert.Equal(t, DryRunOff, testConf.GetDryRun())
+}
+
+func TestConfig_SetAPIMiddlewares(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.Nil(t, testConf.GetAPIMiddlewares())
+	testConf.SetAPIMiddlewares("headers", "logging")
+	assert.Equal(t, []string{"headers", "logging"}, testConf.GetAPIMiddlewares())
+
+	parsed, err := ParseDSN(testConf.Stringify())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"headers", "logging"}, parsed.GetAPIMiddlewares())
+
+	testConf.SetAPIMiddlewares()
+	assert.Nil(t, testConf.GetAPIMiddlewares())
+}
+
+func TestConfig_SetQueryRetries(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.Equal(t, 0, testConf.GetQueryRetries())
+	assert.Equal(t, time.Second, testConf.GetQueryRetryBackoff())
+	testConf.SetQueryRetries(3)
+	testConf.SetQueryRetryBackoff(5 * time.Second)
+	assert.Equal(t, 3, testConf.GetQueryRetries())
+	assert.Equal(t, 5*time.Second, testConf.GetQueryRetryBackoff())
+	testConf.SetQueryRetries(-1)
+	assert.Equal(t, 0, testConf.GetQueryRetries())
+}
+
+func TestConfig_SetCircuitBreaker(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	threshold, cooldown := testConf.GetCircuitBreaker()
+	assert.Equal(t, 0, threshold)
+	assert.Equal(t, 30*time.Second, cooldown)
+	testConf.SetCircuitBreaker(5, time.Minute)
+	threshold, cooldown = testConf.GetCircuitBreaker()
+	assert.Equal(t, 5, threshold)
+	assert.Equal(t, time.Minute, cooldown)
+}
+
+func TestConfig_SetQueryCacheTTL(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.Equal(t, time.Hour, testConf.GetQueryCacheTTL())
+	testConf.SetQueryCacheTTL(10 * time.Minute)
+	assert.Equal(t, 10*time.Minute, testConf.GetQueryCacheTTL())
+}
+
+func TestConfig_SetResultTransport(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.Equal(t, "", testConf.GetResultTransport())
+	testConf.SetResultTransport(ResultTransportS3CSV)
+	assert.Equal(t, ResultTransportS3CSV, testConf.GetResultTransport())
+}
+
+func TestConfig_SetMaxSliceParamLength(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.Equal(t, DefaultMaxSliceParamLength, testConf.GetMaxSliceParamLength())
+	testConf.SetMaxSliceParamLength(10)
+	assert.Equal(t, 10, testConf.GetMaxSliceParamLength())
+	testConf.SetMaxSliceParamLength(0)
+	assert.Equal(t, DefaultMaxSliceParamLength, testConf.GetMaxSliceParamLength())
+}
+
+func TestConfig_SetExecutionParamOverflow(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.Equal(t, ExecutionParamOverflowError, testConf.GetExecutionParamOverflow())
+	testConf.SetExecutionParamOverflow(ExecutionParamOverflowInterpolate)
+	assert.Equal(t, ExecutionParamOverflowInterpolate, testConf.GetExecutionParamOverflow())
+}
+
+func TestConfig_SetIcebergTransactions(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.False(t, testConf.IsIcebergTransactions())
+	testConf.SetIcebergTransactions(true)
+	assert.True(t, testConf.IsIcebergTransactions())
+	testConf.SetIcebergTransactions(false)
+	assert.False(t, testConf.IsIcebergTransactions())
+}
+
+func TestConfig_SetNoOpTransactions(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.False(t, testConf.IsNoOpTransactions())
+	testConf.SetNoOpTransactions(true)
+	assert.True(t, testConf.IsNoOpTransactions())
+	testConf.SetNoOpTransactions(false)
+	assert.False(t, testConf.IsNoOpTransactions())
+}
+
+func TestConfig_SetNamespace(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	_, ok := testConf.GetNamespace("sales")
+	assert.False(t, ok)
+	testConf.SetNamespace("sales", Namespace{Catalog: "dynamo", Database: "orders"})
+	n, ok := testConf.GetNamespace("sales")
+	assert.True(t, ok)
+	assert.Equal(t, Namespace{Catalog: "dynamo", Database: "orders"}, n)
+}
+
+func TestConfig_SetAuditSink(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.Equal(t, "", testConf.GetAuditSink())
+	assert.False(t, testConf.IsAuditFingerprint())
+	testConf.SetAuditSink("audit")
+	testConf.SetAuditFingerprint(true)
+	assert.Equal(t, "audit", testConf.GetAuditSink())
+	assert.True(t, testConf.IsAuditFingerprint())
+}
+
+func TestConfig_SetStatementCacheSize(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.Equal(t, DefaultStatementCacheSize, testConf.GetStatementCacheSize())
+	testConf.SetStatementCacheSize(10)
+	assert.Equal(t, 10, testConf.GetStatementCacheSize())
+	testConf.SetStatementCacheSize(0)
+	assert.Equal(t, 0, testConf.GetStatementCacheSize())
+	testConf.SetStatementCacheSize(-1)
+	assert.Equal(t, DefaultStatementCacheSize, testConf.GetStatementCacheSize())
+}
+
+func TestConfig_SetMaxConnectionAge(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.Equal(t, time.Duration(0), testConf.GetMaxConnectionAge())
+	testConf.SetMaxConnectionAge(time.Hour)
+	assert.Equal(t, time.Hour, testConf.GetMaxConnectionAge())
+	testConf.SetMaxConnectionAge(-time.Hour)
+	assert.Equal(t, time.Duration(0), testConf.GetMaxConnectionAge())
+}
@@ -29,6 +29,8 @@ import (
 	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
@@ -42,13 +44,45 @@ import (
 // TIMESTAMP columns, as Athena timestamp columns only have a millisecond granularity.
 const timestampFormatDriverMicro = "2006-01-02 15:04:05.000000"
 
-// Connection is a connection to AWS Athena. It is not used concurrently by multiple goroutines.
-// Connection is assumed to be stateful.
+const (
+	// ZeroTimeAsLiteral binds zero time.Time arguments as '0000-00-00', which Athena cannot parse. It is the default
+	// but deprecated, and ZeroTimeAsNull will become the default in the next major version.
+	ZeroTimeAsLiteral = "literal"
+
+	// ZeroTimeAsNull binds zero time.Time arguments as NULL.
+	ZeroTimeAsNull = "null"
+
+	// ZeroTimeAsError fails queries with zero time.Time arguments with ErrZeroTime.
+	ZeroTimeAsError = "error"
+)
+
+// Connection is a connection to AWS Athena. It is safe for concurrent use by multiple goroutines, e.g. through
+// sql.Conn.Raw, as all the state of a query lives in the call and its Rows, and the fields are not changed after
+// Connect, except the transaction started by Begin. The Config of its connector must not be changed meanwhile. After
+// Close, QueryContext, ExecContext, Prepare and ResumeRows fail with driver.ErrBadConn. Rows and Statement are not
+// safe for concurrent use.
 type Connection struct {
 	athenaClient AthenaClient
+	s3Client     s3ObjectClient
+	s3Deleter    s3ObjectDeleter
 
 	connector *SQLConnector
-	numInput  int
+	closed    atomic.Bool
+	// tx is the transaction started by Begin, see Config.SetIcebergTransactions and Config.SetNoOpTransactions.
+	tx atomic.Pointer[pseudoTx]
+	// expiresAt is when the connection expires, see Config.SetMaxConnectionAge, and credentialsExpired is set once
+	// Athena rejected its credentials as expired.
+	expiresAt          time.Time
+	credentialsExpired atomic.Bool
+	// stmts are the queries analyzed by Prepare, see Config.SetStatementCacheSize.
+	stmtsOnce sync.Once
+	stmts     *stmtCache
+}
+
+// tracer is to get the tracer of a query, with the logger and metrics scope of its context, see WithLogger and
+// WithMetrics.
+func (c *Connection) tracer(ctx context.Context) *DriverTracer {
+	return c.connector.tracer.withContext(ctx)
 }
 
 // buildExecutionParams converts Go data types into strings for query arguments in parameterized queries.
@@ -57,8 +91,13 @@ func (c *Connection) buildExecutionParams(args []driver.Value) ([]string, error)
 		return nil, nil
 	}
 
+	strict := c.connector.config.IsStrictParams()
 	executionParams := []string{}
-	for _, arg := range args {
+	for i, arg := range args {
+		arg, err := convertParam(arg)
+		if err != nil {
+			return []string{}, err
+		}
 		if arg == nil {
 			executionParams = append(executionParams, "NULL")
 			continue
@@ -73,7 +112,9 @@ func (c *Connection) buildExecutionParams(args []driver.Value) ([]string, error)
 		case float64:
 			val = strconv.FormatFloat(v, 'g', -1, 64)
 		case bool:
-			if v {
+			if c.connector.config.IsBooleanLiterals() {
+				val = strconv.FormatBool(v)
+			} else if v {
 				val = "1"
 			} else {
 				val = "0"
@@ -85,8 +126,12 @@ func (c *Connection) buildExecutionParams(args []driver.Value) ([]string, error)
 			// For DATE/TIME/TIMESTAMP, it is better to pass in string arguments with a typecast. Refer to the string
 			// case below.
 			// Matches interpolateParams() behavior.
-			val = "'0000-00-00'" // Special-cased.
-			if !v.IsZero() {
+			if v.IsZero() {
+				var err error
+				if val, err = c.zeroTimeValue(); err != nil {
+					return []string{}, err
+				}
+			} else {
 				v := v.In(time.UTC)
 				v = v.Add(time.Nanosecond * 500) // To round under microsecond
 				dateFormat := timestampFormatDriverMicro
@@ -101,6 +146,11 @@ func (c *Connection) buildExecutionParams(args []driver.Value) ([]string, error)
 			// Like the string case below, enclosing in single quotes would prevent typecasting or function calls in
 			// execution parameters. Prior to passing in query arguments, Format* functions in utils.go can be used.
 			val = string(v)
+			if strict && !isQuotedLiteral(val, true) {
+				return []string{}, &UnformattedParamError{Ordinal: i + 1}
+			}
+		case time.Duration, YearMonthInterval:
+			val, _ = formatIntervalLiteral(v)
 		case string:
 			// Note: Different from interpolateParams() behavior.
 			// For parameterized queries, typecasting or function calls go in the execution parameters. For example,
@@ -108,6 +158,11 @@ func (c *Connection) buildExecutionParams(args []driver.Value) ([]string, error)
 			// `TIMESTAMP '2024-07-01 00:00:00.000'` (arg). Therefore, we cannot simply enclose the full string with
 			// single quotes here. Users should use the Format* functions in utils.go to format input string arguments.
 			val = v
+			if strict && !isQuotedLiteral(val, false) {
+				return []string{}, &UnformattedParamError{Ordinal: i + 1}
+			}
+		case RawParam:
+			val = string(v)
 		default:
 			return []string{}, ErrQueryUnknownType
 		}
@@ -116,27 +171,65 @@ func (c *Connection) buildExecutionParams(args []driver.Value) ([]string, error)
 	return executionParams, nil
 }
 
+// zeroTimeValue is to get what a zero time.Time argument is bound as, according to the zero time mode.
+func (c *Connection) zeroTimeValue() (string, error) {
+	switch c.connector.config.GetZeroTimeMode() {
+	case ZeroTimeAsNull:
+		return "NULL", nil
+	case ZeroTimeAsError:
+		return "", ErrZeroTime
+	}
+	c.connector.tracer.Scope().Counter(DriverName + ".deprecated.zerotimeliteral").Inc(1)
+	return "'0000-00-00'", nil
+}
+
+// queryBufferPool keeps the buffers interpolateParams builds queries in, so a busy service does not allocate
+// a MAXQueryStringLength buffer per query.
+var queryBufferPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, MAXQueryStringLength)
+		return &b
+	},
+}
+
 func (c *Connection) interpolateParams(query string, args []driver.Value) (string, error) {
-	c.numInput = len(args)
 	// Number of ? should be same to len(args)
-	if strings.Count(query, "?") != c.numInput {
+	if countPlaceholders(query) != len(args) {
 		return "", ErrInvalidQuery
 	}
 
-	queryBuffer := make([]byte, MAXQueryStringLength)
-	queryBuffer = queryBuffer[:0]
+	// Backslash escaping is MySQL style and is deprecated: Athena takes backslashes literally, so an escaped
+	// string can silently change its value. Presto escaping will become the default in the next major version.
+	prestoEscaping := c.connector.config.IsPrestoEscaping()
+	if !prestoEscaping {
+		c.connector.tracer.Scope().Counter(DriverName + ".deprecated.backslashescaping").Inc(1)
+	}
+	booleanLiterals := c.connector.config.IsBooleanLiterals()
+
+	bufferPtr := queryBufferPool.Get().(*[]byte)
+	queryBuffer := (*bufferPtr)[:0]
+	defer func() {
+		// Buffers grown for an oversized query are left to the GC instead of being pinned by the pool.
+		if cap(queryBuffer) <= MAXQueryStringLength {
+			*bufferPtr = queryBuffer
+			queryBufferPool.Put(bufferPtr)
+		}
+	}()
 	argPos := 0
 
 	for i := 0; i < len(query); i++ {
-		q := strings.IndexByte(query[i:], '?')
+		q := nextPlaceholder(query, i)
 		if q == -1 {
 			queryBuffer = append(queryBuffer, query[i:]...)
 			break
 		}
-		queryBuffer = append(queryBuffer, query[i:i+q]...)
-		i += q
+		queryBuffer = append(queryBuffer, query[i:q]...)
+		i = q
 
-		arg := args[argPos]
+		arg, err := convertParam(args[argPos])
+		if err != nil {
+			return "", err
+		}
 		argPos++
 
 		if arg == nil {
@@ -152,14 +245,20 @@ func (c *Connection) interpolateParams(query string, args []driver.Value) (strin
 		case float64:
 			queryBuffer = strconv.AppendFloat(queryBuffer, v, 'g', -1, 64)
 		case bool:
-			if v {
+			if booleanLiterals {
+				queryBuffer = strconv.AppendBool(queryBuffer, v)
+			} else if v {
 				queryBuffer = append(queryBuffer, '1')
 			} else {
 				queryBuffer = append(queryBuffer, '0')
 			}
 		case time.Time:
 			if v.IsZero() {
-				queryBuffer = append(queryBuffer, "'0000-00-00'"...)
+				zero, err := c.zeroTimeValue()
+				if err != nil {
+					return "", err
+				}
+				queryBuffer = append(queryBuffer, zero...)
 			} else {
 				v := v.In(time.UTC)
 				v = v.Add(time.Nanosecond * 500) // To round under microsecond
@@ -203,19 +302,33 @@ func (c *Connection) interpolateParams(query string, args []driver.Value) (strin
 				queryBuffer = append(queryBuffer, '\'')
 			}
 		case []byte:
-			queryBuffer = append(queryBuffer, "_binary'"...)
-			queryBuffer = escapeBytesBackslash(queryBuffer, v)
-			queryBuffer = append(queryBuffer, '\'')
+			if prestoEscaping {
+				queryBuffer = appendHexBinary(queryBuffer, v)
+			} else {
+				queryBuffer = append(queryBuffer, "_binary'"...)
+				queryBuffer = escapeBytesBackslash(queryBuffer, v)
+				queryBuffer = append(queryBuffer, '\'')
+			}
+		case time.Duration, YearMonthInterval:
+			literal, _ := formatIntervalLiteral(v)
+			queryBuffer = append(queryBuffer, literal...)
 		case string:
 			queryBuffer = append(queryBuffer, '\'')
-			queryBuffer = escapeStringBackslash(queryBuffer, v)
+			if prestoEscaping {
+				queryBuffer = escapeStringQuotes(queryBuffer, v)
+			} else {
+				queryBuffer = escapeStringBackslash(queryBuffer, v)
+			}
 			queryBuffer = append(queryBuffer, '\'')
+		case RawParam:
+			queryBuffer = append(queryBuffer, v...)
 		default:
 			return "", ErrQueryUnknownType
 		}
 
-		if len(queryBuffer)+4 > 10*MAXQueryStringLength {
-			return "", ErrQueryBufferOF
+		if len(queryBuffer) > MAXQueryStringLength {
+			return "", fmt.Errorf("%w, got over %d bytes after interpolating %d of %d arguments", ErrQueryTooLong,
+				len(queryBuffer), argPos, len(args))
 		}
 	}
 	return string(queryBuffer), nil
@@ -223,14 +336,31 @@ func (c *Connection) interpolateParams(query string, args []driver.Value) (strin
 
 // CheckNamedValue is to implement interface driver.NamedValueChecker.
 func (c *Connection) CheckNamedValue(nv *driver.NamedValue) (err error) {
-	nv.Value, err = driver.DefaultParameterConverter.ConvertValue(nv.Value)
+	switch nv.Value.(type) {
+	case time.Duration, YearMonthInterval:
+		// kept as is to be rendered as INTERVAL literals
+		return nil
+	case RawParam:
+		return nil
+	}
+	if isSliceParam(nv.Value) {
+		// kept as is to be expanded into an IN list, see expandSliceParams
+		return nil
+	}
+	nv.Value, err = convertParam(nv.Value)
 	return
 }
 
 // ExecContext executes a query that doesn't return rows, such as an INSERT or UPDATE.
 func (c *Connection) ExecContext(ctx context.Context, query string, namedArgs []driver.NamedValue) (driver.Result, error) {
-	var obs = c.connector.tracer
-	var err error
+	if !c.IsValid() {
+		return nil, driver.ErrBadConn
+	}
+	var obs = c.tracer(ctx)
+	query, namedArgs, err := c.bindParams(query, namedArgs)
+	if err != nil {
+		return nil, err
+	}
 	args := namedValueToValue(namedArgs)
 	if len(namedArgs) > 0 {
 		query, err = c.interpolateParams(query, args)
@@ -239,17 +369,26 @@ func (c *Connection) ExecContext(ctx context.Context, query string, namedArgs []
 		}
 		obs.Scope().Counter(DriverName + ".execcontext").Inc(1)
 	}
-	if !isQueryValid(query) {
-		return nil, ErrInvalidQuery
+	if err := validateQueryLength(query); err != nil {
+		return nil, err
+	}
+	tx := c.tx.Load()
+	if tx != nil && !tx.noOp {
+		return tx.add(query)
 	}
 	rows, err := c.QueryContext(ctx, query, []driver.NamedValue{})
 	if err != nil {
 		return nil, err
 	}
+	if tx != nil {
+		tx.executedOne()
+	}
 	var rowAffected int64 = 0
 	r := rows.(*Rows)
 	if r != nil && r.ResultOutput != nil && r.ResultOutput.UpdateCount != nil {
 		rowAffected = *r.ResultOutput.UpdateCount
+	} else if r != nil && r.queryExecution != nil && !isReadOnlyStatement(query, c.connector.config) {
+		rowAffected = c.rowsWritten(ctx, r.queryID)
 	}
 	var lastInsertedID int64 = -1
 	result := AthenaResult{
@@ -259,6 +398,24 @@ func (c *Connection) ExecContext(ctx context.Context, query string, namedArgs []
 	return result, nil
 }
 
+// rowsWritten is to get how many rows a query execution wrote from its runtime statistics, for the statements whose
+// results don't have an update count. It is 0 if the statistics are not available.
+func (c *Connection) rowsWritten(ctx context.Context, queryID string) int64 {
+	resp, err := c.athenaClient.GetQueryRuntimeStatistics(ctx, &athena.GetQueryRuntimeStatisticsInput{
+		QueryExecutionId: aws.String(queryID),
+	})
+	if err != nil {
+		c.tracer(ctx).Scope().Counter(DriverName + ".failure.execcontext.getqueryruntimestatistics").Inc(1)
+		c.tracer(ctx).Log(WarnLevel, "GetQueryRuntimeStatistics failed", zap.String("queryID", queryID),
+			zap.String("error", err.Error()))
+		return 0
+	}
+	if stats := resp.QueryRuntimeStatistics; stats != nil && stats.Rows != nil && stats.Rows.OutputRows != nil {
+		return *stats.Rows.OutputRows
+	}
+	return 0
+}
+
 func (c *Connection) cachedQuery(ctx context.Context, QID string) (driver.Rows, error) {
 	if c.connector.config.IsMoneyWise() {
 		dataScanned := int64(0)
@@ -275,11 +432,11 @@ func (c *Connection) cachedQuery(ctx context.Context, QID string) (driver.Rows,
 	if wg.Name == "" {
 		wg.Name = DefaultWGName
 	}
-	return NewRows(ctx, c.athenaClient, QID, c.connector.config, c.connector.tracer)
+	return NewRows(ctx, c.athenaClient, QID, c.connector.config, c.tracer(ctx))
 }
 
 func (c *Connection) getHeaderlessSingleRowResultPage(ctx context.Context, qid string) (driver.Rows, error) {
-	r, err := NewNonOpsRows(ctx, c.athenaClient, qid, c.connector.config, c.connector.tracer)
+	r, err := NewNonOpsRows(ctx, c.athenaClient, qid, c.connector.config, c.tracer(ctx))
 	colName := "_col0"
 	columnNames := []string{colName}
 	columnTypes := []string{"string"}
@@ -289,6 +446,56 @@ func (c *Connection) getHeaderlessSingleRowResultPage(ctx context.Context, qid s
 	return r, err
 }
 
+// getHeaderlessSingleColumnResultPage returns one row per value in a single string column.
+func (c *Connection) getHeaderlessSingleColumnResultPage(ctx context.Context, colName string,
+	values []string) (driver.Rows, error) {
+	r, err := NewNonOpsRows(ctx, c.athenaClient, "", c.connector.config, c.tracer(ctx))
+	columnNames := []string{colName}
+	columnTypes := []string{"string"}
+	data := make([][]*string, len(values))
+	for i := range values {
+		data[i] = []*string{&values[i]}
+	}
+	r.ResultOutput = newHeaderlessResultPage(columnNames, columnTypes, data)
+	return r, err
+}
+
+// checkWorkgroup is to get the workgroup of the config, checking it is enabled, or creating it if it doesn't exist
+// and remote creation is allowed. The check is skipped if Config.SetSkipWGVerification is set.
+func (c *Connection) checkWorkgroup(ctx context.Context) (Workgroup, error) {
+	var obs = c.tracer(ctx)
+	wg := c.connector.config.GetWorkgroup()
+	if wg.Name == "" {
+		wg.Name = DefaultWGName
+	} else if wg.Name != DefaultWGName && !c.connector.config.IsSkipWGVerification() {
+		athenaWG, err := getWG(ctx, c.athenaClient, wg.Name, c.connector.config.GetWGCacheTTL())
+		if err != nil {
+			obs.Scope().Counter(DriverName + ".failure.querycontext.getwg").Inc(1)
+			obs.Log(WarnLevel, "Didn't find workgroup "+wg.Name+" due to: "+err.Error())
+			if c.connector.config.IsWGRemoteCreationAllowed() {
+				err = wg.CreateWGRemotely(ctx, c.athenaClient)
+				if err != nil {
+					obs.Scope().Counter(DriverName + ".failure.querycontext.createwgremotely").Inc(1)
+					return Workgroup{}, err
+				}
+				obs.Log(DebugLevel, "workgroup "+wg.Name+" is created successfully.")
+			} else {
+				obs.Log(WarnLevel, "workgroup "+DefaultWGName+" is used for "+wg.Name+".")
+				return Workgroup{},
+					fmt.Errorf("workgroup %q doesn't exist and workgroup remote creation is disabled", wg.Name)
+			}
+		} else {
+			if athenaWG.State != athenatypes.WorkGroupStateEnabled {
+				obs.Log(WarnLevel, "workgroup "+DefaultWGName+" is disabled.")
+				obs.Scope().Counter(DriverName + ".failure.querycontext.wgdisabled").Inc(1)
+				return Workgroup{}, fmt.Errorf("workgroup %q is disabled", wg.Name)
+			}
+			obs.Log(DebugLevel, "workgroup "+DefaultWGName+" is enabled.")
+		}
+	}
+	return wg, nil
+}
+
 // QueryContext is implemented to be called by `DB.Query` (QueryerContext interface).
 //
 // "QueryerContext is an optional interface that may be implemented by a Conn.
@@ -300,7 +507,11 @@ func (c *Connection) getHeaderlessSingleRowResultPage(ctx context.Context, qid s
 // With QueryContext implemented, we don't need Queryer.
 // QueryerContext must honor the context timeout and return when the context is canceled.
 func (c *Connection) QueryContext(ctx context.Context, query string, namedArgs []driver.NamedValue) (driver.Rows, error) {
-	var obs = c.connector.tracer
+	if !c.IsValid() {
+		return nil, driver.ErrBadConn
+	}
+	ctx = c.withQueryCaller(ctx)
+	var obs = c.tracer(ctx)
 	var pseudoCommand = ""
 	if strings.HasPrefix(query, "pc:") {
 		query = strings.Trim(query[3:], " ")
@@ -312,58 +523,66 @@ func (c *Connection) QueryContext(ctx context.Context, query string, namedArgs [
 			query = strings.Trim(query[len(pseudoCommand):], " ")
 		} else if pseudoCommand = PCGetDriverVersion; strings.HasPrefix(query, pseudoCommand) {
 			return c.getHeaderlessSingleRowResultPage(ctx, DriverVersion)
+		} else if pseudoCommand = PCCancelAll; query == pseudoCommand {
+			if c.connector.config.IsReadOnly() {
+				return nil, fmt.Errorf("pseudo command " + PCCancelAll + " is disallowed in read-only mode")
+			}
+			stopped, err := c.CancelAll(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return c.getHeaderlessSingleColumnResultPage(ctx, "query_id", stopped)
 		} else {
 			return nil, fmt.Errorf("pseudo command " + query + "doesn't exist")
 		}
 	}
 	if c.connector.config.IsReadOnly() {
-		if !isReadOnlyStatement(query) {
+		if !isReadOnlyStatement(query, c.connector.config) {
 			obs.Scope().Counter(DriverName + ".failure.querycontext.writeviolation").Inc(1)
 			obs.Log(WarnLevel, "write db violation", zap.String("query", query))
 			return nil, fmt.Errorf("writing to Athena database is disallowed in read-only mode")
 		}
 	}
 	now := time.Now()
+	query, namedArgs, err := c.bindParams(query, namedArgs)
+	if err != nil {
+		return nil, err
+	}
 	args := namedValueToValue(namedArgs)
 	queryWithPlaceholders := query // For parameterized queries
-	var err error
 	if len(namedArgs) > 0 {
 		query, err = c.interpolateParams(query, args)
-		if err != nil {
+		if errors.Is(err, ErrQueryTooLong) {
+			// Athena gets the query with placeholders and the arguments as execution parameters, so only the
+			// query with placeholders has to be within the limit. The interpolated one is just for logging.
+			query = queryWithPlaceholders
+		} else if err != nil {
 			return nil, err
 		}
 		obs.Scope().Counter(DriverName + ".prepared.querycontext").Inc(1)
 	}
-	if !isQueryValid(query) {
-		return nil, ErrInvalidQuery
-	}
-	wg := c.connector.config.GetWorkgroup()
-	if wg.Name == "" {
-		wg.Name = DefaultWGName
-	} else if wg.Name != DefaultWGName {
-		athenaWG, err := getWG(ctx, c.athenaClient, wg.Name)
+	if mode := c.dryRunMode(ctx); mode != DryRunOff && pseudoCommand == "" && !IsQID(query) {
+		prefix, err := dryRunPrefix(query, mode)
 		if err != nil {
-			obs.Scope().Counter(DriverName + ".failure.querycontext.getwg").Inc(1)
-			obs.Log(WarnLevel, "Didn't find workgroup "+wg.Name+" due to: "+err.Error())
-			if c.connector.config.IsWGRemoteCreationAllowed() {
-				err = wg.CreateWGRemotely(ctx, c.athenaClient)
-				if err != nil {
-					obs.Scope().Counter(DriverName + ".failure.querycontext.createwgremotely").Inc(1)
-					return nil, err
-				}
-				obs.Log(DebugLevel, "workgroup "+wg.Name+" is created successfully.")
-			} else {
-				obs.Log(WarnLevel, "workgroup "+DefaultWGName+" is used for "+wg.Name+".")
-				return nil,
-					fmt.Errorf("workgroup %q doesn't exist and workgroup remote creation is disabled", wg.Name)
-			}
-		} else {
-			if athenaWG.State != athenatypes.WorkGroupStateEnabled {
-				obs.Log(WarnLevel, "workgroup "+DefaultWGName+" is disabled.")
-				obs.Scope().Counter(DriverName + ".failure.querycontext.wgdisabled").Inc(1)
-				return nil, fmt.Errorf("workgroup %q is disabled", wg.Name)
-			}
-			obs.Log(DebugLevel, "workgroup "+DefaultWGName+" is enabled.")
+			return nil, err
+		}
+		query, queryWithPlaceholders = prefix+query, prefix+queryWithPlaceholders
+	}
+	if err := validateQueryLength(queryWithPlaceholders); err != nil {
+		return nil, err
+	}
+	wg, err := c.checkWorkgroup(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if reservation := c.connector.config.GetCapacityReservation(); reservation != "" &&
+		c.connector.config.IsCapacityFailFast() && !IsQID(query) {
+		if err := c.connector.checkCapacityReservation(ctx, c.athenaClient, reservation); err != nil {
+			obs.Scope().Counter(DriverName + ".failure.querycontext.capacityunavailable").Inc(1)
+			obs.Log(WarnLevel, "capacity reservation check failed", zap.String("capacityReservation", reservation),
+				zap.String("error", err.Error()))
+			return nil, err
 		}
 	}
 
@@ -409,120 +628,403 @@ func (c *Connection) QueryContext(ctx context.Context, query string, namedArgs [
 	if err != nil {
 		return nil, err
 	}
-	resp, err := c.athenaClient.StartQueryExecution(ctx, &athena.StartQueryExecutionInput{
-		QueryString:         aws.String(queryWithPlaceholders),
-		ExecutionParameters: executionParams,
-		QueryExecutionContext: &athenatypes.QueryExecutionContext{
-			Database: aws.String(c.connector.config.GetDB()),
-		},
-		ResultConfiguration: &athenatypes.ResultConfiguration{
-			OutputLocation: aws.String(c.connector.config.GetOutputBucket()),
-		},
-		WorkGroup: aws.String(wg.Name),
-	})
+	queryWithPlaceholders, executionParams, err = c.fitExecutionParams(queryWithPlaceholders, executionParams)
 	if err != nil {
-		if pseudoCommand == PCGetQID {
+		return nil, err
+	}
+	transport := c.resultTransport()
+	var rewritten bool
+	if pseudoCommand == "" {
+		queryWithPlaceholders, rewritten = rewriteQuery(transport, queryWithPlaceholders)
+	}
+	var cache QueryCache
+	var fingerprint string
+	db := NamespaceFromContext(ctx, c.connector.config).String()
+	reuse := pseudoCommand == "" && !rewritten && isResultReuse(ctx, query, c.connector.config)
+	readOnly := pseudoCommand == "" && !rewritten && isReadOnlyStatement(query, c.connector.config)
+	if name := c.connector.config.GetQueryCache(); name != "" && (reuse || readOnly) {
+		if cache, _ = getQueryCache(name); cache != nil {
+			fingerprint = queryFingerprint(queryWithPlaceholders, executionParams, db, wg.Name)
+			if !reuse {
+				// The query is executed, and its fresh result is still cached for the queries which reuse it.
+				obs.Scope().Counter(DriverName + ".querycache.bypass").Inc(1)
+			} else {
+				cachedQID, err := cache.Get(ctx, fingerprint)
+				if err != nil {
+					obs.Scope().Counter(DriverName + ".failure.querycache.get").Inc(1)
+					obs.Log(WarnLevel, "query cache lookup failed", zap.String("error", err.Error()))
+				} else if cachedQID != "" {
+					rows, err := c.cachedQuery(ctx, cachedQID)
+					if err == nil {
+						obs.Scope().Counter(DriverName + ".querycache.hit").Inc(1)
+						return rows, nil
+					}
+					// e.g. the result has been removed from the output location, so the query is executed again.
+					obs.Log(WarnLevel, "cached QID is not readable",
+						zap.String("queryID", cachedQID),
+						zap.String("error", err.Error()))
+				}
+				obs.Scope().Counter(DriverName + ".querycache.miss").Inc(1)
+			}
+		}
+	}
+	if pseudoCommand == PCGetQID {
+		queryID, err := c.startQueryExecution(ctx, queryWithPlaceholders, executionParams, wg.Name,
+			startOfStartQueryExecution)
+		if err != nil {
 			var re *awshttp.ResponseError
 			if errors.As(err, &re) {
 				return c.getHeaderlessSingleRowResultPage(ctx, re.ServiceRequestID())
 			}
+			return nil, err
+		}
+		return c.getHeaderlessSingleRowResultPage(ctx, queryID)
+	}
+	var queryExecution *athenatypes.QueryExecution
+	var coalesced, shared bool
+	if reuse && c.connector.config.IsQueryCoalescing() {
+		key := coalescingKey(queryWithPlaceholders, executionParams, db, c.connector.config.GetOutputBucket(),
+			wg.Name)
+		coalesced = true
+		queryExecution, shared, err = c.connector.getQueryCoalescer().do(ctx, key,
+			c.connector.config.GetQueryCoalescingWindow(),
+			func(ctx context.Context) (*athenatypes.QueryExecution, error) {
+				return c.executeQuery(ctx, queryWithPlaceholders, executionParams, query, wg.Name)
+			})
+		if shared {
+			obs.Scope().Counter(DriverName + ".query.coalesced").Inc(1)
 		}
+	} else {
+		queryExecution, err = c.executeQuery(ctx, queryWithPlaceholders, executionParams, query, wg.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cachedFor time.Duration
+	if cache != nil {
+		err := cache.Put(ctx, fingerprint, *queryExecution.QueryExecutionId, c.connector.config.GetQueryCacheTTL())
+		if err != nil {
+			obs.Scope().Counter(DriverName + ".failure.querycache.put").Inc(1)
+			obs.Log(WarnLevel, "query cache update failed", zap.String("error", err.Error()))
+		} else {
+			cachedFor = c.connector.config.GetQueryCacheTTL()
+		}
+	}
+	// The final status already has everything Rows needs, so the first GetQueryResults is issued right away.
+	rows, err := newQueryExecutionRows(ctx, c.athenaClient, queryExecution, c.connector.config, obs)
+	if err != nil {
 		return nil, err
 	}
+	// The result of a coalesced or cached query is read by other rows too, so only the TTL of the first one applies,
+	// and not before the cache entry expires.
+	if !shared {
+		c.scheduleResultCleanup(rows, queryExecution, !coalesced && cachedFor == 0, cachedFor)
+	}
+	if transport != nil {
+		if err := c.readResultWith(ctx, transport, rows, queryExecution, rewritten); err != nil {
+			rows.Close()
+			return nil, err
+		}
+	}
+	return rows, nil
+}
 
-	timeStartQueryExecution := time.Since(startOfStartQueryExecution)
-	now = time.Now()
-	obs.Scope().Timer(DriverName + ".query.startqueryexecution").Record(timeStartQueryExecution)
+// executeQuery starts the execution of a query and waits for it to succeed, holding a slot of the connector's
+// concurrent query limit meanwhile, and executes it again if it fails transiently, see Config.SetQueryRetries. query
+// is the query with its parameters interpolated, for logging.
+func (c *Connection) executeQuery(ctx context.Context, queryWithPlaceholders string, executionParams []string,
+	query string, wgName string) (*athenatypes.QueryExecution, error) {
+	limiter := c.connector.getQueryLimiter()
+	if err := limiter.acquire(ctx, c.tracer(ctx)); err != nil {
+		return nil, err
+	}
+	defer limiter.release()
 
-	queryID := *resp.QueryExecutionId
-	if pseudoCommand == PCGetQID {
-		return c.getHeaderlessSingleRowResultPage(ctx, queryID)
+	return c.executeQueryWithRetries(ctx, query, func() (string, *athenatypes.QueryExecution, error) {
+		start := time.Now()
+		queryID, err := c.startQueryExecution(ctx, queryWithPlaceholders, executionParams, wgName, start)
+		if err != nil {
+			return "", nil, err
+		}
+		qe, err := c.waitQueryExecution(ctx, queryID, query, wgName, start)
+		return queryID, qe, err
+	})
+}
+
+// isDetachOnCancel is to check if a query keeps running when ctx is done, which the context can override.
+func (c *Connection) isDetachOnCancel(ctx context.Context) bool {
+	if detach, ok := ctx.Value(DetachOnCancelKey).(bool); ok {
+		return detach
+	}
+	return c.connector.config.IsDetachOnCancel()
+}
+
+// detachQuery is to leave a query running after ctx is done, returning its QID in a DetachedQueryError.
+func (c *Connection) detachQuery(ctx context.Context, queryID string) error {
+	c.tracer(ctx).Scope().Counter(DriverName + ".query.detached").Inc(1)
+	c.tracer(ctx).Log(InfoLevel, "query detached", zap.String("queryID", queryID))
+	return &DetachedQueryError{QueryID: queryID, Err: ctx.Err()}
+}
+
+// startQueryExecution starts the execution of a query and returns its query ID.
+func (c *Connection) startQueryExecution(ctx context.Context, query string, executionParams []string,
+	wgName string, start time.Time) (string, error) {
+	namespace := NamespaceFromContext(ctx, c.connector.config)
+	executionContext := &athenatypes.QueryExecutionContext{
+		Database: aws.String(namespace.Database),
 	}
-WAITING_FOR_RESULT:
+	if namespace.Catalog != "" {
+		executionContext.Catalog = aws.String(namespace.Catalog)
+	}
+	resp, err := c.athenaClient.StartQueryExecution(ctx, &athena.StartQueryExecutionInput{
+		QueryString:           aws.String(c.annotateQuery(ctx, query)),
+		ExecutionParameters:   executionParams,
+		QueryExecutionContext: executionContext,
+		ResultConfiguration: &athenatypes.ResultConfiguration{
+			OutputLocation: aws.String(c.connector.config.expandOutputLocation(wgName, start)),
+		},
+		WorkGroup: aws.String(wgName),
+	})
+	if err != nil {
+		if isExpiredToken(err) {
+			// Nothing ran, so database/sql can retry on a connection with fresh credentials.
+			c.credentialsExpired.Store(true)
+			c.tracer(ctx).Scope().Counter(DriverName + ".failure.startqueryexecution.expiredtoken").Inc(1)
+			return "", fmt.Errorf("%w: %w", driver.ErrBadConn, err)
+		}
+		return "", err
+	}
+
+	timeStartQueryExecution := time.Since(start)
+	c.tracer(ctx).Scope().Timer(DriverName + ".query.startqueryexecution").Record(timeStartQueryExecution)
+	c.emitLineage(ctx, LineageStart, *resp.QueryExecutionId, query, wgName, nil)
+	return *resp.QueryExecutionId, nil
+}
+
+// waitQueryExecution polls the status of a query execution until it succeeds, and returns the final status.
+// It fails if the query execution fails, is canceled, times out by the TimeoutPolicy or ctx is done, which stops the query execution
+// unless detaching on cancel is enabled.
+func (c *Connection) waitQueryExecution(ctx context.Context, queryID string, query string, wgName string,
+	start time.Time) (*athenatypes.QueryExecution, error) {
+	var obs = c.tracer(ctx)
+	now := time.Now()
+	policy := c.connector.config.GetTimeoutPolicy()
+	if override, ok := ctx.Value(TimeoutPolicyKey).(TimeoutPolicy); ok {
+		policy = policy.merge(override)
+	}
+	var pollInterval time.Duration
+	// runningSince is when the query was first seen RUNNING, for the Running timeout.
+	var runningSince time.Time
+	// pollLatency is how long the last status check took, to tell whether another one can finish by the deadline.
+	var pollLatency time.Duration
 	for {
-		pollInterval := c.connector.config.GetResultPollIntervalSeconds()
+		pollInterval = nextPollInterval(c.connector.config, time.Since(now), pollInterval)
+		polledAt := time.Now()
 		statusResp, err := c.athenaClient.GetQueryExecution(ctx, &athena.GetQueryExecutionInput{
 			QueryExecutionId: aws.String(queryID),
 		})
+		pollLatency = time.Since(polledAt)
 		if err != nil {
+			if ctx.Err() != nil && c.isDetachOnCancel(ctx) {
+				return nil, c.detachQuery(ctx, queryID)
+			}
 			obs.Log(ErrorLevel, "GetQueryExecutionWithContext failed",
-				zap.String("workgroup", wg.Name),
+				zap.String("workgroup", wgName),
 				zap.String("queryID", queryID),
 				zap.String("error", err.Error()))
 			obs.Scope().Counter(DriverName + ".failure.querycontext.getqueryexecutionwithcontext").Inc(1)
 			return nil, err
 		}
+		reportProgress(ctx, statusResp.QueryExecution, start)
 		//statementType = statusResp.QueryExecution.StatementType
 		switch statusResp.QueryExecution.Status.State {
 		case athenatypes.QueryExecutionStateCancelled:
 			timeCanceled := time.Since(now)
 			obs.Log(ErrorLevel, "QueryExecutionStateCancelled",
-				zap.String("workgroup", wg.Name),
+				zap.String("workgroup", wgName),
 				zap.String("queryID", queryID))
 			obs.Scope().Timer(DriverName + ".query.canceled").Record(timeCanceled)
 			if c.connector.config.IsMoneyWise() {
 				printCost(statusResp)
 			}
+			c.emitLineage(ctx, LineageAbort, queryID, query, wgName, statusResp.QueryExecution)
+			c.writeAudit(ctx, queryID, query, wgName, start, athenatypes.QueryExecutionStateCancelled,
+				statusResp.QueryExecution)
 			return nil, context.Canceled
 		case athenatypes.QueryExecutionStateFailed:
-			reason := *statusResp.QueryExecution.Status.StateChangeReason
+			reason := aws.ToString(statusResp.QueryExecution.Status.StateChangeReason)
 			timeQueryExecutionStateFailed := time.Since(now)
 			obs.Log(ErrorLevel, "QueryExecutionStateFailed",
-				zap.String("workgroup", wg.Name),
+				zap.String("workgroup", wgName),
 				zap.String("queryID", queryID),
 				zap.String("reason", reason))
 			obs.Scope().Timer(DriverName + ".query.queryexecutionstatefailed").Record(timeQueryExecutionStateFailed)
-			return nil, errors.New(reason)
+			c.emitLineage(ctx, LineageFail, queryID, query, wgName, statusResp.QueryExecution)
+			c.writeAudit(ctx, queryID, query, wgName, start, athenatypes.QueryExecutionStateFailed,
+				statusResp.QueryExecution)
+			if fedErr := newFederatedQueryError(statusResp.QueryExecution); fedErr != nil {
+				obs.Scope().Counter(DriverName + ".failure.federated").Inc(1)
+				return nil, fedErr
+			}
+			return nil, newQueryFailedError(statusResp.QueryExecution, reason)
 		case athenatypes.QueryExecutionStateSucceeded:
 			if c.connector.config.IsMoneyWise() {
 				printCost(statusResp)
 			}
 			timeQueryExecutionStateSucceeded := time.Since(now)
 			obs.Scope().Timer(DriverName + ".query.queryexecutionstatesucceeded").Record(timeQueryExecutionStateSucceeded)
-			break WAITING_FOR_RESULT
-		// for athena.QueryExecutionStateQueued and athena.QueryExecutionStateRunning
+			c.emitLineage(ctx, LineageComplete, queryID, query, wgName, statusResp.QueryExecution)
+			c.writeAudit(ctx, queryID, query, wgName, start, athenatypes.QueryExecutionStateSucceeded,
+				statusResp.QueryExecution)
+			return statusResp.QueryExecution, nil
+		case athenatypes.QueryExecutionStateRunning:
+			if runningSince.IsZero() {
+				runningSince = time.Now()
+			}
+		// for athena.QueryExecutionStateQueued
 		default:
 		}
 
-		select {
-		case <-ctx.Done():
-			_, err := c.athenaClient.
-				StopQueryExecution(context.Background(), &athena.StopQueryExecutionInput{
-					QueryExecutionId: aws.String(queryID),
-				})
-			if err != nil {
-				obs.Log(ErrorLevel, "StopQueryExecution failed",
-					zap.String("workgroup", wg.Name),
+		wait := pollInterval
+		if deadline, ok := ctx.Deadline(); ok {
+			var expired bool
+			wait, expired = deadlinePollInterval(time.Until(deadline), pollLatency, pollInterval)
+			if expired && !c.isDetachOnCancel(ctx) {
+				obs.Log(ErrorLevel, "query deadline cannot be met",
+					zap.String("workgroup", wgName),
 					zap.String("queryID", queryID),
 					zap.String("query", query))
-				obs.Scope().Counter(DriverName + ".failure.querycontext.stopqueryexecution.failed").Inc(1)
+				obs.Scope().Counter(DriverName + ".failure.querycontext.deadline").Inc(1)
+				if err := c.stopQuery(ctx, queryID, query, wgName, start, statusResp.QueryExecution); err != nil {
+					return nil, err
+				}
+				return nil, &DeadlineError{QueryID: queryID, State: statusResp.QueryExecution.Status.State,
+					Deadline: deadline}
+			}
+		}
+		nextPoll, stopWaiting := c.waitNextPoll(ctx, queryID, wait)
+		select {
+		case <-ctx.Done():
+			stopWaiting()
+			if c.isDetachOnCancel(ctx) {
+				return nil, c.detachQuery(ctx, queryID)
+			}
+			if err := c.stopQuery(ctx, queryID, query, wgName, start, statusResp.QueryExecution); err != nil {
 				return nil, err
 			}
-			if c.connector.config.IsMoneyWise() {
-				statusRespFinal, _ := c.athenaClient.GetQueryExecution(context.Background(), &athena.GetQueryExecutionInput{
-					QueryExecutionId: aws.String(queryID),
-				})
-				printCost(statusRespFinal)
+			if deadline, ok := ctx.Deadline(); ok && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, &DeadlineError{QueryID: queryID, State: statusResp.QueryExecution.Status.State,
+					Deadline: deadline}
 			}
-			obs.Scope().Counter(DriverName + ".failure.querycontext.stopqueryexecution.succeeded").Inc(1)
-			timeStopQueryExecution := time.Since(now)
-			obs.Scope().Timer(DriverName + ".query.StopQueryExecution").Record(timeStopQueryExecution)
-			obs.Log(ErrorLevel, "query canceled", zap.String("queryID", queryID))
 			return nil, ctx.Err()
-		case <-time.After(pollInterval):
-			if isQueryTimeOut(startOfStartQueryExecution, statusResp.QueryExecution.StatementType, c.connector.config.GetServiceLimitOverride()) {
+		case <-nextPoll:
+			stopWaiting()
+			statementType := statusResp.QueryExecution.StatementType
+			if isQueryTimeOut(start, statementType, policy) {
 				obs.Log(ErrorLevel, "Query timeout failure",
-					zap.String("workgroup", wg.Name),
+					zap.String("workgroup", wgName),
 					zap.String("queryID", queryID),
 					zap.String("query", query))
 				obs.Scope().Counter(DriverName + ".failure.querycontext.timeout").Inc(1)
-				return nil, ErrQueryTimeout
+				return nil, &QueryTimeoutError{QueryID: queryID, Timeout: policy.timeout(statementType)}
+			}
+			state := statusResp.QueryExecution.Status.State
+			if state == athenatypes.QueryExecutionStateQueued && policy.Queued > 0 &&
+				time.Since(start) > policy.Queued {
+				obs.Log(ErrorLevel, "Query queue timeout failure",
+					zap.String("workgroup", wgName),
+					zap.String("queryID", queryID),
+					zap.String("query", query))
+				obs.Scope().Counter(DriverName + ".failure.querycontext.queuetimeout").Inc(1)
+				if err := c.stopQuery(ctx, queryID, query, wgName, start, statusResp.QueryExecution); err != nil {
+					return nil, err
+				}
+				return nil, &QueueTimeoutError{QueryID: queryID, Timeout: policy.Queued}
+			}
+			if !runningSince.IsZero() && policy.Running > 0 && time.Since(runningSince) > policy.Running {
+				obs.Log(ErrorLevel, "Query running timeout failure",
+					zap.String("workgroup", wgName),
+					zap.String("queryID", queryID),
+					zap.String("query", query))
+				obs.Scope().Counter(DriverName + ".failure.querycontext.runningtimeout").Inc(1)
+				if err := c.stopQuery(ctx, queryID, query, wgName, start, statusResp.QueryExecution); err != nil {
+					return nil, err
+				}
+				return nil, &RunningTimeoutError{QueryID: queryID, Timeout: policy.Running}
 			}
 			continue
 		}
 	}
 
-	return NewRows(ctx, c.athenaClient, queryID, c.connector.config, obs)
+}
+
+// stopQuery is to stop a query which is still running because its context is done, or is about to be.
+func (c *Connection) stopQuery(ctx context.Context, queryID string, query string, wgName string, start time.Time,
+	qe *athenatypes.QueryExecution) error {
+	var obs = c.tracer(ctx)
+	now := time.Now()
+	_, err := c.athenaClient.
+		StopQueryExecution(context.Background(), &athena.StopQueryExecutionInput{
+			QueryExecutionId: aws.String(queryID),
+		})
+	if err != nil {
+		obs.Log(ErrorLevel, "StopQueryExecution failed",
+			zap.String("workgroup", wgName),
+			zap.String("queryID", queryID),
+			zap.String("query", query))
+		obs.Scope().Counter(DriverName + ".failure.querycontext.stopqueryexecution.failed").Inc(1)
+		return err
+	}
+	if c.connector.config.IsMoneyWise() {
+		statusRespFinal, _ := c.athenaClient.GetQueryExecution(context.Background(), &athena.GetQueryExecutionInput{
+			QueryExecutionId: aws.String(queryID),
+		})
+		printCost(statusRespFinal)
+	}
+	obs.Scope().Counter(DriverName + ".failure.querycontext.stopqueryexecution.succeeded").Inc(1)
+	c.emitLineage(ctx, LineageAbort, queryID, query, wgName, qe)
+	c.writeAudit(ctx, queryID, query, wgName, start, athenatypes.QueryExecutionStateCancelled, nil)
+	timeStopQueryExecution := time.Since(now)
+	obs.Scope().Timer(DriverName + ".query.StopQueryExecution").Record(timeStopQueryExecution)
+	obs.Log(ErrorLevel, "query canceled", zap.String("queryID", queryID))
+	return nil
+}
+
+// deadlinePollInterval returns the interval before the next status check of a query whose context deadline is in
+// remaining, given the latency of the last check and the interval it would otherwise wait. Close to the deadline,
+// the checks get denser so that the last one lands before it, and expired is true when even the shortest wait and
+// another check would overshoot it.
+func deadlinePollInterval(remaining time.Duration, latency time.Duration, pollInterval time.Duration) (
+	wait time.Duration, expired bool) {
+	budget := remaining - latency
+	if budget < MinDeadlinePollInterval {
+		return MinDeadlinePollInterval, true
+	}
+	if wait = budget / 2; wait < MinDeadlinePollInterval {
+		wait = MinDeadlinePollInterval
+	}
+	if wait > pollInterval {
+		wait = pollInterval
+	}
+	return wait, false
+}
+
+// nextPollInterval returns the interval before the next status check of a query which has been polled for elapsed,
+// given the previous interval: the initial poll interval during the fast poll period, and then twice the previous
+// one, up to the poll interval.
+func nextPollInterval(conf *Config, elapsed time.Duration, previous time.Duration) time.Duration {
+	pollInterval := conf.GetResultPollIntervalSeconds()
+	initial := conf.GetInitialPollInterval()
+	if initial <= 0 || initial >= pollInterval {
+		return pollInterval
+	}
+	if elapsed < conf.GetFastPollPeriod() || previous < initial {
+		return initial
+	}
+	if next := 2 * previous; next < pollInterval {
+		return next
+	}
+	return pollInterval
 }
 
 // Ping implements driver.Pinger interface.
@@ -532,9 +1034,14 @@ WAITING_FOR_RESULT:
 // "We've got network connectivity, we can Ping the DB, so we have valid
 // credentials for a SELECT xxx; but ...".
 func (c *Connection) Ping(ctx context.Context) error {
+	if !c.IsValid() {
+		return driver.ErrBadConn
+	}
 	rows, err := c.QueryContext(ctx, "SELECT 1", nil)
 	if err != nil {
-		return driver.ErrBadConn // https://golang.org/pkg/database/sql/driver/#Pinger
+		// https://golang.org/pkg/database/sql/driver/#Pinger
+		c.tracer(ctx).Scope().Counter(DriverName + ".failure.ping").Inc(1)
+		return newPingError(err)
 	}
 	defer rows.Close()
 	return nil
@@ -542,26 +1049,32 @@ func (c *Connection) Ping(ctx context.Context) error {
 
 // Prepare is inherited from Conn interface.
 func (c *Connection) Prepare(query string) (driver.Stmt, error) {
-	if !isQueryValid(query) {
-		return nil, ErrInvalidQuery
+	if !c.IsValid() {
+		return nil, driver.ErrBadConn
 	}
+	if err := validateQueryLength(query); err != nil {
+		return nil, err
+	}
+	prepared := c.statementCache().get(query, prepareQuery)
 	stmt := &Statement{
 		connection: c,
-		query:      query,
+		query:      prepared.query,
 		closed:     false,
-		numInput:   strings.Count(query, "?"),
+		numInput:   prepared.numInput,
 	}
 	return stmt, nil
 }
 
-// Begin is from Conn interface, but no implementation for AWS Athena.
-func (c *Connection) Begin() (driver.Tx, error) {
-	return nil, ErrAthenaTransactionUnsupported
-}
-
-// BeginTx is to replace Begin as it is deprecated.
-func (c *Connection) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
-	return nil, ErrAthenaTransactionUnsupported
+// BeginTx is from ConnBeginTx interface, to replace Begin as it is deprecated. It starts the same pseudo-transactions
+// as Begin, and fails with ErrAthenaTransactionUnsupported for an isolation level other than the default.
+func (c *Connection) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if sql.IsolationLevel(opts.Isolation) != sql.LevelDefault {
+		return nil, ErrAthenaTransactionUnsupported
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.Begin()
 }
 
 // Close is from Conn interface, but no implementation for AWS Athena.
@@ -570,11 +1083,10 @@ func (c *Connection) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx,
 // idle connections, it shouldn't be necessary for drivers to
 // do their own connection caching.
 func (c *Connection) Close() error {
-	c.connector = nil
-	c.athenaClient = nil
-	c.numInput = -1
+	c.closed.Store(true)
 	return nil
 }
 
 var _ driver.QueryerContext = (*Connection)(nil)
+var _ driver.ConnBeginTx = (*Connection)(nil)
 var _ driver.ExecerContext = (*Connection)(nil)
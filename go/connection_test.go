@@ -24,13 +24,19 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
+	"errors"
+	"fmt"
 	"math/rand"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/athena"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -176,8 +182,8 @@ func TestConnection_BeginTx(t *testing.T) {
 		t.Fatal("uint64 not convertible", err)
 	}
 	tx, err := c.BeginTx(context.Background(),
-		&sql.TxOptions{Isolation: sql.
-			LevelSerializable})
+		driver.TxOptions{Isolation: driver.IsolationLevel(sql.
+			LevelSerializable)})
 	assert.Nil(t, tx)
 	assert.Equal(t, err.Error(), "Athena doesn't support transaction statements")
 }
@@ -186,7 +192,7 @@ func TestConnection_Transaction(t *testing.T) {
 	db, _ := sql.Open(DriverName, NewNoOpsConfig().Stringify())
 	tx, err := db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelSerializable})
 	assert.Nil(t, tx)
-	assert.Equal(t, err.Error(), "sql: driver does not support non-default isolation level")
+	assert.Equal(t, err.Error(), "Athena doesn't support transaction statements")
 }
 
 func TestConnection_InterpolateParams(t *testing.T) {
@@ -224,9 +230,10 @@ func TestConnection_InterpolateParams_Query2(t *testing.T) {
 	assert.Equal(t, q, "")
 	assert.NotNil(t, err)
 
+	// other types are converted like database/sql converts them
 	q, err = c.interpolateParams("?", []driver.Value{1})
-	assert.Equal(t, q, "")
-	assert.NotNil(t, err)
+	assert.Equal(t, q, "1")
+	assert.Nil(t, err)
 }
 
 func TestConnection_InterpolateParams_Bool(t *testing.T) {
@@ -266,16 +273,58 @@ func TestConnection_InterpolateParams_Bool(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestConnection_InterpolateParamsQuotedPlaceholders(t *testing.T) {
+	c := createConnectionFixture()
+	q, err := c.interpolateParams("SELECT 'why?' AS \"a?\", ? -- and?\nFROM t WHERE b = ?", []driver.Value{int64(1),
+		"x"})
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT 'why?' AS \"a?\", 1 -- and?\nFROM t WHERE b = 'x'", q)
+
+	_, err = c.interpolateParams("SELECT 'why?'", []driver.Value{int64(1)})
+	assert.Equal(t, ErrInvalidQuery, err)
+
+	stmt, err := c.Prepare("SELECT 'why?' FROM t WHERE a = ?")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, stmt.NumInput())
+}
+
 // We don't support placeholder in string literal for now.
 // https://github.com/go-sql-driver/mysql/pull/490
 func TestInterpolateParamsPlaceholderInString(t *testing.T) {
 	c := createTestConnection(t)
 
 	q, err := c.interpolateParams("SELECT 'abc?xyz',?", []driver.Value{int64(42)})
-	// When InterpolateParams support string literal, this should return `"SELECT 'abc?xyz', 42`
-	if err != ErrInvalidQuery {
-		t.Errorf("Expected err=ErrInvalidQuery, got err=%#v, q=%#v", err, q)
-	}
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT 'abc?xyz',42", q)
+}
+
+func TestInterpolateParamsPrestoEscaping(t *testing.T) {
+	c := createTestConnection(t)
+	q, err := c.interpolateParams("SELECT ?", []driver.Value{`it's a\n "path"\`})
+	assert.Nil(t, err)
+	assert.Equal(t, `SELECT 'it''s a\\n \"path\"\\'`, q)
+
+	c.connector.config.SetPrestoEscaping(true)
+	q, err = c.interpolateParams("SELECT ?", []driver.Value{`it's a\n "path"\`})
+	assert.Nil(t, err)
+	assert.Equal(t, `SELECT 'it''s a\n "path"\'`, q)
+
+	q, err = c.interpolateParams("SELECT ?", []driver.Value{[]byte{0x00, 0x0a, 0xff, '\''}})
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT X'000aff27'", q)
+}
+
+func TestInterpolateParamsInterval(t *testing.T) {
+	c := createTestConnection(t)
+	q, err := c.interpolateParams("SELECT now() - ?, current_date + ?",
+		[]driver.Value{-(90 * time.Minute), YearMonthInterval{Months: 3}})
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT now() - INTERVAL -'0 01:30:00.000' DAY TO SECOND, "+
+		"current_date + INTERVAL '0-3' YEAR TO MONTH", q)
+
+	value := driver.NamedValue{Value: time.Second}
+	assert.Nil(t, c.CheckNamedValue(&value))
+	assert.Equal(t, time.Second, value.Value)
 }
 
 func TestInterpolateParamsUint64(t *testing.T) {
@@ -334,7 +383,7 @@ func TestBuildExecutionParams(t *testing.T) {
 			name:        "No arguments",
 			inputArgs:   []driver.Value{},
 			expectedErr: nil,
-			expected:    []string{},
+			expected:    nil,
 		},
 		{
 			name:        "Bool",
@@ -342,6 +391,12 @@ func TestBuildExecutionParams(t *testing.T) {
 			expectedErr: nil,
 			expected:    []string{"1", "0"},
 		},
+		{
+			name:        "Intervals",
+			inputArgs:   []driver.Value{26*time.Hour + 6*time.Millisecond, YearMonthInterval{Years: -1, Months: -2}},
+			expectedErr: nil,
+			expected:    []string{"INTERVAL '1 02:00:00.006' DAY TO SECOND", "INTERVAL -'1-2' YEAR TO MONTH"},
+		},
 		{
 			name:        "Zero-value time",
 			inputArgs:   []driver.Value{time.Time{}},
@@ -584,13 +639,59 @@ func TestConnection_QueryContext6(t *testing.T) {
 	c.connector.config = testConf
 
 	e := c.Ping(context.Background())
-	assert.Equal(t, e, driver.ErrBadConn)
+	assert.True(t, errors.Is(e, driver.ErrBadConn))
+	var pingErr *PingError
+	assert.True(t, errors.As(e, &pingErr))
+	assert.NotNil(t, pingErr.Err)
 	driverRows, err := c.QueryContext(context.Background(), "StartQueryExecution_nil_error",
 		[]driver.NamedValue{})
 	assert.Nil(t, driverRows)
 	assert.NotNil(t, err)
 }
 
+func TestConnection_QueryContextSkipWGVerification(t *testing.T) {
+	c := createConnectionFixture()
+	c.connector.config.SetWGRemoteCreationAllowed(false)
+	_, err := c.QueryContext(context.Background(), "SELECTQueryContext_OK", []driver.NamedValue{})
+	assert.NotNil(t, err)
+
+	// GetWorkGroup fails, but the workgroup is left to StartQueryExecution.
+	c.connector.config.SetSkipWGVerification(true)
+	_, err = c.QueryContext(context.Background(), "SELECTQueryContext_OK", []driver.NamedValue{})
+	assert.Nil(t, err)
+	m := c.athenaClient.(*mockAthenaClient)
+	assert.Equal(t, "henry_wu", *m.lastStartInput.WorkGroup)
+}
+
+func TestConnection_QueryContextOutputLocationTemplate(t *testing.T) {
+	c := createConnectionFixture()
+	_ = c.connector.config.SetOutputBucket("s3://results/{workgroup}/{yyyy}/")
+	_, err := c.QueryContext(context.Background(), "SELECTQueryContext_OK", []driver.NamedValue{})
+	assert.Nil(t, err)
+	m := c.athenaClient.(*mockAthenaClient)
+	assert.Equal(t, fmt.Sprintf("s3://results/henry_wu/%d/", time.Now().UTC().Year()),
+		*m.lastStartInput.ResultConfiguration.OutputLocation)
+}
+
+func TestNewPingError(t *testing.T) {
+	err := newPingError(&QueryTimeoutError{QueryID: "QID", Timeout: time.Minute})
+	assert.Equal(t, "QID", err.QueryID)
+	assert.True(t, errors.Is(err, driver.ErrBadConn))
+	assert.True(t, errors.Is(err, ErrQueryTimeout))
+	assert.Contains(t, err.Error(), "query QID")
+
+	awsErr := &awshttp.ResponseError{
+		ResponseError: &smithyhttp.ResponseError{Err: ErrTestMockGeneric},
+		RequestID:     "REQUEST",
+	}
+	err = newPingError(fmt.Errorf("StartQueryExecution: %w", awsErr))
+	assert.Equal(t, "", err.QueryID)
+	assert.Equal(t, "REQUEST", err.RequestID)
+	assert.True(t, errors.Is(err, driver.ErrBadConn))
+	assert.True(t, errors.Is(err, ErrTestMockGeneric))
+	assert.Contains(t, err.Error(), "request REQUEST")
+}
+
 func TestConnection_QueryContext7(t *testing.T) {
 	t.Parallel()
 	c := createConnectionFixture()
@@ -664,7 +765,7 @@ func TestConnection_QueryContext7(t *testing.T) {
 
 	query = randString(MAXQueryStringLength * 10)
 	driverRows, err = c.QueryContext(context.Background(), query, []driver.NamedValue{})
-	assert.Equal(t, err, ErrInvalidQuery)
+	assert.True(t, errors.Is(err, ErrQueryTooLong))
 	assert.Nil(t, driverRows)
 
 	// Cancelled by AWS Athena
@@ -888,3 +989,183 @@ func Test_PseudoCommand(t *testing.T) {
 	assert.Nil(t, er)
 	assert.NotNil(t, dr)
 }
+
+func TestConnection_QueryContext_NoExtraRoundTrip(t *testing.T) {
+	t.Parallel()
+	c := createConnectionFixture()
+	nm := c.athenaClient.(*mockAthenaClient)
+
+	driverRows, err := c.QueryContext(context.Background(), "SELECTQueryContext_OK", []driver.NamedValue{})
+	assert.Nil(t, err)
+	assert.NotNil(t, driverRows)
+	assert.Equal(t, []string{"StartQueryExecution", "GetQueryExecution", "GetQueryResults"}, nm.calls)
+
+	qe := driverRows.(*Rows).QueryExecution()
+	assert.NotNil(t, qe)
+	assert.Equal(t, athenatypes.QueryExecutionStateSucceeded, qe.Status.State)
+}
+
+func BenchmarkConnection_InterpolateParams(b *testing.B) {
+	c := createConnectionFixture()
+	c.connector.config.SetPrestoEscaping(true)
+	query := "SELECT * FROM t WHERE a = ? AND b = ? AND c = ? AND d = ?"
+	args := []driver.Value{int64(42), "it's", 3.14, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.interpolateParams(query, args); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestNextPollInterval(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	testConf.SetResultPollIntervalSeconds(1)
+	var schedule []time.Duration
+	var elapsed, interval time.Duration
+	for i := 0; i < 16; i++ {
+		interval = nextPollInterval(testConf, elapsed, interval)
+		schedule = append(schedule, interval)
+		elapsed += interval
+	}
+	ms := time.Millisecond
+	assert.Equal(t, []time.Duration{100 * ms, 100 * ms, 100 * ms, 100 * ms, 100 * ms, 100 * ms, 100 * ms, 100 * ms,
+		100 * ms, 100 * ms, 200 * ms, 400 * ms, 800 * ms, time.Second, time.Second, time.Second}, schedule)
+
+	testConf.SetInitialPollInterval(0)
+	assert.Equal(t, time.Second, nextPollInterval(testConf, 0, 0))
+	testConf.SetInitialPollInterval(2 * time.Second)
+	assert.Equal(t, time.Second, nextPollInterval(testConf, 0, 0))
+}
+
+func TestConnection_QueryContextDetachOnCancel(t *testing.T) {
+	c := createConnectionFixture()
+	nm := c.athenaClient.(*mockAthenaClient)
+	c.connector.config.SetDetachOnCancel(true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := c.QueryContext(ctx, "SELECTQueryContext_CANCEL_OK", []driver.NamedValue{})
+	var detached *DetachedQueryError
+	assert.ErrorAs(t, err, &detached)
+	assert.Equal(t, "SELECTQueryContext_CANCEL_OK_QID", detached.QueryID)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 0, nm.callCount("StopQueryExecution"))
+
+	// the context overrides the config
+	ctx, cancel = context.WithTimeout(context.WithValue(context.Background(), DetachOnCancelKey, false),
+		50*time.Millisecond)
+	defer cancel()
+	_, err = c.QueryContext(ctx, "SELECTQueryContext_CANCEL_OK", []driver.NamedValue{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, nm.callCount("StopQueryExecution"))
+}
+
+func TestConnection_QueryContextDeadline(t *testing.T) {
+	c := createConnectionFixture()
+	nm := c.athenaClient.(*mockAthenaClient)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.QueryContext(ctx, "SELECTQueryContext_CANCEL_OK", []driver.NamedValue{})
+	var deadlineErr *DeadlineError
+	assert.ErrorAs(t, err, &deadlineErr)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, "SELECTQueryContext_CANCEL_OK_QID", deadlineErr.QueryID)
+	assert.NotEmpty(t, deadlineErr.State)
+	deadline, _ := ctx.Deadline()
+	assert.Equal(t, deadline, deadlineErr.Deadline)
+	// the query is stopped before the deadline rather than a poll interval after it
+	assert.Nil(t, ctx.Err())
+	assert.Less(t, time.Since(start), 200*time.Millisecond)
+	assert.Equal(t, 1, nm.callCount("StopQueryExecution"))
+}
+
+func TestDeadlinePollInterval(t *testing.T) {
+	ms := time.Millisecond
+	wait, expired := deadlinePollInterval(time.Minute, 0, time.Second)
+	assert.Equal(t, time.Second, wait)
+	assert.False(t, expired)
+	wait, expired = deadlinePollInterval(time.Second, 200*ms, time.Second)
+	assert.Equal(t, 400*ms, wait)
+	assert.False(t, expired)
+	wait, expired = deadlinePollInterval(25*ms, 8*ms, time.Second)
+	assert.Equal(t, MinDeadlinePollInterval, wait)
+	assert.False(t, expired)
+	_, expired = deadlinePollInterval(30*ms, 25*ms, time.Second)
+	assert.True(t, expired)
+	_, expired = deadlinePollInterval(-ms, 0, time.Second)
+	assert.True(t, expired)
+}
+
+func TestConnection_QueryLengthLimit(t *testing.T) {
+	c := createConnectionFixture()
+	long := strings.Repeat("a", MAXQueryStringLength)
+	_, err := c.interpolateParams("SELECT ?, ?", []driver.Value{long, "b"})
+	assert.True(t, errors.Is(err, ErrQueryTooLong))
+	assert.Contains(t, err.Error(), "after interpolating 1 of 2 arguments")
+
+	_, err = c.ExecContext(context.Background(), "SELECT ?", []driver.NamedValue{{Ordinal: 1, Value: long}})
+	assert.True(t, errors.Is(err, ErrQueryTooLong))
+
+	// Athena gets the arguments as execution parameters, which have a limit of their own.
+	_, err = c.QueryContext(context.Background(), "SELECTQueryContext_?", []driver.NamedValue{{Ordinal: 1,
+		Value: long}})
+	var limitErr *ExecutionParamLimitError
+	assert.True(t, errors.As(err, &limitErr))
+}
+
+func TestConnection_ZeroTimeMode(t *testing.T) {
+	c := createConnectionFixture()
+	q, err := c.interpolateParams("SELECT ?", []driver.Value{time.Time{}})
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT '0000-00-00'", q)
+
+	c.connector.config.SetZeroTimeMode(ZeroTimeAsNull)
+	q, err = c.interpolateParams("SELECT ?", []driver.Value{time.Time{}})
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT NULL", q)
+	params, err := c.buildExecutionParams([]driver.Value{time.Time{}})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"NULL"}, params)
+
+	c.connector.config.SetZeroTimeMode(ZeroTimeAsError)
+	_, err = c.interpolateParams("SELECT ?", []driver.Value{time.Time{}})
+	assert.Equal(t, ErrZeroTime, err)
+	_, err = c.buildExecutionParams([]driver.Value{time.Time{}})
+	assert.Equal(t, ErrZeroTime, err)
+	q, err = c.interpolateParams("SELECT ?", []driver.Value{time.Unix(0, 0)})
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT '1970-01-01 00:00:00'", q)
+}
+
+func TestConnection_BooleanLiterals(t *testing.T) {
+	c := createConnectionFixture()
+	c.connector.config.SetBooleanLiterals(true)
+	q, err := c.interpolateParams("SELECT * FROM t WHERE a = ? AND b = ?", []driver.Value{true, false})
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM t WHERE a = true AND b = false", q)
+	params, err := c.buildExecutionParams([]driver.Value{true, false})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"true", "false"}, params)
+}
+
+func TestConnection_ExecContextRowsAffected(t *testing.T) {
+	c := createConnectionFixture()
+	nm := c.athenaClient.(*mockAthenaClient)
+
+	// from the update count of the result
+	result, err := c.ExecContext(context.Background(), "SELECTExecContext_OK", nil)
+	assert.Nil(t, err)
+	n, _ := result.RowsAffected()
+	assert.Equal(t, int64(1024), n)
+	assert.Equal(t, 0, nm.callCount("GetQueryRuntimeStatistics"))
+
+	// from the runtime statistics when the result has no update count
+	result, err = c.ExecContext(context.Background(), "INSERT INTO t SELECT * FROM s", nil)
+	assert.Nil(t, err)
+	n, _ = result.RowsAffected()
+	assert.Equal(t, int64(42), n)
+	assert.Equal(t, 1, nm.callCount("GetQueryRuntimeStatistics"))
+}
@@ -23,23 +23,50 @@ package athenadriver
 import (
 	"context"
 	"database/sql/driver"
+	"net/http"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/uber-go/tally"
 	"go.uber.org/zap"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/athena"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 )
 
 // SQLConnector is the connector for AWS Athena Driver.
 type SQLConnector struct {
 	config *Config
 	tracer *DriverTracer
+	// client, if set, is used instead of a client created with the credentials of the config.
+	client AthenaClient
+
+	limiterOnce sync.Once
+	limiter     *queryLimiter
+
+	rateLimitOnce sync.Once
+	rateLimits    *rateLimitedClient
+
+	breakerOnce sync.Once
+	breaker     *circuitBreaker
+
+	outputCheckMu sync.Mutex
+	outputChecked bool
+
+	metadataMu sync.Mutex
+	metadata   *Metadata
+
+	coalescerOnce sync.Once
+	coalescer     *queryCoalescer
+
+	capacityMu     sync.Mutex
+	capacityChecks map[string]capacityCheck
 }
 
 // NoopsSQLConnector is to create a noops SQLConnector.
@@ -51,14 +78,154 @@ func NoopsSQLConnector() *SQLConnector {
 	}
 }
 
+// NewSQLConnectorWithClient is to create a SQLConnector whose connections call Athena with client, e.g. a test double
+// of package athenadrivertest, instead of a client created with the credentials of the config. It can be opened with
+// sql.OpenDB.
+func NewSQLConnectorWithClient(config *Config, client AthenaClient) *SQLConnector {
+	return &SQLConnector{
+		config: config,
+		tracer: NewDefaultObservability(config),
+		client: client,
+	}
+}
+
+// newAthenaClient is to get the client set by NewSQLConnectorWithClient, or to create one from awsCfg.
+func (c *SQLConnector) newAthenaClient(awsCfg aws.Config) AthenaClient {
+	if c.client != nil {
+		return c.client
+	}
+	return athena.NewFromConfig(awsCfg, c.withAPIMiddlewares)
+}
+
+// getQueryLimiter returns the limiter of the query executions in flight from all connections of the connector.
+func (c *SQLConnector) getQueryLimiter() *queryLimiter {
+	c.limiterOnce.Do(func() {
+		c.limiter = newQueryLimiter(c.config.GetMaxConcurrentQueries())
+	})
+	return c.limiter
+}
+
+// getQueryCoalescer returns the coalescer of the query executions of all connections of the connector.
+func (c *SQLConnector) getQueryCoalescer() *queryCoalescer {
+	c.coalescerOnce.Do(func() {
+		c.coalescer = &queryCoalescer{calls: map[string]*coalescedCall{}}
+	})
+	return c.coalescer
+}
+
+// withRateLimits returns client calling the APIs within the rate limits of the config. The token buckets are
+// shared by all connections of the connector, as Athena throttles the whole account.
+func (c *SQLConnector) withRateLimits(client AthenaClient) AthenaClient {
+	c.rateLimitOnce.Do(func() {
+		start := newTokenBucket(c.config.GetAPIRateLimit(APIStartQueryExecution))
+		get := newTokenBucket(c.config.GetAPIRateLimit(APIGetQueryExecution))
+		results := newTokenBucket(c.config.GetAPIRateLimit(APIGetQueryResults))
+		if start != nil || get != nil || results != nil {
+			c.rateLimits = &rateLimitedClient{
+				startQueryExecution: start,
+				getQueryExecution:   get,
+				getQueryResults:     results,
+			}
+		}
+	})
+	if c.rateLimits == nil {
+		return client
+	}
+	limited := *c.rateLimits
+	limited.AthenaClient = client
+	return &limited
+}
+
+// withCircuitBreaker returns client failing fast while the circuit breaker of the config is open. The breaker is
+// shared by all connections of the connector, as they call the same regional endpoint.
+func (c *SQLConnector) withCircuitBreaker(client AthenaClient) AthenaClient {
+	c.breakerOnce.Do(func() {
+		c.breaker = newCircuitBreaker(c.config.GetCircuitBreaker())
+	})
+	if c.breaker == nil {
+		return client
+	}
+	return &circuitBreakerClient{AthenaClient: client, breaker: c.breaker, tracer: c.tracer}
+}
+
+// awsConfig is to get the AWS config with the credentials of the driver config, see Connect.
+func (c *SQLConnector) awsConfig(ctx context.Context) (aws.Config, error) {
+	var awsCfg aws.Config
+	var err error
+	// respect AWS_SDK_LOAD_CONFIG and local ~/.aws/credentials, ~/.aws/config
+	if ok, _ := strconv.ParseBool(os.Getenv("AWS_SDK_LOAD_CONFIG")); ok {
+		if profile := c.config.GetAWSProfile(); profile != "" {
+			awsCfg, err = config.LoadDefaultConfig(ctx, config.WithSharedConfigProfile(profile))
+			if err != nil {
+				return aws.Config{}, err
+			}
+		}
+	} else if c.config.GetAccessID() != "" {
+		staticCredentials := credentials.NewStaticCredentialsProvider(c.config.GetAccessID(),
+			c.config.GetSecretAccessKey(),
+			c.config.GetSessionToken())
+		awsCfg = aws.Config{
+			Region:      c.config.GetRegion(),
+			Credentials: staticCredentials,
+		}
+	} else {
+		awsCfg = aws.Config{
+			Region: c.config.GetRegion(),
+		}
+	}
+	if endpoint := c.config.GetEndpoint(); endpoint != "" {
+		awsCfg.BaseEndpoint = aws.String(endpoint)
+	}
+	if timeout := c.config.GetTimeoutPolicy().Connect; timeout > 0 {
+		awsCfg.HTTPClient = awshttp.NewBuildableClient().WithTransportOptions(func(t *http.Transport) {
+			t.DialContext = timeoutDialer(timeout)
+		})
+	}
+
+	return awsCfg, nil
+}
+
+// newS3Client is to create an S3 client, which addresses buckets by path when an endpoint is set.
+func (c *SQLConnector) newS3Client(awsCfg aws.Config) *s3.Client {
+	return s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		o.UsePathStyle = c.config.GetEndpoint() != ""
+	})
+}
+
 // AthenaClient is an interface to facilitate testing
 type AthenaClient interface {
+	BatchGetQueryExecution(context.Context, *athena.BatchGetQueryExecutionInput, ...func(*athena.Options)) (*athena.BatchGetQueryExecutionOutput, error)
+	CreateCapacityReservation(context.Context, *athena.CreateCapacityReservationInput, ...func(*athena.Options)) (*athena.CreateCapacityReservationOutput, error)
 	CreateWorkGroup(context.Context, *athena.CreateWorkGroupInput, ...func(*athena.Options)) (*athena.CreateWorkGroupOutput, error)
+	GetCalculationExecution(context.Context, *athena.GetCalculationExecutionInput, ...func(*athena.Options)) (*athena.GetCalculationExecutionOutput, error)
+	GetCapacityAssignmentConfiguration(context.Context, *athena.GetCapacityAssignmentConfigurationInput, ...func(*athena.Options)) (*athena.GetCapacityAssignmentConfigurationOutput, error)
+	GetCapacityReservation(context.Context, *athena.GetCapacityReservationInput, ...func(*athena.Options)) (*athena.GetCapacityReservationOutput, error)
+	DeletePreparedStatement(context.Context, *athena.DeletePreparedStatementInput, ...func(*athena.Options)) (*athena.DeletePreparedStatementOutput, error)
+	DeleteWorkGroup(context.Context, *athena.DeleteWorkGroupInput, ...func(*athena.Options)) (*athena.DeleteWorkGroupOutput, error)
+	GetPreparedStatement(context.Context, *athena.GetPreparedStatementInput, ...func(*athena.Options)) (*athena.GetPreparedStatementOutput, error)
 	GetQueryExecution(context.Context, *athena.GetQueryExecutionInput, ...func(*athena.Options)) (*athena.GetQueryExecutionOutput, error)
 	GetQueryResults(context.Context, *athena.GetQueryResultsInput, ...func(*athena.Options)) (*athena.GetQueryResultsOutput, error)
+	GetQueryRuntimeStatistics(context.Context, *athena.GetQueryRuntimeStatisticsInput, ...func(*athena.Options)) (*athena.GetQueryRuntimeStatisticsOutput, error)
+	GetTableMetadata(context.Context, *athena.GetTableMetadataInput, ...func(*athena.Options)) (*athena.GetTableMetadataOutput, error)
+	GetSessionStatus(context.Context, *athena.GetSessionStatusInput, ...func(*athena.Options)) (*athena.GetSessionStatusOutput, error)
 	GetWorkGroup(context.Context, *athena.GetWorkGroupInput, ...func(*athena.Options)) (*athena.GetWorkGroupOutput, error)
+	ListCapacityReservations(context.Context, *athena.ListCapacityReservationsInput, ...func(*athena.Options)) (*athena.ListCapacityReservationsOutput, error)
+	ListDataCatalogs(context.Context, *athena.ListDataCatalogsInput, ...func(*athena.Options)) (*athena.ListDataCatalogsOutput, error)
+	ListDatabases(context.Context, *athena.ListDatabasesInput, ...func(*athena.Options)) (*athena.ListDatabasesOutput, error)
+	ListPreparedStatements(context.Context, *athena.ListPreparedStatementsInput, ...func(*athena.Options)) (*athena.ListPreparedStatementsOutput, error)
+	ListQueryExecutions(context.Context, *athena.ListQueryExecutionsInput, ...func(*athena.Options)) (*athena.ListQueryExecutionsOutput, error)
+	ListTableMetadata(context.Context, *athena.ListTableMetadataInput, ...func(*athena.Options)) (*athena.ListTableMetadataOutput, error)
+	ListTagsForResource(context.Context, *athena.ListTagsForResourceInput, ...func(*athena.Options)) (*athena.ListTagsForResourceOutput, error)
+	ListWorkGroups(context.Context, *athena.ListWorkGroupsInput, ...func(*athena.Options)) (*athena.ListWorkGroupsOutput, error)
+	PutCapacityAssignmentConfiguration(context.Context, *athena.PutCapacityAssignmentConfigurationInput, ...func(*athena.Options)) (*athena.PutCapacityAssignmentConfigurationOutput, error)
+	StartCalculationExecution(context.Context, *athena.StartCalculationExecutionInput, ...func(*athena.Options)) (*athena.StartCalculationExecutionOutput, error)
 	StartQueryExecution(context.Context, *athena.StartQueryExecutionInput, ...func(options *athena.Options)) (*athena.StartQueryExecutionOutput, error)
+	StartSession(context.Context, *athena.StartSessionInput, ...func(*athena.Options)) (*athena.StartSessionOutput, error)
 	StopQueryExecution(context.Context, *athena.StopQueryExecutionInput, ...func(*athena.Options)) (*athena.StopQueryExecutionOutput, error)
+	TagResource(context.Context, *athena.TagResourceInput, ...func(*athena.Options)) (*athena.TagResourceOutput, error)
+	TerminateSession(context.Context, *athena.TerminateSessionInput, ...func(*athena.Options)) (*athena.TerminateSessionOutput, error)
+	UntagResource(context.Context, *athena.UntagResourceInput, ...func(*athena.Options)) (*athena.UntagResourceOutput, error)
+	UpdateWorkGroup(context.Context, *athena.UpdateWorkGroupInput, ...func(*athena.Options)) (*athena.UpdateWorkGroupOutput, error)
 }
 
 // Driver is to construct a new SQLConnector.
@@ -82,37 +249,30 @@ func (c *SQLConnector) Connect(ctx context.Context) (driver.Conn, error) {
 		c.tracer.SetLogger(logger)
 	}
 
-	var awsCfg aws.Config
-	var err error
-	// respect AWS_SDK_LOAD_CONFIG and local ~/.aws/credentials, ~/.aws/config
-	if ok, _ := strconv.ParseBool(os.Getenv("AWS_SDK_LOAD_CONFIG")); ok {
-		if profile := c.config.GetAWSProfile(); profile != "" {
-			awsCfg, err = config.LoadDefaultConfig(ctx, config.WithSharedConfigProfile(profile))
-			if err != nil {
-				c.tracer.Scope().Counter(DriverName + ".failure.sqlconnector.newsession").Inc(1)
-				return nil, err
-			}
-		}
-	} else if c.config.GetAccessID() != "" {
-		staticCredentials := credentials.NewStaticCredentialsProvider(c.config.GetAccessID(),
-			c.config.GetSecretAccessKey(),
-			c.config.GetSessionToken())
-		awsCfg = aws.Config{
-			Region:      c.config.GetRegion(),
-			Credentials: staticCredentials,
-		}
-	} else {
-		awsCfg = aws.Config{
-			Region: c.config.GetRegion(),
+	awsCfg, err := c.awsConfig(ctx)
+	if err != nil {
+		c.tracer.Scope().Counter(DriverName + ".failure.sqlconnector.newsession").Inc(1)
+		return nil, err
+	}
+
+	if c.config.IsValidateOutputLocation() {
+		if err := c.checkOutputLocation(ctx, c.newS3Client(awsCfg)); err != nil {
+			return nil, err
 		}
 	}
 
-	athenaClient := athena.NewFromConfig(awsCfg)
+	athenaClient := c.newAthenaClient(awsCfg)
 	timeConnect := time.Since(now)
 	conn := &Connection{
-		athenaClient: athenaClient,
+		athenaClient: c.withCircuitBreaker(c.withRateLimits(athenaClient)),
 		connector:    c,
 	}
+	conn.expiresAt = connectionExpiry(now, c.config.GetMaxConnectionAge(), c.credentials(ctx, awsCfg))
+	// The S3 client reads query results with Config.SetS3ResultThreshold, and the output of Spark calculations.
+	conn.s3Client = c.newS3Client(awsCfg)
+	if c.config.IsResultCleanupOnClose() || c.config.GetResultCleanupTTL() > 0 {
+		conn.s3Deleter = c.newS3Client(awsCfg)
+	}
 	c.tracer.Scope().Timer(DriverName + ".connector.connect").Record(timeConnect)
 	return conn, nil
 }
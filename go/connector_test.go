@@ -26,6 +26,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/stretchr/testify/assert"
 	"github.com/uber-go/tally"
 	"go.uber.org/zap"
@@ -162,3 +164,43 @@ func TestSQLConnector_Driver(t *testing.T) {
 	}
 	assert.NotNil(t, connector.Driver())
 }
+
+func TestSQLConnector_awsConfig_Endpoint(t *testing.T) {
+	testConf, err := NewEmulatorConfig("http://localhost:4566", "s3://results/")
+	assert.Nil(t, err)
+	connector := &SQLConnector{config: testConf, tracer: NewDefaultObservability(testConf)}
+	awsCfg, err := connector.awsConfig(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "http://localhost:4566", aws.ToString(awsCfg.BaseEndpoint))
+	assert.True(t, connector.newS3Client(awsCfg).Options().UsePathStyle)
+
+	connector.config = NewNoOpsConfig()
+	awsCfg, err = connector.awsConfig(context.Background())
+	assert.Nil(t, err)
+	assert.Nil(t, awsCfg.BaseEndpoint)
+	assert.False(t, connector.newS3Client(awsCfg).Options().UsePathStyle)
+}
+
+func TestSQLConnector_awsConfig_ConnectTimeout(t *testing.T) {
+	testConf, err := NewEmulatorConfig("http://localhost:4566", "s3://results/")
+	assert.Nil(t, err)
+	testConf.SetTimeoutPolicy(TimeoutPolicy{Connect: time.Second})
+	connector := &SQLConnector{config: testConf, tracer: NewDefaultObservability(testConf)}
+	awsCfg, err := connector.awsConfig(context.Background())
+	assert.Nil(t, err)
+	client, ok := awsCfg.HTTPClient.(*awshttp.BuildableClient)
+	assert.True(t, ok)
+	assert.NotNil(t, client.GetTransport().DialContext)
+}
+
+func TestNewSQLConnectorWithClient(t *testing.T) {
+	client := newMockAthenaClient()
+	connector := NewSQLConnectorWithClient(NewNoOpsConfig(), client)
+	conn, err := connector.Connect(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, client, conn.(*Connection).athenaClient)
+
+	metadata, err := connector.Metadata(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, client, metadata.client)
+}
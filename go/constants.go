@@ -20,6 +20,8 @@
 
 package athenadriver
 
+import "time"
+
 // TContextKey is a type for key in context.
 type TContextKey string
 
@@ -56,6 +58,38 @@ const (
 	// LoggerKey is the key for Logger in context
 	LoggerKey = TContextKey("LoggerKey")
 
+	// DetachOnCancelKey is the key in context of a bool overriding Config.IsDetachOnCancel for a query
+	DetachOnCancelKey = TContextKey("DetachOnCancelKey")
+
+	// TimeoutPolicyKey is the key in context of a TimeoutPolicy overriding Config.GetTimeoutPolicy for a query
+	TimeoutPolicyKey = TContextKey("TimeoutPolicyKey")
+
+	// LineageJobKey is the key in context of the OpenLineage job name of a query, see Config.SetLineageEmitter
+	LineageJobKey = TContextKey("LineageJobKey")
+
+	// QueryAnnotationsKey is the key in context of a map[string]string of annotations, like trace_id, added to the
+	// comment of a query, see Config.SetQueryAnnotation
+	QueryAnnotationsKey = TContextKey("QueryAnnotationsKey")
+
+	// DryRunKey is the key in context of a DryRunMode overriding Config.GetDryRun for a query
+	DryRunKey = TContextKey("DryRunKey")
+
+	// ResultReuseKey is the key in context of a bool forcing the reuse of earlier results on or off for a query,
+	// see WithResultReuse
+	ResultReuseKey = TContextKey("ResultReuseKey")
+
+	// ProgressKey is the key in context of a ProgressFunc called with the progress of a query while it runs
+	ProgressKey = TContextKey("ProgressKey")
+
+	// NamespaceKey is the key in context of the Namespace queries run in, see WithNamespace
+	NamespaceKey = TContextKey("NamespaceKey")
+
+	// PrincipalKey is the key in context of the principal queries are audited for, see WithPrincipal
+	PrincipalKey = TContextKey("PrincipalKey")
+
+	// PriorityKey is the key in context of the QueryPriority of a query, see WithPriority
+	PriorityKey = TContextKey("PriorityKey")
+
 	// DummyRegion is used when AWS CLI Config is used, ie AWS_SDK_LOAD_CONFIG is set
 	DummyRegion = "dummy"
 
@@ -77,10 +111,42 @@ const (
 	// PoolInterval is the interval between two status checks(unit second).
 	PoolInterval = 3
 
+	// InitialPollInterval is the interval between two status checks while a query has been running for less than
+	// FastPollPeriod. After that, the interval doubles up to the poll interval.
+	InitialPollInterval = 100 * time.Millisecond
+
+	// FastPollPeriod is how long the status of a query is checked every InitialPollInterval.
+	FastPollPeriod = time.Second
+
+	// MinDeadlinePollInterval is the shortest interval between two status checks of a query close to its context
+	// deadline.
+	MinDeadlinePollInterval = 10 * time.Millisecond
+
+	// DefaultSpillMemoryLimit is how many bytes of prefetched pages are kept in memory when spilling to disk.
+	DefaultSpillMemoryLimit = 64 << 20
+
+	// DefaultMaxSliceParamLength is how many elements a slice argument expanded into an IN list may have.
+	DefaultMaxSliceParamLength = 1000
+
+	// DefaultStatementCacheSize is how many prepared queries a connection keeps by default.
+	DefaultStatementCacheSize = 100
+
+	// CapacityCheckTTL is how long a connector reuses the state of a capacity reservation checked before a query,
+	// see Config.SetCapacityFailFast.
+	CapacityCheckTTL = 10 * time.Second
+
+	// CredentialsExpiryMargin is how long before its credentials expire a connection does, so that no query starts
+	// with credentials about to expire.
+	CredentialsExpiryMargin = time.Minute
+
 	// The maximum allowed query string length is 262144 bytes,
 	// where the strings are encoded in UTF-8.
 	// This is not an adjustable quota. (unit bytes)
 	MAXQueryStringLength = 262144
+
+	// MAXExecutionParameterLength is the maximum number of characters of an execution parameter.
+	// This is not an adjustable quota.
+	MAXExecutionParameterLength = 1024
 )
 
 const digits01 = "0123456789012345678901234567890123456789012345678901234567890123456789012345678901234567890123456789"
@@ -91,7 +157,7 @@ var AthenaColumnTypes = [...]string{"tinyint", "smallint", "integer", "bigint",
 	"json", "char", "varchar", "varbinary", "row", "string", "binary",
 	"struct", "interval year to month", "interval day to second", "decimal",
 	"ipaddress", "array", "map", "unknown", "boolean", "date", "time", "time with time zone",
-	"timestamp with time zone", "timestamp", "weird_type"}
+	"timestamp with time zone", "timestamp", "uuid", "weird_type"}
 
 // pseudo commands all start with `PC_`
 
@@ -104,6 +170,9 @@ const PCGetQIDStatus = "get_query_id_status"
 // PCStopQID is the pseudo command to stop a query execution id
 const PCStopQID = "stop_query_id"
 
+// PCCancelAll is the pseudo command to stop all running and queued query executions in the workgroup
+const PCCancelAll = "cancel_all"
+
 // PCGetDriverVersion is the pseudo command to get the version of athenadriver
 const PCGetDriverVersion = "get_driver_version"
 
@@ -0,0 +1,77 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"strings"
+	"sync"
+)
+
+// TypeConverter converts the string form of an Athena value to a Go value.
+type TypeConverter func(val string) (interface{}, error)
+
+// converterKey is what a TypeConverter is registered under: an Athena type name, and for DECIMAL, the name of a
+// decimal mode, which is empty for converters registered with RegisterTypeConverter.
+type converterKey struct {
+	athenaType string
+	mode       string
+}
+
+var (
+	typeConvertersMu sync.RWMutex
+	typeConverters   = map[converterKey]TypeConverter{
+		{athenaType: "decimal", mode: DecimalAsString}:  decimalToString,
+		{athenaType: "decimal", mode: DecimalAsRat}:     decimalToRat,
+		{athenaType: "decimal", mode: DecimalAsFloat64}: decimalToFloat64,
+	}
+)
+
+// RegisterTypeConverter is to install a TypeConverter for an Athena type name, e.g. "geometry" or "varchar",
+// which is used during row materialization instead of the driver's own conversion. NULL values are not
+// passed to the converter, they are handled by the missing value options. A converter registered for "decimal"
+// takes precedence over the decimal mode.
+func RegisterTypeConverter(athenaType string, converter TypeConverter) {
+	registerTypeConverter(converterKey{athenaType: strings.ToLower(athenaType)}, converter)
+}
+
+// UnregisterTypeConverter is to remove the TypeConverter of an Athena type name.
+func UnregisterTypeConverter(athenaType string) {
+	typeConvertersMu.Lock()
+	defer typeConvertersMu.Unlock()
+	delete(typeConverters, converterKey{athenaType: strings.ToLower(athenaType)})
+}
+
+func registerTypeConverter(key converterKey, converter TypeConverter) {
+	typeConvertersMu.Lock()
+	defer typeConvertersMu.Unlock()
+	typeConverters[key] = converter
+}
+
+func getTypeConverter(athenaType string) (TypeConverter, bool) {
+	return lookupTypeConverter(converterKey{athenaType: strings.ToLower(athenaType)})
+}
+
+func lookupTypeConverter(key converterKey) (TypeConverter, bool) {
+	typeConvertersMu.RLock()
+	defer typeConvertersMu.RUnlock()
+	converter, ok := typeConverters[key]
+	return converter, ok
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testPoint struct {
+	WKT string
+}
+
+func TestRegisterTypeConverter(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	r, _ := NewRows(context.Background(), newMockAthenaClient(),
+		"SELECT_OK", testConf, NewDefaultObservability(testConf))
+	c := newColumnInfo("a", "sphericalgeography")
+	rv := "POINT (1 2)"
+
+	// unknown types fail without a converter
+	_, e := r.athenaTypeToGoType(c, &rv, testConf)
+	assert.NotNil(t, e)
+
+	RegisterTypeConverter("SphericalGeography", func(val string) (interface{}, error) {
+		if !strings.HasPrefix(val, "POINT") {
+			return nil, errors.New("not a point")
+		}
+		return testPoint{WKT: val}, nil
+	})
+	defer UnregisterTypeConverter("sphericalgeography")
+
+	g, e := r.athenaTypeToGoType(c, &rv, testConf)
+	assert.Nil(t, e)
+	assert.Equal(t, testPoint{WKT: "POINT (1 2)"}, g)
+
+	rv = "LINESTRING (1 2, 3 4)"
+	g, e = r.athenaTypeToGoType(c, &rv, testConf)
+	assert.NotNil(t, e)
+	assert.Nil(t, g)
+
+	// NULL values are not passed to the converter
+	testConf.SetNullAsNil(true)
+	g, e = r.athenaTypeToGoType(c, nil, testConf)
+	assert.Nil(t, e)
+	assert.Nil(t, g)
+
+	r.ResultOutput.ResultSet.ResultSetMetadata.ColumnInfo[0] = c
+	assert.Equal(t, reflect.TypeOf((*interface{})(nil)).Elem(), r.ColumnTypeScanType(0))
+
+	UnregisterTypeConverter("SPHERICALGEOGRAPHY")
+	_, ok := getTypeConverter("sphericalgeography")
+	assert.False(t, ok)
+}
+
+func TestRegisterTypeConverter_Decimal(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	testConf.SetDecimalMode(DecimalAsFloat64)
+	r, _ := NewRows(context.Background(), newMockAthenaClient(),
+		"SELECT_OK", testConf, NewDefaultObservability(testConf))
+	c := newColumnInfo("price", "decimal")
+	rv := "19.99"
+	g, e := r.athenaTypeToGoType(c, &rv, testConf)
+	assert.Nil(t, e)
+	assert.Equal(t, 19.99, g)
+
+	// a converter of the type takes precedence over the decimal mode
+	RegisterTypeConverter("decimal", func(val string) (interface{}, error) {
+		return "cents:" + strings.Replace(val, ".", "", 1), nil
+	})
+	defer UnregisterTypeConverter("decimal")
+	g, e = r.athenaTypeToGoType(c, &rv, testConf)
+	assert.Nil(t, e)
+	assert.Equal(t, "cents:1999", g)
+
+	// decimal modes and type names do not collide
+	_, ok := getTypeConverter(DecimalAsString)
+	assert.False(t, ok)
+}
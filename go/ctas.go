@@ -0,0 +1,153 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// QueryToTableOptions are the options of QueryToTable. Except Append, Columns and Args, they are the table
+// properties of the table created, and are not used when appending to an existing one.
+type QueryToTableOptions struct {
+	// Append is to insert the rows into an existing table with INSERT INTO rather than create it with CTAS.
+	Append bool
+	// Columns are the columns of the existing table the rows are inserted into, in the order of the query's. They
+	// default to all the columns of the table.
+	Columns []string
+	// Args are the arguments of the placeholders of the query.
+	Args []interface{}
+
+	// Format is the storage format of the table, e.g. PARQUET, ORC, AVRO, JSON or TEXTFILE. Athena creates
+	// PARQUET tables by default.
+	Format string
+	// Compression is the compression of the data files, e.g. SNAPPY, GZIP or ZSTD.
+	Compression string
+	// Location is the S3 location of the data files. It defaults to the query result location, and is required
+	// for Iceberg tables.
+	Location string
+	// PartitionedBy are the partition columns, which must be the last columns of the query, in the same order.
+	// For Iceberg tables, they are partition transforms, e.g. day(created_at).
+	PartitionedBy []string
+	// BucketedBy are the columns the data is bucketed by into BucketCount buckets.
+	BucketedBy  []string
+	BucketCount int
+	// Iceberg is to create an Iceberg table rather than a Hive one.
+	Iceberg bool
+}
+
+// QueryToTable writes the rows of a query into table, which it creates with `CREATE TABLE ... AS` unless
+// opts.Append is set, in which case they are inserted with `INSERT INTO` into the existing table. It returns the
+// number of rows written. The table name is used verbatim, so it can be qualified with its database.
+func QueryToTable(ctx context.Context, db Execer, table string, query string, opts *QueryToTableOptions) (int64,
+	error) {
+	if opts == nil {
+		opts = &QueryToTableOptions{}
+	}
+	query = strings.TrimRight(strings.TrimSpace(query), "; \t\r\n")
+	if table == "" || query == "" {
+		return 0, fmt.Errorf("%w: QueryToTable needs a table and a query", ErrInvalidQuery)
+	}
+	var statement string
+	if opts.Append {
+		statement = insertIntoStatement(table, query, opts)
+	} else {
+		statement = ctasStatement(table, query, opts)
+	}
+	result, err := db.ExecContext(ctx, statement, opts.Args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// insertIntoStatement renders the INSERT INTO statement of QueryToTable.
+func insertIntoStatement(table string, query string, opts *QueryToTableOptions) string {
+	var b strings.Builder
+	b.WriteString("INSERT INTO " + table + " ")
+	if len(opts.Columns) > 0 {
+		b.WriteByte('(')
+		for i, column := range opts.Columns {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(QuoteIdentifier(column))
+		}
+		b.WriteString(") ")
+	}
+	b.WriteString(query)
+	return b.String()
+}
+
+// ctasStatement renders the CREATE TABLE AS statement of QueryToTable.
+func ctasStatement(table string, query string, opts *QueryToTableOptions) string {
+	var properties []string
+	property := func(name string, value string) {
+		properties = append(properties, name+" = "+value)
+	}
+	if opts.Iceberg {
+		property("table_type", QuoteLiteral("ICEBERG"))
+		property("is_external", "false")
+	}
+	if opts.Format != "" {
+		property("format", QuoteLiteral(strings.ToUpper(opts.Format)))
+	}
+	if opts.Compression != "" {
+		property("write_compression", QuoteLiteral(strings.ToUpper(opts.Compression)))
+	}
+	if opts.Location != "" {
+		if opts.Iceberg {
+			property("location", QuoteLiteral(opts.Location))
+		} else {
+			property("external_location", QuoteLiteral(opts.Location))
+		}
+	}
+	if len(opts.PartitionedBy) > 0 {
+		if opts.Iceberg {
+			property("partitioning", arrayOfLiterals(opts.PartitionedBy))
+		} else {
+			property("partitioned_by", arrayOfLiterals(opts.PartitionedBy))
+		}
+	}
+	if len(opts.BucketedBy) > 0 {
+		property("bucketed_by", arrayOfLiterals(opts.BucketedBy))
+		property("bucket_count", strconv.Itoa(opts.BucketCount))
+	}
+
+	var b strings.Builder
+	b.WriteString("CREATE TABLE " + table + " ")
+	if len(properties) > 0 {
+		b.WriteString("WITH (" + strings.Join(properties, ", ") + ") ")
+	}
+	b.WriteString("AS " + query)
+	return b.String()
+}
+
+// arrayOfLiterals renders strings as an ARRAY of varchar literals, as table properties take column names.
+func arrayOfLiterals(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = QuoteLiteral(v)
+	}
+	return "ARRAY[" + strings.Join(quoted, ", ") + "]"
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryToTable_CTAS(t *testing.T) {
+	e := &recordingExecer{}
+	_, err := QueryToTable(context.Background(), e, "mydb.daily", "SELECT id, dt FROM events;", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"CREATE TABLE mydb.daily AS SELECT id, dt FROM events"}, e.queries)
+
+	e = &recordingExecer{}
+	_, err = QueryToTable(context.Background(), e, "mydb.daily", "SELECT id, dt FROM events",
+		&QueryToTableOptions{
+			Format:        "parquet",
+			Compression:   "snappy",
+			Location:      "s3://bucket/daily/",
+			PartitionedBy: []string{"dt"},
+			BucketedBy:    []string{"id"},
+			BucketCount:   8,
+		})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"CREATE TABLE mydb.daily WITH (format = 'PARQUET', write_compression = 'SNAPPY', " +
+		"external_location = 's3://bucket/daily/', partitioned_by = ARRAY['dt'], bucketed_by = ARRAY['id'], " +
+		"bucket_count = 8) AS SELECT id, dt FROM events"}, e.queries)
+}
+
+func TestQueryToTable_Iceberg(t *testing.T) {
+	e := &recordingExecer{}
+	_, err := QueryToTable(context.Background(), e, "mydb.daily", "SELECT id, created_at FROM events",
+		&QueryToTableOptions{
+			Iceberg:       true,
+			Location:      "s3://bucket/daily/",
+			PartitionedBy: []string{"day(created_at)"},
+		})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"CREATE TABLE mydb.daily WITH (table_type = 'ICEBERG', is_external = false, " +
+		"location = 's3://bucket/daily/', partitioning = ARRAY['day(created_at)']) " +
+		"AS SELECT id, created_at FROM events"}, e.queries)
+}
+
+func TestQueryToTable_Append(t *testing.T) {
+	e := &recordingExecer{}
+	_, err := QueryToTable(context.Background(), e, "mydb.daily", "SELECT id, dt FROM events",
+		&QueryToTableOptions{Append: true, Columns: []string{"id", "dt"}, Format: "ORC"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{`INSERT INTO mydb.daily ("id", "dt") SELECT id, dt FROM events`}, e.queries)
+
+	_, err = QueryToTable(context.Background(), e, "", "SELECT 1", nil)
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+	_, err = QueryToTable(context.Background(), e, "t", " ; ", nil)
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+
+	failed := errors.New("failed")
+	_, err = QueryToTable(context.Background(), &recordingExecer{err: failed}, "t", "SELECT 1", nil)
+	assert.Equal(t, failed, err)
+}
+
+func TestQueryToTable_RowCount(t *testing.T) {
+	db := sql.OpenDB(NewSQLConnectorWithClient(NewNoOpsConfig(), newMockAthenaClient()))
+	defer db.Close()
+	n, err := QueryToTable(context.Background(), db, "t", "SELECT * FROM s", &QueryToTableOptions{Append: true})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(42), n)
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// Cursor is a position in the result set of a query. Rows can be resumed from it by NewRowsFromCursor, e.g. by
+// another request or process serving the next page of a paginated API, as long as Athena keeps the query result.
+type Cursor struct {
+	QueryID string
+	// NextToken is the GetQueryResults token of the page the position is in. It is empty for the first page.
+	NextToken string
+	// Offset is the number of rows of the page which have been read.
+	Offset int
+}
+
+// MarshalText encodes the cursor as an opaque URL safe string.
+func (c Cursor) MarshalText() ([]byte, error) {
+	s := c.QueryID + "\n" + strconv.Itoa(c.Offset) + "\n" + c.NextToken
+	return []byte(base64.RawURLEncoding.EncodeToString([]byte(s))), nil
+}
+
+// UnmarshalText decodes a cursor encoded by MarshalText.
+func (c *Cursor) UnmarshalText(text []byte) error {
+	b, err := base64.RawURLEncoding.DecodeString(string(text))
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	parts := strings.SplitN(string(b), "\n", 3)
+	if len(parts) != 3 || !IsQID(parts[0]) {
+		return ErrInvalidCursor
+	}
+	offset, err := strconv.Atoi(parts[1])
+	if err != nil || offset < 0 {
+		return ErrInvalidCursor
+	}
+	*c = Cursor{QueryID: parts[0], NextToken: parts[2], Offset: offset}
+	return nil
+}
+
+// Cursor returns the position of the next row to be read.
+func (r *Rows) Cursor() Cursor {
+	c := Cursor{QueryID: r.queryID, Offset: r.pageOffset}
+	if r.pageToken != nil {
+		c.NextToken = *r.pageToken
+	}
+	// Once the page has been read through, the next page is a cheaper place to resume from.
+	if r.ResultOutput != nil && r.remainingRows() == 0 &&
+		r.ResultOutput.NextToken != nil && *r.ResultOutput.NextToken != "" {
+		c.NextToken = *r.ResultOutput.NextToken
+		c.Offset = 0
+	}
+	return c
+}
+
+// NewRowsFromCursor is to create a new Rows which resumes reading the result set of a query from a cursor.
+func NewRowsFromCursor(ctx context.Context, client AthenaClient, cursor Cursor, driverConfig *Config,
+	obs *DriverTracer) (*Rows, error) {
+	if cursor.QueryID == "" || cursor.Offset < 0 {
+		return nil, ErrInvalidCursor
+	}
+	r := Rows{
+		athena:    client,
+		ctx:       ctx,
+		queryID:   cursor.QueryID,
+		config:    driverConfig,
+		tracer:    obs,
+		pageCount: -1,
+	}
+	r.setFetchTimeout()
+	var token *string
+	if cursor.NextToken != "" {
+		token = aws.String(cursor.NextToken)
+		// Only the first page starts with the header row.
+		r.pageCount = 0
+	}
+	if err := r.fetchNextPage(token); err != nil {
+		return nil, err
+	}
+	if !r.reachedLastPage {
+		rows := r.ResultOutput.ResultSet.Rows
+		skip := cursor.Offset
+		if skip > len(rows) {
+			skip = len(rows)
+		}
+		r.ResultOutput.ResultSet.Rows = rows[skip:]
+		r.pageOffset = skip
+	}
+	r.startPrefetch()
+	return &r, nil
+}
+
+// ResumeRows is to resume reading the result set of a query from a cursor got by Rows.Cursor, with the
+// connection's client and config.
+func (c *Connection) ResumeRows(ctx context.Context, cursor Cursor) (*Rows, error) {
+	if c.closed.Load() {
+		return nil, driver.ErrBadConn
+	}
+	return NewRowsFromCursor(ctx, c.athenaClient, cursor, c.connector.config, c.tracer(ctx))
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursor_MarshalText(t *testing.T) {
+	c := Cursor{QueryID: "c89088ab-595d-4ee6-a9ce-73b55aeb8955", NextToken: "AbC/+=\n", Offset: 42}
+	text, err := c.MarshalText()
+	assert.Nil(t, err)
+	var got Cursor
+	assert.Nil(t, got.UnmarshalText(text))
+	assert.Equal(t, c, got)
+
+	for _, s := range []string{"", "!!", "bm90IGEgY3Vyc29y"} {
+		assert.Equal(t, ErrInvalidCursor, got.UnmarshalText([]byte(s)), s)
+	}
+}
+
+func TestRows_Cursor(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	obs := NewDefaultObservability(testConf)
+	r, err := NewRows(context.Background(), newMockAthenaClient(), "SELECT_OK", testConf, obs)
+	assert.Nil(t, err)
+	dest := make([]driver.Value, len(r.Columns()))
+	total := 0
+	for r.Next(dest) == nil {
+		total++
+	}
+
+	for read := 0; read <= total; read++ {
+		r, err = NewRows(context.Background(), newMockAthenaClient(), "SELECT_OK", testConf, obs)
+		assert.Nil(t, err)
+		for i := 0; i < read; i++ {
+			assert.Nil(t, r.Next(dest))
+		}
+		cursor := r.Cursor()
+		assert.Equal(t, "SELECT_OK", cursor.QueryID)
+
+		resumed, err := NewRowsFromCursor(context.Background(), newMockAthenaClient(), cursor, testConf, obs)
+		assert.Nil(t, err)
+		rest := 0
+		for resumed.Next(dest) == nil {
+			rest++
+		}
+		assert.Equal(t, total, read+rest, read)
+	}
+
+	// a cursor at the end of a page points to the start of the next one
+	r, err = NewRows(context.Background(), newMockAthenaClient(), "SELECT_OK", testConf, obs)
+	assert.Nil(t, err)
+	for r.remainingRows() > 0 {
+		assert.Nil(t, r.Next(dest))
+	}
+	assert.Equal(t, Cursor{QueryID: "SELECT_OK", NextToken: "a1"}, r.Cursor())
+
+	_, err = NewRowsFromCursor(context.Background(), newMockAthenaClient(), Cursor{}, testConf, obs)
+	assert.Equal(t, ErrInvalidCursor, err)
+
+	r, err = NewRowsFromCursor(context.Background(), newMockAthenaClient(),
+		Cursor{QueryID: "SELECT_OK", NextToken: "a4", Offset: 1000}, testConf, obs)
+	assert.Nil(t, err)
+	assert.Equal(t, io.EOF, r.Next(dest))
+}
@@ -41,17 +41,70 @@ var timeLayouts = []string{
 	"2006-01-02 15:04:05.000000000",
 	"2006-01-02 15:04:05.000000",
 	"2006-01-02 15:04:05.000",
+	"2006-01-02 15:04:05",
+	"15:04:05.000000000",
+	"15:04:05.000000",
+	"15:04:05",
+}
+
+// clockLayouts and dateTimeLayouts are the time layouts by number of fractional second digits.
+var (
+	clockLayouts = map[int]string{
+		0: "15:04:05",
+		3: "15:04:05.000",
+		6: "15:04:05.000000",
+		9: "15:04:05.000000000",
+	}
+	dateTimeLayouts = map[int]string{
+		0: "2006-01-02 15:04:05",
+		3: "2006-01-02 15:04:05.000",
+		6: "2006-01-02 15:04:05.000000",
+		9: "2006-01-02 15:04:05.000000000",
+	}
+)
+
+// zoneOffsetLayouts are the numeric time zone formats Athena uses for `time with time zone` and
+// `timestamp with time zone`, e.g. `01:02:03.456 +08:00`.
+var zoneOffsetLayouts = []string{
+	"-07:00",
+	"-0700",
+	"-07",
 }
 
 func scanTime(vv string) (AthenaTime, error) {
-	parts := strings.Split(vv, " ")
-	if len(parts) > 1 && !unicode.IsDigit(rune(parts[len(parts)-1][0])) {
+	if idx := strings.LastIndexByte(vv, ' '); idx != -1 && idx+1 < len(vv) && !unicode.IsDigit(rune(vv[idx+1])) {
 		return parseAthenaTimeWithLocation(vv)
 	}
 	return parseAthenaTime(vv)
 }
 
+// guessTimeLayout returns the layout of a value in the format Athena returns dates, times and timestamps in,
+// or "" if the value is in none of them. Trying it first saves parsing with the layouts which cannot match,
+// as every failed attempt allocates an error.
+func guessTimeLayout(v string) string {
+	layouts, clock := clockLayouts, v
+	if len(v) >= 10 && v[4] == '-' {
+		if len(v) == 10 {
+			return "2006-01-02"
+		}
+		if v[10] != ' ' {
+			return ""
+		}
+		layouts, clock = dateTimeLayouts, v[11:]
+	}
+	fraction := 0
+	if dot := strings.IndexByte(clock, '.'); dot != -1 {
+		fraction = len(clock) - dot - 1
+	}
+	return layouts[fraction]
+}
+
 func parseAthenaTime(v string) (AthenaTime, error) {
+	if layout := guessTimeLayout(v); layout != "" {
+		if t, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+			return AthenaTime{Valid: true, Time: t}, nil
+		}
+	}
 	var t time.Time
 	var err error
 	for _, layout := range timeLayouts {
@@ -69,10 +122,15 @@ func parseAthenaTimeWithLocation(v string) (AthenaTime, error) {
 		return AthenaTime{}, fmt.Errorf("cannot convert %v (%T) to time+zone", v, v)
 	}
 	stamp, location := v[:idx], v[idx+1:]
-	loc, err := time.LoadLocation(location)
+	loc, err := loadAthenaLocation(location)
 	if err != nil {
 		return AthenaTime{}, fmt.Errorf("cannot load timezone %q: %v", location, err)
 	}
+	if layout := guessTimeLayout(stamp); layout != "" {
+		if t, err := time.ParseInLocation(layout, stamp, loc); err == nil {
+			return AthenaTime{Valid: true, Time: t}, nil
+		}
+	}
 	var t time.Time
 	for _, layout := range timeLayouts {
 		t, err = time.ParseInLocation(layout, stamp, loc)
@@ -82,3 +140,22 @@ func parseAthenaTimeWithLocation(v string) (AthenaTime, error) {
 	}
 	return AthenaTime{}, err
 }
+
+// loadAthenaLocation returns the location of a zone ID like `America/Los_Angeles` or `UTC`, or
+// a fixed zone for a numeric offset like `+08:00`.
+func loadAthenaLocation(location string) (*time.Location, error) {
+	if location == "Z" {
+		return time.UTC, nil
+	}
+	if strings.HasPrefix(location, "+") || strings.HasPrefix(location, "-") {
+		for _, layout := range zoneOffsetLayouts {
+			t, err := time.Parse(layout, location)
+			if err == nil {
+				_, offset := t.Zone()
+				return time.FixedZone(location, offset), nil
+			}
+		}
+		return nil, fmt.Errorf("unknown time zone offset %s", location)
+	}
+	return time.LoadLocation(location)
+}
@@ -22,6 +22,7 @@ package athenadriver
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -100,3 +101,66 @@ func TestDateTime_ParseAthenaTimeWithLocation(t *testing.T) {
 	assert.False(t, r.Valid)
 	assert.Equal(t, r.Time.String(), ZeroDateTimeString)
 }
+
+func TestDateTime_ScanTimeStampWithTimeZoneOffset(t *testing.T) {
+	r, e := scanTime("2001-08-22 03:04:05.321 +08:00")
+	assert.Nil(t, e)
+	assert.True(t, r.Valid)
+	_, offset := r.Time.Zone()
+	assert.Equal(t, 8*3600, offset)
+	assert.Equal(t, "2001-08-21T19:04:05.321Z", r.Time.UTC().Format(time.RFC3339Nano))
+
+	r, e = scanTime("2001-08-22 03:04:05.321 -0530")
+	assert.Nil(t, e)
+	_, offset = r.Time.Zone()
+	assert.Equal(t, -(5*3600 + 30*60), offset)
+
+	r, e = scanTime("2001-08-22 03:04:05.321 UTC")
+	assert.Nil(t, e)
+	assert.Equal(t, time.UTC, r.Time.Location())
+
+	r, e = scanTime("2001-08-22 03:04:05 America/New_York")
+	assert.Nil(t, e)
+	assert.Equal(t, "America/New_York", r.Time.Location().String())
+	assert.Equal(t, "2001-08-22T07:04:05Z", r.Time.UTC().Format(time.RFC3339))
+
+	r, e = scanTime("2001-08-22 03:04:05.321 +8:0x")
+	assert.NotNil(t, e)
+	assert.False(t, r.Valid)
+}
+
+func TestDateTime_ScanTimeWithTimeZoneOffset(t *testing.T) {
+	r, e := scanTime("01:02:03.456 +08:00")
+	assert.Nil(t, e)
+	assert.True(t, r.Valid)
+	assert.Equal(t, 1, r.Time.Hour())
+	assert.Equal(t, 456000000, r.Time.Nanosecond())
+	_, offset := r.Time.Zone()
+	assert.Equal(t, 8*3600, offset)
+
+	r, e = scanTime("01:02:03 Z")
+	assert.Nil(t, e)
+	assert.Equal(t, time.UTC, r.Time.Location())
+}
+
+func TestDateTime_GuessTimeLayout(t *testing.T) {
+	tests := map[string]string{
+		"2001-08-22":                    "2006-01-02",
+		"2001-08-22 03:04:05":           "2006-01-02 15:04:05",
+		"2001-08-22 03:04:05.321":       "2006-01-02 15:04:05.000",
+		"2001-08-22 03:04:05.321000":    "2006-01-02 15:04:05.000000",
+		"2001-08-22 03:04:05.321000000": "2006-01-02 15:04:05.000000000",
+		"03:04:05":                      "15:04:05",
+		"03:04:05.321":                  "15:04:05.000",
+		"2001-08-22 03:04:05.3210":      "",
+		"2001-08-22T03:04:05":           "",
+	}
+	for v, layout := range tests {
+		assert.Equal(t, layout, guessTimeLayout(v), v)
+	}
+
+	// values the guessed layout does not fit still parse with the full list of layouts
+	r, e := scanTime("2001-08-22 03:04:05.3210")
+	assert.Nil(t, e)
+	assert.Equal(t, 321000000, r.Time.Nanosecond())
+}
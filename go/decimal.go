@@ -0,0 +1,67 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+const (
+	// DecimalAsString returns DECIMAL values as string, exactly as Athena sends them. It is the default.
+	DecimalAsString = "string"
+
+	// DecimalAsRat returns DECIMAL values as *big.Rat without losing precision.
+	DecimalAsRat = "rat"
+
+	// DecimalAsFloat64 returns DECIMAL values as float64, which may lose precision.
+	DecimalAsFloat64 = "float64"
+)
+
+// DecimalConverter is the TypeConverter of DECIMAL values selected by a decimal mode.
+type DecimalConverter = TypeConverter
+
+// RegisterDecimalConverter is to register a DecimalConverter under a name, so a decimal type of choice,
+// e.g. shopspring/decimal, can be selected with Config.SetDecimalMode(name).
+func RegisterDecimalConverter(name string, converter DecimalConverter) {
+	registerTypeConverter(converterKey{athenaType: "decimal", mode: name}, converter)
+}
+
+func getDecimalConverter(name string) (DecimalConverter, bool) {
+	return lookupTypeConverter(converterKey{athenaType: "decimal", mode: name})
+}
+
+func decimalToString(val string) (interface{}, error) {
+	return val, nil
+}
+
+func decimalToRat(val string) (interface{}, error) {
+	r, ok := new(big.Rat).SetString(val)
+	if !ok {
+		return nil, fmt.Errorf("cannot convert %q to decimal", val)
+	}
+	return r, nil
+}
+
+func decimalToFloat64(val string) (interface{}, error) {
+	return strconv.ParseFloat(val, 64)
+}
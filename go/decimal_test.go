@@ -0,0 +1,86 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testMoney struct {
+	cents int64
+}
+
+func TestRows_AthenaTypeToGoType_Decimal(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	r, _ := NewRows(context.Background(), newMockAthenaClient(),
+		"SELECT_OK", testConf, NewDefaultObservability(testConf))
+	c := newColumnInfo("a", "decimal")
+	rv := "12345678901234567890.123456789"
+
+	g, e := r.athenaTypeToGoType(c, &rv, testConf)
+	assert.Nil(t, e)
+	assert.Equal(t, rv, g)
+
+	testConf.SetDecimalMode(DecimalAsRat)
+	g, e = r.athenaTypeToGoType(c, &rv, testConf)
+	assert.Nil(t, e)
+	expected, _ := new(big.Rat).SetString(rv)
+	assert.Equal(t, 0, expected.Cmp(g.(*big.Rat)))
+	bad := "1.2.3"
+	_, e = r.athenaTypeToGoType(c, &bad, testConf)
+	assert.NotNil(t, e)
+
+	testConf.SetDecimalMode(DecimalAsFloat64)
+	g, e = r.athenaTypeToGoType(c, &rv, testConf)
+	assert.Nil(t, e)
+	assert.InDelta(t, 1.2345678901234567e19, g, 1e4)
+
+	testConf.SetDecimalMode("no_such_mode")
+	_, e = r.athenaTypeToGoType(c, &rv, testConf)
+	assert.NotNil(t, e)
+}
+
+func TestRegisterDecimalConverter(t *testing.T) {
+	RegisterDecimalConverter("test_money", func(val string) (interface{}, error) {
+		r, _ := new(big.Rat).SetString(val)
+		r.Mul(r, big.NewRat(100, 1))
+		return testMoney{cents: r.Num().Int64() / r.Denom().Int64()}, nil
+	})
+	testConf := NewNoOpsConfig()
+	testConf.SetDecimalMode("test_money")
+	r, _ := NewRows(context.Background(), newMockAthenaClient(),
+		"SELECT_OK", testConf, NewDefaultObservability(testConf))
+	rv := "19.99"
+	g, e := r.athenaTypeToGoType(newColumnInfo("price", "decimal"), &rv, testConf)
+	assert.Nil(t, e)
+	assert.Equal(t, testMoney{cents: 1999}, g)
+}
+
+func TestConfig_SetDecimalMode(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	assert.Equal(t, DecimalAsString, testConf.GetDecimalMode())
+	testConf.SetDecimalMode(DecimalAsRat)
+	assert.Equal(t, DecimalAsRat, testConf.GetDecimalMode())
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"fmt"
+)
+
+// DryRunMode is how the queries are previewed instead of being executed, see Config.SetDryRun.
+type DryRunMode string
+
+const (
+	// DryRunOff executes the queries.
+	DryRunOff DryRunMode = ""
+	// DryRunExplain executes SELECT statements as EXPLAIN, which plans them without scanning any data.
+	DryRunExplain DryRunMode = "explain"
+	// DryRunExplainAnalyze executes SELECT statements as EXPLAIN ANALYZE, which runs them to report the rows and
+	// bytes each stage processes, and is billed like the query.
+	DryRunExplainAnalyze DryRunMode = "analyze"
+)
+
+// dryRunPassThrough are the keywords of the statements which are executed as they are in a dry run, as they don't
+// scan any data.
+var dryRunPassThrough = map[string]bool{
+	"SHOW":     true,
+	"DESCRIBE": true,
+	"DESC":     true,
+	"EXPLAIN":  true,
+}
+
+// dryRunMode is to get the DryRunMode of a query, which the context can override.
+func (c *Connection) dryRunMode(ctx context.Context) DryRunMode {
+	if mode, ok := ctx.Value(DryRunKey).(DryRunMode); ok {
+		return mode
+	}
+	return c.connector.config.GetDryRun()
+}
+
+// dryRunPrefix is to get what is prepended to a query in a dry run: EXPLAIN for SELECT statements, and nothing for
+// the statements which don't scan data. Other statements can't be previewed and fail.
+func dryRunPrefix(query string, mode DryRunMode) (string, error) {
+	keyword := statementKeyword(query)
+	switch {
+	case keyword == "SELECT" || keyword == "VALUES" || keyword == "TABLE":
+		if mode == DryRunExplainAnalyze {
+			return "EXPLAIN ANALYZE ", nil
+		}
+		return "EXPLAIN ", nil
+	case dryRunPassThrough[keyword]:
+		return "", nil
+	}
+	return "", fmt.Errorf("%w: %s statements can't be dry run", ErrDryRunUnsupported, keyword)
+}
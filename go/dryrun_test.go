@@ -0,0 +1,75 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDryRunPrefix(t *testing.T) {
+	for query, prefix := range map[string]string{
+		"SELECT 1":                             "EXPLAIN ",
+		"WITH t AS (SELECT 1) SELECT * FROM t": "EXPLAIN ",
+		"VALUES 1":                             "EXPLAIN ",
+		"SHOW TABLES":                          "",
+		"DESCRIBE t":                           "",
+		"EXPLAIN SELECT 1":                     "",
+	} {
+		got, err := dryRunPrefix(query, DryRunExplain)
+		assert.Nil(t, err, query)
+		assert.Equal(t, prefix, got, query)
+	}
+	got, err := dryRunPrefix("SELECT 1", DryRunExplainAnalyze)
+	assert.Nil(t, err)
+	assert.Equal(t, "EXPLAIN ANALYZE ", got)
+
+	for _, query := range []string{"INSERT INTO t VALUES (1)", "DROP TABLE t", "EXPLAIN ANALYZE DELETE FROM t"} {
+		_, err = dryRunPrefix(query, DryRunExplain)
+		assert.ErrorIs(t, err, ErrDryRunUnsupported, query)
+	}
+}
+
+func TestConnection_QueryContextDryRun(t *testing.T) {
+	c := createConnectionFixture()
+	m := c.athenaClient.(*mockAthenaClient)
+	ctx := context.WithValue(context.Background(), DryRunKey, DryRunExplain)
+	rows, err := c.QueryContext(ctx, "SELECT * FROM t WHERE id = ?", []driver.NamedValue{{Ordinal: 1, Value: int64(1)}})
+	assert.Nil(t, err)
+	assert.NotNil(t, rows)
+	assert.Equal(t, "EXPLAIN SELECT * FROM t WHERE id = ?", *m.lastStartInput.QueryString)
+
+	c.connector.config.SetDryRun(DryRunExplainAnalyze)
+	_, err = c.QueryContext(context.Background(), "SELECT * FROM t", []driver.NamedValue{})
+	assert.Nil(t, err)
+	assert.Equal(t, "EXPLAIN ANALYZE SELECT * FROM t", *m.lastStartInput.QueryString)
+
+	_, err = c.ExecContext(context.Background(), "DROP TABLE t", []driver.NamedValue{})
+	assert.ErrorIs(t, err, ErrDryRunUnsupported)
+
+	// the context turns the dry run off
+	ctx = context.WithValue(context.Background(), DryRunKey, DryRunOff)
+	_, err = c.QueryContext(ctx, "SELECTQueryContext_OK", []driver.NamedValue{})
+	assert.Nil(t, err)
+}
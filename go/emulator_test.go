@@ -0,0 +1,110 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// emulatorEndpointEnv is the environment variable with the endpoint of LocalStack, or another emulator of the
+// Athena and S3 APIs, the end-to-end tests run against, e.g. http://localhost:4566. They are skipped without it.
+const emulatorEndpointEnv = "ATHENADRIVER_TEST_ENDPOINT"
+
+const emulatorBucket = "athenadriver-test"
+
+// openEmulatorDB opens a database through the whole driver path against the emulator, creating the bucket of the
+// query results if needed.
+func openEmulatorDB(t *testing.T) *sql.DB {
+	endpoint := os.Getenv(emulatorEndpointEnv)
+	if endpoint == "" {
+		t.Skipf("%s is not set", emulatorEndpointEnv)
+	}
+	conf, err := NewEmulatorConfig(endpoint, "s3://"+emulatorBucket+"/results/")
+	assert.Nil(t, err)
+
+	connector := &SQLConnector{config: conf, tracer: NewDefaultObservability(conf)}
+	awsCfg, err := connector.awsConfig(context.Background())
+	assert.Nil(t, err)
+	_, err = connector.newS3Client(awsCfg).CreateBucket(context.Background(), &s3.CreateBucketInput{
+		Bucket: aws.String(emulatorBucket),
+	})
+	var owned *s3types.BucketAlreadyOwnedByYou
+	if err != nil && !errors.As(err, &owned) {
+		t.Fatalf("cannot create bucket %s: %v", emulatorBucket, err)
+	}
+
+	db, err := sql.Open(DriverName, conf.Stringify())
+	assert.Nil(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestEmulator_Query(t *testing.T) {
+	db := openEmulatorDB(t)
+	ctx := context.Background()
+	assert.Nil(t, db.PingContext(ctx))
+
+	var n int
+	var s string
+	err := db.QueryRowContext(ctx, "SELECT 1 AS n, 'athena' AS s").Scan(&n, &s)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, "athena", s)
+
+	err = db.QueryRowContext(ctx, "SELECT ? + 1", 41).Scan(&n)
+	assert.Nil(t, err)
+	assert.Equal(t, 42, n)
+}
+
+func TestEmulator_Tables(t *testing.T) {
+	db := openEmulatorDB(t)
+	ctx := context.Background()
+	table := "athenadriver_emulator_test"
+	_, err := db.ExecContext(ctx, "DROP TABLE IF EXISTS "+table)
+	assert.Nil(t, err)
+	_, err = db.ExecContext(ctx, "CREATE EXTERNAL TABLE "+table+" (id bigint, name string) "+
+		"STORED AS PARQUET LOCATION 's3://"+emulatorBucket+"/tables/"+table+"/'")
+	assert.Nil(t, err)
+	defer db.ExecContext(ctx, "DROP TABLE IF EXISTS "+table)
+
+	type row struct {
+		ID   int64
+		Name string
+	}
+	n, err := BulkInsert(ctx, db, table, []row{{1, "a"}, {2, "b"}}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+
+	rows, err := db.QueryContext(ctx, "SELECT id, name FROM "+table+" ORDER BY id")
+	assert.Nil(t, err)
+	result, err := Collect[row](rows)
+	assert.Nil(t, err)
+	assert.Equal(t, []row{{1, "a"}, {2, "b"}}, result)
+}
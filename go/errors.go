@@ -21,8 +21,11 @@
 package athenadriver
 
 import (
+	"database/sql/driver"
 	"errors"
 	"fmt"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 )
 
 // Various errors the driver might return. Can change between driver versions.
@@ -37,10 +40,87 @@ var (
 	ErrQueryUnknownType             = errors.New("query parameter type is unknown")
 	ErrQueryBufferOF                = errors.New("query buffer overflow")
 	ErrQueryTimeout                 = errors.New("query timeout")
+	ErrQueueTimeout                 = errors.New("query queue timeout")
+	ErrRunningTimeout               = errors.New("query running timeout")
+	ErrFetchTimeout                 = errors.New("result fetch timeout")
+	ErrConnectTimeout               = errors.New("connect timeout")
 	ErrAthenaTransactionUnsupported = errors.New("Athena doesn't support transaction statements")
 	ErrAthenaNilDatum               = errors.New("*athena.Datum must not be nil")
 	ErrAthenaNilClient              = errors.New("athenaClient must not be nil")
+	ErrS3NilClient                  = errors.New("S3 client must not be nil")
 	ErrTestMockGeneric              = errors.New("some_mock_error_for_test")
 	ErrTestMockFailedByAthena       = errors.New("the reason why Athena failed the query")
+	ErrCapacityUnavailable          = errors.New("capacity reservation has no capacity available")
+	ErrSparkSessionNotReady         = errors.New("spark session is not ready")
+	ErrSparkCalculationFailed       = errors.New("spark calculation failed")
 	ErrServiceLimitOverride         = fmt.Errorf("service limit override must be greater than %d", PoolInterval)
+	ErrInvalidCursor                = errors.New("cursor is not valid")
+	ErrZeroTime                     = errors.New("zero time.Time argument cannot be bound")
+	ErrNotQueryStateChange          = errors.New("event is not an Athena Query State Change")
+	ErrDryRunUnsupported            = errors.New("statement is not supported in dry run")
+	ErrCircuitOpen                  = errors.New("circuit breaker is open")
+	ErrInvalidPlan                  = errors.New("query plan is not valid")
+	ErrEmptySliceParam              = fmt.Errorf("%w: slice argument is empty", ErrInvalidQuery)
+	ErrQueryTooLong                 = fmt.Errorf("%w: Athena allows at most %d bytes", ErrInvalidQuery,
+		MAXQueryStringLength)
 )
+
+// DetachedQueryError is returned when the context of a query is done while detaching on cancel is enabled, see
+// Config.SetDetachOnCancel. The query execution keeps running, and its result can be read later with its QueryID.
+type DetachedQueryError struct {
+	QueryID string
+	Err     error
+}
+
+// Error is to implement interface error.
+func (e *DetachedQueryError) Error() string {
+	return fmt.Sprintf("query %s is detached: %v", e.QueryID, e.Err)
+}
+
+// Unwrap is to get the error of the context.
+func (e *DetachedQueryError) Unwrap() error {
+	return e.Err
+}
+
+// PingError is returned by Connection.Ping when the health check query fails. It unwraps to both driver.ErrBadConn,
+// so that database/sql discards the connection, and the error of the query.
+type PingError struct {
+	// QueryID is the QID of the health check query, if it was started.
+	QueryID string
+	// RequestID is the AWS request ID of the failed API call, if any.
+	RequestID string
+	Err       error
+}
+
+func newPingError(err error) *PingError {
+	e := &PingError{Err: err}
+	var detached *DetachedQueryError
+	var timeout *QueryTimeoutError
+	if errors.As(err, &detached) {
+		e.QueryID = detached.QueryID
+	} else if errors.As(err, &timeout) {
+		e.QueryID = timeout.QueryID
+	}
+	var re *awshttp.ResponseError
+	if errors.As(err, &re) {
+		e.RequestID = re.ServiceRequestID()
+	}
+	return e
+}
+
+// Error is to implement interface error.
+func (e *PingError) Error() string {
+	msg := "ping failed"
+	if e.QueryID != "" {
+		msg += ", query " + e.QueryID
+	}
+	if e.RequestID != "" {
+		msg += ", request " + e.RequestID
+	}
+	return fmt.Sprintf("%s: %v: %v", msg, driver.ErrBadConn, e.Err)
+}
+
+// Unwrap is to get driver.ErrBadConn and the error of the health check query.
+func (e *PingError) Unwrap() []error {
+	return []error{driver.ErrBadConn, e.Err}
+}
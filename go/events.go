@@ -0,0 +1,191 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"go.uber.org/zap"
+)
+
+// QueryEventListener is a CompletionNotifier fed with the Athena Query State Change events which EventBridge
+// delivers, e.g. to an API destination served by its ServeHTTP, or by a Lambda function calling HandleEvent.
+// Once registered with RegisterCompletionNotifier and named by Config.SetCompletionNotifier, it resolves the
+// outstanding QueryHandle of the query execution an event is about.
+type QueryEventListener struct {
+	waiters *completionWaiters
+}
+
+// NewQueryEventListener is to create a QueryEventListener.
+func NewQueryEventListener() *QueryEventListener {
+	return &QueryEventListener{waiters: newCompletionWaiters()}
+}
+
+// HandleEvent is to handle an Athena Query State Change event, raw or in an SNS notification.
+func (l *QueryEventListener) HandleEvent(body []byte) error {
+	queryID, final, ok := parseQueryStateChange(string(body))
+	if !ok {
+		return ErrNotQueryStateChange
+	}
+	if final {
+		l.waiters.notify(queryID)
+	}
+	return nil
+}
+
+// ServeHTTP is to implement interface http.Handler, handling the event in the request body.
+func (l *QueryEventListener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if err := l.HandleEvent(body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Wait is to implement interface CompletionNotifier.
+func (l *QueryEventListener) Wait(ctx context.Context, queryID string) error {
+	return l.waiters.wait(ctx, queryID, nil)
+}
+
+// QueryHandle is a query execution started by Connection.StartQuery, whose result is read once it is done.
+type QueryHandle struct {
+	QueryID string
+
+	conn   *Connection
+	query  string
+	wgName string
+	start  time.Time
+}
+
+// StartQuery is to start the execution of a query without waiting for it, e.g. in a service which is told when it
+// is done by a QueryEventListener. It is reached through sql.Conn.Raw. Unlike QueryContext, the query doesn't
+// count against the concurrent query limit of the connector.
+func (c *Connection) StartQuery(ctx context.Context, query string, args ...interface{}) (*QueryHandle, error) {
+	if c.closed.Load() {
+		return nil, driver.ErrBadConn
+	}
+	ctx = c.withQueryCaller(ctx)
+	if c.connector.config.IsReadOnly() && !isReadOnlyStatement(query, c.connector.config) {
+		return nil, fmt.Errorf("writing to Athena database is disallowed in read-only mode")
+	}
+	namedArgs := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		namedArgs[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+		if err := c.CheckNamedValue(&namedArgs[i]); err != nil {
+			return nil, err
+		}
+	}
+	query, namedArgs, err := c.bindParams(query, namedArgs)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateQueryLength(query); err != nil {
+		return nil, err
+	}
+	executionParams, err := c.buildExecutionParams(namedValueToValue(namedArgs))
+	if err != nil {
+		return nil, err
+	}
+	query, executionParams, err = c.fitExecutionParams(query, executionParams)
+	if err != nil {
+		return nil, err
+	}
+	wg, err := c.checkWorkgroup(ctx)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	queryID, err := c.startQueryExecution(ctx, query, executionParams, wg.Name, start)
+	if err != nil {
+		return nil, err
+	}
+	return &QueryHandle{QueryID: queryID, conn: c, query: query, wgName: wg.Name, start: start}, nil
+}
+
+// Wait blocks until the query execution succeeds, and fails like QueryContext otherwise. With the completion
+// notifier of the config, the status is only checked once the notifier tells the query execution is done, or
+// once GetCompletionNotifierFallback has passed without a notification.
+func (h *QueryHandle) Wait(ctx context.Context) error {
+	_, err := h.wait(ctx)
+	return err
+}
+
+// Rows is to wait for the query execution like Wait, and read its result.
+func (h *QueryHandle) Rows(ctx context.Context) (*Rows, error) {
+	queryExecution, err := h.wait(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newQueryExecutionRows(ctx, h.conn.athenaClient, queryExecution, h.conn.connector.config,
+		h.conn.tracer(ctx))
+}
+
+func (h *QueryHandle) wait(ctx context.Context) (*athenatypes.QueryExecution, error) {
+	c := h.conn
+	if name := c.connector.config.GetCompletionNotifier(); name != "" {
+		if notifier, ok := getCompletionNotifier(name); ok {
+			waitCtx, cancel := context.WithTimeout(ctx, c.connector.config.GetCompletionNotifierFallback())
+			err := notifier.Wait(waitCtx, h.QueryID)
+			cancel()
+			if err != nil && ctx.Err() != nil {
+				return nil, h.stop(ctx)
+			}
+		}
+	}
+	return c.waitQueryExecution(ctx, h.QueryID, h.query, h.wgName, h.start)
+}
+
+// stop is to stop the query execution once ctx is done, unless detaching on cancel is enabled.
+func (h *QueryHandle) stop(ctx context.Context) error {
+	c := h.conn
+	if c.isDetachOnCancel(ctx) {
+		return c.detachQuery(ctx, h.QueryID)
+	}
+	_, err := c.athenaClient.StopQueryExecution(context.Background(), &athena.StopQueryExecutionInput{
+		QueryExecutionId: aws.String(h.QueryID),
+	})
+	if err != nil {
+		c.tracer(ctx).Log(ErrorLevel, "StopQueryExecution failed",
+			zap.String("workgroup", h.wgName),
+			zap.String("queryID", h.QueryID))
+		return err
+	}
+	c.emitLineage(ctx, LineageAbort, h.QueryID, h.query, h.wgName, nil)
+	c.writeAudit(ctx, h.QueryID, h.query, h.wgName, h.start, athenatypes.QueryExecutionStateCancelled, nil)
+	return ctx.Err()
+}
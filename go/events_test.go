@@ -0,0 +1,126 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryEventListener_HandleEvent(t *testing.T) {
+	l := NewQueryEventListener()
+	assert.ErrorIs(t, l.HandleEvent([]byte("not json")), ErrNotQueryStateChange)
+	assert.Nil(t, l.HandleEvent([]byte(queryStateChangeEvent("qid", "RUNNING"))))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, l.Wait(ctx, "qid"), context.DeadlineExceeded)
+
+	assert.Nil(t, l.HandleEvent([]byte(queryStateChangeEvent("qid", "SUCCEEDED"))))
+	assert.Nil(t, l.Wait(context.Background(), "qid"))
+}
+
+func TestQueryEventListener_ServeHTTP(t *testing.T) {
+	l := NewQueryEventListener()
+	for body, status := range map[string]int{
+		queryStateChangeEvent("qid", "FAILED"): http.StatusNoContent,
+		"{}":                                   http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		l.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+		assert.Equal(t, status, w.Code, body)
+	}
+	w := httptest.NewRecorder()
+	l.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Nil(t, l.Wait(context.Background(), "qid"))
+}
+
+func TestQueryHandle_Rows(t *testing.T) {
+	l := NewQueryEventListener()
+	RegisterCompletionNotifier("TestQueryHandle_Rows", l)
+	defer UnregisterCompletionNotifier("TestQueryHandle_Rows")
+
+	c := createConnectionFixture()
+	c.connector.config.SetCompletionNotifier("TestQueryHandle_Rows")
+	m := c.athenaClient.(*mockAthenaClient)
+
+	h, err := c.StartQuery(context.Background(), "SELECTQueryContext_OK")
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECTQueryContext_OK_QID", h.QueryID)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_ = l.HandleEvent([]byte(queryStateChangeEvent(h.QueryID, "SUCCEEDED")))
+	}()
+	rows, err := h.Rows(context.Background())
+	assert.Nil(t, err)
+	assert.NotNil(t, rows)
+	assert.Equal(t, 1, m.callCount("GetQueryExecution"))
+}
+
+func TestQueryHandle_WaitCanceled(t *testing.T) {
+	l := NewQueryEventListener()
+	RegisterCompletionNotifier("TestQueryHandle_WaitCanceled", l)
+	defer UnregisterCompletionNotifier("TestQueryHandle_WaitCanceled")
+
+	c := createConnectionFixture()
+	c.connector.config.SetCompletionNotifier("TestQueryHandle_WaitCanceled")
+	m := c.athenaClient.(*mockAthenaClient)
+
+	h, err := c.StartQuery(context.Background(), "SELECTQueryContext_CANCEL_OK")
+	assert.Nil(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, h.Wait(ctx), context.DeadlineExceeded)
+	assert.Equal(t, 0, m.callCount("GetQueryExecution"))
+	assert.Equal(t, 1, m.callCount("StopQueryExecution"))
+}
+
+func TestQueryHandle_WaitFallback(t *testing.T) {
+	l := NewQueryEventListener()
+	RegisterCompletionNotifier("TestQueryHandle_WaitFallback", l)
+	defer UnregisterCompletionNotifier("TestQueryHandle_WaitFallback")
+
+	c := createConnectionFixture()
+	c.connector.config.SetCompletionNotifier("TestQueryHandle_WaitFallback")
+	c.connector.config.SetCompletionNotifierFallback(10 * time.Millisecond)
+	m := c.athenaClient.(*mockAthenaClient)
+
+	h, err := c.StartQuery(context.Background(), "SELECTQueryContext_OK")
+	assert.Nil(t, err)
+	assert.Nil(t, h.Wait(context.Background()))
+	assert.Equal(t, 1, m.callCount("GetQueryExecution"))
+	assert.Equal(t, 0, m.callCount("StopQueryExecution"))
+}
+
+func TestConnection_StartQueryReadOnly(t *testing.T) {
+	c := createConnectionFixture()
+	c.connector.config.SetReadOnly(true)
+	_, err := c.StartQuery(context.Background(), "DROP TABLE t")
+	assert.NotNil(t, err)
+}
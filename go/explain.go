@@ -0,0 +1,235 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Plan is a query plan as EXPLAIN or EXPLAIN ANALYZE print it, parsed into its stages and their operators.
+type Plan struct {
+	Stages []*PlanStage
+	// Text is the plan as Athena printed it.
+	Text string
+}
+
+// PlanStage is a stage, or fragment, of a plan, which Athena runs as a set of tasks.
+type PlanStage struct {
+	ID int
+	// Partitioning is how the data of the stage is distributed, e.g. SOURCE, HASH or SINGLE.
+	Partitioning string
+	// CPU, InputRows and OutputRows are only reported by EXPLAIN ANALYZE, and are 0 otherwise.
+	CPU        time.Duration
+	InputRows  int64
+	OutputRows int64
+	// Root is the last operator of the stage, whose output is that of the stage.
+	Root *PlanOperator
+}
+
+// PlanOperator is an operator of a stage, e.g. TableScan, Filter or Aggregate.
+type PlanOperator struct {
+	Name string
+	// Args are the arguments of the operator between its square brackets, e.g. `type = FINAL`.
+	Args string
+	// EstimatedRows is the number of output rows the planner estimated, or -1 if it had no estimate.
+	EstimatedRows float64
+	// CPU and OutputRows are the actual CPU time and number of output rows, only reported by EXPLAIN ANALYZE.
+	CPU        time.Duration
+	OutputRows int64
+	// Details are the other lines Athena printed for the operator, e.g. its layout and assignments.
+	Details  []string
+	Children []*PlanOperator
+}
+
+// Operators returns the operators of all the stages, each before its children.
+func (p *Plan) Operators() []*PlanOperator {
+	var operators []*PlanOperator
+	var walk func(o *PlanOperator)
+	walk = func(o *PlanOperator) {
+		operators = append(operators, o)
+		for _, child := range o.Children {
+			walk(child)
+		}
+	}
+	for _, stage := range p.Stages {
+		if stage.Root != nil {
+			walk(stage.Root)
+		}
+	}
+	return operators
+}
+
+var (
+	planFragmentRegex = regexp.MustCompile(`^Fragment (\d+) \[(\w+)\]`)
+	planOperatorRegex = regexp.MustCompile(`^([A-Z][A-Za-z]*)(?:\[(.*)\])?$`)
+	planRowsRegex     = regexp.MustCompile(`(Input|Output): (\d+) rows?\b`)
+	planCPURegex      = regexp.MustCompile(`^CPU: ([0-9.]+)([a-z]+)`)
+	planEstimateRegex = regexp.MustCompile(`^Estimates: \{rows: ([0-9.?]+)`)
+)
+
+// ExplainAnalyze runs a query with EXPLAIN ANALYZE and returns its plan with the actual CPU time and rows of its
+// stages and operators next to the estimated ones, e.g. to detect plan regressions. EXPLAIN ANALYZE runs the query,
+// and is billed like it.
+func ExplainAnalyze(ctx context.Context, db Queryer, query string, args ...interface{}) (*Plan, error) {
+	query = strings.TrimRight(strings.TrimSpace(query), "; \t\r\n")
+	if statementKeyword(query) == "EXPLAIN" {
+		return nil, fmt.Errorf("%w: the query is already an EXPLAIN statement", ErrInvalidQuery)
+	}
+	rows, err := db.QueryContext(ctx, "EXPLAIN ANALYZE "+query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var lines []string
+	for rows.Next() {
+		var line sql.NullString
+		if err := rows.Scan(&line); err != nil {
+			return nil, err
+		}
+		lines = append(lines, line.String)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ParsePlan(strings.Join(lines, "\n"))
+}
+
+// ParsePlan is to parse the text of a plan printed by EXPLAIN or EXPLAIN ANALYZE.
+func ParsePlan(text string) (*Plan, error) {
+	plan := &Plan{Text: text}
+	var stage *PlanStage
+	// operators are the operators of the current stage the next ones can be children of, by their indentation.
+	type indented struct {
+		indent   int
+		operator *PlanOperator
+	}
+	var operators []indented
+	for _, line := range strings.Split(text, "\n") {
+		indent, content := planLine(line)
+		if content == "" {
+			continue
+		}
+		if m := planFragmentRegex.FindStringSubmatch(content); m != nil {
+			id, _ := strconv.Atoi(m[1])
+			stage = &PlanStage{ID: id, Partitioning: m[2]}
+			plan.Stages = append(plan.Stages, stage)
+			operators = operators[:0]
+			continue
+		}
+		if stage == nil {
+			// e.g. the column name of the result
+			continue
+		}
+		if m := planOperatorRegex.FindStringSubmatch(content); m != nil {
+			o := &PlanOperator{Name: m[1], Args: m[2], EstimatedRows: -1}
+			for len(operators) > 0 && operators[len(operators)-1].indent >= indent {
+				operators = operators[:len(operators)-1]
+			}
+			if len(operators) == 0 {
+				if stage.Root != nil {
+					return nil, fmt.Errorf("%w: fragment %d has more than one root operator", ErrInvalidPlan, stage.ID)
+				}
+				stage.Root = o
+			} else {
+				parent := operators[len(operators)-1].operator
+				parent.Children = append(parent.Children, o)
+			}
+			operators = append(operators, indented{indent: indent, operator: o})
+			continue
+		}
+		if len(operators) == 0 {
+			// the statistics of the stage
+			if cpu, ok := planCPU(content); ok {
+				stage.CPU = cpu
+				for _, m := range planRowsRegex.FindAllStringSubmatch(content, -1) {
+					n, _ := strconv.ParseInt(m[2], 10, 64)
+					if m[1] == "Input" {
+						stage.InputRows = n
+					} else {
+						stage.OutputRows = n
+					}
+				}
+			}
+			continue
+		}
+		o := operators[len(operators)-1].operator
+		if m := planEstimateRegex.FindStringSubmatch(content); m != nil {
+			if rows, err := strconv.ParseFloat(m[1], 64); err == nil {
+				o.EstimatedRows = rows
+			}
+		} else if cpu, ok := planCPU(content); ok {
+			o.CPU = cpu
+			for _, m := range planRowsRegex.FindAllStringSubmatch(content, -1) {
+				if m[1] == "Output" {
+					o.OutputRows, _ = strconv.ParseInt(m[2], 10, 64)
+				}
+			}
+		}
+		o.Details = append(o.Details, content)
+	}
+	if len(plan.Stages) == 0 {
+		return nil, fmt.Errorf("%w: no fragment in the plan", ErrInvalidPlan)
+	}
+	return plan, nil
+}
+
+// planLine returns the indentation of a line of a plan, with the lines drawing the tree of operators counted as
+// spaces, and what follows it.
+func planLine(line string) (int, string) {
+	indent := 0
+	for _, r := range line {
+		switch r {
+		case ' ', '\t', '│', '└', '├', '─':
+			indent++
+			continue
+		}
+		break
+	}
+	content := strings.TrimSpace(strings.TrimLeft(line, " \t│└├─"))
+	return indent, content
+}
+
+// planCPU returns the CPU time of a line of statistics of a plan, e.g. `CPU: 9.48ms, Scheduled: ...`.
+func planCPU(content string) (time.Duration, bool) {
+	m := planCPURegex.FindStringSubmatch(content)
+	if m == nil {
+		return 0, false
+	}
+	unit := m[2]
+	if unit == "d" {
+		v, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return 0, false
+		}
+		return time.Duration(v * float64(24*time.Hour)), true
+	}
+	d, err := time.ParseDuration(m[1] + unit)
+	if err != nil {
+		return 0, false
+	}
+	return d, true
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const testExplainAnalyzePlan = `Query Plan
+Fragment 1 [SINGLE]
+    CPU: 1.52ms, Scheduled: 2.10ms, Blocked 41.23ms (Input: 20.10ms, Output: 0.00ns), Input: 3 rows (63B); per task: avg.: 3.00 std.dev.: 0.00, Output: 3 rows (63B)
+    Output layout: [orderstatus, count]
+    Output partitioning: SINGLE []
+    Aggregate[type = FINAL, keys = [orderstatus]]
+    │   Layout: [orderstatus:varchar(1), count:bigint]
+    │   Estimates: {rows: 3 (48B), cpu: ?, memory: ?, network: ?}
+    │   CPU: 1.00ms (10.00%), Scheduled: 1.20ms (8.00%), Blocked: 0.00ns (0.00%), Output: 3 rows (63B)
+    │   count := count("count_0")
+    └─ LocalExchange[partitioning = HASH, arguments = ["orderstatus"]]
+       │   Layout: [orderstatus:varchar(1), count_0:bigint]
+       │   Estimates: {rows: ? (?), cpu: ?, memory: 0B, network: 0B}
+       │   CPU: 0.30ms (3.00%), Scheduled: 0.40ms (2.00%), Blocked: 20.00ms (40.00%), Output: 3 rows (63B)
+       └─ RemoteSource[sourceFragmentIds = [2]]
+              Layout: [orderstatus:varchar(1), count_0:bigint]
+
+Fragment 2 [SOURCE]
+    CPU: 9.48ms, Scheduled: 10.50ms, Blocked 0.00ns (Input: 0.00ns, Output: 0.00ns), Input: 15000 rows (0B); per task: avg.: 15000.00 std.dev.: 0.00, Output: 3 rows (63B)
+    Aggregate[type = PARTIAL, keys = [orderstatus]]
+    │   Estimates: {rows: 3 (48B), cpu: ?, memory: ?, network: ?}
+    │   CPU: 2.10ms (21.00%), Scheduled: 2.50ms (19.00%), Blocked: 0.00ns (0.00%), Output: 3 rows (63B)
+    └─ Filter[filterPredicate = ("orderdate" > DATE '1995-01-01')]
+       │   Estimates: {rows: 7500.5 (64kB), cpu: 120k, memory: 0B, network: 0B}
+       │   CPU: 5.20ms (52.00%), Scheduled: 5.90ms (45.00%), Blocked: 0.00ns (0.00%), Output: 7324 rows (100kB)
+       └─ TableScan[table = awsdatacatalog:tpch:orders]
+              Estimates: {rows: 15000 (128kB), cpu: 128k, memory: 0B, network: 0B}
+              CPU: 2.18ms (21.80%), Scheduled: 2.20ms (16.00%), Blocked: 0.00ns (0.00%), Output: 15000 rows (128kB)
+              Input: 15000 rows (128kB), Physical input: 48kB, Physical input time: 1.20ms
+`
+
+func TestParsePlan(t *testing.T) {
+	plan, err := ParsePlan(testExplainAnalyzePlan)
+	assert.Nil(t, err)
+	assert.Equal(t, testExplainAnalyzePlan, plan.Text)
+	assert.Len(t, plan.Stages, 2)
+
+	s := plan.Stages[0]
+	assert.Equal(t, 1, s.ID)
+	assert.Equal(t, "SINGLE", s.Partitioning)
+	assert.Equal(t, 1520*time.Microsecond, s.CPU)
+	assert.Equal(t, int64(3), s.InputRows)
+	assert.Equal(t, int64(3), s.OutputRows)
+	assert.Equal(t, "Aggregate", s.Root.Name)
+	assert.Equal(t, "type = FINAL, keys = [orderstatus]", s.Root.Args)
+	assert.Equal(t, float64(3), s.Root.EstimatedRows)
+	assert.Equal(t, int64(3), s.Root.OutputRows)
+	assert.Equal(t, time.Millisecond, s.Root.CPU)
+	assert.Equal(t, []string{
+		"Layout: [orderstatus:varchar(1), count:bigint]",
+		"Estimates: {rows: 3 (48B), cpu: ?, memory: ?, network: ?}",
+		"CPU: 1.00ms (10.00%), Scheduled: 1.20ms (8.00%), Blocked: 0.00ns (0.00%), Output: 3 rows (63B)",
+		`count := count("count_0")`,
+	}, s.Root.Details)
+	exchange := s.Root.Children[0]
+	assert.Equal(t, "LocalExchange", exchange.Name)
+	assert.Equal(t, float64(-1), exchange.EstimatedRows)
+	assert.Equal(t, "RemoteSource", exchange.Children[0].Name)
+	assert.Equal(t, int64(0), exchange.Children[0].OutputRows)
+
+	s = plan.Stages[1]
+	assert.Equal(t, "SOURCE", s.Partitioning)
+	assert.Equal(t, int64(15000), s.InputRows)
+	var names []string
+	for _, o := range plan.Operators() {
+		names = append(names, o.Name)
+	}
+	assert.Equal(t, []string{"Aggregate", "LocalExchange", "RemoteSource", "Aggregate", "Filter", "TableScan"}, names)
+	filter := s.Root.Children[0]
+	assert.Equal(t, 7500.5, filter.EstimatedRows)
+	assert.Equal(t, int64(7324), filter.OutputRows)
+	scan := filter.Children[0]
+	assert.Equal(t, "table = awsdatacatalog:tpch:orders", scan.Args)
+	assert.Equal(t, int64(15000), scan.OutputRows)
+	assert.Empty(t, scan.Children)
+
+	// EXPLAIN without ANALYZE has no actual statistics.
+	plan, err = ParsePlan("Fragment 0 [SOURCE]\n    TableScan[table = t]\n        Estimates: {rows: 10 (1kB)}")
+	assert.Nil(t, err)
+	assert.Equal(t, float64(10), plan.Stages[0].Root.EstimatedRows)
+	assert.Equal(t, time.Duration(0), plan.Stages[0].Root.CPU)
+
+	_, err = ParsePlan("Query Plan\nsomething else")
+	assert.ErrorIs(t, err, ErrInvalidPlan)
+	_, err = ParsePlan("Fragment 0 [SOURCE]\n    TableScan[table = t]\n    TableScan[table = s]")
+	assert.ErrorIs(t, err, ErrInvalidPlan)
+}
+
+func TestExplainAnalyze(t *testing.T) {
+	m := newMockAthenaClient()
+	m.queryToResultsGenMap["EXPLAIN_QID"] = func(_ string) (*athena.GetQueryResultsOutput, error) {
+		var rows []athenatypes.Row
+		for _, line := range strings.Split(testExplainAnalyzePlan, "\n") {
+			rows = append(rows, newRow(1, []string{line}))
+		}
+		return &athena.GetQueryResultsOutput{
+			ResultSet: &athenatypes.ResultSet{
+				ResultSetMetadata: &athenatypes.ResultSetMetadata{
+					ColumnInfo: []athenatypes.ColumnInfo{newColumnInfo("Query Plan", "varchar")},
+				},
+				Rows: rows,
+			},
+		}, nil
+	}
+	db := sql.OpenDB(NewSQLConnectorWithClient(NewNoOpsConfig(), m))
+	defer db.Close()
+
+	plan, err := ExplainAnalyze(context.Background(), db,
+		"SELECT orderstatus, count(*) FROM orders WHERE orderdate > ? GROUP BY 1;", "DATE '1995-01-01'")
+	assert.Nil(t, err)
+	assert.Equal(t, "EXPLAIN ANALYZE SELECT orderstatus, count(*) FROM orders WHERE orderdate > ? GROUP BY 1",
+		*m.lastStartInput.QueryString)
+	assert.Len(t, plan.Stages, 2)
+	assert.Len(t, plan.Operators(), 6)
+
+	_, err = ExplainAnalyze(context.Background(), db, "EXPLAIN SELECT 1")
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+}
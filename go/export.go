@@ -0,0 +1,129 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// WriteCSV streams the columns and rows of sql.Rows to w in CSV format, one row at a time, so it works for
+// result sets which don't fit in memory. NULL is written as an empty field, and time.Time in RFC 3339 format.
+func WriteCSV(rows *sql.Rows, w io.Writer) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.Write(columns); err != nil {
+		return err
+	}
+	record := make([]string, len(columns))
+	err = scanEachRow(rows, len(columns), func(values []interface{}) error {
+		for i, v := range values {
+			record[i] = formatValue(v)
+		}
+		return csvWriter.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// WriteJSONLines streams the rows of sql.Rows to w as JSON Lines, one object per row with the columns in
+// query order, so it works for result sets which don't fit in memory. Values are written as json.Marshal writes
+// them, so varbinary values, which are []byte, are base64 strings and survive bytes which aren't valid UTF-8.
+func WriteJSONLines(rows *sql.Rows, w io.Writer) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	keys := make([][]byte, len(columns))
+	for i, column := range columns {
+		if keys[i], err = json.Marshal(column); err != nil {
+			return err
+		}
+	}
+	bw := bufio.NewWriter(w)
+	err = scanEachRow(rows, len(columns), func(values []interface{}) error {
+		bw.WriteByte('{')
+		for i, v := range values {
+			if i > 0 {
+				bw.WriteByte(',')
+			}
+			bw.Write(keys[i])
+			bw.WriteByte(':')
+			b, err := json.Marshal(v)
+			if err != nil {
+				return fmt.Errorf("cannot write column %s: %w", columns[i], err)
+			}
+			bw.Write(b)
+		}
+		bw.WriteByte('}')
+		_, err := bw.WriteString("\n")
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// scanEachRow scans every row of sql.Rows and calls fn with the values, which are only valid during the call.
+func scanEachRow(rows *sql.Rows, columnCount int, fn func(values []interface{}) error) error {
+	values := make([]interface{}, columnCount)
+	dest := make([]interface{}, columnCount)
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		if err := fn(values); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// formatValue formats a value scanned from sql.Rows as text.
+func formatValue(v interface{}) string {
+	switch vv := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return vv
+	case []byte:
+		return string(vv)
+	case time.Time:
+		return vv.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(vv)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteCSV(t *testing.T) {
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 6000000, time.UTC)
+	sqlRows := sqlmock.NewRows([]string{"s", "n", "t", "null"})
+	sqlRows.AddRow("a,\"b\"\nc", int64(1), ts, nil)
+	sqlRows.AddRow("", 2.5, ts, nil)
+	var buf bytes.Buffer
+	assert.Nil(t, WriteCSV(mockRowsToSQLRows(sqlRows), &buf))
+	assert.Equal(t, "s,n,t,null\n"+
+		"\"a,\"\"b\"\"\nc\",1,2020-01-02T03:04:05.006Z,\n"+
+		",2.5,2020-01-02T03:04:05.006Z,\n", buf.String())
+
+	sqlRows = sqlmock.NewRows([]string{"s"})
+	sqlRows.AddRow("a").RowError(0, errors.New("row error"))
+	assert.NotNil(t, WriteCSV(mockRowsToSQLRows(sqlRows), &buf))
+}
+
+func TestWriteJSONLines(t *testing.T) {
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	sqlRows := sqlmock.NewRows([]string{"z", "a", "t", "null"})
+	sqlRows.AddRow("x\"y", int64(1), ts, nil)
+	sqlRows.AddRow("", true, ts, nil)
+	var buf bytes.Buffer
+	assert.Nil(t, WriteJSONLines(mockRowsToSQLRows(sqlRows), &buf))
+	assert.Equal(t,
+		`{"z":"x\"y","a":1,"t":"2020-01-02T03:04:05Z","null":null}`+"\n"+
+			`{"z":"","a":true,"t":"2020-01-02T03:04:05Z","null":null}`+"\n",
+		buf.String())
+
+	// varbinary is base64 encoded, so bytes which aren't valid UTF-8 are kept
+	sqlRows = sqlmock.NewRows([]string{"b"})
+	sqlRows.AddRow([]byte{0xff, 0x00, 'a'})
+	buf.Reset()
+	assert.Nil(t, WriteJSONLines(mockRowsToSQLRows(sqlRows), &buf))
+	assert.Equal(t, `{"b":"/wBh"}`+"\n", buf.String())
+
+	sqlRows = sqlmock.NewRows([]string{"s"})
+	sqlRows.AddRow("a").RowError(0, errors.New("row error"))
+	assert.NotNil(t, WriteJSONLines(mockRowsToSQLRows(sqlRows), &buf))
+
+	b, err := json.Marshal([]interface{}{UUID{1}, YearMonthInterval{Years: 1, Months: 2}})
+	assert.Nil(t, err)
+	assert.Equal(t, `["01000000-0000-0000-0000-000000000000","1-2"]`, string(b))
+}
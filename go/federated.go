@@ -0,0 +1,84 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"regexp"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+)
+
+var (
+	lambdaFunctionPattern = regexp.MustCompile(`LambdaFunction\[([^\]]*)\]`)
+	lambdaMessagePattern  = regexp.MustCompile(`(?s)with message\[(.*)\]\s*$`)
+)
+
+// FederatedQueryError is returned when a query fails in the Lambda connector of a federated data catalog, e.g.
+// because the connector cannot reach its DynamoDB table or RDS database. Its error string is the reason Athena
+// reports.
+type FederatedQueryError struct {
+	QueryID string
+	// Catalog is the data catalog the query ran in, if it was set.
+	Catalog string
+	// LambdaFunction is the ARN of the Lambda function of the connector.
+	LambdaFunction string
+	// Message is the error message of the connector.
+	Message string
+	Reason  string
+}
+
+// Error is to implement interface error.
+func (e *FederatedQueryError) Error() string {
+	return e.Reason
+}
+
+// newFederatedQueryError returns a *FederatedQueryError if a failed query execution failed in a Lambda connector,
+// or nil otherwise.
+func newFederatedQueryError(qe *athenatypes.QueryExecution) *FederatedQueryError {
+	if qe == nil || qe.Status == nil {
+		return nil
+	}
+	reason := aws.ToString(qe.Status.StateChangeReason)
+	if qe.Status.AthenaError != nil && qe.Status.AthenaError.ErrorMessage != nil {
+		reason = *qe.Status.AthenaError.ErrorMessage
+	}
+	function := lambdaFunctionPattern.FindStringSubmatch(reason)
+	if function == nil {
+		return nil
+	}
+	e := &FederatedQueryError{
+		QueryID:        aws.ToString(qe.QueryExecutionId),
+		LambdaFunction: function[1],
+		Message:        reason,
+		Reason:         aws.ToString(qe.Status.StateChangeReason),
+	}
+	if e.Reason == "" {
+		e.Reason = reason
+	}
+	if message := lambdaMessagePattern.FindStringSubmatch(reason); message != nil {
+		e.Message = message[1]
+	}
+	if qe.QueryExecutionContext != nil {
+		e.Catalog = aws.ToString(qe.QueryExecutionContext.Catalog)
+	}
+	return e
+}
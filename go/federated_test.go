@@ -0,0 +1,86 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnection_QueryContext_FederatedCatalog(t *testing.T) {
+	t.Parallel()
+	nm := newMockAthenaClient()
+	c := &Connection{
+		athenaClient: nm,
+		connector:    NoopsSQLConnector(),
+	}
+	c.connector.config.SetCatalog("dynamo")
+
+	_, err := c.QueryContext(context.Background(), "SELECTQueryContext_FEDERATED_FAIL", []driver.NamedValue{})
+	var fedErr *FederatedQueryError
+	assert.True(t, errors.As(err, &fedErr))
+	assert.Equal(t, "SELECTQueryContext_FEDERATED_FAIL_QID", fedErr.QueryID)
+	assert.Equal(t, "dynamo", fedErr.Catalog)
+	assert.Equal(t, "arn:aws:lambda:us-east-1:123456789012:function:dynamo", fedErr.LambdaFunction)
+	assert.Equal(t, "Requested resource not found", fedErr.Message)
+	assert.Contains(t, err.Error(), "GENERIC_USER_ERROR")
+
+	assert.Equal(t, "dynamo", aws.ToString(nm.lastStartInput.QueryExecutionContext.Catalog))
+	assert.Equal(t, c.connector.config.GetDB(), aws.ToString(nm.lastStartInput.QueryExecutionContext.Database))
+}
+
+func TestConnection_QueryContext_DefaultCatalog(t *testing.T) {
+	t.Parallel()
+	nm := newMockAthenaClient()
+	c := &Connection{
+		athenaClient: nm,
+		connector:    NoopsSQLConnector(),
+	}
+	_, err := c.QueryContext(context.Background(), "SELECTQueryContext_AWS_FAIL", []driver.NamedValue{})
+	var fedErr *FederatedQueryError
+	assert.False(t, errors.As(err, &fedErr))
+	assert.EqualError(t, err, "something_broken")
+	assert.Nil(t, nm.lastStartInput.QueryExecutionContext.Catalog)
+}
+
+func TestNewFederatedQueryError(t *testing.T) {
+	assert.Nil(t, newFederatedQueryError(nil))
+	assert.Nil(t, newFederatedQueryError(&athenatypes.QueryExecution{}))
+	assert.Nil(t, newFederatedQueryError(&athenatypes.QueryExecution{Status: &athenatypes.QueryExecutionStatus{
+		StateChangeReason: aws.String("SYNTAX_ERROR: line 1:8: Column 'x' cannot be resolved"),
+	}}))
+
+	// the message of AthenaError is used when the reason is missing
+	e := newFederatedQueryError(&athenatypes.QueryExecution{Status: &athenatypes.QueryExecutionStatus{
+		AthenaError: &athenatypes.AthenaError{ErrorMessage: aws.String("from your LambdaFunction[arn:fn] " +
+			"executed in context[reading] with message[timeout [30s]]")},
+	}})
+	assert.Equal(t, "arn:fn", e.LambdaFunction)
+	assert.Equal(t, "timeout [30s]", e.Message)
+	assert.Equal(t, "", e.Catalog)
+	assert.Equal(t, e.Reason, e.Error())
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"regexp"
+	"strings"
+)
+
+// valueListRegex matches a parenthesized list of placeholders, e.g. the IN list of a slice argument.
+var valueListRegex = regexp.MustCompile(`\( ?\?(?: ?, ?\?)* ?\)`)
+
+// NormalizeQuery is to get a query with its comments removed, each run of whitespace outside string literals and
+// quoted identifiers replaced by a single space, and the trailing semicolons removed. Queries which only differ in
+// their formatting are normalized the same, which is how the query cache and query coalescing match them.
+func NormalizeQuery(query string) string {
+	return normalizeQuery(query, false)
+}
+
+// Fingerprint is to get the shape of a query, for grouping metrics and logs of the queries which only differ in
+// their values: the query is normalized like NormalizeQuery does, with keywords and unquoted identifiers in upper
+// case, string and number literals replaced by `?`, and a parenthesized list of them by `(?)`, e.g.
+// `SELECT * FROM T WHERE ID IN (?) AND NAME = ?` for `select * from t where id in (1, 2, 3) and name = 'x'`.
+func Fingerprint(query string) string {
+	return valueListRegex.ReplaceAllString(normalizeQuery(query, true), "(?)")
+}
+
+// normalizeQuery is to normalize a query, and with shape, to replace its literals by `?`, see Fingerprint.
+func normalizeQuery(query string, shape bool) string {
+	var b strings.Builder
+	t := sqlTokenizer{query: query}
+	last := 0
+	literal := false
+	for {
+		start, end := t.scan()
+		if start == end {
+			break
+		}
+		adjacent := start == last
+		if b.Len() > 0 && !adjacent {
+			b.WriteByte(' ')
+		}
+		tok := query[start:end]
+		last = end
+		if !shape {
+			b.WriteString(tok)
+			continue
+		}
+		switch c := tok[0]; {
+		case c == '\'':
+			// A doubled quote in a string literal is read as two adjacent string literals.
+			if !(literal && adjacent) {
+				b.WriteByte('?')
+			}
+			literal = true
+			continue
+		case c >= '0' && c <= '9':
+			// The fraction of a decimal number is read as a dot and a word.
+			for t.pos+1 < len(query) && query[t.pos] == '.' && isWordChar(query[t.pos+1]) {
+				for t.pos++; t.pos < len(query) && isWordChar(query[t.pos]); t.pos++ {
+				}
+			}
+			last = t.pos
+			b.WriteByte('?')
+		case c == '"' || c == '`':
+			b.WriteString(tok)
+		default:
+			b.WriteString(strings.ToUpper(tok))
+		}
+		literal = false
+	}
+	return strings.TrimRight(b.String(), "; ")
+}
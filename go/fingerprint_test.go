@@ -0,0 +1,51 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeQuery(t *testing.T) {
+	assert.Equal(t, "SELECT * FROM t WHERE a = 'x  y' AND b=\"c  d\"",
+		NormalizeQuery(" SELECT *\n  FROM t -- comment\nWHERE a = 'x  y'\tAND b=\"c  d\" ;"))
+	assert.Equal(t, "SELECT a b", NormalizeQuery("SELECT a/* comment */b;;"))
+	assert.Equal(t, "", NormalizeQuery(" -- nothing"))
+}
+
+func TestFingerprint(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected string
+	}{
+		{"select * from t where id in (1, 2, 3) and name = 'x'", "SELECT * FROM T WHERE ID IN (?) AND NAME = ?"},
+		{"SELECT a FROM t WHERE b = 'it''s' AND c > 1.5 AND d = -2", "SELECT A FROM T WHERE B = ? AND C > ? AND D = -?"},
+		{"SELECT \"Col\" FROM t WHERE id IN (?,?) LIMIT 10;", "SELECT \"Col\" FROM T WHERE ID IN (?) LIMIT ?"},
+		{"SELECT col1, DATE '2024-07-01' FROM t1", "SELECT COL1, DATE ? FROM T1"},
+		{"SELECT count(*), f(1) FROM t", "SELECT COUNT(*), F(?) FROM T"},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, Fingerprint(tc.query), tc.query)
+	}
+	assert.Equal(t, Fingerprint("SELECT * FROM t WHERE id = 1"), Fingerprint("select *\nfrom t\nwhere id = 42"))
+}
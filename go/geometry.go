@@ -0,0 +1,452 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	// GeometryAsBytes returns geospatial values as they are: varbinary as []byte and geometry as WKT string.
+	// It is the default.
+	GeometryAsBytes = "bytes"
+
+	// GeometryAsWKT returns geospatial values as WKT strings, e.g. `POINT (-74.006801 40.70522)`.
+	GeometryAsWKT = "wkt"
+
+	// GeometryAsGeometry returns geospatial values as Geometry.
+	GeometryAsGeometry = "geometry"
+)
+
+// ErrNotWKB is returned when a varbinary value is not a WKB encoded geometry.
+var ErrNotWKB = errors.New("not a WKB geometry")
+
+// Geometry is a geospatial value. Only 2D geometries are supported.
+type Geometry interface {
+	// WKT returns the geometry in Well-Known Text format.
+	WKT() string
+}
+
+// Point is a geometry of a single location.
+type Point struct {
+	X, Y float64
+}
+
+// LineString is a geometry of connected points.
+type LineString []Point
+
+// Polygon is a geometry of rings, the first one is the exterior ring.
+type Polygon []LineString
+
+// MultiPoint is a geometry of points.
+type MultiPoint []Point
+
+// MultiLineString is a geometry of line strings.
+type MultiLineString []LineString
+
+// MultiPolygon is a geometry of polygons.
+type MultiPolygon []Polygon
+
+// GeometryCollection is a geometry of geometries.
+type GeometryCollection []Geometry
+
+// WKT returns the point in Well-Known Text format.
+func (p Point) WKT() string { return "POINT (" + p.coordinates() + ")" }
+
+// WKT returns the line string in Well-Known Text format.
+func (l LineString) WKT() string { return "LINESTRING " + l.text() }
+
+// WKT returns the polygon in Well-Known Text format.
+func (p Polygon) WKT() string { return "POLYGON " + p.text() }
+
+// WKT returns the points in Well-Known Text format.
+func (m MultiPoint) WKT() string {
+	if len(m) == 0 {
+		return "MULTIPOINT EMPTY"
+	}
+	parts := make([]string, len(m))
+	for i, p := range m {
+		parts[i] = "(" + p.coordinates() + ")"
+	}
+	return "MULTIPOINT (" + strings.Join(parts, ", ") + ")"
+}
+
+// WKT returns the line strings in Well-Known Text format.
+func (m MultiLineString) WKT() string {
+	parts := make([]string, len(m))
+	for i, l := range m {
+		parts[i] = l.text()
+	}
+	return "MULTILINESTRING " + joinWKT(parts)
+}
+
+// WKT returns the polygons in Well-Known Text format.
+func (m MultiPolygon) WKT() string {
+	parts := make([]string, len(m))
+	for i, p := range m {
+		parts[i] = p.text()
+	}
+	return "MULTIPOLYGON " + joinWKT(parts)
+}
+
+// WKT returns the geometries in Well-Known Text format.
+func (c GeometryCollection) WKT() string {
+	parts := make([]string, len(c))
+	for i, g := range c {
+		parts[i] = g.WKT()
+	}
+	return "GEOMETRYCOLLECTION " + joinWKT(parts)
+}
+
+func (p Point) coordinates() string {
+	return strconv.FormatFloat(p.X, 'f', -1, 64) + " " + strconv.FormatFloat(p.Y, 'f', -1, 64)
+}
+
+func (l LineString) text() string {
+	parts := make([]string, len(l))
+	for i, p := range l {
+		parts[i] = p.coordinates()
+	}
+	return joinWKT(parts)
+}
+
+func (p Polygon) text() string {
+	parts := make([]string, len(p))
+	for i, l := range p {
+		parts[i] = l.text()
+	}
+	return joinWKT(parts)
+}
+
+func joinWKT(parts []string) string {
+	if len(parts) == 0 {
+		return "EMPTY"
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+// wkbReader decodes Well-Known Binary, as returned by ST_AsBinary.
+type wkbReader struct {
+	b []byte
+}
+
+// parseWKB decodes a Well-Known Binary geometry.
+func parseWKB(b []byte) (Geometry, error) {
+	r := &wkbReader{b: b}
+	g, err := r.geometry()
+	if err != nil {
+		return nil, err
+	}
+	if len(r.b) != 0 {
+		return nil, fmt.Errorf("%w: %d trailing bytes", ErrNotWKB, len(r.b))
+	}
+	return g, nil
+}
+
+func (r *wkbReader) geometry() (Geometry, error) {
+	if len(r.b) < 5 || r.b[0] > 1 {
+		return nil, ErrNotWKB
+	}
+	var order binary.ByteOrder = binary.BigEndian
+	if r.b[0] == 1 {
+		order = binary.LittleEndian
+	}
+	geometryType := order.Uint32(r.b[1:5])
+	r.b = r.b[5:]
+	switch geometryType {
+	case 1:
+		return r.point(order)
+	case 2:
+		return r.lineString(order)
+	case 3:
+		return r.polygon(order)
+	case 4, 5, 6, 7:
+		n, err := r.count(order)
+		if err != nil {
+			return nil, err
+		}
+		geometries := make([]Geometry, n)
+		for i := range geometries {
+			if geometries[i], err = r.geometry(); err != nil {
+				return nil, err
+			}
+		}
+		return collect(geometryType, geometries)
+	}
+	return nil, fmt.Errorf("%w: unsupported geometry type %d", ErrNotWKB, geometryType)
+}
+
+// collect converts the members of a multi geometry to its type.
+func collect(geometryType uint32, geometries []Geometry) (Geometry, error) {
+	var ok bool
+	switch geometryType {
+	case 4:
+		m := make(MultiPoint, len(geometries))
+		for i, g := range geometries {
+			if m[i], ok = g.(Point); !ok {
+				return nil, fmt.Errorf("%w: %T in multipoint", ErrNotWKB, g)
+			}
+		}
+		return m, nil
+	case 5:
+		m := make(MultiLineString, len(geometries))
+		for i, g := range geometries {
+			if m[i], ok = g.(LineString); !ok {
+				return nil, fmt.Errorf("%w: %T in multilinestring", ErrNotWKB, g)
+			}
+		}
+		return m, nil
+	case 6:
+		m := make(MultiPolygon, len(geometries))
+		for i, g := range geometries {
+			if m[i], ok = g.(Polygon); !ok {
+				return nil, fmt.Errorf("%w: %T in multipolygon", ErrNotWKB, g)
+			}
+		}
+		return m, nil
+	}
+	return GeometryCollection(geometries), nil
+}
+
+func (r *wkbReader) count(order binary.ByteOrder) (int, error) {
+	if len(r.b) < 4 {
+		return 0, ErrNotWKB
+	}
+	n := order.Uint32(r.b)
+	r.b = r.b[4:]
+	// every member takes at least 4 bytes, so a larger count can only be garbage
+	if int(n) > len(r.b)/4 {
+		return 0, ErrNotWKB
+	}
+	return int(n), nil
+}
+
+func (r *wkbReader) point(order binary.ByteOrder) (Point, error) {
+	if len(r.b) < 16 {
+		return Point{}, ErrNotWKB
+	}
+	p := Point{
+		X: math.Float64frombits(order.Uint64(r.b)),
+		Y: math.Float64frombits(order.Uint64(r.b[8:])),
+	}
+	r.b = r.b[16:]
+	return p, nil
+}
+
+func (r *wkbReader) lineString(order binary.ByteOrder) (LineString, error) {
+	n, err := r.count(order)
+	if err != nil {
+		return nil, err
+	}
+	l := make(LineString, n)
+	for i := range l {
+		if l[i], err = r.point(order); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+func (r *wkbReader) polygon(order binary.ByteOrder) (Polygon, error) {
+	n, err := r.count(order)
+	if err != nil {
+		return nil, err
+	}
+	p := make(Polygon, n)
+	for i := range p {
+		if p[i], err = r.lineString(order); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// wktParser decodes Well-Known Text, as Athena returns geometry columns.
+type wktParser struct {
+	s string
+}
+
+// parseWKT decodes a Well-Known Text geometry.
+func parseWKT(s string) (Geometry, error) {
+	p := &wktParser{s: s}
+	g, err := p.geometry()
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(p.s) != "" {
+		return nil, fmt.Errorf("invalid WKT `%s`", s)
+	}
+	return g, nil
+}
+
+func (p *wktParser) geometry() (Geometry, error) {
+	p.s = strings.TrimSpace(p.s)
+	i := strings.IndexAny(p.s, " (")
+	if i == -1 {
+		i = len(p.s)
+	}
+	keyword := strings.ToUpper(p.s[:i])
+	p.s = p.s[i:]
+	switch keyword {
+	case "POINT":
+		if p.empty() {
+			return nil, errors.New("empty point is not supported")
+		}
+		var pt Point
+		err := p.list(func() (err error) { pt, err = p.point(); return })
+		return pt, err
+	case "LINESTRING":
+		return p.lineString()
+	case "POLYGON":
+		return p.polygon()
+	case "MULTIPOINT":
+		var m MultiPoint
+		err := p.list(func() error {
+			// both MULTIPOINT ((1 2), (3 4)) and MULTIPOINT (1 2, 3 4) are valid
+			var pt Point
+			var err error
+			if p.peek() == '(' {
+				err = p.list(func() (err error) { pt, err = p.point(); return })
+			} else {
+				pt, err = p.point()
+			}
+			m = append(m, pt)
+			return err
+		})
+		return m, err
+	case "MULTILINESTRING":
+		var m MultiLineString
+		err := p.list(func() error {
+			l, err := p.lineString()
+			m = append(m, l)
+			return err
+		})
+		return m, err
+	case "MULTIPOLYGON":
+		var m MultiPolygon
+		err := p.list(func() error {
+			pg, err := p.polygon()
+			m = append(m, pg)
+			return err
+		})
+		return m, err
+	case "GEOMETRYCOLLECTION":
+		var c GeometryCollection
+		err := p.list(func() error {
+			g, err := p.geometry()
+			c = append(c, g)
+			return err
+		})
+		return c, err
+	}
+	return nil, fmt.Errorf("unsupported WKT geometry `%s`", keyword)
+}
+
+func (p *wktParser) peek() byte {
+	p.s = strings.TrimSpace(p.s)
+	if p.s == "" {
+		return 0
+	}
+	return p.s[0]
+}
+
+// empty consumes the EMPTY keyword if it is next.
+func (p *wktParser) empty() bool {
+	p.s = strings.TrimSpace(p.s)
+	if len(p.s) >= 5 && strings.EqualFold(p.s[:5], "EMPTY") {
+		p.s = p.s[5:]
+		return true
+	}
+	return false
+}
+
+// list parses `EMPTY` or a parenthesized, comma separated list of items.
+func (p *wktParser) list(item func() error) error {
+	if p.empty() {
+		return nil
+	}
+	if p.peek() != '(' {
+		return errors.New("invalid WKT, expecting (")
+	}
+	p.s = p.s[1:]
+	for {
+		if err := item(); err != nil {
+			return err
+		}
+		switch p.peek() {
+		case ',':
+			p.s = p.s[1:]
+		case ')':
+			p.s = p.s[1:]
+			return nil
+		default:
+			return errors.New("invalid WKT, expecting , or )")
+		}
+	}
+}
+
+func (p *wktParser) point() (Point, error) {
+	fields := strings.Fields(strings.TrimSpace(p.s))
+	if len(fields) < 2 {
+		return Point{}, errors.New("invalid WKT point")
+	}
+	x, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return Point{}, err
+	}
+	yEnd := strings.IndexAny(fields[1], ",)")
+	if yEnd == -1 {
+		yEnd = len(fields[1])
+	}
+	y, err := strconv.ParseFloat(fields[1][:yEnd], 64)
+	if err != nil {
+		return Point{}, err
+	}
+	p.s = strings.TrimSpace(p.s)
+	p.s = strings.TrimSpace(p.s[len(fields[0]):])
+	p.s = p.s[yEnd:]
+	return Point{X: x, Y: y}, nil
+}
+
+func (p *wktParser) lineString() (LineString, error) {
+	l := LineString{}
+	err := p.list(func() error {
+		pt, err := p.point()
+		l = append(l, pt)
+		return err
+	})
+	return l, err
+}
+
+func (p *wktParser) polygon() (Polygon, error) {
+	pg := Polygon{}
+	err := p.list(func() error {
+		l, err := p.lineString()
+		pg = append(pg, l)
+		return err
+	})
+	return pg, err
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"testing"
+
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWKB(t *testing.T) {
+	tests := map[string]string{
+		// little endian POINT (1 2)
+		"0101000000000000000000f03f0000000000000040": "POINT (1 2)",
+		// big endian POINT (-74.5 40.25)
+		"0000000001c052a000000000004044200000000000":                                         "POINT (-74.5 40.25)",
+		"01020000000200000000000000000000000000000000000000000000000000f03f000000000000f03f": "LINESTRING (0 0, 1 1)",
+		"01030000000100000004000000000000000000000000000000000000000000000000000000000000000000f03f00000000" +
+			"0000f03f000000000000f03f00000000000000000000000000000000": "POLYGON ((0 0, 0 1, 1 1, 0 0))",
+		"0104000000020000000101000000000000000000f03f0000000000000040010100000000000000000008400000000000001040": "MULTIPOINT ((1 2), (3 4))",
+		"010700000001000000" + "0101000000000000000000f03f0000000000000040":                                      "GEOMETRYCOLLECTION (POINT (1 2))",
+		"010500000000000000": "MULTILINESTRING EMPTY",
+	}
+	for wkb, wkt := range tests {
+		b, err := hex.DecodeString(wkb)
+		assert.Nil(t, err)
+		g, err := parseWKB(b)
+		assert.Nil(t, err, wkt)
+		if err == nil {
+			assert.Equal(t, wkt, g.WKT())
+		}
+	}
+
+	for _, wkb := range []string{"", "68656c6c6f", "0101000000000000000000f03f", "0109000000",
+		"0101000000000000000000f03f000000000000004000", "0104000000ffffffff",
+		"010400000001000000010200000000000000"} {
+		b, _ := hex.DecodeString(wkb)
+		_, err := parseWKB(b)
+		assert.True(t, errors.Is(err, ErrNotWKB), wkb)
+	}
+}
+
+func TestParseWKT(t *testing.T) {
+	for _, wkt := range []string{
+		"POINT (-74.006801 40.70522)",
+		"LINESTRING (0 0, 1 1, 2.5 -3)",
+		"POLYGON ((0 0, 0 1, 1 1, 0 0), (0.1 0.1, 0.2 0.2, 0.1 0.1))",
+		"MULTIPOINT ((1 2), (3 4))",
+		"MULTILINESTRING ((0 0, 1 1), (2 2, 3 3))",
+		"MULTIPOLYGON (((0 0, 0 1, 1 1, 0 0)), ((5 5, 5 6, 6 6, 5 5)))",
+		"GEOMETRYCOLLECTION (POINT (1 2), LINESTRING (0 0, 1 1))",
+		"LINESTRING EMPTY",
+	} {
+		g, err := parseWKT(wkt)
+		assert.Nil(t, err, wkt)
+		if err == nil {
+			assert.Equal(t, wkt, g.WKT())
+		}
+	}
+
+	g, err := parseWKT("multipoint(1 2,3 4)")
+	assert.Nil(t, err)
+	assert.Equal(t, MultiPoint{{1, 2}, {3, 4}}, g)
+
+	for _, wkt := range []string{"", "POINT", "POINT EMPTY", "POINT (1)", "POINT (1 2 3)", "POINT (1 2", "POINT (1 2) x",
+		"CIRCLE (1 2)", "LINESTRING (a b)"} {
+		_, err := parseWKT(wkt)
+		assert.NotNil(t, err, wkt)
+	}
+}
+
+func TestRows_Geometry(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	r, _ := NewRows(context.Background(), newMockAthenaClient(),
+		"SELECT_OK", testConf, NewDefaultObservability(testConf))
+	c := newColumnInfo("a", "varbinary")
+	point := "01 01 00 00 00 00 00 00 00 00 00 f0 3f 00 00 00 00 00 00 00 40"
+	notGeometry := "68 65"
+
+	g, e := r.athenaTypeToGoType(c, &point, testConf)
+	assert.Nil(t, e)
+	assert.IsType(t, []byte{}, g)
+
+	testConf.SetGeometryMode(GeometryAsWKT)
+	g, e = r.athenaTypeToGoType(c, &point, testConf)
+	assert.Nil(t, e)
+	assert.Equal(t, "POINT (1 2)", g)
+	g, e = r.athenaTypeToGoType(c, &notGeometry, testConf)
+	assert.Nil(t, e)
+	assert.Equal(t, []byte("he"), g)
+
+	testConf.SetGeometryMode(GeometryAsGeometry)
+	g, e = r.athenaTypeToGoType(c, &point, testConf)
+	assert.Nil(t, e)
+	assert.Equal(t, Point{X: 1, Y: 2}, g)
+
+	c = newColumnInfo("a", "geometry")
+	wkt := "POINT (1 2)"
+	g, e = r.athenaTypeToGoType(c, &wkt, testConf)
+	assert.Nil(t, e)
+	assert.Equal(t, Point{X: 1, Y: 2}, g)
+	r.ResultOutput.ResultSet.ResultSetMetadata.ColumnInfo = []athenatypes.ColumnInfo{c}
+	assert.Equal(t, reflect.TypeOf((*Geometry)(nil)).Elem(), r.ColumnTypeScanType(0))
+
+	wkt = "POINT (1)"
+	_, e = r.athenaTypeToGoType(c, &wkt, testConf)
+	assert.NotNil(t, e)
+
+	testConf.SetGeometryMode(GeometryAsWKT)
+	wkt = "POINT (1 2)"
+	g, e = r.athenaTypeToGoType(c, &wkt, testConf)
+	assert.Nil(t, e)
+	assert.Equal(t, wkt, g)
+	assert.Equal(t, reflect.TypeOf(""), r.ColumnTypeScanType(0))
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OptimizeStatement is to build the OPTIMIZE statement which compacts the data files of an Iceberg table, e.g.
+// sampledb.orders, optionally only of the rows matching where.
+func OptimizeStatement(table string, where string) string {
+	stmt := "OPTIMIZE " + table + " REWRITE DATA USING BIN_PACK"
+	if where != "" {
+		stmt += " WHERE " + where
+	}
+	return stmt
+}
+
+// VacuumStatement is to build the VACUUM statement which expires the old snapshots of an Iceberg table and removes
+// the files which are not referenced anymore, according to the vacuum_* table properties.
+func VacuumStatement(table string) string {
+	return "VACUUM " + table
+}
+
+// ForTimestampAsOf is to reference an Iceberg table as it was at a time, for time travel queries like
+// "SELECT * FROM " + ForTimestampAsOf("sampledb.orders", t).
+func ForTimestampAsOf(table string, t time.Time) string {
+	return table + " FOR TIMESTAMP AS OF TIMESTAMP '" + t.UTC().Format("2006-01-02 15:04:05.000") + " UTC'"
+}
+
+// ForVersionAsOf is to reference an Iceberg table as it was at a snapshot, whose ID is in the $snapshots metadata
+// table, for time travel queries.
+func ForVersionAsOf(table string, snapshotID int64) string {
+	return table + " FOR VERSION AS OF " + strconv.FormatInt(snapshotID, 10)
+}
+
+// baseTypeName is to get the name of a column type without its precision, e.g. timestamp for the timestamp(6)
+// columns of Iceberg tables, and timestamp with time zone for timestamp(6) with time zone.
+func baseTypeName(athenaType string) string {
+	if !strings.HasPrefix(athenaType, "time") {
+		return athenaType
+	}
+	open := strings.IndexByte(athenaType, '(')
+	if open == -1 {
+		return athenaType
+	}
+	end := strings.IndexByte(athenaType[open:], ')')
+	if end == -1 {
+		return athenaType
+	}
+	return athenaType[:open] + athenaType[open+end+1:]
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIcebergStatements(t *testing.T) {
+	assert.Equal(t, "OPTIMIZE sampledb.orders REWRITE DATA USING BIN_PACK", OptimizeStatement("sampledb.orders", ""))
+	assert.Equal(t, "OPTIMIZE sampledb.orders REWRITE DATA USING BIN_PACK WHERE dt >= DATE '2024-07-01'",
+		OptimizeStatement("sampledb.orders", "dt >= DATE '2024-07-01'"))
+	assert.Equal(t, "VACUUM sampledb.orders", VacuumStatement("sampledb.orders"))
+
+	at := time.Date(2024, 7, 1, 10, 0, 0, 0, time.FixedZone("", 2*3600))
+	assert.Equal(t, "sampledb.orders FOR TIMESTAMP AS OF TIMESTAMP '2024-07-01 08:00:00.000 UTC'",
+		ForTimestampAsOf("sampledb.orders", at))
+	assert.Equal(t, "sampledb.orders FOR VERSION AS OF 949530903748831860",
+		ForVersionAsOf("sampledb.orders", 949530903748831860))
+}
+
+func TestIcebergStatementsReadOnly(t *testing.T) {
+	for _, query := range []string{
+		"UPDATE orders SET status = 'shipped' WHERE id = 1",
+		"DELETE FROM orders WHERE id = 1",
+		"MERGE INTO orders o USING updates u ON o.id = u.id WHEN MATCHED THEN UPDATE SET status = u.status",
+		OptimizeStatement("orders", ""),
+		VacuumStatement("orders"),
+	} {
+		assert.False(t, isReadOnlyStatement(query, nil), query)
+	}
+	assert.True(t, isReadOnlyStatement("SELECT * FROM "+ForVersionAsOf("orders", 1), nil))
+	assert.True(t, isReadOnlyStatement(`SELECT * FROM "orders$snapshots"`, nil))
+}
+
+func TestBaseTypeName(t *testing.T) {
+	assert.Equal(t, "timestamp", baseTypeName("timestamp(6)"))
+	assert.Equal(t, "timestamp with time zone", baseTypeName("timestamp(6) with time zone"))
+	assert.Equal(t, "time", baseTypeName("time(3)"))
+	assert.Equal(t, "timestamp", baseTypeName("timestamp"))
+	assert.Equal(t, "decimal(10,2)", baseTypeName("decimal(10,2)"))
+
+	r := &Rows{config: NewNoOpsConfig(), tracer: NewDefaultObservability(NewNoOpsConfig())}
+	col := newColumnInfo("ts", "timestamp(6)")
+	r.ResultOutput = &athena.GetQueryResultsOutput{ResultSet: &athenatypes.ResultSet{
+		ResultSetMetadata: &athenatypes.ResultSetMetadata{ColumnInfo: []athenatypes.ColumnInfo{col}},
+	}}
+	v, err := r.athenaTypeToGoType(col, aws.String("2024-07-01 08:00:00.123456"), r.config)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2024, 7, 1, 8, 0, 0, 123456000, time.Local), v)
+	assert.Equal(t, reflect.TypeOf(time.Time{}), r.ColumnTypeScanType(0))
+}
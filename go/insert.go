@@ -0,0 +1,283 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Execer is the part of *sql.DB, *sql.Conn and *sql.Tx BulkInsert needs.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// InsertProgress is reported by BulkInsert after every statement it has executed.
+type InsertProgress struct {
+	Statements int
+	Inserted   int
+	Total      int
+}
+
+// InsertOptions are the options of BulkInsert.
+type InsertOptions struct {
+	// Columns are the column names of the rows, when they are not structs. For structs, they default to the
+	// `athena` tag or snake_case name of every exported field.
+	Columns []string
+	// MaxQueryLength is the size in bytes statements are kept under. It defaults to MAXQueryStringLength.
+	MaxQueryLength int
+	// Progress, if set, is called after every statement.
+	Progress func(InsertProgress)
+}
+
+// BulkInsert inserts rows into table with as few `INSERT INTO ... VALUES` statements as the Athena query length
+// limit allows, and returns the number of rows inserted, which is less than len(rows) on error.
+// Rows are structs, pointers to structs, or slices of values in the order of opts.Columns. Values are rendered
+// as literals: strings as varchar, []byte as varbinary, time.Time as timestamp in UTC, time.Duration and
+// YearMonthInterval as intervals, json.Number as a number, nil as NULL, and RawParam verbatim. driver.Valuer is
+// called first.
+// The table name is used verbatim, so it can be qualified with its database.
+func BulkInsert[T any](ctx context.Context, db Execer, table string, rows []T, opts *InsertOptions) (int, error) {
+	if opts == nil {
+		opts = &InsertOptions{}
+	}
+	maxLength := opts.MaxQueryLength
+	if maxLength <= 0 || maxLength > MAXQueryStringLength {
+		maxLength = MAXQueryStringLength
+	}
+
+	columns := opts.Columns
+	var fieldIndexes [][]int
+	var zero T
+	structType := reflect.TypeOf(&zero).Elem()
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() == reflect.Struct && !isScannerType(structType) {
+		fieldColumns, indexes := insertFields(structType)
+		if len(columns) == 0 {
+			columns, fieldIndexes = fieldColumns, indexes
+		} else {
+			byName := make(map[string][]int, len(fieldColumns))
+			for i, name := range fieldColumns {
+				byName[strings.ToLower(name)] = indexes[i]
+			}
+			fields := collectFields(structType)
+			for _, column := range columns {
+				index, ok := byName[strings.ToLower(column)]
+				if !ok {
+					index, ok = fields[strings.ToLower(column)]
+				}
+				if !ok {
+					return 0, fmt.Errorf("no field of %s for column %s", structType, column)
+				}
+				fieldIndexes = append(fieldIndexes, index)
+			}
+		}
+		if len(columns) == 0 {
+			return 0, fmt.Errorf("no exported field in %s", structType)
+		}
+	}
+
+	header := []byte("INSERT INTO " + table + " ")
+	if len(columns) > 0 {
+		header = append(header, '(')
+		for i, column := range columns {
+			if i > 0 {
+				header = append(header, ", "...)
+			}
+			header = append(header, QuoteIdentifier(column)...)
+		}
+		header = append(header, ") "...)
+	}
+	header = append(header, "VALUES "...)
+
+	progress := InsertProgress{Total: len(rows)}
+	query := append([]byte{}, header...)
+	pending := 0
+	flush := func() error {
+		if _, err := db.ExecContext(ctx, string(query)); err != nil {
+			return err
+		}
+		progress.Statements++
+		progress.Inserted += pending
+		if opts.Progress != nil {
+			opts.Progress(progress)
+		}
+		query, pending = append(query[:0], header...), 0
+		return nil
+	}
+
+	var tuple []byte
+	for i := range rows {
+		values, err := insertValues(reflect.ValueOf(&rows[i]).Elem(), fieldIndexes)
+		if err != nil {
+			return progress.Inserted, fmt.Errorf("row %d: %w", i, err)
+		}
+		if len(columns) > 0 && len(values) != len(columns) {
+			return progress.Inserted, fmt.Errorf("row %d: got %d values for %d columns", i, len(values),
+				len(columns))
+		}
+		tuple = append(tuple[:0], '(')
+		for j, v := range values {
+			if j > 0 {
+				tuple = append(tuple, ", "...)
+			}
+			if tuple, err = appendInsertLiteral(tuple, v); err != nil {
+				return progress.Inserted, fmt.Errorf("row %d, column %d: %w", i, j+1, err)
+			}
+		}
+		tuple = append(tuple, ')')
+
+		if len(header)+len(tuple) > maxLength {
+			return progress.Inserted, fmt.Errorf("%w, got %d bytes for row %d alone", ErrQueryTooLong,
+				len(header)+len(tuple), i)
+		}
+		if pending > 0 && len(query)+len(", ")+len(tuple) > maxLength {
+			if err := flush(); err != nil {
+				return progress.Inserted, err
+			}
+		}
+		if pending > 0 {
+			query = append(query, ", "...)
+		}
+		query = append(query, tuple...)
+		pending++
+	}
+	if pending > 0 {
+		if err := flush(); err != nil {
+			return progress.Inserted, err
+		}
+	}
+	return progress.Inserted, nil
+}
+
+// insertFields returns the column names and field indexes of a struct type in field order, embedded
+// structs included.
+func insertFields(t reflect.Type) ([]string, [][]int) {
+	var names []string
+	var indexes [][]int
+	seen := map[string]bool{}
+	var walk func(t reflect.Type, prefix []int)
+	walk = func(t reflect.Type, prefix []int) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get(CollectTagName)
+			if tag == "-" {
+				continue
+			}
+			index := append(append([]int{}, prefix...), f.Index...)
+			if f.Anonymous && tag == "" && f.Type.Kind() == reflect.Struct && !isScannerType(f.Type) {
+				walk(f.Type, index)
+				continue
+			}
+			if !f.IsExported() {
+				continue
+			}
+			name := tag
+			if name == "" {
+				name = toSnakeCase(f.Name)
+			}
+			if seen[strings.ToLower(name)] {
+				continue
+			}
+			seen[strings.ToLower(name)] = true
+			names = append(names, name)
+			indexes = append(indexes, index)
+		}
+	}
+	walk(t, nil)
+	return names, indexes
+}
+
+// insertValues returns the values of a row, which is a struct when fieldIndexes is set, or a slice otherwise.
+func insertValues(row reflect.Value, fieldIndexes [][]int) ([]interface{}, error) {
+	if fieldIndexes != nil {
+		if row.Kind() == reflect.Ptr {
+			if row.IsNil() {
+				return nil, fmt.Errorf("nil %s", row.Type())
+			}
+			row = row.Elem()
+		}
+		values := make([]interface{}, len(fieldIndexes))
+		for i, index := range fieldIndexes {
+			values[i] = row.FieldByIndex(index).Interface()
+		}
+		return values, nil
+	}
+	if row.Kind() == reflect.Interface {
+		row = row.Elem()
+	}
+	if row.Kind() != reflect.Slice && row.Kind() != reflect.Array {
+		return nil, fmt.Errorf("cannot insert %s as a row", row.Type())
+	}
+	values := make([]interface{}, row.Len())
+	for i := range values {
+		values[i] = row.Index(i).Interface()
+	}
+	return values, nil
+}
+
+// appendInsertLiteral appends v as an Athena literal.
+func appendInsertLiteral(buf []byte, v interface{}) ([]byte, error) {
+	v, err := convertParam(v)
+	if err != nil {
+		return buf, err
+	}
+	switch v := v.(type) {
+	case nil:
+		return append(buf, "NULL"...), nil
+	case int64:
+		return strconv.AppendInt(buf, v, 10), nil
+	case uint64:
+		return strconv.AppendUint(buf, v, 10), nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return buf, fmt.Errorf("cannot insert %v", v)
+		}
+		return strconv.AppendFloat(buf, v, 'g', -1, 64), nil
+	case bool:
+		return strconv.AppendBool(buf, v), nil
+	case string:
+		buf = append(buf, '\'')
+		buf = escapeStringQuotes(buf, v)
+		return append(buf, '\''), nil
+	case []byte:
+		if v == nil {
+			return append(buf, "NULL"...), nil
+		}
+		return appendHexBinary(buf, v), nil
+	case time.Time:
+		return append(buf, v.UTC().Format("TIMESTAMP '2006-01-02 15:04:05.000'")...), nil
+	case time.Duration, YearMonthInterval:
+		literal, _ := formatIntervalLiteral(v)
+		return append(buf, literal...), nil
+	case RawParam:
+		return append(buf, v...), nil
+	}
+	return buf, ErrQueryUnknownType
+}
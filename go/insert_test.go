@@ -0,0 +1,141 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingExecer struct {
+	queries []string
+	err     error
+}
+
+func (e *recordingExecer) ExecContext(_ context.Context, query string, _ ...interface{}) (sql.Result, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	e.queries = append(e.queries, query)
+	return driver.RowsAffected(0), nil
+}
+
+type insertBase struct {
+	ID int64 `athena:"id"`
+}
+
+type insertRow struct {
+	insertBase
+	UserName  string
+	CreatedAt time.Time
+	Score     *float64
+	Payload   []byte `athena:"payload"`
+	Ignored   string `athena:"-"`
+	internal  int
+}
+
+func TestBulkInsert_Structs(t *testing.T) {
+	e := &recordingExecer{}
+	score := 1.5
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 6000000, time.UTC)
+	rows := []insertRow{
+		{insertBase: insertBase{ID: 1}, UserName: "o'neil", CreatedAt: ts, Score: &score, Payload: []byte{0x0a}},
+		{insertBase: insertBase{ID: 2}, UserName: "bob", CreatedAt: ts},
+	}
+	n, err := BulkInsert(context.Background(), e, "db.users", rows, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{`INSERT INTO db.users ("id", "user_name", "created_at", "score", "payload") VALUES ` +
+		`(1, 'o''neil', TIMESTAMP '2020-01-02 03:04:05.006', 1.5, X'0a'), ` +
+		`(2, 'bob', TIMESTAMP '2020-01-02 03:04:05.006', NULL, NULL)`}, e.queries)
+
+	e = &recordingExecer{}
+	_, err = BulkInsert(context.Background(), e, "t", []*insertRow{{UserName: "a"}},
+		&InsertOptions{Columns: []string{"UserName"}})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{`INSERT INTO t ("UserName") VALUES ('a')`}, e.queries)
+
+	_, err = BulkInsert(context.Background(), e, "t", []insertRow{{}}, &InsertOptions{Columns: []string{"nope"}})
+	assert.NotNil(t, err)
+}
+
+func TestBulkInsert_Batches(t *testing.T) {
+	e := &recordingExecer{}
+	rows := [][]interface{}{{1, "a"}, {2, "b"}, {3, "c"}}
+	var reports []InsertProgress
+	header := `INSERT INTO t ("n", "s") VALUES `
+	n, err := BulkInsert(context.Background(), e, "t", rows, &InsertOptions{
+		Columns:        []string{"n", "s"},
+		MaxQueryLength: len(header) + len(`(1, 'a'), (2, 'b')`),
+		Progress:       func(p InsertProgress) { reports = append(reports, p) },
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, []string{header + `(1, 'a'), (2, 'b')`, header + `(3, 'c')`}, e.queries)
+	assert.Equal(t, []InsertProgress{{Statements: 1, Inserted: 2, Total: 3}, {Statements: 2, Inserted: 3, Total: 3}},
+		reports)
+
+	_, err = BulkInsert(context.Background(), e, "t", [][]interface{}{{"too long"}},
+		&InsertOptions{MaxQueryLength: 10})
+	assert.True(t, errors.Is(err, ErrQueryTooLong))
+
+	_, err = BulkInsert(context.Background(), e, "t", [][]interface{}{{1}}, &InsertOptions{Columns: []string{"a", "b"}})
+	assert.NotNil(t, err)
+
+	e.err = errors.New("boom")
+	n, err = BulkInsert(context.Background(), e, "t", rows, nil)
+	assert.Equal(t, e.err, err)
+	assert.Equal(t, 0, n)
+
+	n, err = BulkInsert(context.Background(), e, "t", []interface{}{}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestAppendInsertLiteral(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		want string
+	}{
+		{nil, "NULL"},
+		{true, "true"},
+		{uint8(7), "7"},
+		{-3.25, "-3.25"},
+		{"it's", "'it''s'"},
+		{sql.NullString{}, "NULL"},
+		{sql.NullInt64{Int64: 4, Valid: true}, "4"},
+		{time.Hour, "INTERVAL '0 01:00:00.000' DAY TO SECOND"},
+		{RawParam("DATE '2020-01-01'"), "DATE '2020-01-01'"},
+	}
+	for _, test := range tests {
+		got, err := appendInsertLiteral(nil, test.in)
+		assert.Nil(t, err)
+		assert.Equal(t, test.want, string(got))
+	}
+	_, err := appendInsertLiteral(nil, struct{}{})
+	assert.NotNil(t, err)
+}
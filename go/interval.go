@@ -0,0 +1,137 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// YearMonthInterval is the Go type of Athena `interval year to month` columns.
+// For a negative interval, both Years and Months are negative or zero.
+type YearMonthInterval struct {
+	Years  int32
+	Months int32
+}
+
+// String returns the interval in Athena's format, like `1-2` for 1 year and 2 months.
+func (i YearMonthInterval) String() string {
+	if i.Years < 0 || i.Months < 0 {
+		return fmt.Sprintf("-%d-%d", -i.Years, -i.Months)
+	}
+	return fmt.Sprintf("%d-%d", i.Years, i.Months)
+}
+
+// MarshalText renders the interval in Athena's format, e.g. in JSON.
+func (i YearMonthInterval) MarshalText() ([]byte, error) {
+	return []byte(i.String()), nil
+}
+
+// parseYearToMonthInterval parses an `interval year to month` value like `1-2` or `-1-2`.
+func parseYearToMonthInterval(val string) (YearMonthInterval, error) {
+	s, sign := strings.TrimPrefix(val, "-"), int32(1)
+	if len(s) != len(val) {
+		sign = -1
+	}
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return YearMonthInterval{}, fmt.Errorf("invalid interval year to month value `%s`", val)
+	}
+	years, err := strconv.ParseInt(parts[0], 10, 32)
+	if err != nil {
+		return YearMonthInterval{}, fmt.Errorf("invalid interval year to month value `%s`: %w", val, err)
+	}
+	months, err := strconv.ParseInt(parts[1], 10, 32)
+	if err != nil {
+		return YearMonthInterval{}, fmt.Errorf("invalid interval year to month value `%s`: %w", val, err)
+	}
+	return YearMonthInterval{Years: sign * int32(years), Months: sign * int32(months)}, nil
+}
+
+// parseDayToSecondInterval parses an `interval day to second` value like `2 03:04:05.006` or
+// `-2 03:04:05.006` to a time.Duration.
+func parseDayToSecondInterval(val string) (time.Duration, error) {
+	s, sign := strings.TrimPrefix(val, "-"), time.Duration(1)
+	if len(s) != len(val) {
+		sign = -1
+	}
+	dayPart, clockPart, ok := strings.Cut(s, " ")
+	if !ok {
+		return 0, fmt.Errorf("invalid interval day to second value `%s`", val)
+	}
+	clock := strings.Split(clockPart, ":")
+	if len(clock) != 3 {
+		return 0, fmt.Errorf("invalid interval day to second value `%s`", val)
+	}
+	seconds, fraction, _ := strings.Cut(clock[2], ".")
+	if len(fraction) > 9 {
+		return 0, fmt.Errorf("invalid interval day to second value `%s`", val)
+	}
+	fraction += strings.Repeat("0", 9-len(fraction))
+	var d time.Duration
+	for i, part := range []string{dayPart, clock[0], clock[1], seconds, fraction} {
+		n, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid interval day to second value `%s`", val)
+		}
+		d += time.Duration(n) * []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second, 1}[i]
+	}
+	return sign * d, nil
+}
+
+// formatDayToSecondInterval formats a time.Duration in Athena's `interval day to second` format,
+// which has millisecond precision.
+func formatDayToSecondInterval(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+	d -= minutes * time.Minute
+	seconds := d / time.Second
+	d -= seconds * time.Second
+	return fmt.Sprintf("%s%d %02d:%02d:%02d.%03d", sign, days, hours, minutes, seconds, d/time.Millisecond)
+}
+
+// formatIntervalLiteral renders a time.Duration or YearMonthInterval query argument as an INTERVAL literal.
+func formatIntervalLiteral(v interface{}) (string, bool) {
+	var value, fields string
+	switch iv := v.(type) {
+	case time.Duration:
+		value, fields = formatDayToSecondInterval(iv), "DAY TO SECOND"
+	case YearMonthInterval:
+		value, fields = iv.String(), "YEAR TO MONTH"
+	default:
+		return "", false
+	}
+	// the sign goes outside of the quoted string, e.g. INTERVAL -'1-2' YEAR TO MONTH
+	sign := ""
+	if strings.HasPrefix(value, "-") {
+		sign, value = "-", value[1:]
+	}
+	return fmt.Sprintf("INTERVAL %s'%s' %s", sign, value, fields), true
+}
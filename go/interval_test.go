@@ -0,0 +1,82 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestYearToMonthInterval(t *testing.T) {
+	for val, expected := range map[string]YearMonthInterval{
+		"0-3":   {Months: 3},
+		"1-2":   {Years: 1, Months: 2},
+		"-1-2":  {Years: -1, Months: -2},
+		"-0-11": {Months: -11},
+	} {
+		iv, err := parseYearToMonthInterval(val)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, iv)
+		assert.Equal(t, val, iv.String())
+	}
+
+	for _, val := range []string{"", "1", "1-2-3", "a-1", "1-a"} {
+		_, err := parseYearToMonthInterval(val)
+		assert.NotNil(t, err, val)
+	}
+}
+
+func TestDayToSecondInterval(t *testing.T) {
+	for val, expected := range map[string]time.Duration{
+		"2 00:00:00.000":  48 * time.Hour,
+		"0 01:02:03.456":  time.Hour + 2*time.Minute + 3*time.Second + 456*time.Millisecond,
+		"-1 00:00:01.000": -(24*time.Hour + time.Second),
+	} {
+		d, err := parseDayToSecondInterval(val)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, d)
+		assert.Equal(t, val, formatDayToSecondInterval(d))
+	}
+
+	d, err := parseDayToSecondInterval("0 00:00:01")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Second, d)
+
+	for _, val := range []string{"", "1", "1 00:00", "a 00:00:00.000", "1 00:-1:00.000", "1 00:00:00.0000000001"} {
+		_, err := parseDayToSecondInterval(val)
+		assert.NotNil(t, err, val)
+	}
+}
+
+func TestFormatIntervalLiteral(t *testing.T) {
+	s, ok := formatIntervalLiteral(3 * time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, "INTERVAL '0 00:00:03.000' DAY TO SECOND", s)
+
+	s, ok = formatIntervalLiteral(YearMonthInterval{Years: 2})
+	assert.True(t, ok)
+	assert.Equal(t, "INTERVAL '2-0' YEAR TO MONTH", s)
+
+	_, ok = formatIntervalLiteral("1 day")
+	assert.False(t, ok)
+}
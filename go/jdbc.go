@@ -0,0 +1,103 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"net/url"
+	"strings"
+)
+
+// jdbcURLPrefix is the prefix of the connection URLs of the Athena JDBC driver.
+const jdbcURLPrefix = "jdbc:awsathena://"
+
+// jdbcProperties maps the lower case names of the connection properties of the Athena JDBC and ODBC drivers to the
+// DSN keys of this driver. Their other properties, e.g. MaxCatalogNameLength, have no counterpart and are ignored.
+var jdbcProperties = map[string]string{
+	"awsregion":        "region",
+	"schema":           "db",
+	"catalog":          "catalog",
+	"workgroup":        "workgroupName",
+	"user":             "accessID",
+	"uid":              "accessID",
+	"password":         "secretAccessKey",
+	"pwd":              "secretAccessKey",
+	"sessiontoken":     "sessionToken",
+	"profile":          "AWSProfile",
+	"profilename":      "AWSProfile",
+	"endpointoverride": "endpoint",
+}
+
+// parseJDBCURL is to convert a connection URL of the Athena JDBC driver, like
+// jdbc:awsathena://AwsRegion=us-east-1;S3OutputLocation=s3://bucket/path/;Workgroup=primary, to a DSN of this
+// driver. The region is taken from the endpoint host, e.g. athena.us-east-1.amazonaws.com:443 or
+// athena.cn-north-1.amazonaws.com.cn:443, if it is not set.
+func parseJDBCURL(dsn string) string {
+	values := url.Values{}
+	var endpointRegion string
+	for _, property := range strings.Split(dsn[len(jdbcURLPrefix):], ";") {
+		property = strings.TrimSpace(property)
+		key, value, ok := strings.Cut(property, "=")
+		if !ok {
+			// The host of the URL, which is either the endpoint or absent.
+			if parts := strings.Split(property, "."); len(parts) > 2 && parts[0] == "athena" && IsValidRegion(parts[1]) {
+				endpointRegion = parts[1]
+			}
+			continue
+		}
+		values.Add(key, value)
+	}
+	u := url.URL{Scheme: "s3"}
+	normalizeJDBCProperties(&u, values)
+	if values.Get("region") == "" && endpointRegion != "" {
+		values.Set("region", endpointRegion)
+	}
+	u.RawQuery = values.Encode()
+	return u.String()
+}
+
+// normalizeJDBCProperties is to rename the JDBC and ODBC properties in the values of a DSN to the keys of this
+// driver, and to take the output location of the DSN from S3OutputLocation if it has none. The keys of this driver
+// take precedence over the properties. It returns whether there were any properties.
+func normalizeJDBCProperties(u *url.URL, values url.Values) bool {
+	found := false
+	for key, vs := range values {
+		lower := strings.ToLower(key)
+		if lower == "s3outputlocation" || lower == "outputlocation" {
+			delete(values, key)
+			found = true
+			if location := vs[0]; u.Host == "" && strings.HasPrefix(location, "s3://") {
+				u.Host, u.Path, _ = strings.Cut(location[len("s3://"):], "/")
+				u.Path = "/" + u.Path
+			}
+			continue
+		}
+		name, ok := jdbcProperties[lower]
+		if !ok || name == key {
+			continue
+		}
+		delete(values, key)
+		found = true
+		if values.Get(name) == "" {
+			values.Set(name, vs[0])
+		}
+	}
+	return found
+}
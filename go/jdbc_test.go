@@ -0,0 +1,82 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDSN_JDBCURL(t *testing.T) {
+	conf, err := ParseDSN("jdbc:awsathena://AwsRegion=us-west-2;S3OutputLocation=s3://results/athena/;" +
+		"Workgroup=analytics;Schema=sales;Catalog=lakehouse;User=AKIAEXAMPLE;Password=secret;" +
+		"MaxCatalogNameLength=255;MetadataRetrievalMethod=ProxyAPI")
+	assert.Nil(t, err)
+	assert.Equal(t, "us-west-2", conf.GetRegion())
+	assert.Equal(t, "s3://results/athena/", conf.GetOutputBucket())
+	assert.Equal(t, "analytics", conf.GetWorkgroup().Name)
+	assert.Equal(t, "sales", conf.GetDB())
+	assert.Equal(t, "lakehouse", conf.GetCatalog())
+	assert.Equal(t, "AKIAEXAMPLE", conf.GetAccessID())
+	assert.Equal(t, "secret", conf.GetSecretAccessKey())
+
+	// the region of the endpoint host
+	conf, err = ParseDSN("jdbc:awsathena://athena.eu-west-1.amazonaws.com:443;UID=AKIAEXAMPLE;PWD=secret;" +
+		"S3OutputLocation=s3://results")
+	assert.Nil(t, err)
+	assert.Equal(t, "eu-west-1", conf.GetRegion())
+	assert.Equal(t, "s3://results/", conf.GetOutputBucket())
+	assert.Equal(t, "AKIAEXAMPLE", conf.GetAccessID())
+
+	conf, err = ParseDSN("jdbc:awsathena://athena.cn-north-1.amazonaws.com.cn:443;S3OutputLocation=s3://results")
+	assert.Nil(t, err)
+	assert.Equal(t, "cn-north-1", conf.GetRegion())
+	conf, err = ParseDSN("jdbc:awsathena://athena.us-gov-west-1.amazonaws.com:443;S3OutputLocation=s3://results")
+	assert.Nil(t, err)
+	assert.Equal(t, "us-gov-west-1", conf.GetRegion())
+
+	// a JDBC URL converted to a DSN round-trips
+	parsed, err := ParseDSN(conf.Stringify())
+	assert.Nil(t, err)
+	assert.Equal(t, conf.Stringify(), parsed.Stringify())
+
+	_, err = ParseDSN("jdbc:awsathena://S3OutputLocation=s3://results/")
+	assert.Equal(t, ErrConfigInvalidConfig, err)
+}
+
+func TestParseDSN_JDBCProperties(t *testing.T) {
+	conf, err := ParseDSN("s3://?awsregion=us-east-1&S3OutputLocation=s3%3A%2F%2Fresults%2Fathena%2F&" +
+		"WorkGroup=analytics&Schema=sales&ProfileName=dev&MaxCatalogNameLength=255")
+	assert.Nil(t, err)
+	assert.Equal(t, "us-east-1", conf.GetRegion())
+	assert.Equal(t, "s3://results/athena/", conf.GetOutputBucket())
+	assert.Equal(t, "analytics", conf.GetWorkgroup().Name)
+	assert.Equal(t, "sales", conf.GetDB())
+	assert.Equal(t, "dev", conf.GetAWSProfile())
+	assert.NotContains(t, conf.String(), "Schema")
+
+	// the keys and the output location of the driver take precedence
+	conf, err = ParseDSN("s3://bucket/prefix/?region=us-east-1&AwsRegion=us-west-2&S3OutputLocation=s3%3A%2F%2Fother")
+	assert.Nil(t, err)
+	assert.Equal(t, "us-east-1", conf.GetRegion())
+	assert.Equal(t, "s3://bucket/prefix/", conf.GetOutputBucket())
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/smithy-go"
+)
+
+// expiredTokenCodes are the error codes AWS APIs fail requests signed with expired credentials with.
+var expiredTokenCodes = map[string]bool{
+	"ExpiredToken":          true,
+	"ExpiredTokenException": true,
+}
+
+// isExpiredToken is to check if an AWS API call failed because its credentials have expired.
+func isExpiredToken(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && expiredTokenCodes[apiErr.ErrorCode()]
+}
+
+// connectionExpiry returns when a connection created at now expires: maxAge later, or CredentialsExpiryMargin before
+// its credentials expire, whichever comes first. It is zero for a connection which never expires.
+func connectionExpiry(now time.Time, maxAge time.Duration, creds aws.Credentials) time.Time {
+	var expiry time.Time
+	if maxAge > 0 {
+		expiry = now.Add(maxAge)
+	}
+	if creds.CanExpire && !creds.Expires.IsZero() {
+		if credsExpiry := creds.Expires.Add(-CredentialsExpiryMargin); expiry.IsZero() || credsExpiry.Before(expiry) {
+			expiry = credsExpiry
+		}
+	}
+	return expiry
+}
+
+// credentials is to get the credentials the connections of a connector sign their requests with, or none if they
+// can't be retrieved, in which case the connections don't expire with them.
+func (c *SQLConnector) credentials(ctx context.Context, awsCfg aws.Config) aws.Credentials {
+	if awsCfg.Credentials == nil {
+		return aws.Credentials{}
+	}
+	creds, err := awsCfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return aws.Credentials{}
+	}
+	return creds
+}
+
+// expired is to check if the connection is past its max age or its credentials have expired, see
+// Config.SetMaxConnectionAge.
+func (c *Connection) expired() bool {
+	if c.credentialsExpired.Load() {
+		return true
+	}
+	return !c.expiresAt.IsZero() && !time.Now().Before(c.expiresAt)
+}
+
+// IsValid implements driver.Validator, so that database/sql drops the connection rather than putting it back into
+// its pool once it is closed or expired.
+func (c *Connection) IsValid() bool {
+	return !c.closed.Load() && !c.expired()
+}
+
+// ResetSession implements driver.SessionResetter, so that database/sql does not reuse an expired connection.
+func (c *Connection) ResetSession(ctx context.Context) error {
+	if !c.IsValid() {
+		c.tracer(ctx).Scope().Counter(DriverName + ".connection.expired").Inc(1)
+		return driver.ErrBadConn
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnectionExpiry(t *testing.T) {
+	now := time.Now()
+	assert.True(t, connectionExpiry(now, 0, aws.Credentials{}).IsZero())
+	assert.Equal(t, now.Add(time.Hour), connectionExpiry(now, time.Hour, aws.Credentials{}))
+
+	creds := aws.Credentials{CanExpire: true, Expires: now.Add(30 * time.Minute)}
+	assert.Equal(t, now.Add(29*time.Minute), connectionExpiry(now, 0, creds))
+	assert.Equal(t, now.Add(29*time.Minute), connectionExpiry(now, time.Hour, creds))
+	assert.Equal(t, now.Add(10*time.Minute), connectionExpiry(now, 10*time.Minute, creds))
+	// static credentials don't expire
+	assert.True(t, connectionExpiry(now, 0, aws.Credentials{Expires: now}).IsZero())
+}
+
+func TestConnection_IsValid(t *testing.T) {
+	c := createConnectionFixture()
+	assert.True(t, c.IsValid())
+	assert.Nil(t, c.ResetSession(context.Background()))
+
+	c.expiresAt = time.Now().Add(-time.Second)
+	assert.False(t, c.IsValid())
+	assert.Equal(t, driver.ErrBadConn, c.ResetSession(context.Background()))
+	_, err := c.QueryContext(context.Background(), "SELECTQueryContext_OK", nil)
+	assert.Equal(t, driver.ErrBadConn, err)
+	_, err = c.Prepare("SELECT 1")
+	assert.Equal(t, driver.ErrBadConn, err)
+
+	c = createConnectionFixture()
+	assert.Nil(t, c.Close())
+	assert.False(t, c.IsValid())
+}
+
+func TestConnection_ExpiredToken(t *testing.T) {
+	c := createConnectionFixture()
+	_, err := c.QueryContext(context.Background(), "SELECT expired_token", nil)
+	assert.ErrorIs(t, err, driver.ErrBadConn)
+	assert.Contains(t, err.Error(), "ExpiredTokenException")
+	assert.False(t, c.IsValid())
+	assert.Equal(t, driver.ErrBadConn, c.ResetSession(context.Background()))
+}
+
+func TestSQLConnector_ConnectExpiry(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	testConf.SetMaxConnectionAge(time.Hour)
+	connector := NoopsSQLConnector()
+	connector.config = testConf
+	conn, err := connector.Connect(context.Background())
+	assert.Nil(t, err)
+	expiresAt := conn.(*Connection).expiresAt
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Minute)
+}
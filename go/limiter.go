@@ -0,0 +1,133 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// QueryPriority is the priority of a query waiting for the concurrency limit of Config.SetMaxConcurrentQueries, see
+// WithPriority.
+type QueryPriority int
+
+const (
+	// PriorityLow is for batch queries, which wait while queries of a higher priority are waiting.
+	PriorityLow QueryPriority = -1
+	// PriorityNormal is the priority of queries whose context sets none.
+	PriorityNormal QueryPriority = 0
+	// PriorityHigh is for interactive queries, which go ahead of the others waiting.
+	PriorityHigh QueryPriority = 1
+)
+
+// WithPriority is to set the priority the queries run with the returned context wait for the concurrency limit
+// with. A query waits while others of a higher priority are waiting, and after those of the same priority which
+// started waiting first. Queries which are already running are not preempted.
+func WithPriority(ctx context.Context, priority QueryPriority) context.Context {
+	return context.WithValue(ctx, PriorityKey, priority)
+}
+
+// priorityOf is to get the index of the waiting list of the priority of a context, 0 for the highest priority.
+func priorityOf(ctx context.Context) int {
+	priority, _ := ctx.Value(PriorityKey).(QueryPriority)
+	switch {
+	case priority > PriorityNormal:
+		return 0
+	case priority < PriorityNormal:
+		return 2
+	}
+	return 1
+}
+
+// queryLimiter is a semaphore bounding the number of query executions in flight. Submissions over the limit
+// queue until a slot is released or their context is done, and a released slot is handed to the first submission
+// of the highest priority waiting. A nil queryLimiter has no limit.
+type queryLimiter struct {
+	mu    sync.Mutex
+	limit int
+	inUse int
+	// waiting are the channels closed to hand a slot to the waiting submissions, by priority, highest first.
+	waiting [3]list.List
+}
+
+func newQueryLimiter(n int) *queryLimiter {
+	if n <= 0 {
+		return nil
+	}
+	return &queryLimiter{limit: n}
+}
+
+// acquire is to take a slot, waiting for one as long as ctx is not done.
+func (l *queryLimiter) acquire(ctx context.Context, obs *DriverTracer) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	if l.inUse < l.limit {
+		l.inUse++
+		l.mu.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	queue := &l.waiting[priorityOf(ctx)]
+	e := queue.PushBack(ready)
+	l.mu.Unlock()
+
+	now := time.Now()
+	obs.Scope().Counter(DriverName + ".query.limiter.queued").Inc(1)
+	select {
+	case <-ready:
+		obs.Scope().Timer(DriverName + ".query.limiter.wait").Record(time.Since(now))
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		select {
+		case <-ready:
+			// The slot was handed over meanwhile, so it is passed on.
+			l.mu.Unlock()
+			l.release()
+		default:
+			queue.Remove(e)
+			l.mu.Unlock()
+		}
+		obs.Scope().Counter(DriverName + ".failure.querycontext.limiter").Inc(1)
+		return ctx.Err()
+	}
+}
+
+// release is to give back a slot taken by acquire, handing it to the next waiting submission if any.
+func (l *queryLimiter) release() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := range l.waiting {
+		if e := l.waiting[i].Front(); e != nil {
+			l.waiting[i].Remove(e)
+			close(e.Value.(chan struct{}))
+			return
+		}
+	}
+	l.inUse--
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryLimiter(t *testing.T) {
+	obs := NewDefaultObservability(NewNoOpsConfig())
+	assert.Nil(t, newQueryLimiter(0))
+	var unlimited *queryLimiter
+	assert.Nil(t, unlimited.acquire(context.Background(), obs))
+	unlimited.release()
+
+	l := newQueryLimiter(2)
+	assert.Nil(t, l.acquire(context.Background(), obs))
+	assert.Nil(t, l.acquire(context.Background(), obs))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, l.acquire(ctx, obs))
+
+	acquired := make(chan error)
+	go func() {
+		acquired <- l.acquire(context.Background(), obs)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("acquired a slot over the limit")
+	case <-time.After(10 * time.Millisecond):
+	}
+	l.release()
+	assert.Nil(t, <-acquired)
+}
+
+func TestQueryLimiter_Priority(t *testing.T) {
+	obs := NewDefaultObservability(NewNoOpsConfig())
+	l := newQueryLimiter(1)
+	assert.Nil(t, l.acquire(context.Background(), obs))
+
+	order := make(chan QueryPriority, 3)
+	wait := func(p QueryPriority) {
+		l.mu.Lock()
+		n := l.waiting[0].Len() + l.waiting[1].Len() + l.waiting[2].Len()
+		l.mu.Unlock()
+		go func() {
+			assert.Nil(t, l.acquire(WithPriority(context.Background(), p), obs))
+			order <- p
+		}()
+		assert.Eventually(t, func() bool {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			return l.waiting[0].Len()+l.waiting[1].Len()+l.waiting[2].Len() == n+1
+		}, time.Second, time.Millisecond)
+	}
+	wait(PriorityLow)
+	wait(PriorityNormal)
+	wait(PriorityHigh)
+
+	for _, want := range []QueryPriority{PriorityHigh, PriorityNormal, PriorityLow} {
+		l.release()
+		assert.Equal(t, want, <-order)
+	}
+	l.release()
+	assert.Equal(t, 0, l.inUse)
+}
+
+func TestQueryLimiter_CancelWaiting(t *testing.T) {
+	obs := NewDefaultObservability(NewNoOpsConfig())
+	l := newQueryLimiter(1)
+	assert.Nil(t, l.acquire(context.Background(), obs))
+
+	ctx, cancel := context.WithTimeout(WithPriority(context.Background(), PriorityHigh), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, l.acquire(ctx, obs))
+	assert.Equal(t, 0, l.waiting[0].Len())
+
+	l.release()
+	assert.Equal(t, 0, l.inUse)
+	assert.Nil(t, l.acquire(context.Background(), obs))
+}
+
+func TestConnection_QueryContextLimiter(t *testing.T) {
+	c := createConnectionFixture()
+	c.connector.config.SetMaxConcurrentQueries(1)
+	driverRows, err := c.QueryContext(context.Background(), "SELECTQueryContext_OK", []driver.NamedValue{})
+	assert.Nil(t, err)
+	assert.NotNil(t, driverRows)
+	assert.Equal(t, 0, c.connector.getQueryLimiter().inUse)
+
+	// a query waiting for a slot gives up with its context
+	assert.Nil(t, c.connector.getQueryLimiter().acquire(context.Background(), c.connector.tracer))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	driverRows, err = c.QueryContext(ctx, "SELECTQueryContext_OK", []driver.NamedValue{})
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Nil(t, driverRows)
+}
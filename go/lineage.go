@@ -0,0 +1,389 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"go.uber.org/zap"
+)
+
+// The event types of OpenLineage run events.
+const (
+	LineageStart    = "START"
+	LineageComplete = "COMPLETE"
+	LineageFail     = "FAIL"
+	LineageAbort    = "ABORT"
+)
+
+const (
+	lineageProducer       = "https://github.com/prequel-co/athenadriver"
+	lineageSchemaURL      = "https://openlineage.io/spec/2-0-2/OpenLineage.json#/$defs/RunEvent"
+	lineageSQLFacetURL    = "https://openlineage.io/spec/facets/1-0-1/SQLJobFacet.json#/$defs/SQLJobFacet"
+	lineageErrorFacetURL  = "https://openlineage.io/spec/facets/1-0-0/ErrorMessageRunFacet.json#/$defs/ErrorMessageRunFacet"
+	lineageAthenaFacetURL = lineageProducer + "#AthenaRunFacet"
+	lineageDefaultCatalog = "awsdatacatalog"
+)
+
+var (
+	lineageEmittersMu sync.RWMutex
+	lineageEmitters   = map[string]LineageEmitter{}
+)
+
+// LineageEmitter is given the OpenLineage run events of the queries of the connections whose
+// Config.SetLineageEmitter names it. Emit is called while the query is executed, so it should be quick.
+type LineageEmitter interface {
+	Emit(ctx context.Context, event *LineageRunEvent) error
+}
+
+// RegisterLineageEmitter is to install a LineageEmitter under a name.
+func RegisterLineageEmitter(name string, emitter LineageEmitter) {
+	lineageEmittersMu.Lock()
+	defer lineageEmittersMu.Unlock()
+	lineageEmitters[name] = emitter
+}
+
+// UnregisterLineageEmitter is to remove the LineageEmitter installed under a name.
+func UnregisterLineageEmitter(name string) {
+	lineageEmittersMu.Lock()
+	defer lineageEmittersMu.Unlock()
+	delete(lineageEmitters, name)
+}
+
+func getLineageEmitter(name string) (LineageEmitter, bool) {
+	lineageEmittersMu.RLock()
+	defer lineageEmittersMu.RUnlock()
+	emitter, ok := lineageEmitters[name]
+	return emitter, ok
+}
+
+// LineageRunEvent is an OpenLineage run event, see https://openlineage.io/docs/spec/object-model.
+type LineageRunEvent struct {
+	EventType string           `json:"eventType"`
+	EventTime time.Time        `json:"eventTime"`
+	Run       LineageRun       `json:"run"`
+	Job       LineageJob       `json:"job"`
+	Inputs    []LineageDataset `json:"inputs"`
+	Outputs   []LineageDataset `json:"outputs"`
+	Producer  string           `json:"producer"`
+	SchemaURL string           `json:"schemaURL"`
+}
+
+// LineageRun is the run of a LineageRunEvent. Its RunID is the QID of the query execution.
+type LineageRun struct {
+	RunID  string                  `json:"runId"`
+	Facets map[string]LineageFacet `json:"facets,omitempty"`
+}
+
+// LineageJob is the job of a LineageRunEvent. Its Name is the value of LineageJobKey in the context of the query,
+// or else the workgroup followed by a hash of the query.
+type LineageJob struct {
+	Namespace string                  `json:"namespace"`
+	Name      string                  `json:"name"`
+	Facets    map[string]LineageFacet `json:"facets,omitempty"`
+}
+
+// LineageDataset is a table a query reads or writes. Its Name is `catalog.database.table`.
+type LineageDataset struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// LineageFacet is the facet of a run or a job, including its `_producer` and `_schemaURL`.
+type LineageFacet map[string]interface{}
+
+func newLineageFacet(schemaURL string) LineageFacet {
+	return LineageFacet{"_producer": lineageProducer, "_schemaURL": schemaURL}
+}
+
+// HTTPLineageEmitter is a LineageEmitter posting the events to the OpenLineage HTTP API, e.g. of Marquez.
+type HTTPLineageEmitter struct {
+	// URL is where the events are posted, e.g. http://marquez:5000/api/v1/lineage.
+	URL string
+	// APIKey is sent as a bearer token, if set.
+	APIKey string
+	Client *http.Client
+}
+
+// NewHTTPLineageEmitter is to create an HTTPLineageEmitter posting the events to a URL, timing out after 5 seconds.
+func NewHTTPLineageEmitter(url string) *HTTPLineageEmitter {
+	return &HTTPLineageEmitter{URL: url, Client: &http.Client{Timeout: 5 * time.Second}}
+}
+
+// Emit is to implement interface LineageEmitter.
+func (e *HTTPLineageEmitter) Emit(ctx context.Context, event *LineageRunEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.APIKey)
+	}
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("OpenLineage endpoint responded %s", resp.Status)
+	}
+	return nil
+}
+
+// emitLineage is to give the OpenLineage run event of a query execution to the LineageEmitter of the config, if
+// any. The final status, if known, adds its statistics and failure reason to the event.
+func (c *Connection) emitLineage(ctx context.Context, eventType string, queryID string, query string,
+	wgName string, queryExecution *athenatypes.QueryExecution) {
+	name := c.connector.config.GetLineageEmitter()
+	if name == "" {
+		return
+	}
+	emitter, ok := getLineageEmitter(name)
+	if !ok {
+		return
+	}
+	if queryExecution != nil && queryExecution.Query != nil {
+		query = *queryExecution.Query
+	}
+	event := c.newLineageRunEvent(ctx, eventType, queryID, query, wgName, queryExecution)
+	// The events of canceled queries are emitted too.
+	if err := emitter.Emit(context.WithoutCancel(ctx), event); err != nil {
+		c.tracer(ctx).Scope().Counter(DriverName + ".failure.lineage").Inc(1)
+		c.tracer(ctx).Log(WarnLevel, "emitting lineage event failed",
+			zap.String("queryID", queryID),
+			zap.String("eventType", eventType),
+			zap.String("error", err.Error()))
+	}
+}
+
+func (c *Connection) newLineageRunEvent(ctx context.Context, eventType string, queryID string, query string,
+	wgName string, queryExecution *athenatypes.QueryExecution) *LineageRunEvent {
+	conf := c.connector.config
+	namespace := NamespaceFromContext(ctx, conf)
+	catalog := namespace.Catalog
+	if catalog == "" {
+		catalog = lineageDefaultCatalog
+	}
+	jobName, _ := ctx.Value(LineageJobKey).(string)
+	if jobName == "" {
+		jobName = wgName + "." + queryFingerprint(query, nil, namespace.String(), wgName)[:16]
+	}
+	sqlFacet := newLineageFacet(lineageSQLFacetURL)
+	sqlFacet["query"] = query
+	athenaFacet := newLineageFacet(lineageAthenaFacetURL)
+	athenaFacet["queryExecutionId"] = queryID
+	athenaFacet["workGroup"] = wgName
+	athenaFacet["catalog"] = catalog
+	athenaFacet["database"] = namespace.Database
+	event := &LineageRunEvent{
+		EventType: eventType,
+		EventTime: time.Now().UTC(),
+		Run: LineageRun{
+			RunID:  queryID,
+			Facets: map[string]LineageFacet{"athena": athenaFacet},
+		},
+		Job: LineageJob{
+			Namespace: conf.GetLineageNamespace(),
+			Name:      jobName,
+			Facets:    map[string]LineageFacet{"sql": sqlFacet},
+		},
+		Inputs:    []LineageDataset{},
+		Outputs:   []LineageDataset{},
+		Producer:  lineageProducer,
+		SchemaURL: lineageSchemaURL,
+	}
+	if queryExecution != nil {
+		if status := queryExecution.Status; status != nil {
+			athenaFacet["state"] = string(status.State)
+			if status.State == athenatypes.QueryExecutionStateFailed && status.StateChangeReason != nil {
+				errorFacet := newLineageFacet(lineageErrorFacetURL)
+				errorFacet["message"] = *status.StateChangeReason
+				errorFacet["programmingLanguage"] = "SQL"
+				event.Run.Facets["errorMessage"] = errorFacet
+			}
+		}
+		if stats := queryExecution.Statistics; stats != nil {
+			if stats.DataScannedInBytes != nil {
+				athenaFacet["dataScannedInBytes"] = *stats.DataScannedInBytes
+			}
+			if stats.EngineExecutionTimeInMillis != nil {
+				athenaFacet["engineExecutionTimeInMillis"] = *stats.EngineExecutionTimeInMillis
+			}
+			if stats.TotalExecutionTimeInMillis != nil {
+				athenaFacet["totalExecutionTimeInMillis"] = *stats.TotalExecutionTimeInMillis
+			}
+		}
+	}
+	datasetNamespace := "awsathena://" + AthenaEndpoint(conf.GetRegion())
+	inputs, outputs := lineageTables(query, catalog, namespace.Database)
+	for _, name := range inputs {
+		event.Inputs = append(event.Inputs, LineageDataset{Namespace: datasetNamespace, Name: name})
+	}
+	for _, name := range outputs {
+		event.Outputs = append(event.Outputs, LineageDataset{Namespace: datasetNamespace, Name: name})
+	}
+	return event
+}
+
+// lineageReservedWords are the words which can follow a table in FROM or JOIN, so they are not its alias.
+var lineageReservedWords = map[string]bool{
+	"WHERE": true, "JOIN": true, "LEFT": true, "RIGHT": true, "INNER": true, "OUTER": true, "FULL": true,
+	"CROSS": true, "NATURAL": true, "ON": true, "USING": true, "GROUP": true, "ORDER": true, "HAVING": true,
+	"LIMIT": true, "OFFSET": true, "FETCH": true, "UNION": true, "INTERSECT": true, "EXCEPT": true, "WINDOW": true,
+	"FOR": true, "TABLESAMPLE": true, "WHEN": true, "SET": true, "VALUES": true, "SELECT": true,
+}
+
+// lineageTables is to get the `catalog.database.table` names of the tables a query reads and writes, as far as
+// they can be told from its FROM, JOIN, USING, INTO, UPDATE, DELETE FROM and CREATE TABLE or VIEW clauses. The
+// names of common table expressions are left out.
+func lineageTables(query string, catalog string, db string) (inputs []string, outputs []string) {
+	var toks []string
+	t := sqlTokenizer{query: query}
+	for start, end := t.scan(); start != end; start, end = t.scan() {
+		toks = append(toks, query[start:end])
+	}
+	upper := func(i int) string {
+		if i < 0 || i >= len(toks) {
+			return ""
+		}
+		return strings.ToUpper(toks[i])
+	}
+	isIdentifier := func(i int) bool {
+		return i < len(toks) && (toks[i][0] == '"' || toks[i][0] == '`' || isWordChar(toks[i][0]))
+	}
+	// name is to read the possibly qualified name starting at i, returning the index after it.
+	name := func(i int) ([]string, int) {
+		var parts []string
+		for isIdentifier(i) {
+			parts = append(parts, unquoteIdentifier(toks[i]))
+			if upper(i+1) != "." {
+				return parts, i + 1
+			}
+			i += 2
+		}
+		return parts, i
+	}
+	ctes := map[string]bool{}
+	for i := range toks {
+		// name [(columns)] AS (query)
+		j := i + 1
+		if upper(j) == "(" {
+			for j < len(toks) && toks[j] != ")" {
+				j++
+			}
+			j++
+		}
+		if isIdentifier(i) && upper(j) == "AS" && upper(j+1) == "(" {
+			ctes[unquoteIdentifier(toks[i])] = true
+		}
+	}
+	seen := map[string]bool{}
+	add := func(tables *[]string, parts []string, output bool) {
+		if len(parts) == 0 || len(parts) == 1 && ctes[parts[0]] {
+			return
+		}
+		switch len(parts) {
+		case 1:
+			parts = []string{catalog, db, parts[0]}
+		case 2:
+			parts = []string{catalog, parts[0], parts[1]}
+		}
+		qualified := strings.Join(parts, ".")
+		key := fmt.Sprint(output, qualified)
+		if !seen[key] {
+			seen[key] = true
+			*tables = append(*tables, qualified)
+		}
+	}
+	for i := 0; i < len(toks); i++ {
+		switch upper(i) {
+		case "FROM", "JOIN", "USING":
+			if upper(i) == "FROM" && upper(i-1) == "DELETE" {
+				parts, _ := name(i + 1)
+				add(&outputs, parts, true)
+				continue
+			}
+			for j := i + 1; ; {
+				parts, next := name(j)
+				// a subquery, or a table function like UNNEST
+				if len(parts) == 0 || upper(next) == "(" {
+					break
+				}
+				add(&inputs, parts, false)
+				if upper(next) == "AS" {
+					next += 2
+				} else if isIdentifier(next) && !lineageReservedWords[upper(next)] {
+					next++
+				}
+				if upper(i) != "FROM" || upper(next) != "," {
+					break
+				}
+				j = next + 1
+			}
+		case "INTO":
+			parts, _ := name(i + 1)
+			add(&outputs, parts, true)
+		case "UPDATE":
+			if upper(i+1) != "SET" {
+				parts, _ := name(i + 1)
+				add(&outputs, parts, true)
+			}
+		case "TABLE", "VIEW":
+			k := i - 1
+			for upper(k) == "EXTERNAL" || upper(k) == "REPLACE" || upper(k) == "OR" {
+				k--
+			}
+			if upper(k) != "CREATE" {
+				continue
+			}
+			j := i + 1
+			if upper(j) == "IF" && upper(j+1) == "NOT" && upper(j+2) == "EXISTS" {
+				j += 3
+			}
+			parts, _ := name(j)
+			add(&outputs, parts, true)
+		}
+	}
+	return inputs, outputs
+}
+
+// unquoteIdentifier is to get the name of an identifier. Athena names are lower case, quoted or not.
+func unquoteIdentifier(tok string) string {
+	if c := tok[0]; c == '"' || c == '`' {
+		tok = strings.Trim(tok, string(c))
+	}
+	return strings.ToLower(tok)
+}
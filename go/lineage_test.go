@@ -0,0 +1,131 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineageTables(t *testing.T) {
+	for _, tc := range []struct {
+		query   string
+		inputs  []string
+		outputs []string
+	}{
+		{"SELECT 1", nil, nil},
+		{"SELECT * FROM t", []string{"c.db.t"}, nil},
+		{`SELECT * FROM "Sales"."orders" o JOIN db2.customers AS c ON o.id = c.id`,
+			[]string{"c.sales.orders", "c.db2.customers"}, nil},
+		{"SELECT * FROM a, b x, other.db.c WHERE a.id = x.id", []string{"c.db.a", "c.db.b", "other.db.c"}, nil},
+		{"WITH recent (id) AS (SELECT id FROM events) SELECT * FROM recent CROSS JOIN UNNEST(tags) AS t(tag)",
+			[]string{"c.db.events"}, nil},
+		{"INSERT INTO summary (id, n) SELECT id, count(*) FROM (SELECT * FROM events) GROUP BY id",
+			[]string{"c.db.events"}, []string{"c.db.summary"}},
+		{"CREATE TABLE IF NOT EXISTS s.copy AS SELECT * FROM t", []string{"c.db.t"}, []string{"c.s.copy"}},
+		{"CREATE OR REPLACE VIEW v AS SELECT * FROM t -- FROM comment", []string{"c.db.t"}, []string{"c.db.v"}},
+		{"MERGE INTO target USING source s ON target.id = s.id WHEN MATCHED THEN UPDATE SET n = s.n",
+			[]string{"c.db.source"}, []string{"c.db.target"}},
+		{"DELETE FROM t WHERE id IN (SELECT id FROM removed)", []string{"c.db.removed"}, []string{"c.db.t"}},
+		{"UPDATE t SET n = 1 WHERE x = 'FROM s'", nil, []string{"c.db.t"}},
+	} {
+		inputs, outputs := lineageTables(tc.query, "c", "db")
+		assert.Equal(t, tc.inputs, inputs, tc.query)
+		assert.Equal(t, tc.outputs, outputs, tc.query)
+	}
+}
+
+type recordingLineageEmitter struct {
+	mu     sync.Mutex
+	events []*LineageRunEvent
+}
+
+func (e *recordingLineageEmitter) Emit(_ context.Context, event *LineageRunEvent) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = append(e.events, event)
+	return nil
+}
+
+func TestConnection_emitLineage(t *testing.T) {
+	emitter := &recordingLineageEmitter{}
+	RegisterLineageEmitter("TestConnection_emitLineage", emitter)
+	defer UnregisterLineageEmitter("TestConnection_emitLineage")
+
+	c := createConnectionFixture()
+	_, err := c.QueryContext(context.Background(), "SELECTQueryContext_OK", []driver.NamedValue{})
+	assert.Nil(t, err)
+	assert.Empty(t, emitter.events)
+
+	c.connector.config.SetLineageEmitter("TestConnection_emitLineage")
+	c.connector.config.SetLineageNamespace("reports")
+	ctx := context.WithValue(context.Background(), LineageJobKey, "daily")
+	_, err = c.QueryContext(ctx, "SELECTQueryContext_OK", []driver.NamedValue{})
+	assert.Nil(t, err)
+	if assert.Len(t, emitter.events, 2) {
+		start, complete := emitter.events[0], emitter.events[1]
+		assert.Equal(t, LineageStart, start.EventType)
+		assert.Equal(t, LineageComplete, complete.EventType)
+		assert.Equal(t, "SELECTQueryContext_OK_QID", complete.Run.RunID)
+		assert.Equal(t, LineageJob{Namespace: "reports", Name: "daily", Facets: complete.Job.Facets}, complete.Job)
+		assert.Equal(t, "SELECTQueryContext_OK", start.Job.Facets["sql"]["query"])
+		assert.Equal(t, "SUCCEEDED", complete.Run.Facets["athena"]["state"])
+	}
+
+	emitter.events = nil
+	_, err = c.QueryContext(context.Background(), "SELECTQueryContext_FEDERATED_FAIL", []driver.NamedValue{})
+	assert.NotNil(t, err)
+	if assert.Len(t, emitter.events, 2) {
+		assert.Equal(t, LineageFail, emitter.events[1].EventType)
+		assert.NotEmpty(t, emitter.events[1].Run.Facets["errorMessage"]["message"])
+		// the default job name is the same for all the events of a query
+		assert.Equal(t, emitter.events[0].Job.Name, emitter.events[1].Job.Name)
+	}
+}
+
+func TestHTTPLineageEmitter(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	emitter := NewHTTPLineageEmitter(server.URL)
+	emitter.APIKey = "key"
+	event := &LineageRunEvent{EventType: LineageStart, Run: LineageRun{RunID: "qid"}, Inputs: []LineageDataset{},
+		Outputs: []LineageDataset{}}
+	assert.Nil(t, emitter.Emit(context.Background(), event))
+	assert.Equal(t, "START", got["eventType"])
+	assert.Equal(t, []interface{}{}, got["inputs"])
+
+	failing := NewHTTPLineageEmitter(server.URL + "/missing")
+	server.Config.Handler = http.NotFoundHandler()
+	assert.NotNil(t, failing.Emit(context.Background(), event))
+}
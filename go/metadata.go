@@ -0,0 +1,166 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/shogo82148/memoize"
+)
+
+// Metadata is a client of the data catalog APIs of Athena, which caches the results for a TTL. It is safe for
+// concurrent use.
+type Metadata struct {
+	client AthenaClient
+	ttl    time.Duration
+	caches atomic.Pointer[metadataCaches]
+}
+
+type metadataCaches struct {
+	catalogs  memoize.Group[struct{}, []athenatypes.DataCatalogSummary]
+	databases memoize.Group[string, []athenatypes.Database]
+	tables    memoize.Group[[2]string, []athenatypes.TableMetadata]
+	table     memoize.Group[[3]string, *athenatypes.TableMetadata]
+}
+
+// NewMetadata is to create a Metadata caching the results for ttl. With a ttl of 0, only concurrent calls are
+// coalesced.
+func NewMetadata(client AthenaClient, ttl time.Duration) *Metadata {
+	m := &Metadata{client: client, ttl: ttl}
+	m.caches.Store(&metadataCaches{})
+	return m
+}
+
+// Invalidate is to drop the cached results, e.g. after a DDL statement.
+func (m *Metadata) Invalidate() {
+	m.caches.Store(&metadataCaches{})
+}
+
+// ListDataCatalogs is to list the data catalogs, e.g. AwsDataCatalog.
+func (m *Metadata) ListDataCatalogs(ctx context.Context) ([]athenatypes.DataCatalogSummary, error) {
+	if m.client == nil {
+		return nil, ErrAthenaNilClient
+	}
+	catalogs, _, err := m.caches.Load().catalogs.Do(ctx, struct{}{},
+		func(ctx context.Context, _ struct{}) ([]athenatypes.DataCatalogSummary, time.Time, error) {
+			var catalogs []athenatypes.DataCatalogSummary
+			paginator := athena.NewListDataCatalogsPaginator(m.client, &athena.ListDataCatalogsInput{})
+			for paginator.HasMorePages() {
+				page, err := paginator.NextPage(ctx)
+				if err != nil {
+					return nil, time.Time{}, err
+				}
+				catalogs = append(catalogs, page.DataCatalogsSummary...)
+			}
+			return catalogs, time.Now().Add(m.ttl), nil
+		})
+	return catalogs, err
+}
+
+// ListDatabases is to list the databases of a data catalog.
+func (m *Metadata) ListDatabases(ctx context.Context, catalog string) ([]athenatypes.Database, error) {
+	if m.client == nil {
+		return nil, ErrAthenaNilClient
+	}
+	databases, _, err := m.caches.Load().databases.Do(ctx, catalog,
+		func(ctx context.Context, catalog string) ([]athenatypes.Database, time.Time, error) {
+			var databases []athenatypes.Database
+			paginator := athena.NewListDatabasesPaginator(m.client, &athena.ListDatabasesInput{
+				CatalogName: aws.String(catalog),
+			})
+			for paginator.HasMorePages() {
+				page, err := paginator.NextPage(ctx)
+				if err != nil {
+					return nil, time.Time{}, err
+				}
+				databases = append(databases, page.DatabaseList...)
+			}
+			return databases, time.Now().Add(m.ttl), nil
+		})
+	return databases, err
+}
+
+// ListTableMetadata is to list the tables of a database, with their columns and partition keys.
+func (m *Metadata) ListTableMetadata(ctx context.Context, catalog string, database string) (
+	[]athenatypes.TableMetadata, error) {
+	if m.client == nil {
+		return nil, ErrAthenaNilClient
+	}
+	tables, _, err := m.caches.Load().tables.Do(ctx, [2]string{catalog, database},
+		func(ctx context.Context, key [2]string) ([]athenatypes.TableMetadata, time.Time, error) {
+			var tables []athenatypes.TableMetadata
+			paginator := athena.NewListTableMetadataPaginator(m.client, &athena.ListTableMetadataInput{
+				CatalogName:  aws.String(key[0]),
+				DatabaseName: aws.String(key[1]),
+			})
+			for paginator.HasMorePages() {
+				page, err := paginator.NextPage(ctx)
+				if err != nil {
+					return nil, time.Time{}, err
+				}
+				tables = append(tables, page.TableMetadataList...)
+			}
+			return tables, time.Now().Add(m.ttl), nil
+		})
+	return tables, err
+}
+
+// GetTableMetadata is to get a table of a database, with its columns and partition keys.
+func (m *Metadata) GetTableMetadata(ctx context.Context, catalog string, database string, table string) (
+	*athenatypes.TableMetadata, error) {
+	if m.client == nil {
+		return nil, ErrAthenaNilClient
+	}
+	metadata, _, err := m.caches.Load().table.Do(ctx, [3]string{catalog, database, table},
+		func(ctx context.Context, key [3]string) (*athenatypes.TableMetadata, time.Time, error) {
+			out, err := m.client.GetTableMetadata(ctx, &athena.GetTableMetadataInput{
+				CatalogName:  aws.String(key[0]),
+				DatabaseName: aws.String(key[1]),
+				TableName:    aws.String(key[2]),
+			})
+			if err != nil {
+				return nil, time.Time{}, err
+			}
+			return out.TableMetadata, time.Now().Add(m.ttl), nil
+		})
+	return metadata, err
+}
+
+// Metadata is to get the Metadata of the connector, whose results are cached for all its users for the TTL set by
+// Config.SetMetadataCacheTTL. Its client is created with the credentials of the config, like by Connect.
+func (c *SQLConnector) Metadata(ctx context.Context) (*Metadata, error) {
+	c.metadataMu.Lock()
+	defer c.metadataMu.Unlock()
+	if c.metadata != nil {
+		return c.metadata, nil
+	}
+	awsCfg, err := c.awsConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.metadata = NewMetadata(c.withRateLimits(c.newAthenaClient(awsCfg)), c.config.GetMetadataCacheTTL())
+	return c.metadata, nil
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadata(t *testing.T) {
+	client := newMockAthenaClient()
+	client.tableMetadata = map[string][]athenatypes.TableMetadata{
+		"AwsDataCatalog.default": nil,
+		"AwsDataCatalog.sampledb": {
+			{Name: aws.String("elb_logs"), Columns: []athenatypes.Column{{Name: aws.String("url"), Type: aws.String("string")}}},
+			{Name: aws.String("flights")},
+		},
+	}
+	m := NewMetadata(client, time.Minute)
+	ctx := context.Background()
+
+	catalogs, err := m.ListDataCatalogs(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "AwsDataCatalog", *catalogs[0].CatalogName)
+
+	databases, err := m.ListDatabases(ctx, "AwsDataCatalog")
+	assert.Nil(t, err)
+	assert.Len(t, databases, 2)
+	assert.Equal(t, "sampledb", *databases[1].Name)
+	assert.Equal(t, 2, client.callCount("ListDatabases"))
+
+	tables, err := m.ListTableMetadata(ctx, "AwsDataCatalog", "sampledb")
+	assert.Nil(t, err)
+	assert.Len(t, tables, 2)
+	_, err = m.ListTableMetadata(ctx, "AwsDataCatalog", "missing")
+	assert.Equal(t, ErrTestMockGeneric, err)
+
+	table, err := m.GetTableMetadata(ctx, "AwsDataCatalog", "sampledb", "elb_logs")
+	assert.Nil(t, err)
+	assert.Equal(t, "url", *table.Columns[0].Name)
+
+	// cached
+	_, _ = m.ListDatabases(ctx, "AwsDataCatalog")
+	_, _ = m.GetTableMetadata(ctx, "AwsDataCatalog", "sampledb", "elb_logs")
+	assert.Equal(t, 2, client.callCount("ListDatabases"))
+	assert.Equal(t, 1, client.callCount("GetTableMetadata"))
+
+	m.Invalidate()
+	_, _ = m.GetTableMetadata(ctx, "AwsDataCatalog", "sampledb", "elb_logs")
+	assert.Equal(t, 2, client.callCount("GetTableMetadata"))
+
+	_, err = NewMetadata(nil, 0).ListDataCatalogs(ctx)
+	assert.Equal(t, ErrAthenaNilClient, err)
+}
+
+func TestSQLConnector_Metadata(t *testing.T) {
+	c := NoopsSQLConnector()
+	m, err := c.Metadata(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 10*time.Minute, m.ttl)
+	m2, err := c.Metadata(context.Background())
+	assert.Nil(t, err)
+	assert.Same(t, m, m2)
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	"github.com/aws/smithy-go/middleware"
+	"go.uber.org/zap"
+)
+
+// APIMiddleware customizes the smithy-go middleware stack of the Athena API calls, e.g. to add headers, tweak
+// request signing or log requests. It is called for every API call, and it is an aws.Config APIOptions function.
+type APIMiddleware func(stack *middleware.Stack) error
+
+var (
+	apiMiddlewaresMu sync.RWMutex
+	apiMiddlewares   = map[string]APIMiddleware{}
+)
+
+// RegisterAPIMiddleware is to install an APIMiddleware under a name, so the Athena clients created for the
+// connections whose Config.SetAPIMiddlewares names it have it.
+func RegisterAPIMiddleware(name string, m APIMiddleware) {
+	apiMiddlewaresMu.Lock()
+	defer apiMiddlewaresMu.Unlock()
+	apiMiddlewares[name] = m
+}
+
+// UnregisterAPIMiddleware is to remove the APIMiddleware installed under a name.
+func UnregisterAPIMiddleware(name string) {
+	apiMiddlewaresMu.Lock()
+	defer apiMiddlewaresMu.Unlock()
+	delete(apiMiddlewares, name)
+}
+
+func getAPIMiddleware(name string) (APIMiddleware, bool) {
+	apiMiddlewaresMu.RLock()
+	defer apiMiddlewaresMu.RUnlock()
+	m, ok := apiMiddlewares[name]
+	return m, ok
+}
+
+// withAPIMiddlewares is to add the APIMiddlewares named in the config to the options of an Athena client, in the
+// order they are named. Names which are not registered are skipped.
+func (c *SQLConnector) withAPIMiddlewares(o *athena.Options) {
+	for _, name := range c.config.GetAPIMiddlewares() {
+		if m, ok := getAPIMiddleware(name); ok {
+			o.APIOptions = append(o.APIOptions, m)
+		} else {
+			c.tracer.Log(WarnLevel, "API middleware is not registered", zap.String("name", name))
+		}
+	}
+}
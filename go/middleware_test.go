@@ -0,0 +1,67 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLConnector_APIMiddlewares(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		_, _ = w.Write([]byte(`{"WorkGroups":[]}`))
+	}))
+	defer server.Close()
+
+	RegisterAPIMiddleware("TestSQLConnector_APIMiddlewares", func(stack *middleware.Stack) error {
+		return stack.Build.Add(middleware.BuildMiddlewareFunc("TeamHeader",
+			func(ctx context.Context, in middleware.BuildInput, next middleware.BuildHandler) (
+				middleware.BuildOutput, middleware.Metadata, error) {
+				if req, ok := in.Request.(*smithyhttp.Request); ok {
+					req.Header.Set("X-Team", "analytics")
+				}
+				return next.HandleBuild(ctx, in)
+			}), middleware.After)
+	})
+	defer UnregisterAPIMiddleware("TestSQLConnector_APIMiddlewares")
+
+	testConf, err := NewEmulatorConfig(server.URL, "s3://results/")
+	assert.Nil(t, err)
+	testConf.SetAPIMiddlewares("TestSQLConnector_APIMiddlewares", "unknown")
+	connector := &SQLConnector{config: testConf, tracer: NewDefaultObservability(testConf)}
+	awsCfg, err := connector.awsConfig(context.Background())
+	assert.Nil(t, err)
+	client := connector.newAthenaClient(awsCfg)
+	_, err = client.ListWorkGroups(context.Background(), &athena.ListWorkGroupsInput{MaxResults: aws.Int32(1)})
+	assert.Nil(t, err)
+	assert.Equal(t, "analytics", (<-headers).Get("X-Team"))
+}
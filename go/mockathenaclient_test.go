@@ -25,9 +25,14 @@ import (
 	"fmt"
 	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/aws/smithy-go"
 	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/athena"
 )
 
@@ -38,11 +43,61 @@ type mockAthenaClient struct {
 	// queryToResultsGenMap is a map from string to a function type genQueryResultsOutputByToken.
 	queryToResultsGenMap map[string]genQueryResultsOutputByToken
 
+	// queryExecutions are returned by ListQueryExecutions and BatchGetQueryExecution, newest first.
+	queryExecutions []athenatypes.QueryExecution
+
+	// capacityReservations and capacityAssignments back the capacity reservation APIs, keyed by reservation name.
+	capacityReservations map[string]athenatypes.CapacityReservation
+	capacityAssignments  map[string][]athenatypes.CapacityAssignment
+
+	// sessionStates and calculationStates are returned in order by the Spark status APIs.
+	sessionStates     []athenatypes.SessionState
+	calculationStates []athenatypes.CalculationExecutionState
+
+	// preparedStatements are the server-side prepared statements, keyed by workgroup and statement name.
+	preparedStatements map[string]map[string]athenatypes.PreparedStatement
+
+	// tableMetadata backs the data catalog APIs, keyed by catalog and database name like AwsDataCatalog.sampledb.
+	tableMetadata map[string][]athenatypes.TableMetadata
+
+	// calls records the names of the query execution APIs called, in order.
+	callsMu sync.Mutex
+	calls   []string
+	// lastStartInput is the input of the last StartQueryExecution call.
+	lastStartInput *athena.StartQueryExecutionInput
+
+	// workgroups are returned by ListWorkGroups, a page per workgroup, and wgTags back the tag APIs, keyed by ARN.
+	workgroups    []athenatypes.WorkGroupSummary
+	wgTags        map[string][]athenatypes.Tag
+	lastWGUpdate  *athena.UpdateWorkGroupInput
+	lastWGDeleted *athena.DeleteWorkGroupInput
+
+	// flakyFailures is how many executions of "SELECT flaky" fail with a retryable error before one succeeds.
+	flakyFailures int
+
 	CreateWGStatus bool
 	GetWGStatus    bool
 	WGDisabled     bool
 }
 
+func (m *mockAthenaClient) record(api string) {
+	m.callsMu.Lock()
+	defer m.callsMu.Unlock()
+	m.calls = append(m.calls, api)
+}
+
+func (m *mockAthenaClient) callCount(api string) int {
+	m.callsMu.Lock()
+	defer m.callsMu.Unlock()
+	n := 0
+	for _, call := range m.calls {
+		if call == api {
+			n++
+		}
+	}
+	return n
+}
+
 func newMockAthenaClient() *mockAthenaClient {
 	var m = mockAthenaClient{
 		queryToResultsGenMap: map[string]genQueryResultsOutputByToken{
@@ -63,6 +118,9 @@ func newMockAthenaClient() *mockAthenaClient {
 			"00000000-0000-0000-0000-000000000000": PingResponse,
 			"pc:get_query_id":                      PingResponse,
 			"FAILED_AFTER_GETQID":                  MissingDataResponse,
+			"INSERT_QID":                           OneColumnZeroRowResponse,
+			"EXPLAIN_QID":                          OneColumnZeroRowResponse,
+			"SCHEMA_QID":                           SchemaResponse,
 		},
 	}
 	return &m
@@ -70,6 +128,7 @@ func newMockAthenaClient() *mockAthenaClient {
 
 // GetQueryResults is a mock against athena.Client.GetQueryResults().
 func (m *mockAthenaClient) GetQueryResults(_ context.Context, query *athena.GetQueryResultsInput, _ ...func(*athena.Options)) (*athena.GetQueryResultsOutput, error) {
+	m.record("GetQueryResults")
 	var nextToken = ""
 	if query.NextToken != nil {
 		nextToken = *query.NextToken
@@ -80,6 +139,9 @@ func (m *mockAthenaClient) GetQueryResults(_ context.Context, query *athena.GetQ
 	if nextToken == "GetQueryResultsWithContext_return_error" {
 		return nil, ErrTestMockGeneric
 	}
+	if strings.HasPrefix(*query.QueryExecutionId, "SELECT_FLAKY_QID_") {
+		return OneColumnZeroRowResponseValid(nextToken)
+	}
 	return m.queryToResultsGenMap[*query.QueryExecutionId](nextToken)
 }
 
@@ -108,7 +170,99 @@ func (m *mockAthenaClient) GetWorkGroup(_ context.Context, _ *athena.GetWorkGrou
 	return nil, ErrTestMockGeneric
 }
 
+func (m *mockAthenaClient) UpdateWorkGroup(_ context.Context, input *athena.UpdateWorkGroupInput, _ ...func(*athena.Options)) (*athena.UpdateWorkGroupOutput, error) {
+	m.lastWGUpdate = input
+	return &athena.UpdateWorkGroupOutput{}, nil
+}
+
+func (m *mockAthenaClient) DeleteWorkGroup(_ context.Context, input *athena.DeleteWorkGroupInput, _ ...func(*athena.Options)) (*athena.DeleteWorkGroupOutput, error) {
+	m.lastWGDeleted = input
+	return &athena.DeleteWorkGroupOutput{}, nil
+}
+
+func (m *mockAthenaClient) ListWorkGroups(_ context.Context, input *athena.ListWorkGroupsInput, _ ...func(*athena.Options)) (*athena.ListWorkGroupsOutput, error) {
+	i := 0
+	if input.NextToken != nil {
+		i, _ = strconv.Atoi(*input.NextToken)
+	}
+	if i >= len(m.workgroups) {
+		return &athena.ListWorkGroupsOutput{}, nil
+	}
+	out := &athena.ListWorkGroupsOutput{WorkGroups: m.workgroups[i : i+1]}
+	if i+1 < len(m.workgroups) {
+		out.NextToken = aws.String(strconv.Itoa(i + 1))
+	}
+	return out, nil
+}
+
+func (m *mockAthenaClient) TagResource(_ context.Context, input *athena.TagResourceInput, _ ...func(*athena.Options)) (*athena.TagResourceOutput, error) {
+	if m.wgTags == nil {
+		m.wgTags = map[string][]athenatypes.Tag{}
+	}
+	arn := *input.ResourceARN
+	for _, tag := range input.Tags {
+		replaced := false
+		for i, existing := range m.wgTags[arn] {
+			if *existing.Key == *tag.Key {
+				m.wgTags[arn][i] = tag
+				replaced = true
+			}
+		}
+		if !replaced {
+			m.wgTags[arn] = append(m.wgTags[arn], tag)
+		}
+	}
+	return &athena.TagResourceOutput{}, nil
+}
+
+func (m *mockAthenaClient) UntagResource(_ context.Context, input *athena.UntagResourceInput, _ ...func(*athena.Options)) (*athena.UntagResourceOutput, error) {
+	arn := *input.ResourceARN
+	var kept []athenatypes.Tag
+	for _, tag := range m.wgTags[arn] {
+		removed := false
+		for _, key := range input.TagKeys {
+			removed = removed || *tag.Key == key
+		}
+		if !removed {
+			kept = append(kept, tag)
+		}
+	}
+	m.wgTags[arn] = kept
+	return &athena.UntagResourceOutput{}, nil
+}
+
+func (m *mockAthenaClient) ListTagsForResource(_ context.Context, input *athena.ListTagsForResourceInput, _ ...func(*athena.Options)) (*athena.ListTagsForResourceOutput, error) {
+	if m.wgTags == nil {
+		return nil, ErrTestMockGeneric
+	}
+	return &athena.ListTagsForResourceOutput{Tags: m.wgTags[*input.ResourceARN]}, nil
+}
+
 func (m *mockAthenaClient) StartQueryExecution(_ context.Context, s *athena.StartQueryExecutionInput, _ ...func(options *athena.Options)) (*athena.StartQueryExecutionOutput, error) {
+	m.record("StartQueryExecution")
+	m.callsMu.Lock()
+	m.lastStartInput = s
+	m.callsMu.Unlock()
+	// The queries are matched without their annotation comment.
+	if i := strings.Index(*s.QueryString, " */ "); strings.HasPrefix(*s.QueryString, "/* ") && i > 0 {
+		unannotated := *s
+		unannotated.QueryString = aws.String((*s.QueryString)[i+4:])
+		s = &unannotated
+	}
+	if *s.QueryString == "SELECT expired_token" {
+		return nil, &smithy.GenericAPIError{Code: "ExpiredTokenException",
+			Message: "The security token included in the request is expired"}
+	}
+	if *s.QueryString == "SELECT flaky" {
+		return &athena.StartQueryExecutionOutput{
+			QueryExecutionId: aws.String(fmt.Sprintf("SELECT_FLAKY_QID_%d", m.callCount("StartQueryExecution"))),
+		}, nil
+	}
+	if *s.QueryString == "SELECTQueryContext_FEDERATED_FAIL" {
+		return &athena.StartQueryExecutionOutput{
+			QueryExecutionId: aws.String("SELECTQueryContext_FEDERATED_FAIL_QID"),
+		}, nil
+	}
 	if strings.ToLower(*s.QueryString) == "select 1" { // Ping
 		qid := "PING_OK_QID"
 		return &athena.StartQueryExecutionOutput{
@@ -153,6 +307,12 @@ func (m *mockAthenaClient) StartQueryExecution(_ context.Context, s *athena.Star
 			QueryExecutionId: &qid,
 		}, nil
 	}
+	if *s.QueryString == "SELECTQueryContext_RUNNING" {
+		qid := "SELECTQueryContext_RUNNING_QID"
+		return &athena.StartQueryExecutionOutput{
+			QueryExecutionId: &qid,
+		}, nil
+	}
 	if *s.QueryString == "SELECTQueryContext_TIMEOUT" { // Ping
 		qid := "SELECTQueryContext_TIMEOUT_QID"
 		return &athena.StartQueryExecutionOutput{
@@ -189,21 +349,62 @@ func (m *mockAthenaClient) StartQueryExecution(_ context.Context, s *athena.Star
 	if *s.QueryString == "FAILED_AFTER_GETQID2" {
 		qid := "FAILED_AFTER_GETQID_123"
 		smithyErr := &smithyhttp.ResponseError{Err: fmt.Errorf("FAILED_AFTER_GETQID_FAILED")}
-		awsErr := &awshttp.ResponseError{smithyErr, "unk"}
+		awsErr := &awshttp.ResponseError{ResponseError: smithyErr, RequestID: "unk"}
 		return &athena.StartQueryExecutionOutput{
 			QueryExecutionId: &qid,
 		}, awsErr
 	}
+	if strings.HasPrefix(*s.QueryString, "EXPLAIN ") {
+		return &athena.StartQueryExecutionOutput{
+			QueryExecutionId: aws.String("EXPLAIN_QID"),
+		}, nil
+	}
+	if strings.HasSuffix(*s.QueryString, "\n) LIMIT 0") {
+		return &athena.StartQueryExecutionOutput{
+			QueryExecutionId: aws.String("SCHEMA_QID"),
+		}, nil
+	}
+	if *s.QueryString == "INSERT INTO t SELECT * FROM s" {
+		return &athena.StartQueryExecutionOutput{
+			QueryExecutionId: aws.String("INSERT_QID"),
+		}, nil
+	}
+	if *s.QueryString == "DELETE FROM t WHERE broken" {
+		return &athena.StartQueryExecutionOutput{
+			QueryExecutionId: aws.String("SELECTQueryContext_AWS_FAIL_QID"),
+		}, nil
+	}
 	return nil, nil
 }
 
 func (m *mockAthenaClient) GetQueryExecution(_ context.Context, input *athena.GetQueryExecutionInput, _ ...func(*athena.Options)) (*athena.GetQueryExecutionOutput, error) {
+	m.record("GetQueryExecution")
 	if *input.QueryExecutionId == "When_StartQueryExecution_Succeed_but_GetQueryExecutionWithContext_return_nil_and_error_QID" {
 		return nil, ErrTestMockGeneric
 	}
 	if *input.QueryExecutionId == "QueryExecutionStateCancelled_QID" {
 		return nil, context.Canceled
 	}
+	if n, ok := strings.CutPrefix(*input.QueryExecutionId, "SELECT_FLAKY_QID_"); ok {
+		status := &athenatypes.QueryExecutionStatus{State: athenatypes.QueryExecutionStateSucceeded}
+		if attempt, _ := strconv.Atoi(n); attempt <= m.flakyFailures {
+			status = &athenatypes.QueryExecutionStatus{
+				State:             athenatypes.QueryExecutionStateFailed,
+				StateChangeReason: aws.String("HIVE_CURSOR_ERROR: Please reduce your request rate."),
+				AthenaError: &athenatypes.AthenaError{
+					ErrorCategory: aws.Int32(1),
+					Retryable:     true,
+				},
+			}
+		}
+		return &athena.GetQueryExecutionOutput{
+			QueryExecution: &athenatypes.QueryExecution{
+				QueryExecutionId: input.QueryExecutionId,
+				Status:           status,
+				StatementType:    athenatypes.StatementTypeDml,
+			},
+		}, nil
+	}
 	if *input.QueryExecutionId == "QueryExecutionStateFailed_QID" {
 		return nil, ErrTestMockFailedByAthena
 	}
@@ -248,6 +449,9 @@ func (m *mockAthenaClient) GetQueryExecution(_ context.Context, input *athena.Ge
 					State: stat,
 				},
 				StatementType: athenatypes.StatementTypeDdl,
+				ResultConfiguration: &athenatypes.ResultConfiguration{
+					OutputLocation: aws.String("s3://results/SELECTQueryContext_OK_QID.csv"),
+				},
 			},
 		}, nil
 	}
@@ -286,6 +490,21 @@ func (m *mockAthenaClient) GetQueryExecution(_ context.Context, input *athena.Ge
 			},
 		}, nil
 	}
+	if *input.QueryExecutionId == "SELECTQueryContext_FEDERATED_FAIL_QID" {
+		return &athena.GetQueryExecutionOutput{
+			QueryExecution: &athenatypes.QueryExecution{
+				QueryExecutionId:      input.QueryExecutionId,
+				QueryExecutionContext: &athenatypes.QueryExecutionContext{Catalog: aws.String("dynamo")},
+				Status: &athenatypes.QueryExecutionStatus{
+					State: athenatypes.QueryExecutionStateFailed,
+					StateChangeReason: aws.String("GENERIC_USER_ERROR: Encountered an exception" +
+						"[java.lang.RuntimeException] from your LambdaFunction" +
+						"[arn:aws:lambda:us-east-1:123456789012:function:dynamo] executed in context" +
+						"[retrieving meta-data] with message[Requested resource not found]"),
+				},
+			},
+		}, nil
+	}
 	if *input.QueryExecutionId == "SELECTQueryContext_AWS_FAIL_QID" {
 		ping := "SELECTQueryContext_AWS_FAIL_QID"
 		stat := athenatypes.QueryExecutionStateFailed
@@ -314,6 +533,21 @@ func (m *mockAthenaClient) GetQueryExecution(_ context.Context, input *athena.Ge
 			},
 		}, nil
 	}
+	if *input.QueryExecutionId == "SELECTQueryContext_RUNNING_QID" {
+		return &athena.GetQueryExecutionOutput{
+			QueryExecution: &athenatypes.QueryExecution{
+				QueryExecutionId: input.QueryExecutionId,
+				Status: &athenatypes.QueryExecutionStatus{
+					State: athenatypes.QueryExecutionStateRunning,
+				},
+				Statistics: &athenatypes.QueryExecutionStatistics{
+					DataScannedInBytes:          aws.Int64(1024),
+					EngineExecutionTimeInMillis: aws.Int64(1500),
+				},
+				StatementType: athenatypes.StatementTypeDml,
+			},
+		}, nil
+	}
 	if *input.QueryExecutionId == "SELECTQueryContext_TIMEOUT_QID" {
 		ping := "SELECTQueryContext_TIMEOUT_QID"
 		stat := athenatypes.QueryExecutionStateQueued
@@ -348,12 +582,323 @@ func (m *mockAthenaClient) GetQueryExecution(_ context.Context, input *athena.Ge
 			},
 		}, nil
 	}
+	if *input.QueryExecutionId == "EXPLAIN_QID" {
+		return &athena.GetQueryExecutionOutput{
+			QueryExecution: &athenatypes.QueryExecution{
+				QueryExecutionId: aws.String("EXPLAIN_QID"),
+				Status: &athenatypes.QueryExecutionStatus{
+					State: athenatypes.QueryExecutionStateSucceeded,
+				},
+				StatementType: athenatypes.StatementTypeUtility,
+			},
+		}, nil
+	}
+	if *input.QueryExecutionId == "SCHEMA_QID" {
+		return &athena.GetQueryExecutionOutput{
+			QueryExecution: &athenatypes.QueryExecution{
+				QueryExecutionId: aws.String("SCHEMA_QID"),
+				Status: &athenatypes.QueryExecutionStatus{
+					State: athenatypes.QueryExecutionStateSucceeded,
+				},
+				StatementType: athenatypes.StatementTypeDml,
+			},
+		}, nil
+	}
+	if *input.QueryExecutionId == "INSERT_QID" {
+		return &athena.GetQueryExecutionOutput{
+			QueryExecution: &athenatypes.QueryExecution{
+				QueryExecutionId: aws.String("INSERT_QID"),
+				Status: &athenatypes.QueryExecutionStatus{
+					State: athenatypes.QueryExecutionStateSucceeded,
+				},
+				StatementType: athenatypes.StatementTypeDml,
+			},
+		}, nil
+	}
+	return nil, ErrTestMockGeneric
+}
+
+// GetQueryRuntimeStatistics is a mock against athena.Client.GetQueryRuntimeStatistics().
+func (m *mockAthenaClient) GetQueryRuntimeStatistics(_ context.Context, input *athena.GetQueryRuntimeStatisticsInput,
+	_ ...func(*athena.Options)) (*athena.GetQueryRuntimeStatisticsOutput, error) {
+	m.record("GetQueryRuntimeStatistics")
+	if *input.QueryExecutionId == "INSERT_QID" {
+		return &athena.GetQueryRuntimeStatisticsOutput{
+			QueryRuntimeStatistics: &athenatypes.QueryRuntimeStatistics{
+				Rows: &athenatypes.QueryRuntimeStatisticsRows{OutputRows: aws.Int64(42)},
+			},
+		}, nil
+	}
 	return nil, ErrTestMockGeneric
 }
 
+func (m *mockAthenaClient) ListQueryExecutions(_ context.Context, input *athena.ListQueryExecutionsInput,
+	_ ...func(*athena.Options)) (*athena.ListQueryExecutionsOutput, error) {
+	if input.WorkGroup != nil && *input.WorkGroup == "ListQueryExecutions_return_error" {
+		return nil, ErrTestMockGeneric
+	}
+	start := 0
+	if input.NextToken != nil {
+		start, _ = strconv.Atoi(*input.NextToken)
+	}
+	pageSize := 50
+	if input.MaxResults != nil {
+		pageSize = int(*input.MaxResults)
+	}
+	out := &athena.ListQueryExecutionsOutput{}
+	for i := start; i < len(m.queryExecutions) && i < start+pageSize; i++ {
+		out.QueryExecutionIds = append(out.QueryExecutionIds, *m.queryExecutions[i].QueryExecutionId)
+	}
+	if start+pageSize < len(m.queryExecutions) {
+		out.NextToken = aws.String(strconv.Itoa(start + pageSize))
+	}
+	return out, nil
+}
+
+func (m *mockAthenaClient) BatchGetQueryExecution(_ context.Context, input *athena.BatchGetQueryExecutionInput,
+	_ ...func(*athena.Options)) (*athena.BatchGetQueryExecutionOutput, error) {
+	out := &athena.BatchGetQueryExecutionOutput{}
+	for _, id := range input.QueryExecutionIds {
+		for _, qe := range m.queryExecutions {
+			if *qe.QueryExecutionId == id {
+				out.QueryExecutions = append(out.QueryExecutions, qe)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (m *mockAthenaClient) CreateCapacityReservation(_ context.Context, input *athena.CreateCapacityReservationInput,
+	_ ...func(*athena.Options)) (*athena.CreateCapacityReservationOutput, error) {
+	if m.capacityReservations == nil {
+		m.capacityReservations = map[string]athenatypes.CapacityReservation{}
+	}
+	if _, ok := m.capacityReservations[*input.Name]; ok {
+		return nil, ErrTestMockGeneric
+	}
+	m.capacityReservations[*input.Name] = athenatypes.CapacityReservation{
+		Name:       input.Name,
+		TargetDpus: input.TargetDpus,
+		Status:     athenatypes.CapacityReservationStatusPending,
+	}
+	return &athena.CreateCapacityReservationOutput{}, nil
+}
+
+func (m *mockAthenaClient) GetCapacityReservation(_ context.Context, input *athena.GetCapacityReservationInput,
+	_ ...func(*athena.Options)) (*athena.GetCapacityReservationOutput, error) {
+	m.record("GetCapacityReservation")
+	r, ok := m.capacityReservations[*input.Name]
+	if !ok {
+		return nil, ErrTestMockGeneric
+	}
+	return &athena.GetCapacityReservationOutput{CapacityReservation: &r}, nil
+}
+
+func (m *mockAthenaClient) ListDataCatalogs(_ context.Context, _ *athena.ListDataCatalogsInput,
+	_ ...func(*athena.Options)) (*athena.ListDataCatalogsOutput, error) {
+	m.record("ListDataCatalogs")
+	return &athena.ListDataCatalogsOutput{
+		DataCatalogsSummary: []athenatypes.DataCatalogSummary{{
+			CatalogName: aws.String("AwsDataCatalog"),
+			Type:        athenatypes.DataCatalogTypeGlue,
+		}},
+	}, nil
+}
+
+// ListDatabases returns a database per page, to exercise pagination.
+func (m *mockAthenaClient) ListDatabases(_ context.Context, input *athena.ListDatabasesInput,
+	_ ...func(*athena.Options)) (*athena.ListDatabasesOutput, error) {
+	m.record("ListDatabases")
+	var names []string
+	for key := range m.tableMetadata {
+		if catalog, db, _ := strings.Cut(key, "."); catalog == *input.CatalogName {
+			names = append(names, db)
+		}
+	}
+	sort.Strings(names)
+	i := 0
+	if input.NextToken != nil {
+		i, _ = strconv.Atoi(*input.NextToken)
+	}
+	out := &athena.ListDatabasesOutput{}
+	if i < len(names) {
+		out.DatabaseList = []athenatypes.Database{{Name: aws.String(names[i])}}
+	}
+	if i+1 < len(names) {
+		out.NextToken = aws.String(strconv.Itoa(i + 1))
+	}
+	return out, nil
+}
+
+func (m *mockAthenaClient) ListTableMetadata(_ context.Context, input *athena.ListTableMetadataInput,
+	_ ...func(*athena.Options)) (*athena.ListTableMetadataOutput, error) {
+	m.record("ListTableMetadata")
+	tables, ok := m.tableMetadata[*input.CatalogName+"."+*input.DatabaseName]
+	if !ok {
+		return nil, ErrTestMockGeneric
+	}
+	return &athena.ListTableMetadataOutput{TableMetadataList: tables}, nil
+}
+
+func (m *mockAthenaClient) GetTableMetadata(_ context.Context, input *athena.GetTableMetadataInput,
+	_ ...func(*athena.Options)) (*athena.GetTableMetadataOutput, error) {
+	m.record("GetTableMetadata")
+	for _, table := range m.tableMetadata[*input.CatalogName+"."+*input.DatabaseName] {
+		if *table.Name == *input.TableName {
+			table := table
+			return &athena.GetTableMetadataOutput{TableMetadata: &table}, nil
+		}
+	}
+	return nil, ErrTestMockGeneric
+}
+
+func (m *mockAthenaClient) ListCapacityReservations(_ context.Context, _ *athena.ListCapacityReservationsInput,
+	_ ...func(*athena.Options)) (*athena.ListCapacityReservationsOutput, error) {
+	out := &athena.ListCapacityReservationsOutput{}
+	for _, r := range m.capacityReservations {
+		out.CapacityReservations = append(out.CapacityReservations, r)
+	}
+	return out, nil
+}
+
+func (m *mockAthenaClient) GetCapacityAssignmentConfiguration(_ context.Context,
+	input *athena.GetCapacityAssignmentConfigurationInput,
+	_ ...func(*athena.Options)) (*athena.GetCapacityAssignmentConfigurationOutput, error) {
+	if _, ok := m.capacityReservations[*input.CapacityReservationName]; !ok {
+		return nil, ErrTestMockGeneric
+	}
+	return &athena.GetCapacityAssignmentConfigurationOutput{
+		CapacityAssignmentConfiguration: &athenatypes.CapacityAssignmentConfiguration{
+			CapacityReservationName: input.CapacityReservationName,
+			CapacityAssignments:     m.capacityAssignments[*input.CapacityReservationName],
+		},
+	}, nil
+}
+
+func (m *mockAthenaClient) PutCapacityAssignmentConfiguration(_ context.Context,
+	input *athena.PutCapacityAssignmentConfigurationInput,
+	_ ...func(*athena.Options)) (*athena.PutCapacityAssignmentConfigurationOutput, error) {
+	if m.capacityAssignments == nil {
+		m.capacityAssignments = map[string][]athenatypes.CapacityAssignment{}
+	}
+	m.capacityAssignments[*input.CapacityReservationName] = input.CapacityAssignments
+	return &athena.PutCapacityAssignmentConfigurationOutput{}, nil
+}
+
+func (m *mockAthenaClient) StartSession(_ context.Context, input *athena.StartSessionInput,
+	_ ...func(*athena.Options)) (*athena.StartSessionOutput, error) {
+	if *input.WorkGroup == "StartSession_return_error" {
+		return nil, ErrTestMockGeneric
+	}
+	return &athena.StartSessionOutput{SessionId: aws.String("session_1"), State: athenatypes.SessionStateCreating}, nil
+}
+
+func (m *mockAthenaClient) GetSessionStatus(_ context.Context, input *athena.GetSessionStatusInput,
+	_ ...func(*athena.Options)) (*athena.GetSessionStatusOutput, error) {
+	if len(m.sessionStates) == 0 {
+		return nil, ErrTestMockGeneric
+	}
+	state := m.sessionStates[0]
+	m.sessionStates = m.sessionStates[1:]
+	return &athena.GetSessionStatusOutput{
+		SessionId: input.SessionId,
+		Status: &athenatypes.SessionStatus{
+			State:             state,
+			StateChangeReason: aws.String("mock reason"),
+		},
+	}, nil
+}
+
+func (m *mockAthenaClient) TerminateSession(_ context.Context, input *athena.TerminateSessionInput,
+	_ ...func(*athena.Options)) (*athena.TerminateSessionOutput, error) {
+	if *input.SessionId != "session_1" {
+		return nil, ErrTestMockGeneric
+	}
+	return &athena.TerminateSessionOutput{State: athenatypes.SessionStateTerminating}, nil
+}
+
+func (m *mockAthenaClient) StartCalculationExecution(_ context.Context, input *athena.StartCalculationExecutionInput,
+	_ ...func(*athena.Options)) (*athena.StartCalculationExecutionOutput, error) {
+	if *input.SessionId != "session_1" {
+		return nil, ErrTestMockGeneric
+	}
+	return &athena.StartCalculationExecutionOutput{
+		CalculationExecutionId: aws.String("calculation_1"),
+		State:                  athenatypes.CalculationExecutionStateCreating,
+	}, nil
+}
+
+func (m *mockAthenaClient) GetCalculationExecution(_ context.Context, input *athena.GetCalculationExecutionInput,
+	_ ...func(*athena.Options)) (*athena.GetCalculationExecutionOutput, error) {
+	if len(m.calculationStates) == 0 {
+		return nil, ErrTestMockGeneric
+	}
+	state := m.calculationStates[0]
+	m.calculationStates = m.calculationStates[1:]
+	return &athena.GetCalculationExecutionOutput{
+		CalculationExecutionId: input.CalculationExecutionId,
+		SessionId:              aws.String("session_1"),
+		Status: &athenatypes.CalculationStatus{
+			State:             state,
+			StateChangeReason: aws.String("mock reason"),
+		},
+		Result: &athenatypes.CalculationResult{
+			ResultType:  aws.String("application/json"),
+			ResultS3Uri: aws.String("s3://bucket/calculation_1/result.json"),
+			StdOutS3Uri: aws.String("s3://bucket/calculation_1/stdout.txt"),
+		},
+		Statistics: &athenatypes.CalculationStatistics{
+			DpuExecutionInMillis: aws.Int64(1500),
+		},
+	}, nil
+}
+
+func (m *mockAthenaClient) ListPreparedStatements(_ context.Context, input *athena.ListPreparedStatementsInput,
+	_ ...func(*athena.Options)) (*athena.ListPreparedStatementsOutput, error) {
+	if *input.WorkGroup == "ListPreparedStatements_return_error" {
+		return nil, ErrTestMockGeneric
+	}
+	out := &athena.ListPreparedStatementsOutput{}
+	for _, ps := range m.preparedStatements[*input.WorkGroup] {
+		out.PreparedStatements = append(out.PreparedStatements, athenatypes.PreparedStatementSummary{
+			StatementName:    ps.StatementName,
+			LastModifiedTime: ps.LastModifiedTime,
+		})
+	}
+	return out, nil
+}
+
+func (m *mockAthenaClient) GetPreparedStatement(_ context.Context, input *athena.GetPreparedStatementInput,
+	_ ...func(*athena.Options)) (*athena.GetPreparedStatementOutput, error) {
+	ps, ok := m.preparedStatements[*input.WorkGroup][*input.StatementName]
+	if !ok {
+		return nil, ErrTestMockGeneric
+	}
+	return &athena.GetPreparedStatementOutput{PreparedStatement: &ps}, nil
+}
+
+func (m *mockAthenaClient) DeletePreparedStatement(_ context.Context, input *athena.DeletePreparedStatementInput,
+	_ ...func(*athena.Options)) (*athena.DeletePreparedStatementOutput, error) {
+	if _, ok := m.preparedStatements[*input.WorkGroup][*input.StatementName]; !ok {
+		return nil, ErrTestMockGeneric
+	}
+	delete(m.preparedStatements[*input.WorkGroup], *input.StatementName)
+	return &athena.DeletePreparedStatementOutput{}, nil
+}
+
 func (m *mockAthenaClient) StopQueryExecution(_ context.Context, input *athena.StopQueryExecutionInput,
 	_ ...func(*athena.Options)) (*athena.StopQueryExecutionOutput, error) {
-	if *input.QueryExecutionId == "SELECTQueryContext_CANCEL_OK_QID" {
+	m.record("StopQueryExecution")
+	for _, qe := range m.queryExecutions {
+		if *qe.QueryExecutionId == *input.QueryExecutionId && strings.HasPrefix(*qe.QueryExecutionId, "finished_") {
+			return nil, &athenatypes.InvalidRequestException{Message: aws.String("query has already finished")}
+		}
+		if *qe.QueryExecutionId == *input.QueryExecutionId {
+			return &athena.StopQueryExecutionOutput{}, nil
+		}
+	}
+	if *input.QueryExecutionId == "SELECTQueryContext_CANCEL_OK_QID" ||
+		*input.QueryExecutionId == "SELECTQueryContext_RUNNING_QID" {
 		return &athena.StopQueryExecutionOutput{}, nil
 	}
 	if *input.QueryExecutionId == "SELECTQueryContext_CANCEL_FAIL_QID" {
@@ -597,6 +1142,27 @@ func headPageWithColumnButNoRowResponse(token string) (*athena.GetQueryResultsOu
 	}
 }
 
+// SchemaResponse is the result of a query with a LIMIT 0, which only has the header row.
+func SchemaResponse(token string) (*athena.GetQueryResultsOutput, error) {
+	if token != "" {
+		return nil, ErrTestMockGeneric
+	}
+	id := newColumnInfo("id", "bigint")
+	id.Nullable = athenatypes.ColumnNullableNotNull
+	price := newColumnInfo("price", "decimal")
+	price.Precision, price.Scale = 10, 2
+	price.Nullable = athenatypes.ColumnNullableNullable
+	name := newColumnInfo("name", "varchar")
+	name.Nullable = athenatypes.ColumnNullableUnknown
+	columns := []athenatypes.ColumnInfo{id, price, name}
+	return &athena.GetQueryResultsOutput{
+		ResultSet: &athenatypes.ResultSet{
+			ResultSetMetadata: &athenatypes.ResultSetMetadata{ColumnInfo: columns},
+			Rows:              []athenatypes.Row{genHeaderRow(columns)},
+		},
+	}, nil
+}
+
 func PingResponse(token string) (*athena.GetQueryResultsOutput,
 	error) {
 	switch token {
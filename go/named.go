@@ -0,0 +1,104 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// bindNamedParams is to rewrite the `:name` and `@name` placeholders of a query passed with sql.Named arguments into
+// `?` placeholders, and to return the arguments in the order of the placeholders, which become the ExecutionParameters.
+// A name used more than once is bound once per placeholder. The query and the arguments are returned as is if no
+// argument is named.
+func bindNamedParams(query string, namedArgs []driver.NamedValue) (string, []driver.NamedValue, error) {
+	values := make(map[string]driver.Value, len(namedArgs))
+	for _, arg := range namedArgs {
+		if arg.Name != "" {
+			values[arg.Name] = arg.Value
+		}
+	}
+	if len(values) == 0 {
+		return query, namedArgs, nil
+	}
+	if len(values) != len(namedArgs) {
+		return "", nil, fmt.Errorf("%w: named and positional arguments cannot be mixed", ErrInvalidQuery)
+	}
+
+	var bound []byte
+	var args []driver.NamedValue
+	used := make(map[string]bool, len(values))
+	last := 0
+	for _, p := range namedPlaceholders(query) {
+		name := query[p.start+1 : p.end]
+		value, ok := values[name]
+		if !ok {
+			return "", nil, fmt.Errorf("%w: no argument named %q", ErrInvalidQuery, name)
+		}
+		used[name] = true
+		bound = append(append(bound, query[last:p.start]...), '?')
+		last = p.end
+		args = append(args, driver.NamedValue{Ordinal: len(args) + 1, Value: value})
+	}
+	for name := range values {
+		if !used[name] {
+			return "", nil, fmt.Errorf("%w: argument %q is not used", ErrInvalidQuery, name)
+		}
+	}
+	return string(append(bound, query[last:]...)), args, nil
+}
+
+// placeholder is where a named placeholder, including its `:` or `@`, is in a query.
+type placeholder struct {
+	start int
+	end   int
+}
+
+// namedPlaceholders is to find the `:name` and `@name` placeholders in a query, skipping string literals, quoted
+// identifiers and comments.
+func namedPlaceholders(query string) []placeholder {
+	var placeholders []placeholder
+	t := sqlTokenizer{query: query}
+	for {
+		start, end := t.scan()
+		if start == end {
+			return placeholders
+		}
+		if c := query[start]; (c == ':' || c == '@') && end < len(query) && isNameStart(query[end]) &&
+			(start == 0 || query[start-1] != ':') {
+			_, end = t.scan()
+			placeholders = append(placeholders, placeholder{start: start, end: end})
+		}
+	}
+}
+
+// numInput is to get the number of arguments a query takes, or -1 if it has named placeholders, whose arguments
+// can be repeated.
+func numInput(query string) int {
+	if len(namedPlaceholders(query)) > 0 {
+		return -1
+	}
+	return countPlaceholders(query)
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBindNamedParams(t *testing.T) {
+	query, args, err := bindNamedParams("SELECT * FROM t WHERE a = :a OR b = @b OR c = :a AND d = 'x:a' -- :a\n"+
+		"AND e = CAST('1' AS VARCHAR)", []driver.NamedValue{
+		{Name: "b", Ordinal: 1, Value: "y"},
+		{Name: "a", Ordinal: 2, Value: int64(1)},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM t WHERE a = ? OR b = ? OR c = ? AND d = 'x:a' -- :a\n"+
+		"AND e = CAST('1' AS VARCHAR)", query)
+	assert.Equal(t, []driver.NamedValue{
+		{Ordinal: 1, Value: int64(1)},
+		{Ordinal: 2, Value: "y"},
+		{Ordinal: 3, Value: int64(1)},
+	}, args)
+
+	// positional arguments are left alone
+	positional := []driver.NamedValue{{Ordinal: 1, Value: int64(1)}}
+	query, args, err = bindNamedParams("SELECT ?", positional)
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT ?", query)
+	assert.Equal(t, positional, args)
+
+	for _, tc := range []struct {
+		query string
+		args  []driver.NamedValue
+	}{
+		{"SELECT :a, ?", []driver.NamedValue{{Name: "a", Ordinal: 1}, {Ordinal: 2}}},
+		{"SELECT :a, :b", []driver.NamedValue{{Name: "a", Ordinal: 1}}},
+		{"SELECT :a", []driver.NamedValue{{Name: "a", Ordinal: 1}, {Name: "b", Ordinal: 2}}},
+	} {
+		_, _, err = bindNamedParams(tc.query, tc.args)
+		assert.True(t, errors.Is(err, ErrInvalidQuery), tc.query)
+	}
+}
+
+func TestNumInput(t *testing.T) {
+	assert.Equal(t, 0, numInput("SELECT 1"))
+	assert.Equal(t, 2, numInput("SELECT ?, ?"))
+	assert.Equal(t, -1, numInput("SELECT :a, :a"))
+	assert.Equal(t, 0, numInput("SELECT ':a', '@b', x::y"))
+}
+
+func TestConnection_QueryContextNamed(t *testing.T) {
+	c := createConnectionFixture()
+	rows, err := c.QueryContext(context.Background(), "SELECTQueryContext_:p",
+		[]driver.NamedValue{{Name: "p", Ordinal: 1, Value: "OK"}})
+	assert.Nil(t, err)
+	assert.NotNil(t, rows)
+
+	stmt, err := c.Prepare("SELECTQueryContext_@p")
+	assert.Nil(t, err)
+	assert.Equal(t, -1, stmt.NumInput())
+	rows, err = stmt.(*Statement).QueryContext(context.Background(),
+		[]driver.NamedValue{{Name: "p", Ordinal: 1, Value: "OK"}})
+	assert.Nil(t, err)
+	assert.NotNil(t, rows)
+}
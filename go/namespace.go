@@ -0,0 +1,74 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+)
+
+// Namespace is a data catalog and a database in it, which unqualified table names in a query refer to.
+type Namespace struct {
+	// Catalog is the data catalog, e.g. a federated one. It is empty for the default AwsDataCatalog.
+	Catalog string
+	// Database is the database in the catalog.
+	Database string
+}
+
+// Table is to get the quoted name of a table in the namespace, e.g. "sales"."orders"."items" for the items table
+// of the orders database of the sales catalog, see QuoteQualifiedTable.
+func (n Namespace) Table(name string) string {
+	return QuoteQualifiedTable(n.Catalog, n.Database, name)
+}
+
+// Ident is to get the name of a table in the namespace as a Template value.
+func (n Namespace) Ident(name string) Ident {
+	return Ident{n.Catalog, n.Database, name}
+}
+
+// String is to get the database qualified with the catalog if it is set, unquoted.
+func (n Namespace) String() string {
+	if n.Catalog != "" {
+		return n.Catalog + "." + n.Database
+	}
+	return n.Database
+}
+
+// WithNamespace is to run the queries of the returned context in a catalog and database other than the ones of the
+// config, e.g. one of the namespaces got by Config.GetNamespace. An empty Catalog or Database keeps the one of the
+// config.
+func WithNamespace(ctx context.Context, n Namespace) context.Context {
+	return context.WithValue(ctx, NamespaceKey, n)
+}
+
+// NamespaceFromContext is to get the catalog and database the queries run with a context run in: the ones set by
+// WithNamespace, or else the ones of the config.
+func NamespaceFromContext(ctx context.Context, conf *Config) Namespace {
+	n := Namespace{Catalog: conf.GetCatalog(), Database: conf.GetDB()}
+	if override, ok := ctx.Value(NamespaceKey).(Namespace); ok {
+		if override.Catalog != "" {
+			n.Catalog = override.Catalog
+		}
+		if override.Database != "" {
+			n.Database = override.Database
+		}
+	}
+	return n
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespace(t *testing.T) {
+	n := Namespace{Catalog: "dynamo", Database: "orders"}
+	assert.Equal(t, `"dynamo"."orders"."items"`, n.Table("items"))
+	assert.Equal(t, Ident{"dynamo", "orders", "items"}, n.Ident("items"))
+	assert.Equal(t, "dynamo.orders", n.String())
+	assert.Equal(t, `"orders"."items"`, Namespace{Database: "orders"}.Table("items"))
+	assert.Equal(t, "orders", Namespace{Database: "orders"}.String())
+}
+
+func TestNamespaceFromContext(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	testConf.SetCatalog("dynamo")
+	ctx := context.Background()
+	assert.Equal(t, Namespace{Catalog: "dynamo", Database: DefaultDBName}, NamespaceFromContext(ctx, testConf))
+	assert.Equal(t, Namespace{Catalog: "dynamo", Database: "orders"},
+		NamespaceFromContext(WithNamespace(ctx, Namespace{Database: "orders"}), testConf))
+	assert.Equal(t, Namespace{Catalog: "rds", Database: "orders"},
+		NamespaceFromContext(WithNamespace(ctx, Namespace{Catalog: "rds", Database: "orders"}), testConf))
+}
+
+func TestConnection_WithNamespace(t *testing.T) {
+	c := createConnectionFixture()
+	m := c.athenaClient.(*mockAthenaClient)
+	ctx := WithNamespace(context.Background(), Namespace{Catalog: "dynamo", Database: "orders"})
+	_, err := c.QueryContext(ctx, "SELECTQueryContext_OK", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "dynamo", *m.lastStartInput.QueryExecutionContext.Catalog)
+	assert.Equal(t, "orders", *m.lastStartInput.QueryExecutionContext.Database)
+}
@@ -0,0 +1,269 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+var (
+	completionNotifiersMu sync.RWMutex
+	completionNotifiers   = map[string]CompletionNotifier{}
+)
+
+// CompletionNotifier is told when query executions reach a final state, so that connections whose
+// Config.SetCompletionNotifier names it wait for it instead of polling GetQueryExecution.
+type CompletionNotifier interface {
+	// Wait blocks until the query execution has reached a final state, or ctx is done.
+	Wait(ctx context.Context, queryID string) error
+}
+
+// RegisterCompletionNotifier is to install a CompletionNotifier under a name.
+func RegisterCompletionNotifier(name string, notifier CompletionNotifier) {
+	completionNotifiersMu.Lock()
+	defer completionNotifiersMu.Unlock()
+	completionNotifiers[name] = notifier
+}
+
+// UnregisterCompletionNotifier is to remove the CompletionNotifier installed under a name.
+func UnregisterCompletionNotifier(name string) {
+	completionNotifiersMu.Lock()
+	defer completionNotifiersMu.Unlock()
+	delete(completionNotifiers, name)
+}
+
+func getCompletionNotifier(name string) (CompletionNotifier, bool) {
+	completionNotifiersMu.RLock()
+	defer completionNotifiersMu.RUnlock()
+	notifier, ok := completionNotifiers[name]
+	return notifier, ok
+}
+
+// waitNextPoll returns a channel which is closed when the status of a query execution is to be checked again: after
+// pollInterval, or, with a completion notifier, when it tells the query execution is done. The fallback interval of
+// the config bounds the wait in case a notification is lost. cancel must be called once the channel is not used.
+func (c *Connection) waitNextPoll(ctx context.Context, queryID string, pollInterval time.Duration) (
+	<-chan struct{}, context.CancelFunc) {
+	ready := make(chan struct{})
+	var notifier CompletionNotifier
+	if name := c.connector.config.GetCompletionNotifier(); name != "" {
+		notifier, _ = getCompletionNotifier(name)
+	}
+	if notifier == nil {
+		timer := time.AfterFunc(pollInterval, func() { close(ready) })
+		return ready, func() { timer.Stop() }
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, c.connector.config.GetCompletionNotifierFallback())
+	go func() {
+		_ = notifier.Wait(waitCtx, queryID)
+		close(ready)
+	}()
+	return ready, cancel
+}
+
+// SQSAPI is the part of the SQS client used by SQSCompletionNotifier.
+type SQSAPI interface {
+	ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
+	DeleteMessageBatch(ctx context.Context, params *sqs.DeleteMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error)
+}
+
+// finishedTTL is how long a query execution is remembered as finished, for the waits starting after its
+// notification has been received.
+const finishedTTL = 10 * time.Minute
+
+// completionWaiters are the waits for query executions to finish, released by notify.
+type completionWaiters struct {
+	mu       sync.Mutex
+	waiters  map[string][]chan struct{}
+	finished map[string]time.Time
+}
+
+func newCompletionWaiters() *completionWaiters {
+	return &completionWaiters{
+		waiters:  map[string][]chan struct{}{},
+		finished: map[string]time.Time{},
+	}
+}
+
+// wait blocks until notify is called for a query execution, or ctx is done. onWait, if set, is called with the lock
+// held once the wait is registered.
+func (w *completionWaiters) wait(ctx context.Context, queryID string, onWait func()) error {
+	w.mu.Lock()
+	if _, ok := w.finished[queryID]; ok {
+		w.mu.Unlock()
+		return nil
+	}
+	done := make(chan struct{})
+	w.waiters[queryID] = append(w.waiters[queryID], done)
+	if onWait != nil {
+		onWait()
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		waiters := w.waiters[queryID]
+		for i, d := range waiters {
+			if d == done {
+				w.waiters[queryID] = append(waiters[:i], waiters[i+1:]...)
+				break
+			}
+		}
+		if len(w.waiters[queryID]) == 0 {
+			delete(w.waiters, queryID)
+		}
+		return ctx.Err()
+	}
+}
+
+// notify is to release the waits of a finished query execution.
+func (w *completionWaiters) notify(queryID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	now := time.Now()
+	for id, at := range w.finished {
+		if now.Sub(at) > finishedTTL {
+			delete(w.finished, id)
+		}
+	}
+	w.finished[queryID] = now
+	for _, done := range w.waiters[queryID] {
+		close(done)
+	}
+	delete(w.waiters, queryID)
+}
+
+// SQSCompletionNotifier is a CompletionNotifier receiving the Athena Query State Change events which an EventBridge
+// rule sends to an SQS queue, directly or through an SNS topic the queue is subscribed to. It deletes the events it
+// receives, so each process needs a queue of its own. Other messages are left in the queue.
+type SQSCompletionNotifier struct {
+	client   SQSAPI
+	queueURL string
+	waiters  *completionWaiters
+	// receiving is guarded by the lock of waiters.
+	receiving bool
+}
+
+// NewSQSCompletionNotifier is to create an SQSCompletionNotifier receiving the events of a queue.
+func NewSQSCompletionNotifier(client SQSAPI, queueURL string) *SQSCompletionNotifier {
+	return &SQSCompletionNotifier{
+		client:   client,
+		queueURL: queueURL,
+		waiters:  newCompletionWaiters(),
+	}
+}
+
+// Wait is to implement interface CompletionNotifier. The queue is only received from while there are waits.
+func (n *SQSCompletionNotifier) Wait(ctx context.Context, queryID string) error {
+	return n.waiters.wait(ctx, queryID, func() {
+		if !n.receiving {
+			n.receiving = true
+			go n.receive()
+		}
+	})
+}
+
+// receive is to receive the events of the queue until there is no wait left.
+func (n *SQSCompletionNotifier) receive() {
+	for {
+		n.waiters.mu.Lock()
+		if len(n.waiters.waiters) == 0 {
+			n.receiving = false
+			n.waiters.mu.Unlock()
+			return
+		}
+		n.waiters.mu.Unlock()
+
+		out, err := n.client.ReceiveMessage(context.Background(), &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(n.queueURL),
+			MaxNumberOfMessages: 10,
+			WaitTimeSeconds:     20,
+		})
+		if err != nil {
+			// the waits fall back to polling, so the queue is tried again a bit later
+			time.Sleep(time.Second)
+			continue
+		}
+		var entries []sqstypes.DeleteMessageBatchRequestEntry
+		for i, m := range out.Messages {
+			queryID, final, ok := parseQueryStateChange(aws.ToString(m.Body))
+			if !ok {
+				continue
+			}
+			if final {
+				n.waiters.notify(queryID)
+			}
+			entries = append(entries, sqstypes.DeleteMessageBatchRequestEntry{
+				Id:            aws.String(strconv.Itoa(i)),
+				ReceiptHandle: m.ReceiptHandle,
+			})
+		}
+		if len(entries) > 0 {
+			_, _ = n.client.DeleteMessageBatch(context.Background(), &sqs.DeleteMessageBatchInput{
+				QueueUrl: aws.String(n.queueURL),
+				Entries:  entries,
+			})
+		}
+	}
+}
+
+// queryStateChange is the part of an Athena Query State Change event, or of the SNS notification wrapping it, which
+// is needed to tell a query execution is done.
+type queryStateChange struct {
+	Type       string `json:"Type"`
+	Message    string `json:"Message"`
+	DetailType string `json:"detail-type"`
+	Detail     struct {
+		CurrentState     string `json:"currentState"`
+		QueryExecutionID string `json:"queryExecutionId"`
+	} `json:"detail"`
+}
+
+// parseQueryStateChange is to get the QID of an Athena Query State Change event and whether its state is final.
+func parseQueryStateChange(body string) (queryID string, final bool, ok bool) {
+	var event queryStateChange
+	if err := json.Unmarshal([]byte(body), &event); err != nil {
+		return "", false, false
+	}
+	if event.Type == "Notification" && event.Message != "" {
+		return parseQueryStateChange(event.Message)
+	}
+	if event.DetailType != "Athena Query State Change" || event.Detail.QueryExecutionID == "" {
+		return "", false, false
+	}
+	switch event.Detail.CurrentState {
+	case "SUCCEEDED", "FAILED", "CANCELLED":
+		final = true
+	}
+	return event.Detail.QueryExecutionID, final, true
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func queryStateChangeEvent(queryID, state string) string {
+	return `{"detail-type":"Athena Query State Change","source":"aws.athena","detail":{"currentState":"` + state +
+		`","previousState":"RUNNING","queryExecutionId":"` + queryID + `","workgroupName":"primary"}}`
+}
+
+func TestParseQueryStateChange(t *testing.T) {
+	queryID, final, ok := parseQueryStateChange(queryStateChangeEvent("qid", "SUCCEEDED"))
+	assert.True(t, ok)
+	assert.True(t, final)
+	assert.Equal(t, "qid", queryID)
+
+	_, final, ok = parseQueryStateChange(queryStateChangeEvent("qid", "RUNNING"))
+	assert.True(t, ok)
+	assert.False(t, final)
+
+	message, _ := json.Marshal(queryStateChangeEvent("sns", "FAILED"))
+	queryID, final, ok = parseQueryStateChange(`{"Type":"Notification","Message":` + string(message) + `}`)
+	assert.True(t, ok)
+	assert.True(t, final)
+	assert.Equal(t, "sns", queryID)
+
+	for _, body := range []string{"", "not json", `{"detail-type":"EC2 Instance State-change Notification"}`} {
+		_, _, ok = parseQueryStateChange(body)
+		assert.False(t, ok, body)
+	}
+}
+
+type mockSQS struct {
+	mu       sync.Mutex
+	messages chan sqstypes.Message
+	deleted  []string
+}
+
+func (m *mockSQS) ReceiveMessage(ctx context.Context, _ *sqs.ReceiveMessageInput,
+	_ ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	select {
+	case msg := <-m.messages:
+		return &sqs.ReceiveMessageOutput{Messages: []sqstypes.Message{msg}}, nil
+	case <-time.After(10 * time.Millisecond):
+		return &sqs.ReceiveMessageOutput{}, nil
+	}
+}
+
+func (m *mockSQS) DeleteMessageBatch(_ context.Context, input *sqs.DeleteMessageBatchInput,
+	_ ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range input.Entries {
+		m.deleted = append(m.deleted, *e.ReceiptHandle)
+	}
+	return &sqs.DeleteMessageBatchOutput{}, nil
+}
+
+func TestSQSCompletionNotifier(t *testing.T) {
+	client := &mockSQS{messages: make(chan sqstypes.Message, 10)}
+	n := NewSQSCompletionNotifier(client, "https://sqs.us-east-1.amazonaws.com/123456789012/athena")
+
+	done := make(chan error)
+	go func() { done <- n.Wait(context.Background(), "qid") }()
+	client.messages <- sqstypes.Message{Body: aws.String(queryStateChangeEvent("qid", "RUNNING")),
+		ReceiptHandle: aws.String("running")}
+	client.messages <- sqstypes.Message{Body: aws.String("unrelated"), ReceiptHandle: aws.String("unrelated")}
+	client.messages <- sqstypes.Message{Body: aws.String(queryStateChangeEvent("qid", "SUCCEEDED")),
+		ReceiptHandle: aws.String("succeeded")}
+	select {
+	case err := <-done:
+		assert.Nil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait did not return")
+	}
+	// a finished query execution is remembered for the waits starting later
+	assert.Nil(t, n.Wait(context.Background(), "qid"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.True(t, errors.Is(n.Wait(ctx, "other"), context.DeadlineExceeded))
+
+	client.mu.Lock()
+	assert.Equal(t, []string{"running", "succeeded"}, client.deleted)
+	client.mu.Unlock()
+	assert.Eventually(t, func() bool {
+		n.waiters.mu.Lock()
+		defer n.waiters.mu.Unlock()
+		return !n.receiving && len(n.waiters.waiters) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+type instantNotifier struct {
+	waits []string
+}
+
+func (n *instantNotifier) Wait(_ context.Context, queryID string) error {
+	n.waits = append(n.waits, queryID)
+	return nil
+}
+
+func TestConnection_waitNextPoll(t *testing.T) {
+	c := &Connection{connector: NoopsSQLConnector()}
+	start := time.Now()
+	ready, cancel := c.waitNextPoll(context.Background(), "qid", 20*time.Millisecond)
+	<-ready
+	cancel()
+	assert.True(t, time.Since(start) >= 20*time.Millisecond)
+
+	notifier := &instantNotifier{}
+	RegisterCompletionNotifier("TestConnection_waitNextPoll", notifier)
+	defer UnregisterCompletionNotifier("TestConnection_waitNextPoll")
+	c.connector.config.SetCompletionNotifier("TestConnection_waitNextPoll")
+	ready, cancel = c.waitNextPoll(context.Background(), "qid", time.Hour)
+	select {
+	case <-ready:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the notifier was not waited for")
+	}
+	cancel()
+	assert.Equal(t, []string{"qid"}, notifier.waits)
+}
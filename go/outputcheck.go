@@ -0,0 +1,115 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// outputProbeKey is the name of the object written under the output location to check it is writable.
+const outputProbeKey = ".athenadriver-probe"
+
+// s3BucketClient is the part of the S3 client used to validate the output location.
+type s3BucketClient interface {
+	HeadBucket(context.Context, *s3.HeadBucketInput, ...func(*s3.Options)) (*s3.HeadBucketOutput, error)
+	PutObject(context.Context, *s3.PutObjectInput, ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	DeleteObject(context.Context, *s3.DeleteObjectInput, ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+}
+
+// OutputLocationError is returned by Connect when the output location fails the check enabled by
+// SetValidateOutputLocation.
+type OutputLocationError struct {
+	Location string
+	Reason   string
+	Err      error
+}
+
+func (e *OutputLocationError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("output location %s %s: %v", e.Location, e.Reason, e.Err)
+	}
+	return fmt.Sprintf("output location %s %s", e.Location, e.Reason)
+}
+
+func (e *OutputLocationError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes errors.Is(err, ErrConfigOutputLocation) report a misconfigured output location.
+func (e *OutputLocationError) Is(target error) bool {
+	return target == ErrConfigOutputLocation
+}
+
+// validateOutputLocation is to check the bucket of the output location exists, is in the region and is writable,
+// by writing and then deleting a probe object under it.
+func validateOutputLocation(ctx context.Context, client s3BucketClient, location string, region string) error {
+	bucket, prefix, _ := strings.Cut(strings.TrimPrefix(location, "s3://"), "/")
+	if !strings.HasPrefix(location, "s3://") || bucket == "" {
+		return &OutputLocationError{Location: location, Reason: "is not an s3://bucket/prefix location"}
+	}
+	head, err := client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		return &OutputLocationError{Location: location, Reason: "bucket is not accessible", Err: err}
+	}
+	if region != "" && head.BucketRegion != nil && *head.BucketRegion != region {
+		return &OutputLocationError{Location: location,
+			Reason: fmt.Sprintf("bucket is in region %s, not %s", *head.BucketRegion, region)}
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	key := aws.String(prefix + outputProbeKey)
+	_, err = client.PutObject(ctx, &s3.PutObjectInput{Bucket: aws.String(bucket), Key: key,
+		Body: strings.NewReader("")})
+	if err != nil {
+		return &OutputLocationError{Location: location, Reason: "is not writable", Err: err}
+	}
+	// The probe is only cleaned up on a best effort basis, as writing query results doesn't need s3:DeleteObject.
+	_, _ = client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: aws.String(bucket), Key: key})
+	return nil
+}
+
+// checkOutputLocation is to validate the output location once for all connections of the connector. Failures
+// are not remembered, so that a fixed bucket is picked up by the next Connect.
+func (c *SQLConnector) checkOutputLocation(ctx context.Context, client s3BucketClient) error {
+	c.outputCheckMu.Lock()
+	defer c.outputCheckMu.Unlock()
+	if c.outputChecked {
+		return nil
+	}
+	wgName := c.config.GetWorkgroup().Name
+	if wgName == "" {
+		wgName = DefaultWGName
+	}
+	location := c.config.expandOutputLocation(wgName, time.Now())
+	if err := validateOutputLocation(ctx, client, location, c.config.GetRegion()); err != nil {
+		c.tracer.Scope().Counter(DriverName + ".failure.sqlconnector.outputlocation").Inc(1)
+		return err
+	}
+	c.outputChecked = true
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockS3BucketClient struct {
+	region  string
+	headErr error
+	putErr  error
+	heads   int
+	puts    []string
+	deletes []string
+}
+
+func (m *mockS3BucketClient) HeadBucket(_ context.Context, input *s3.HeadBucketInput,
+	_ ...func(*s3.Options)) (*s3.HeadBucketOutput, error) {
+	m.heads++
+	if m.headErr != nil {
+		return nil, m.headErr
+	}
+	return &s3.HeadBucketOutput{BucketRegion: aws.String(m.region)}, nil
+}
+
+func (m *mockS3BucketClient) PutObject(_ context.Context, input *s3.PutObjectInput,
+	_ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	if m.putErr != nil {
+		return nil, m.putErr
+	}
+	m.puts = append(m.puts, *input.Bucket+"/"+*input.Key)
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (m *mockS3BucketClient) DeleteObject(_ context.Context, input *s3.DeleteObjectInput,
+	_ ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	m.deletes = append(m.deletes, *input.Bucket+"/"+*input.Key)
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func TestValidateOutputLocation(t *testing.T) {
+	ctx := context.Background()
+	client := &mockS3BucketClient{region: "us-east-1"}
+	assert.Nil(t, validateOutputLocation(ctx, client, "s3://bucket/results", "us-east-1"))
+	assert.Equal(t, []string{"bucket/results/" + outputProbeKey}, client.puts)
+	assert.Equal(t, client.puts, client.deletes)
+
+	client = &mockS3BucketClient{region: "us-east-1"}
+	assert.Nil(t, validateOutputLocation(ctx, client, "s3://bucket/", ""))
+	assert.Equal(t, []string{"bucket/" + outputProbeKey}, client.puts)
+
+	err := validateOutputLocation(ctx, client, "s3://bucket/results", "eu-west-1")
+	assert.True(t, errors.Is(err, ErrConfigOutputLocation))
+	assert.Contains(t, err.Error(), "region us-east-1, not eu-west-1")
+
+	client = &mockS3BucketClient{headErr: ErrTestMockGeneric}
+	err = validateOutputLocation(ctx, client, "s3://bucket/results", "us-east-1")
+	assert.True(t, errors.Is(err, ErrTestMockGeneric))
+	assert.Contains(t, err.Error(), "bucket is not accessible")
+
+	client = &mockS3BucketClient{region: "us-east-1", putErr: ErrTestMockGeneric}
+	err = validateOutputLocation(ctx, client, "s3://bucket/results", "us-east-1")
+	assert.True(t, errors.Is(err, ErrTestMockGeneric))
+	assert.Contains(t, err.Error(), "is not writable")
+
+	err = validateOutputLocation(ctx, client, "s3:///results", "us-east-1")
+	assert.True(t, errors.Is(err, ErrConfigOutputLocation))
+}
+
+func TestSQLConnector_CheckOutputLocation(t *testing.T) {
+	c := NoopsSQLConnector()
+	_ = c.config.SetOutputBucket("s3://bucket/results")
+	_ = c.config.SetRegion("us-east-1")
+
+	client := &mockS3BucketClient{region: "us-east-1", headErr: ErrTestMockGeneric}
+	assert.NotNil(t, c.checkOutputLocation(context.Background(), client))
+	client.headErr = nil
+	assert.Nil(t, c.checkOutputLocation(context.Background(), client))
+	assert.Nil(t, c.checkOutputLocation(context.Background(), client))
+	assert.Equal(t, 2, client.heads)
+}
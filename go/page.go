@@ -0,0 +1,78 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+)
+
+// resultPage is a page of a result set stored column by column: the values of each column are in one slice, and
+// which of them are NULL in a bitmap, instead of a Row with a *string per value. Reading a row goes through each
+// column slice in order, and the Rows and Datums of the page decoded by the SDK can be released once it is built.
+type resultPage struct {
+	columns [][]string
+	// nulls has a bit set for each NULL value of a column, the value of row i being bit i%64 of nulls[c][i/64].
+	nulls [][]uint64
+	// rows is the number of rows in the page, and next is the index of the next row to read.
+	rows int
+	next int
+}
+
+// newResultPage is to store rows with columnCount columns column by column. The strings are shared with the rows,
+// not copied. Values missing from a row, or rows with more values than columns, are read as NULL and dropped.
+func newResultPage(rows []athenatypes.Row, columnCount int) resultPage {
+	p := resultPage{
+		columns: make([][]string, columnCount),
+		nulls:   make([][]uint64, columnCount),
+		rows:    len(rows),
+	}
+	words := (len(rows) + 63) / 64
+	values := make([]string, columnCount*len(rows))
+	nulls := make([]uint64, columnCount*words)
+	for c := 0; c < columnCount; c++ {
+		p.columns[c] = values[c*len(rows) : (c+1)*len(rows) : (c+1)*len(rows)]
+		p.nulls[c] = nulls[c*words : (c+1)*words : (c+1)*words]
+	}
+	for i, row := range rows {
+		for c := 0; c < columnCount; c++ {
+			if c >= len(row.Data) || row.Data[c].VarCharValue == nil {
+				p.nulls[c][i/64] |= 1 << (i % 64)
+				continue
+			}
+			p.columns[c][i] = *row.Data[c].VarCharValue
+		}
+	}
+	return p
+}
+
+// remaining is the number of rows left to read.
+func (p *resultPage) remaining() int {
+	return p.rows - p.next
+}
+
+// value returns the value of a column in a row, or nil if it is NULL. The pointer is into the page, so getting it
+// allocates nothing.
+func (p *resultPage) value(column int, row int) *string {
+	if p.nulls[column][row/64]&(1<<(row%64)) != 0 {
+		return nil
+	}
+	return &p.columns[column][row]
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewResultPage(t *testing.T) {
+	rows := make([]athenatypes.Row, 70)
+	for i := range rows {
+		rows[i] = newRow(2, []string{"a", "b"})
+	}
+	rows[1].Data[0].VarCharValue = nil
+	rows[65].Data[1].VarCharValue = nil
+	rows[66].Data = rows[66].Data[:1]
+	value := rows[0].Data[0].VarCharValue
+
+	p := newResultPage(rows, 2)
+	assert.Equal(t, 70, p.remaining())
+	assert.Equal(t, "a", *p.value(0, 0))
+	assert.Equal(t, "b", *p.value(1, 0))
+	assert.Nil(t, p.value(0, 1))
+	assert.Equal(t, "b", *p.value(1, 1))
+	assert.Nil(t, p.value(1, 65))
+	assert.Equal(t, "a", *p.value(0, 65))
+	// a value missing from a row is NULL
+	assert.Nil(t, p.value(1, 66))
+	assert.Equal(t, "b", *p.value(1, 69))
+	// the strings are shared with the rows
+	assert.Equal(t, p.columns[0][0], *value)
+
+	p.next = 70
+	assert.Zero(t, p.remaining())
+	empty := newResultPage(nil, 3)
+	assert.Zero(t, empty.remaining())
+}
+
+func TestRows_CurrentPage(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	testConf.SetNullAsNil(true)
+	r, _ := NewNonOpsRows(context.Background(), nil, "", testConf, NewDefaultObservability(testConf))
+	r.ResultOutput = newHeaderlessResultPage([]string{"a", "b"}, []string{"varchar", "integer"},
+		[][]*string{{aws.String("x"), aws.String("1")}, {nil, nil}, {aws.String("z"), aws.String("3")}})
+	assert.Equal(t, 3, r.remainingRows())
+	dest := make([]driver.Value, 2)
+	assert.Nil(t, r.Next(dest))
+	assert.Equal(t, []driver.Value{"x", int32(1)}, dest)
+	// the rows of the output are released once the page replaces them
+	assert.Nil(t, r.ResultOutput.ResultSet.Rows)
+	assert.Equal(t, 2, r.remainingRows())
+	assert.Nil(t, r.Next(dest))
+	assert.Equal(t, []driver.Value{nil, nil}, dest)
+	assert.Nil(t, r.Next(dest))
+	assert.Equal(t, []driver.Value{"z", int32(3)}, dest)
+	assert.Zero(t, r.remainingRows())
+	assert.Equal(t, 3, r.Cursor().Offset)
+}
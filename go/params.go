@@ -0,0 +1,281 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// ExecutionParamOverflowError fails a query with an *ExecutionParamLimitError when one of its execution parameters
+	// is out of the length Athena allows. It is the default.
+	ExecutionParamOverflowError = "error"
+
+	// ExecutionParamOverflowInterpolate binds the execution parameters of such a query into its placeholders before
+	// submitting it, the way Athena would, so the query is run without execution parameters.
+	ExecutionParamOverflowInterpolate = "interpolate"
+)
+
+// ExecutionParamLimitError is returned for a query with an execution parameter Athena would reject for its length,
+// before the query is submitted, see Config.SetExecutionParamOverflow.
+type ExecutionParamLimitError struct {
+	// Ordinal is the position of the execution parameter, starting from 1.
+	Ordinal int
+	// Length is the number of characters of the execution parameter.
+	Length int
+	// Limit is the maximum number of characters of an execution parameter, MAXExecutionParameterLength.
+	Limit int
+}
+
+func (e *ExecutionParamLimitError) Error() string {
+	return fmt.Sprintf("execution parameter %d has %d characters, Athena allows 1 to %d", e.Ordinal, e.Length,
+		e.Limit)
+}
+
+// RawParam is a query argument bound to its placeholder verbatim, e.g. `TIMESTAMP '2024-07-01 00:00:00'`. It is the
+// way to pass typecasts and function calls as arguments in strict parameter mode, see SetStrictParams.
+type RawParam string
+
+// UnformattedParamError is returned in strict parameter mode for a string or []byte argument which is neither
+// quoted by QuoteLiteral or QuoteBinary, nor a RawParam.
+type UnformattedParamError struct {
+	// Ordinal is the position of the argument, starting from 1.
+	Ordinal int
+}
+
+func (e *UnformattedParamError) Error() string {
+	return fmt.Sprintf("argument %d is not quoted by QuoteLiteral or QuoteBinary, use RawParam to bind it as is",
+		e.Ordinal)
+}
+
+// isQuotedLiteral is to check if s is a single string literal, like QuoteLiteral produces, so that binding it cannot
+// change the rest of the query. Athena takes backslashes literally, so a single quote can only be escaped by
+// doubling it. If binary is true, a varbinary literal like QuoteBinary produces, e.g. X'0a', is accepted as well.
+func isQuotedLiteral(s string, binary bool) bool {
+	if binary && len(s) >= 3 && (s[0] == 'X' || s[0] == 'x') && s[1] == '\'' {
+		return isHexLiteralBody(s[2:])
+	}
+	if len(s) < 2 || s[0] != '\'' {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if s[i] != '\'' {
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == '\'' {
+			i++
+			continue
+		}
+		return i == len(s)-1
+	}
+	return false
+}
+
+// isHexLiteralBody is to check if s is an even number of hex digits followed by the closing single quote.
+func isHexLiteralBody(s string) bool {
+	if len(s)%2 == 0 || s[len(s)-1] != '\'' {
+		return false
+	}
+	for _, c := range s[:len(s)-1] {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// convertParam is to convert a query argument to a type query arguments are formatted from: a driver.Value,
+// time.Duration, YearMonthInterval or RawParam. Like database/sql does, a driver.Valuer, e.g. sql.NullString, is
+// replaced by its value, a pointer by what it points to, or NULL if it is nil, and other types, like int32 or a
+// named string type, by their driver.Value. A json.Number is bound as a number literal.
+func convertParam(v interface{}) (interface{}, error) {
+	switch vv := v.(type) {
+	case nil, int64, float64, bool, string, []byte, time.Time, time.Duration, YearMonthInterval, RawParam:
+		return v, nil
+	case uint64:
+		// Unlike database/sql, values with the high bit set are kept rather than rejected.
+		if vv <= math.MaxInt64 {
+			return int64(vv), nil
+		}
+		return v, nil
+	case float32:
+		// Formatted with the precision of a float32, so 0.1 is not bound as 0.10000000149011612.
+		f, _ := strconv.ParseFloat(strconv.FormatFloat(float64(vv), 'g', -1, 32), 64)
+		return f, nil
+	case json.Number:
+		// Bound as the number literal it is, rather than as a string.
+		if vv == "" || !(vv[0] == '-' || vv[0] >= '0' && vv[0] <= '9') || !json.Valid([]byte(vv)) {
+			return nil, fmt.Errorf("invalid json.Number %q", string(vv))
+		}
+		return RawParam(vv), nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		if _, ok := v.(driver.Valuer); !ok {
+			return convertParam(rv.Elem().Interface())
+		}
+	}
+	if valuer, ok := v.(driver.Valuer); ok {
+		value, err := valuer.Value()
+		if err != nil {
+			return nil, err
+		}
+		return convertParam(value)
+	}
+	return driver.DefaultParameterConverter.ConvertValue(v)
+}
+
+// isSliceParam is to check if a query argument is a slice to be expanded into an IN list, see expandSliceParams.
+// []byte, which is bound as a binary literal, and a driver.Valuer slice, which provides its own value, are not.
+func isSliceParam(v interface{}) bool {
+	if _, ok := v.(driver.Valuer); ok {
+		return false
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8
+}
+
+// expandSliceParams is to expand the `?` placeholder of each slice argument into a list of placeholders, one per
+// element, e.g. `a IN ?` with []int{1, 2} into `a IN (?, ?)`, and to return the arguments with the elements in place
+// of the slice, so each element is formatted like any other argument. A placeholder already in parentheses, like
+// `a IN (?)`, is not parenthesized again. An empty slice fails with ErrEmptySliceParam, as `IN ()` is not valid SQL,
+// and one longer than maxLen fails too. The query and the arguments are returned as is if no argument is a slice.
+func expandSliceParams(query string, namedArgs []driver.NamedValue, maxLen int) (string, []driver.NamedValue,
+	error) {
+	expand := false
+	for _, arg := range namedArgs {
+		if isSliceParam(arg.Value) {
+			expand = true
+			break
+		}
+	}
+	if !expand {
+		return query, namedArgs, nil
+	}
+
+	var expanded []byte
+	var args []driver.NamedValue
+	last := 0
+	i := 0
+	for p := nextPlaceholder(query, 0); p >= 0 && i < len(namedArgs); p = nextPlaceholder(query, p+1) {
+		value := namedArgs[i].Value
+		i++
+		if !isSliceParam(value) {
+			args = append(args, driver.NamedValue{Ordinal: len(args) + 1, Value: value})
+			continue
+		}
+		rv := reflect.ValueOf(value)
+		if rv.Len() == 0 {
+			return "", nil, fmt.Errorf("%w: argument %d", ErrEmptySliceParam, i)
+		}
+		if rv.Len() > maxLen {
+			return "", nil, fmt.Errorf("%w: argument %d has %d elements, at most %d are allowed", ErrInvalidQuery,
+				i, rv.Len(), maxLen)
+		}
+		expanded = append(expanded, query[last:p]...)
+		parenthesized := strings.HasSuffix(strings.TrimRight(query[:p], " \t\r\n"), "(") &&
+			strings.HasPrefix(strings.TrimLeft(query[p+1:], " \t\r\n"), ")")
+		if !parenthesized {
+			expanded = append(expanded, '(')
+		}
+		for j := 0; j < rv.Len(); j++ {
+			if j > 0 {
+				expanded = append(expanded, ", "...)
+			}
+			expanded = append(expanded, '?')
+			args = append(args, driver.NamedValue{Ordinal: len(args) + 1, Value: rv.Index(j).Interface()})
+		}
+		if !parenthesized {
+			expanded = append(expanded, ')')
+		}
+		last = p + 1
+	}
+	// Arguments without a placeholder are kept, to fail the query the way they would without a slice.
+	for ; i < len(namedArgs); i++ {
+		args = append(args, driver.NamedValue{Ordinal: len(args) + 1, Value: namedArgs[i].Value})
+	}
+	return string(append(expanded, query[last:]...)), args, nil
+}
+
+// bindParams is to bind the named arguments of a query, see bindNamedParams, and to expand its slice arguments, see
+// expandSliceParams.
+func (c *Connection) bindParams(query string, namedArgs []driver.NamedValue) (string, []driver.NamedValue, error) {
+	query, namedArgs, err := bindNamedParams(query, namedArgs)
+	if err != nil {
+		return "", nil, err
+	}
+	return expandSliceParams(query, namedArgs, c.connector.config.GetMaxSliceParamLength())
+}
+
+// checkExecutionParams is to check if every execution parameter has a length Athena allows.
+func checkExecutionParams(executionParams []string) error {
+	for i, p := range executionParams {
+		if n := utf8.RuneCountInString(p); n == 0 || n > MAXExecutionParameterLength {
+			return &ExecutionParamLimitError{Ordinal: i + 1, Length: n, Limit: MAXExecutionParameterLength}
+		}
+	}
+	return nil
+}
+
+// substituteExecutionParams is to replace the `?` placeholders of a query with its execution parameters verbatim,
+// which is what Athena does with them, so the query runs the same without execution parameters.
+func substituteExecutionParams(query string, executionParams []string) string {
+	var b strings.Builder
+	last := 0
+	i := 0
+	for p := nextPlaceholder(query, 0); p >= 0 && i < len(executionParams); p = nextPlaceholder(query, p+1) {
+		b.WriteString(query[last:p])
+		b.WriteString(executionParams[i])
+		last = p + 1
+		i++
+	}
+	b.WriteString(query[last:])
+	return b.String()
+}
+
+// fitExecutionParams is to check the execution parameters of a query before it is submitted. Out of the limits of
+// Athena, the query fails with an *ExecutionParamLimitError, or, with ExecutionParamOverflowInterpolate, is returned
+// with the parameters substituted into it and no execution parameters.
+func (c *Connection) fitExecutionParams(query string, executionParams []string) (string, []string, error) {
+	err := checkExecutionParams(executionParams)
+	if err == nil {
+		return query, executionParams, nil
+	}
+	if c.connector.config.GetExecutionParamOverflow() != ExecutionParamOverflowInterpolate {
+		return "", nil, err
+	}
+	substituted := substituteExecutionParams(query, executionParams)
+	if validateQueryLength(substituted) != nil {
+		return "", nil, err
+	}
+	c.connector.tracer.Scope().Counter(DriverName + ".query.executionparamsinterpolated").Inc(1)
+	return substituted, nil, nil
+}
@@ -0,0 +1,281 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsQuotedLiteral(t *testing.T) {
+	assert.True(t, isQuotedLiteral(QuoteLiteral(""), false))
+	assert.True(t, isQuotedLiteral(QuoteLiteral(`it's a \ "string"`+"\n"), false))
+	assert.True(t, isQuotedLiteral(QuoteLiteral(`x\' OR 1=1 --`), false))
+	assert.True(t, isQuotedLiteral(QuoteBinary([]byte("bytes'\x00")), true))
+	assert.True(t, isQuotedLiteral("X''", true))
+
+	assert.False(t, isQuotedLiteral("", false))
+	assert.False(t, isQuotedLiteral("'", false))
+	assert.False(t, isQuotedLiteral("a string", false))
+	assert.False(t, isQuotedLiteral("'a' OR 1=1", false))
+	assert.False(t, isQuotedLiteral("'a' OR 'b'", false))
+	assert.False(t, isQuotedLiteral("TIMESTAMP '2024-07-01 00:00:00'", false))
+	// Athena takes backslashes literally, so the literal ends at the quote after the backslash.
+	assert.False(t, isQuotedLiteral(`'x\' OR 1=1 --'`, false))
+	assert.False(t, isQuotedLiteral(`'it\'s'`, false))
+	assert.False(t, isQuotedLiteral(string(FormatBytes([]byte("b"))), true))
+	assert.False(t, isQuotedLiteral(QuoteBinary([]byte("b")), false))
+	assert.False(t, isQuotedLiteral("X'0'", true))
+	assert.False(t, isQuotedLiteral("X'0g'", true))
+	assert.False(t, isQuotedLiteral("X'00' OR 1=1 --'", true))
+}
+
+func TestBuildExecutionParamsStrict(t *testing.T) {
+	c := createTestConnection(t)
+	c.connector.config.SetStrictParams(true)
+
+	params, err := c.buildExecutionParams([]driver.Value{int64(1), QuoteLiteral("a"), []byte(QuoteBinary([]byte("b"))),
+		RawParam("TIMESTAMP '2024-07-01 00:00:00'")})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1", "'a'", "X'62'", "TIMESTAMP '2024-07-01 00:00:00'"}, params)
+
+	_, err = c.buildExecutionParams([]driver.Value{QuoteLiteral("a"), "'a' OR 1=1"})
+	assert.Equal(t, &UnformattedParamError{Ordinal: 2}, err)
+	_, err = c.buildExecutionParams([]driver.Value{[]byte("b")})
+	assert.Equal(t, &UnformattedParamError{Ordinal: 1}, err)
+	_, err = c.buildExecutionParams([]driver.Value{`'x\' OR 1=1 --'`})
+	assert.Equal(t, &UnformattedParamError{Ordinal: 1}, err)
+	_, err = c.buildExecutionParams([]driver.Value{FormatBytes([]byte("b"))})
+	assert.Equal(t, &UnformattedParamError{Ordinal: 1}, err)
+
+	value := driver.NamedValue{Value: RawParam("NOW()")}
+	assert.Nil(t, c.CheckNamedValue(&value))
+	assert.Equal(t, RawParam("NOW()"), value.Value)
+
+	query, err := c.interpolateParams("SELECT ?", []driver.Value{RawParam("NOW()")})
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT NOW()", query)
+}
+
+type weekday int
+
+// rawValuer is a driver.Valuer returning a RawParam, which database/sql would reject.
+type rawValuer struct{}
+
+func (rawValuer) Value() (driver.Value, error) {
+	return RawParam("current_date"), nil
+}
+
+type failingValuer struct{}
+
+func (failingValuer) Value() (driver.Value, error) {
+	return nil, errors.New("no value")
+}
+
+func TestConvertParam(t *testing.T) {
+	s := "gopher"
+	var nilString *string
+	ts := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	d := time.Hour
+	testCases := []struct {
+		arg      interface{}
+		expected interface{}
+	}{
+		{nil, nil},
+		{"gopher", "gopher"},
+		{&s, "gopher"},
+		{nilString, nil},
+		{int32(7), int64(7)},
+		{weekday(3), int64(3)},
+		{uint64(1), int64(1)},
+		{uint64(math.MaxUint64), uint64(math.MaxUint64)},
+		{sql.NullString{String: "a", Valid: true}, "a"},
+		{sql.NullString{}, nil},
+		{sql.NullInt64{Int64: 42, Valid: true}, int64(42)},
+		{sql.NullTime{Time: ts, Valid: true}, ts},
+		{sql.NullTime{}, nil},
+		{&sql.NullBool{Bool: true, Valid: true}, true},
+		{&d, time.Hour},
+		{rawValuer{}, RawParam("current_date")},
+	}
+	for _, tc := range testCases {
+		v, err := convertParam(tc.arg)
+		assert.Nil(t, err, "%#v", tc.arg)
+		assert.Equal(t, tc.expected, v, "%#v", tc.arg)
+	}
+	_, err := convertParam(failingValuer{})
+	assert.NotNil(t, err)
+	_, err = convertParam(struct{}{})
+	assert.NotNil(t, err)
+}
+
+func TestConnection_ValuerParams(t *testing.T) {
+	c := createTestConnection(t)
+	c.connector.config.SetPrestoEscaping(true)
+	args := []driver.Value{sql.NullString{String: "it's", Valid: true}, sql.NullInt64{}, int32(5), rawValuer{}}
+	params, err := c.buildExecutionParams(args)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"it's", "NULL", "5", "current_date"}, params)
+
+	q, err := c.interpolateParams("SELECT ?, ?, ?, ?", args)
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT 'it''s', NULL, 5, current_date", q)
+
+	_, err = c.buildExecutionParams([]driver.Value{failingValuer{}})
+	assert.NotNil(t, err)
+	_, err = c.interpolateParams("?", []driver.Value{failingValuer{}})
+	assert.NotNil(t, err)
+}
+
+func TestConvertParam_Numbers(t *testing.T) {
+	testCases := []struct {
+		arg      interface{}
+		expected interface{}
+	}{
+		{int(-1), int64(-1)},
+		{int32(math.MaxInt32), int64(math.MaxInt32)},
+		{uint32(math.MaxUint32), int64(math.MaxUint32)},
+		{float32(0.1), 0.1},
+		{float32(1.5), 1.5},
+		{json.Number("42"), RawParam("42")},
+		{json.Number("-1.5e3"), RawParam("-1.5e3")},
+	}
+	for _, tc := range testCases {
+		v, err := convertParam(tc.arg)
+		assert.Nil(t, err, "%#v", tc.arg)
+		assert.Equal(t, tc.expected, v, "%#v", tc.arg)
+	}
+	for _, n := range []json.Number{"", "NaN", "0x10", "1 OR 1=1", `"1"`} {
+		_, err := convertParam(n)
+		assert.NotNil(t, err, n)
+	}
+
+	c := createTestConnection(t)
+	args := []driver.Value{1, uint32(2), float32(0.1), json.Number("12345678901234567890")}
+	params, err := c.buildExecutionParams(args)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1", "2", "0.1", "12345678901234567890"}, params)
+	q, err := c.interpolateParams("SELECT ?, ?, ?, ?", args)
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT 1, 2, 0.1, 12345678901234567890", q)
+}
+
+func TestExpandSliceParams(t *testing.T) {
+	args := []driver.NamedValue{
+		{Ordinal: 1, Value: int64(1)},
+		{Ordinal: 2, Value: []int{2, 3}},
+		{Ordinal: 3, Value: []string{"a"}},
+		{Ordinal: 4, Value: []byte("b")},
+	}
+	query, expanded, err := expandSliceParams("SELECT ? FROM t WHERE a IN ? AND b IN ( ? ) AND c = ? -- ?", args, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT ? FROM t WHERE a IN (?, ?) AND b IN ( ? ) AND c = ? -- ?", query)
+	assert.Equal(t, []driver.NamedValue{
+		{Ordinal: 1, Value: int64(1)},
+		{Ordinal: 2, Value: 2},
+		{Ordinal: 3, Value: 3},
+		{Ordinal: 4, Value: "a"},
+		{Ordinal: 5, Value: []byte("b")},
+	}, expanded)
+
+	query, expanded, err = expandSliceParams("SELECT ?", args[:1], 2)
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT ?", query)
+	assert.Equal(t, args[:1], expanded)
+
+	_, _, err = expandSliceParams("SELECT ?", []driver.NamedValue{{Ordinal: 1, Value: []string{}}}, 2)
+	assert.True(t, errors.Is(err, ErrEmptySliceParam))
+	_, _, err = expandSliceParams("SELECT ?", []driver.NamedValue{{Ordinal: 1, Value: []int{1, 2, 3}}}, 2)
+	assert.True(t, errors.Is(err, ErrInvalidQuery))
+}
+
+func TestConnection_SliceParams(t *testing.T) {
+	c := createTestConnection(t)
+	c.connector.config.SetPrestoEscaping(true)
+	nv := driver.NamedValue{Ordinal: 1, Value: []string{"x", "it's"}}
+	assert.Nil(t, c.CheckNamedValue(&nv))
+	assert.Equal(t, []string{"x", "it's"}, nv.Value)
+
+	query, args, err := c.bindParams("SELECT * FROM t WHERE a IN :a AND b = :b",
+		[]driver.NamedValue{{Name: "a", Ordinal: 1, Value: nv.Value}, {Name: "b", Ordinal: 2, Value: int64(1)}})
+	assert.Nil(t, err)
+	q, err := c.interpolateParams(query, namedValueToValue(args))
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM t WHERE a IN ('x', 'it''s') AND b = 1", q)
+
+	c.connector.config.SetMaxSliceParamLength(1)
+	_, _, err = c.bindParams("SELECT ?", []driver.NamedValue{{Ordinal: 1, Value: nv.Value}})
+	assert.NotNil(t, err)
+}
+
+func TestCheckExecutionParams(t *testing.T) {
+	assert.Nil(t, checkExecutionParams(nil))
+	assert.Nil(t, checkExecutionParams([]string{"1", strings.Repeat("é", MAXExecutionParameterLength)}))
+
+	err := checkExecutionParams([]string{"1", strings.Repeat("a", MAXExecutionParameterLength+1)})
+	var limitErr *ExecutionParamLimitError
+	assert.True(t, errors.As(err, &limitErr))
+	assert.Equal(t, ExecutionParamLimitError{Ordinal: 2, Length: MAXExecutionParameterLength + 1,
+		Limit: MAXExecutionParameterLength}, *limitErr)
+	err = checkExecutionParams([]string{""})
+	assert.True(t, errors.As(err, &limitErr))
+	assert.Equal(t, 1, limitErr.Ordinal)
+}
+
+func TestSubstituteExecutionParams(t *testing.T) {
+	assert.Equal(t, "SELECT 'a', 1 FROM t WHERE b = '?' -- ?",
+		substituteExecutionParams("SELECT ?, ? FROM t WHERE b = '?' -- ?", []string{"'a'", "1"}))
+	assert.Equal(t, "SELECT 1", substituteExecutionParams("SELECT 1", nil))
+}
+
+func TestConnection_ExecutionParamOverflow(t *testing.T) {
+	c := createConnectionFixture()
+	m := c.athenaClient.(*mockAthenaClient)
+	_, err := c.QueryContext(context.Background(), "SELECTQueryContext_?OK",
+		[]driver.NamedValue{{Ordinal: 1, Value: ""}})
+	var limitErr *ExecutionParamLimitError
+	assert.True(t, errors.As(err, &limitErr))
+	assert.Equal(t, 0, m.callCount("StartQueryExecution"))
+
+	c.connector.config.SetExecutionParamOverflow(ExecutionParamOverflowInterpolate)
+	rows, err := c.QueryContext(context.Background(), "SELECTQueryContext_?OK",
+		[]driver.NamedValue{{Ordinal: 1, Value: ""}})
+	assert.Nil(t, err)
+	assert.NotNil(t, rows)
+	assert.Equal(t, "SELECTQueryContext_OK", *m.lastStartInput.QueryString)
+	assert.Nil(t, m.lastStartInput.ExecutionParameters)
+
+	// Interpolated parameters are checked in strict mode all the same.
+	c.connector.config.SetStrictParams(true)
+	_, err = c.QueryContext(context.Background(), "SELECTQueryContext_?OK",
+		[]driver.NamedValue{{Ordinal: 1, Value: `'x\' OR 1=1 --'`}})
+	assert.Equal(t, &UnformattedParamError{Ordinal: 1}, err)
+	assert.Equal(t, 1, m.callCount("StartQueryExecution"))
+}
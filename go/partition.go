@@ -0,0 +1,166 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"strings"
+)
+
+// hivePartitionDefaultName is the directory name of a partition whose value is NULL or empty.
+const hivePartitionDefaultName = "__HIVE_DEFAULT_PARTITION__"
+
+// PartitionValue is the value of a partition column.
+type PartitionValue struct {
+	Column string
+	Value  string
+}
+
+// Partition is a partition of a Hive table, by the values of its partition columns in their order.
+type Partition struct {
+	Values []PartitionValue
+	// Location is the S3 location of the partition data. Without it, the partition is in the directory
+	// PartitionPath returns under the table location.
+	Location string
+}
+
+// PartitionPath returns the path of a partition under the table location, like dt=2024-01-02/region=us%3Aeast/,
+// with the values escaped the way Hive and MSCK REPAIR TABLE expect.
+func PartitionPath(values []PartitionValue) string {
+	var sb strings.Builder
+	for _, v := range values {
+		sb.WriteString(escapePartitionPathName(v.Column))
+		sb.WriteByte('=')
+		if v.Value == "" {
+			sb.WriteString(hivePartitionDefaultName)
+		} else {
+			sb.WriteString(escapePartitionPathName(v.Value))
+		}
+		sb.WriteByte('/')
+	}
+	return sb.String()
+}
+
+// AddPartitionsStatements is to build the ALTER TABLE ADD IF NOT EXISTS PARTITION statements registering
+// partitions of a table, e.g. sampledb.elb_logs, with as many partitions per statement as the query length
+// limit allows.
+func AddPartitionsStatements(table string, partitions []Partition) []string {
+	clauses := make([]string, len(partitions))
+	for i, p := range partitions {
+		clauses[i] = "PARTITION " + partitionSpec(p.Values)
+		if p.Location != "" {
+			clauses[i] += " LOCATION " + quoteDDLString(p.Location)
+		}
+	}
+	return packPartitionClauses("ALTER TABLE "+table+" ADD IF NOT EXISTS ", " ", clauses)
+}
+
+// DropPartitionsStatements is to build the ALTER TABLE DROP IF EXISTS PARTITION statements removing partitions
+// of a table. The data of the partitions is left on S3.
+func DropPartitionsStatements(table string, partitions []Partition) []string {
+	clauses := make([]string, len(partitions))
+	for i, p := range partitions {
+		clauses[i] = "PARTITION " + partitionSpec(p.Values)
+	}
+	return packPartitionClauses("ALTER TABLE "+table+" DROP IF EXISTS ", ", ", clauses)
+}
+
+// AddPartitions is to register partitions of a table, skipping the existing ones.
+func AddPartitions(ctx context.Context, db Execer, table string, partitions []Partition) error {
+	return execPartitionStatements(ctx, db, AddPartitionsStatements(table, partitions))
+}
+
+// DropPartitions is to remove partitions of a table, skipping the missing ones.
+func DropPartitions(ctx context.Context, db Execer, table string, partitions []Partition) error {
+	return execPartitionStatements(ctx, db, DropPartitionsStatements(table, partitions))
+}
+
+func execPartitionStatements(ctx context.Context, db Execer, statements []string) error {
+	for _, stmt := range statements {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// packPartitionClauses joins clauses after prefix into as few statements under MAXQueryStringLength as possible.
+func packPartitionClauses(prefix, sep string, clauses []string) []string {
+	var statements []string
+	var sb strings.Builder
+	for _, clause := range clauses {
+		if sb.Len() > 0 && sb.Len()+len(sep)+len(clause) > MAXQueryStringLength {
+			statements = append(statements, sb.String())
+			sb.Reset()
+		}
+		if sb.Len() == 0 {
+			sb.WriteString(prefix)
+		} else {
+			sb.WriteString(sep)
+		}
+		sb.WriteString(clause)
+	}
+	if sb.Len() > 0 {
+		statements = append(statements, sb.String())
+	}
+	return statements
+}
+
+// partitionSpec returns the partition spec of DDL statements, like (`dt` = '2024-01-02').
+func partitionSpec(values []PartitionValue) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = "`" + strings.ReplaceAll(v.Column, "`", "``") + "` = " + quoteDDLString(v.Value)
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+// quoteDDLString quotes a string literal of a DDL statement, which, unlike in queries, escapes quotes with
+// backslashes.
+func quoteDDLString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('\'')
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\'' || s[i] == '\\' {
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(s[i])
+	}
+	sb.WriteByte('\'')
+	return sb.String()
+}
+
+// escapePartitionPathName escapes the characters Hive escapes in partition directory names.
+func escapePartitionPathName(s string) string {
+	const hexDigits = "0123456789ABCDEF"
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x20 || c == 0x7f || strings.IndexByte("\"#%'*/:=?\\{[]^", c) >= 0 {
+			sb.WriteByte('%')
+			sb.WriteByte(hexDigits[c>>4])
+			sb.WriteByte(hexDigits[c&0x0f])
+		} else {
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
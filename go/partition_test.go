@@ -0,0 +1,80 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartitionPath(t *testing.T) {
+	assert.Equal(t, "dt=2024-01-02/region=us%3Aeast%2F1/", PartitionPath([]PartitionValue{
+		{Column: "dt", Value: "2024-01-02"}, {Column: "region", Value: "us:east/1"}}))
+	assert.Equal(t, "k=__HIVE_DEFAULT_PARTITION__/", PartitionPath([]PartitionValue{{Column: "k"}}))
+	assert.Equal(t, "k=a b%25%27%0A/", PartitionPath([]PartitionValue{{Column: "k", Value: "a b%'\n"}}))
+	assert.Equal(t, "", PartitionPath(nil))
+}
+
+func TestAddPartitionsStatements(t *testing.T) {
+	partitions := []Partition{
+		{Values: []PartitionValue{{Column: "dt", Value: "2024-01-02"}, {Column: "x", Value: `it's \`}}},
+		{Values: []PartitionValue{{Column: "dt", Value: "2024-01-03"}, {Column: "x", Value: "b"}},
+			Location: "s3://bucket/t/dt=2024-01-03/x=b/"},
+	}
+	assert.Equal(t, []string{"ALTER TABLE db.t ADD IF NOT EXISTS " +
+		"PARTITION (`dt` = '2024-01-02', `x` = 'it\\'s \\\\') " +
+		"PARTITION (`dt` = '2024-01-03', `x` = 'b') LOCATION 's3://bucket/t/dt=2024-01-03/x=b/'"},
+		AddPartitionsStatements("db.t", partitions))
+	assert.Equal(t, []string{"ALTER TABLE db.t DROP IF EXISTS " +
+		"PARTITION (`dt` = '2024-01-02', `x` = 'it\\'s \\\\'), PARTITION (`dt` = '2024-01-03', `x` = 'b')"},
+		DropPartitionsStatements("db.t", partitions))
+	assert.Nil(t, AddPartitionsStatements("db.t", nil))
+
+	var many []Partition
+	for i := 0; i < 10000; i++ {
+		many = append(many, Partition{Values: []PartitionValue{{Column: "id", Value: strings.Repeat("x", 10)}}})
+	}
+	statements := AddPartitionsStatements("db.t", many)
+	assert.Len(t, statements, 2)
+	count := 0
+	for _, stmt := range statements {
+		assert.True(t, len(stmt) <= MAXQueryStringLength)
+		assert.True(t, strings.HasPrefix(stmt, "ALTER TABLE db.t ADD IF NOT EXISTS PARTITION"))
+		count += strings.Count(stmt, "PARTITION (")
+	}
+	assert.Equal(t, len(many), count)
+}
+
+func TestAddPartitions(t *testing.T) {
+	e := &recordingExecer{}
+	partitions := []Partition{{Values: []PartitionValue{{Column: "dt", Value: "2024-01-02"}}}}
+	assert.Nil(t, AddPartitions(context.Background(), e, "t", partitions))
+	assert.Nil(t, DropPartitions(context.Background(), e, "t", partitions))
+	assert.Equal(t, []string{"ALTER TABLE t ADD IF NOT EXISTS PARTITION (`dt` = '2024-01-02')",
+		"ALTER TABLE t DROP IF EXISTS PARTITION (`dt` = '2024-01-02')"}, e.queries)
+
+	e.err = errors.New("boom")
+	assert.Equal(t, e.err, AddPartitions(context.Background(), e, "t", partitions))
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+)
+
+// pageSource provides the result set pages after the first one to Rows instead of GetQueryResults.
+type pageSource interface {
+	// next is to get the next page.
+	next() (*athena.GetQueryResultsOutput, error)
+	// stop is to release the resources of the source when Rows is closed.
+	stop()
+}
+
+// pageResult is a result set page, or the error got when fetching it.
+type pageResult struct {
+	output *athena.GetQueryResultsOutput
+	err    error
+}
+
+// pagePrefetcher fetches result set pages ahead of Rows.Next() in a background worker.
+// Pagination tokens only allow fetching pages one after another, so a single worker keeps up to depth pages
+// buffered while the caller is still converting the current one.
+type pagePrefetcher struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	pages  chan pageResult
+}
+
+// newPagePrefetcher is to start fetching pages from the one of token, keeping at most depth pages buffered.
+func newPagePrefetcher(ctx context.Context, client AthenaClient, queryID string, token *string,
+	depth int) *pagePrefetcher {
+	ctx, cancel := context.WithCancel(ctx)
+	p := &pagePrefetcher{
+		ctx:    ctx,
+		cancel: cancel,
+		pages:  make(chan pageResult, depth),
+	}
+	go p.run(client, queryID, token)
+	return p
+}
+
+func (p *pagePrefetcher) run(client AthenaClient, queryID string, token *string) {
+	defer close(p.pages)
+	for token != nil && *token != "" && p.ctx.Err() == nil {
+		output, err := client.GetQueryResults(p.ctx,
+			&athena.GetQueryResultsInput{
+				QueryExecutionId: aws.String(queryID),
+				NextToken:        token,
+			})
+		select {
+		case p.pages <- pageResult{output: output, err: err}:
+		case <-p.ctx.Done():
+			return
+		}
+		if err != nil {
+			return
+		}
+		token = output.NextToken
+	}
+}
+
+// next is to get the next page, waiting for the worker if it is not fetched yet.
+func (p *pagePrefetcher) next() (*athena.GetQueryResultsOutput, error) {
+	res, ok := <-p.pages
+	if !ok {
+		if err := p.ctx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.ErrUnexpectedEOF
+	}
+	return res.output, res.err
+}
+
+// stop is to stop the worker and drop the pages it has buffered.
+func (p *pagePrefetcher) stop() {
+	p.cancel()
+	go func() {
+		for range p.pages {
+		}
+	}()
+}
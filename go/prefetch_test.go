@@ -0,0 +1,86 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func countRows(t *testing.T, queryID string, depth int) (int, error) {
+	testConf := NewNoOpsConfig()
+	testConf.SetPrefetchPages(depth)
+	r, err := NewRows(context.Background(), newMockAthenaClient(), queryID, testConf,
+		NewDefaultObservability(testConf))
+	assert.Nil(t, err)
+	defer r.Close()
+	dest := make([]driver.Value, len(r.Columns()))
+	n := 0
+	for {
+		if err := r.Next(dest); err != nil {
+			if err == io.EOF {
+				return n, nil
+			}
+			return n, err
+		}
+		n++
+	}
+}
+
+func TestRows_PrefetchPages(t *testing.T) {
+	want, err := countRows(t, "SELECT_OK", 0)
+	assert.Nil(t, err)
+	for _, depth := range []int{1, 2, 10} {
+		got, err := countRows(t, "SELECT_OK", depth)
+		assert.Nil(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	_, err = countRows(t, "SELECT_GetQueryResults_ERR", 2)
+	assert.NotNil(t, err)
+}
+
+func TestRows_PrefetchPagesClose(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	testConf.SetPrefetchPages(1)
+	client := newMockAthenaClient()
+	r, err := NewRows(context.Background(), client, "SELECT_OK", testConf, NewDefaultObservability(testConf))
+	assert.Nil(t, err)
+	pages := r.pages
+	assert.NotNil(t, pages)
+	dest := make([]driver.Value, len(r.Columns()))
+	assert.Nil(t, r.Close())
+	assert.Nil(t, r.pages)
+	assert.Nil(t, r.ResultOutput)
+	assert.Equal(t, io.EOF, r.Next(dest))
+
+	_, err = pages.next()
+	for err == nil {
+		_, err = pages.next()
+	}
+	assert.Equal(t, context.Canceled, err)
+	// the first page, the buffered one and at most one in flight when it was stopped
+	assert.LessOrEqual(t, client.callCount("GetQueryResults"), 3)
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+)
+
+// Server-side prepared statements are created with `PREPARE name FROM query` and live in a workgroup
+// until they are deallocated. Unlike Go's database/sql prepared statements, they survive the connection
+// that created them. The functions in this file manage them in the workgroup of the connection.
+// https://docs.aws.amazon.com/athena/latest/ug/querying-with-prepared-statements.html
+
+// ListPreparedStatements is to list the server-side prepared statements in the workgroup of this connection.
+func (c *Connection) ListPreparedStatements(ctx context.Context) ([]athenatypes.PreparedStatementSummary, error) {
+	var statements []athenatypes.PreparedStatementSummary
+	paginator := athena.NewListPreparedStatementsPaginator(c.athenaClient, &athena.ListPreparedStatementsInput{
+		WorkGroup: aws.String(c.workgroupName()),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			c.tracer(ctx).Scope().Counter(DriverName + ".failure.listpreparedstatements").Inc(1)
+			return nil, err
+		}
+		statements = append(statements, page.PreparedStatements...)
+	}
+	return statements, nil
+}
+
+// DescribePreparedStatement is to get the query string and metadata of a server-side prepared statement.
+func (c *Connection) DescribePreparedStatement(ctx context.Context, name string) (*athenatypes.PreparedStatement, error) {
+	resp, err := c.athenaClient.GetPreparedStatement(ctx, &athena.GetPreparedStatementInput{
+		StatementName: aws.String(name),
+		WorkGroup:     aws.String(c.workgroupName()),
+	})
+	if err != nil {
+		c.tracer(ctx).Scope().Counter(DriverName + ".failure.getpreparedstatement").Inc(1)
+		return nil, err
+	}
+	return resp.PreparedStatement, nil
+}
+
+// DeallocatePreparedStatement is to delete a server-side prepared statement, like `DEALLOCATE PREPARE name`.
+func (c *Connection) DeallocatePreparedStatement(ctx context.Context, name string) error {
+	_, err := c.athenaClient.DeletePreparedStatement(ctx, &athena.DeletePreparedStatementInput{
+		StatementName: aws.String(name),
+		WorkGroup:     aws.String(c.workgroupName()),
+	})
+	if err != nil {
+		c.tracer(ctx).Scope().Counter(DriverName + ".failure.deletepreparedstatement").Inc(1)
+	}
+	return err
+}
+
+// DeallocateStalePreparedStatements is to delete the server-side prepared statements which have not
+// been modified for longer than maxAge, and return their names. Long-lived services can call it
+// periodically so prepared statements don't leak. Statements whose age is unknown are kept.
+func (c *Connection) DeallocateStalePreparedStatements(ctx context.Context, maxAge time.Duration) ([]string, error) {
+	statements, err := c.ListPreparedStatements(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(-maxAge)
+	deallocated := []string{}
+	for _, statement := range statements {
+		if statement.StatementName == nil || statement.LastModifiedTime == nil ||
+			statement.LastModifiedTime.After(deadline) {
+			continue
+		}
+		if err := c.DeallocatePreparedStatement(ctx, *statement.StatementName); err != nil {
+			return deallocated, err
+		}
+		deallocated = append(deallocated, *statement.StatementName)
+	}
+	c.tracer(ctx).Log(InfoLevel, "stale prepared statements deallocated",
+		zap.String("workgroup", c.workgroupName()),
+		zap.Strings("statements", deallocated))
+	return deallocated, nil
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func createPreparedStatementTestConnection() (*Connection, *mockAthenaClient) {
+	nm := newMockAthenaClient()
+	old := time.Now().Add(-48 * time.Hour)
+	fresh := time.Now()
+	nm.preparedStatements = map[string]map[string]athenatypes.PreparedStatement{
+		DefaultWGName: {
+			"stmt_old": {
+				StatementName:    aws.String("stmt_old"),
+				QueryStatement:   aws.String("SELECT * FROM t WHERE id = ?"),
+				WorkGroupName:    aws.String(DefaultWGName),
+				LastModifiedTime: &old,
+			},
+			"stmt_fresh": {
+				StatementName:    aws.String("stmt_fresh"),
+				QueryStatement:   aws.String("SELECT 1"),
+				WorkGroupName:    aws.String(DefaultWGName),
+				LastModifiedTime: &fresh,
+			},
+		},
+	}
+	c := &Connection{
+		athenaClient: nm,
+		connector:    NoopsSQLConnector(),
+	}
+	return c, nm
+}
+
+func TestConnection_ListPreparedStatements(t *testing.T) {
+	c, _ := createPreparedStatementTestConnection()
+	statements, err := c.ListPreparedStatements(context.Background())
+	assert.Nil(t, err)
+	names := []string{}
+	for _, s := range statements {
+		names = append(names, *s.StatementName)
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{"stmt_fresh", "stmt_old"}, names)
+
+	_ = c.connector.config.SetWorkGroup(NewDefaultWG("ListPreparedStatements_return_error", nil, nil))
+	_, err = c.ListPreparedStatements(context.Background())
+	assert.Equal(t, ErrTestMockGeneric, err)
+}
+
+func TestConnection_DescribeAndDeallocatePreparedStatement(t *testing.T) {
+	c, nm := createPreparedStatementTestConnection()
+	ps, err := c.DescribePreparedStatement(context.Background(), "stmt_old")
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM t WHERE id = ?", *ps.QueryStatement)
+
+	assert.Nil(t, c.DeallocatePreparedStatement(context.Background(), "stmt_old"))
+	assert.Equal(t, 1, len(nm.preparedStatements[DefaultWGName]))
+	assert.NotNil(t, c.DeallocatePreparedStatement(context.Background(), "stmt_old"))
+	_, err = c.DescribePreparedStatement(context.Background(), "stmt_old")
+	assert.NotNil(t, err)
+}
+
+func TestConnection_DeallocateStalePreparedStatements(t *testing.T) {
+	c, nm := createPreparedStatementTestConnection()
+	deallocated, err := c.DeallocateStalePreparedStatements(context.Background(), 24*time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"stmt_old"}, deallocated)
+	_, ok := nm.preparedStatements[DefaultWGName]["stmt_fresh"]
+	assert.True(t, ok)
+}
+
+func TestConnection_DeallocateStalePreparedStatementsUnknownAge(t *testing.T) {
+	c, nm := createPreparedStatementTestConnection()
+	nm.preparedStatements[DefaultWGName]["stmt_unknown"] = athenatypes.PreparedStatement{
+		StatementName:  aws.String("stmt_unknown"),
+		QueryStatement: aws.String("SELECT 2"),
+		WorkGroupName:  aws.String(DefaultWGName),
+	}
+	deallocated, err := c.DeallocateStalePreparedStatements(context.Background(), 24*time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"stmt_old"}, deallocated)
+	_, ok := nm.preparedStatements[DefaultWGName]["stmt_unknown"]
+	assert.True(t, ok)
+}
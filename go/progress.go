@@ -0,0 +1,84 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+)
+
+// QueryProgress is the status of a query execution seen by a poll while the driver waits for it to finish.
+type QueryProgress struct {
+	QueryID string
+	State   athenatypes.QueryExecutionState
+	// Elapsed is the time since the query execution was started.
+	Elapsed time.Duration
+	// DataScannedInBytes and EngineExecutionTime are from the statistics so far, and zero until Athena reports them.
+	DataScannedInBytes  int64
+	EngineExecutionTime time.Duration
+	// QueryExecution is the whole status the progress is from.
+	QueryExecution *athenatypes.QueryExecution
+}
+
+// ProgressFunc is called with the progress of a query every time its status is polled, the last time with its final
+// state. It is called by the goroutine waiting for the query, so it should return quickly.
+type ProgressFunc func(QueryProgress)
+
+// WithProgress is to have f called with the progress of the queries run with the returned context, e.g. to show
+// live progress of long CTAS statements.
+func WithProgress(ctx context.Context, f ProgressFunc) context.Context {
+	return context.WithValue(ctx, ProgressKey, f)
+}
+
+// WithProgressChannel is to have the progress of the queries run with the returned context sent to ch. A progress
+// is dropped rather than waited for when ch is full, so a slow reader does not slow down the queries.
+func WithProgressChannel(ctx context.Context, ch chan<- QueryProgress) context.Context {
+	return WithProgress(ctx, func(p QueryProgress) {
+		select {
+		case ch <- p:
+		default:
+		}
+	})
+}
+
+// reportProgress is to call the ProgressFunc of ctx, if any, with the progress of a polled query execution.
+func reportProgress(ctx context.Context, queryExecution *athenatypes.QueryExecution, start time.Time) {
+	f, ok := ctx.Value(ProgressKey).(ProgressFunc)
+	if !ok || f == nil || queryExecution == nil {
+		return
+	}
+	p := QueryProgress{
+		QueryID:        aws.ToString(queryExecution.QueryExecutionId),
+		Elapsed:        time.Since(start),
+		QueryExecution: queryExecution,
+	}
+	if queryExecution.Status != nil {
+		p.State = queryExecution.Status.State
+	}
+	if stats := queryExecution.Statistics; stats != nil {
+		p.DataScannedInBytes = aws.ToInt64(stats.DataScannedInBytes)
+		p.EngineExecutionTime = time.Duration(aws.ToInt64(stats.EngineExecutionTimeInMillis)) * time.Millisecond
+	}
+	f(p)
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnection_QueryContextProgress(t *testing.T) {
+	c := createConnectionFixture()
+	c.connector.config.SetInitialPollInterval(time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var progress []QueryProgress
+	ctx = WithProgress(ctx, func(p QueryProgress) {
+		progress = append(progress, p)
+		if len(progress) == 3 {
+			cancel()
+		}
+	})
+	_, err := c.QueryContext(ctx, "SELECTQueryContext_RUNNING", []driver.NamedValue{})
+	assert.NotNil(t, err)
+	assert.Len(t, progress, 3)
+	for _, p := range progress {
+		assert.Equal(t, "SELECTQueryContext_RUNNING_QID", p.QueryID)
+		assert.Equal(t, athenatypes.QueryExecutionStateRunning, p.State)
+		assert.Equal(t, int64(1024), p.DataScannedInBytes)
+		assert.Equal(t, 1500*time.Millisecond, p.EngineExecutionTime)
+		assert.NotNil(t, p.QueryExecution)
+	}
+	assert.True(t, progress[2].Elapsed >= progress[0].Elapsed)
+
+	// the last progress has the final state
+	ch := make(chan QueryProgress, 1)
+	_, err = c.QueryContext(WithProgressChannel(context.Background(), ch), "SELECTQueryContext_OK",
+		[]driver.NamedValue{})
+	assert.Nil(t, err)
+	p := <-ch
+	assert.Equal(t, athenatypes.QueryExecutionStateSucceeded, p.State)
+	assert.Equal(t, int64(0), p.DataScannedInBytes)
+}
+
+func TestWithProgressChannel(t *testing.T) {
+	ch := make(chan QueryProgress)
+	ctx := WithProgressChannel(context.Background(), ch)
+	// nobody reads the channel, and it does not block
+	reportProgress(ctx, &athenatypes.QueryExecution{}, time.Now())
+	reportProgress(context.Background(), &athenatypes.QueryExecution{}, time.Now())
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"strings"
+)
+
+// QuoteIdentifier is to quote a column, table, schema or catalog name for a query, e.g. "order" for order, so that
+// names built at runtime cannot break out of the identifier. A double quote in name is escaped by doubling it, the
+// way Presto/Trino does. DDL statements quote identifiers with backticks instead.
+func QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// QuoteLiteral is to quote a string as a varchar literal for a query, e.g. 'it''s' for it's. A single quote in s
+// is escaped by doubling it, and every other character, backslash included, is taken literally, unlike
+// FormatString, which escapes with MySQL style backslashes.
+func QuoteLiteral(s string) string {
+	buf := make([]byte, 0, len(s)+2)
+	buf = append(buf, '\'')
+	buf = escapeStringQuotes(buf, s)
+	return string(append(buf, '\''))
+}
+
+// QuoteBinary is to quote bytes as a varbinary literal for a query, e.g. X'0a' for a newline.
+func QuoteBinary(b []byte) string {
+	return string(appendHexBinary(make([]byte, 0, len(b)*2+3), b))
+}
+
+// QuoteQualifiedTable is to quote the parts of a qualified table name, e.g. "awsdatacatalog"."sampledb"."elb_logs"
+// for the catalog, the schema and the table. Empty parts are skipped, so an optional catalog or schema can be
+// passed as "".
+func QuoteQualifiedTable(names ...string) string {
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		if name != "" {
+			quoted = append(quoted, QuoteIdentifier(name))
+		}
+	}
+	return strings.Join(quoted, ".")
+}
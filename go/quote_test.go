@@ -0,0 +1,54 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuoteIdentifier(t *testing.T) {
+	assert.Equal(t, `"order"`, QuoteIdentifier("order"))
+	assert.Equal(t, `"a""b"`, QuoteIdentifier(`a"b`))
+	assert.Equal(t, `"x"" FROM t; DROP TABLE y --"`, QuoteIdentifier(`x" FROM t; DROP TABLE y --`))
+	assert.Equal(t, `""`, QuoteIdentifier(""))
+}
+
+func TestQuoteLiteral(t *testing.T) {
+	assert.Equal(t, `'it''s'`, QuoteLiteral("it's"))
+	assert.Equal(t, `'a\b'`, QuoteLiteral(`a\b`))
+	assert.Equal(t, `''`, QuoteLiteral(""))
+	assert.Equal(t, `''' OR 1=1 --'`, QuoteLiteral("' OR 1=1 --"))
+	assert.Equal(t, "'ünï\ncode'", QuoteLiteral("ünï\ncode"))
+}
+
+func TestQuoteQualifiedTable(t *testing.T) {
+	assert.Equal(t, `"awsdatacatalog"."sampledb"."elb_logs"`, QuoteQualifiedTable("awsdatacatalog", "sampledb", "elb_logs"))
+	assert.Equal(t, `"sampledb"."elb""logs"`, QuoteQualifiedTable("", "sampledb", `elb"logs`))
+	assert.Equal(t, `"elb_logs"`, QuoteQualifiedTable("elb_logs"))
+	assert.Equal(t, "", QuoteQualifiedTable())
+}
+
+func TestQuoteBinary(t *testing.T) {
+	assert.Equal(t, "X'0a27ff'", QuoteBinary([]byte("\n'\xff")))
+	assert.Equal(t, "X''", QuoteBinary(nil))
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+)
+
+// Athena APIs which can be rate limited with Config.SetAPIRateLimit.
+const (
+	APIStartQueryExecution = "StartQueryExecution"
+	APIGetQueryExecution   = "GetQueryExecution"
+	APIGetQueryResults     = "GetQueryResults"
+)
+
+// tokenBucket is a token bucket rate limiter. A nil tokenBucket has no limit.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket is to create a token bucket which allows rate requests per second on average, and bursts of
+// rate rounded up.
+func newTokenBucket(rate float64) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	burst := math.Ceil(rate)
+	return &tokenBucket{
+		rate:   rate,
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+// wait is to take a token, waiting until one is available or ctx is done.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	// The token is reserved now, so waiters are served in order.
+	b.tokens--
+	delay := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	b.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// rateLimitedClient is an AthenaClient which waits for a token of the bucket of an API before calling it.
+type rateLimitedClient struct {
+	AthenaClient
+	startQueryExecution *tokenBucket
+	getQueryExecution   *tokenBucket
+	getQueryResults     *tokenBucket
+}
+
+// StartQueryExecution calls AthenaClient.StartQueryExecution within the rate limit.
+func (c *rateLimitedClient) StartQueryExecution(ctx context.Context, input *athena.StartQueryExecutionInput,
+	optFns ...func(*athena.Options)) (*athena.StartQueryExecutionOutput, error) {
+	if err := c.startQueryExecution.wait(ctx); err != nil {
+		return nil, err
+	}
+	return c.AthenaClient.StartQueryExecution(ctx, input, optFns...)
+}
+
+// GetQueryExecution calls AthenaClient.GetQueryExecution within the rate limit.
+func (c *rateLimitedClient) GetQueryExecution(ctx context.Context, input *athena.GetQueryExecutionInput,
+	optFns ...func(*athena.Options)) (*athena.GetQueryExecutionOutput, error) {
+	if err := c.getQueryExecution.wait(ctx); err != nil {
+		return nil, err
+	}
+	return c.AthenaClient.GetQueryExecution(ctx, input, optFns...)
+}
+
+// GetQueryResults calls AthenaClient.GetQueryResults within the rate limit.
+func (c *rateLimitedClient) GetQueryResults(ctx context.Context, input *athena.GetQueryResultsInput,
+	optFns ...func(*athena.Options)) (*athena.GetQueryResultsOutput, error) {
+	if err := c.getQueryResults.wait(ctx); err != nil {
+		return nil, err
+	}
+	return c.AthenaClient.GetQueryResults(ctx, input, optFns...)
+}
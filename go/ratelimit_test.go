@@ -0,0 +1,80 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket(t *testing.T) {
+	assert.Nil(t, newTokenBucket(0))
+	var unlimited *tokenBucket
+	assert.Nil(t, unlimited.wait(context.Background()))
+
+	b := newTokenBucket(20)
+	start := time.Now()
+	for i := 0; i < 20; i++ {
+		assert.Nil(t, b.wait(context.Background()))
+	}
+	assert.True(t, time.Since(start) < 25*time.Millisecond)
+	assert.Nil(t, b.wait(context.Background()))
+	assert.True(t, time.Since(start) >= 40*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, b.wait(ctx))
+}
+
+func TestSQLConnector_WithRateLimits(t *testing.T) {
+	mock := newMockAthenaClient()
+	connector := NoopsSQLConnector()
+	assert.Equal(t, AthenaClient(mock), connector.withRateLimits(mock))
+
+	connector = NoopsSQLConnector()
+	connector.config.SetAPIRateLimit(APIGetQueryExecution, 1)
+	client := connector.withRateLimits(mock)
+	limited, ok := client.(*rateLimitedClient)
+	assert.True(t, ok)
+	assert.NotNil(t, limited.getQueryExecution)
+	assert.Nil(t, limited.startQueryExecution)
+	assert.Nil(t, limited.getQueryResults)
+	// buckets are shared by the connections of the connector
+	assert.Equal(t, limited.getQueryExecution, connector.withRateLimits(mock).(*rateLimitedClient).getQueryExecution)
+
+	input := &athena.GetQueryExecutionInput{QueryExecutionId: aws.String("SELECTQueryContext_OK_QID")}
+	_, err := client.GetQueryExecution(context.Background(), input)
+	assert.Nil(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = client.GetQueryExecution(ctx, input)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, 1, mock.callCount("GetQueryExecution"))
+
+	_, err = client.GetQueryResults(context.Background(),
+		&athena.GetQueryResultsInput{QueryExecutionId: aws.String("SELECT_OK")})
+	assert.Nil(t, err)
+}
@@ -0,0 +1,213 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"strings"
+)
+
+// readOnlyKeywords are the keywords of the statements which do not write to Athena.
+var readOnlyKeywords = map[string]bool{
+	"SELECT":   true,
+	"VALUES":   true,
+	"TABLE":    true,
+	"SHOW":     true,
+	"DESCRIBE": true,
+	"DESC":     true,
+	"EXPLAIN":  true,
+}
+
+// isReadOnlyStatement is to check if a query doesn't write to Athena. Statements are classified by the keyword of
+// the statement which actually runs, see statementKeyword, and the overrides in the config take precedence.
+func isReadOnlyStatement(query string, conf *Config) bool {
+	if IsQID(query) {
+		return true
+	}
+	keyword := statementKeyword(query)
+	if conf != nil {
+		if readOnly, ok := conf.GetStatementReadOnly(keyword); ok {
+			return readOnly
+		}
+	}
+	return readOnlyKeywords[keyword]
+}
+
+// statementKeyword is to get the upper case keyword of the statement which actually runs for a query, skipping
+// comments and leading parentheses. For example, it is SELECT for `WITH t AS (...) SELECT ...` and for
+// `USING FUNCTION ... SELECT ...`, and INSERT for `EXPLAIN ANALYZE INSERT ...`, which runs the INSERT.
+func statementKeyword(query string) string {
+	t := sqlTokenizer{query: query}
+	tok := t.next()
+	for tok == "(" {
+		tok = t.next()
+	}
+	switch tok {
+	case "WITH":
+		// WITH [RECURSIVE] name [(columns)] AS (query) [, ...] statement
+		depth, prev := 0, ""
+		for tok = t.next(); tok != ""; tok = t.next() {
+			switch {
+			case tok == "(":
+				depth++
+			case tok == ")":
+				depth--
+			case depth == 0 && prev == ")" && tok != "," && tok != "AS":
+				return tok
+			}
+			prev = tok
+		}
+		return "WITH"
+	case "USING":
+		// USING [EXTERNAL] FUNCTION ... [, ...] SELECT ...
+		depth := 0
+		for tok = t.next(); tok != ""; tok = t.next() {
+			switch {
+			case tok == "(":
+				depth++
+			case tok == ")":
+				depth--
+			case depth == 0 && tok == "SELECT":
+				return tok
+			}
+		}
+		return "USING"
+	case "EXPLAIN":
+		// EXPLAIN [(option [, ...])] statement only plans the statement, but EXPLAIN ANALYZE [VERBOSE] runs it.
+		if t.peek() == "ANALYZE" {
+			t.next()
+			if t.peek() == "VERBOSE" {
+				t.next()
+			}
+			return statementKeyword(t.rest())
+		}
+		return "EXPLAIN"
+	}
+	return tok
+}
+
+// sqlTokenizer splits a query into upper case words and punctuation, skipping whitespace and comments. String
+// literals and quoted identifiers are returned as their quote character.
+type sqlTokenizer struct {
+	query string
+	pos   int
+}
+
+// next is to get the next token, or empty at the end of the query.
+func (t *sqlTokenizer) next() string {
+	start, end := t.scan()
+	if start == end {
+		return ""
+	}
+	if c := t.query[start]; c == '\'' || c == '"' || c == '`' {
+		return string(c)
+	}
+	return strings.ToUpper(t.query[start:end])
+}
+
+// scan is to move past the next token and get where it is in the query, which is empty at the end of the query.
+func (t *sqlTokenizer) scan() (start int, end int) {
+	t.skipSpaceAndComments()
+	if t.pos >= len(t.query) {
+		return t.pos, t.pos
+	}
+	start = t.pos
+	switch c := t.query[t.pos]; {
+	case c == '\'' || c == '"' || c == '`':
+		// A doubled quote inside is read as two adjacent quoted tokens, which is just as good here.
+		for t.pos++; t.pos < len(t.query) && t.query[t.pos] != c; t.pos++ {
+		}
+		t.pos = min(t.pos+1, len(t.query))
+	case isWordChar(c):
+		for t.pos < len(t.query) && isWordChar(t.query[t.pos]) {
+			t.pos++
+		}
+	default:
+		t.pos++
+	}
+	return start, t.pos
+}
+
+// peek is to get the next token without consuming it.
+func (t *sqlTokenizer) peek() string {
+	pos := t.pos
+	tok := t.next()
+	t.pos = pos
+	return tok
+}
+
+// rest is to get the part of the query which is not consumed yet.
+func (t *sqlTokenizer) rest() string {
+	if t.pos >= len(t.query) {
+		return ""
+	}
+	return t.query[t.pos:]
+}
+
+func (t *sqlTokenizer) skipSpaceAndComments() {
+	for t.pos < len(t.query) {
+		switch {
+		case strings.IndexByte(" \t\r\n\f", t.query[t.pos]) >= 0:
+			t.pos++
+		case strings.HasPrefix(t.query[t.pos:], "--"):
+			if i := strings.IndexByte(t.query[t.pos:], '\n'); i >= 0 {
+				t.pos += i + 1
+			} else {
+				t.pos = len(t.query)
+			}
+		case strings.HasPrefix(t.query[t.pos:], "/*"):
+			if i := strings.Index(t.query[t.pos+2:], "*/"); i >= 0 {
+				t.pos += i + 4
+			} else {
+				t.pos = len(t.query)
+			}
+		default:
+			return
+		}
+	}
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
+
+// nextPlaceholder is to get the index of the first `?` placeholder at or after from, or -1 if there is none. A `?`
+// in a string literal, quoted identifier or comment is not a placeholder.
+func nextPlaceholder(query string, from int) int {
+	t := sqlTokenizer{query: query, pos: from}
+	for {
+		start, end := t.scan()
+		if start == end {
+			return -1
+		}
+		if query[start] == '?' {
+			return start
+		}
+	}
+}
+
+// countPlaceholders is to count the `?` placeholders in a query, see nextPlaceholder.
+func countPlaceholders(query string) int {
+	n := 0
+	for i := nextPlaceholder(query, 0); i >= 0; i = nextPlaceholder(query, i+1) {
+		n++
+	}
+	return n
+}
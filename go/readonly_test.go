@@ -0,0 +1,89 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatementKeyword(t *testing.T) {
+	tests := map[string]string{
+		"select 1":                             "SELECT",
+		"  -- comment\n/* block\n */ SELECT 1": "SELECT",
+		"(SELECT 1) UNION (SELECT 2)":          "SELECT",
+		"WITH t AS (SELECT 1) SELECT * FROM t": "SELECT",
+		"with recursive t(n) as (select 1 union all select n + 1 from t where n < 3), " +
+			"u as (select 'a)' as x) select * from t, u": "SELECT",
+		"WITH t AS (SELECT 1) INSERT INTO x SELECT * FROM t":                                               "INSERT",
+		"USING FUNCTION f(x VARCHAR) RETURNS VARCHAR LAMBDA_INVOKE WITH (lambda_name = 'l') SELECT f('a')": "SELECT",
+		"EXPLAIN SELECT 1":                                 "EXPLAIN",
+		"EXPLAIN (TYPE DISTRIBUTED) DELETE FROM x":         "EXPLAIN",
+		"EXPLAIN ANALYZE VERBOSE INSERT INTO x VALUES (1)": "INSERT",
+		"SHOW TABLES":                                      "SHOW",
+		"describe t":                                       "DESCRIBE",
+		"DROP TABLE t":                                     "DROP",
+		"/* unterminated":                                  "",
+		"":                                                 "",
+	}
+	for query, expected := range tests {
+		assert.Equal(t, expected, statementKeyword(query), query)
+	}
+}
+
+func TestIsReadOnlyStatement(t *testing.T) {
+	for _, query := range []string{"SELECT 1", "-- c\nWITH t AS (SELECT 1) SELECT * FROM t", "EXPLAIN DROP TABLE t",
+		"SHOW PARTITIONS t", "DESC t", "VALUES 1", "00000000-0000-0000-0000-000000000000"} {
+		assert.True(t, isReadOnlyStatement(query, nil), query)
+	}
+	for _, query := range []string{"INSERT INTO t VALUES (1)", "CREATE TABLE t AS SELECT 1", "MSCK REPAIR TABLE t",
+		"EXPLAIN ANALYZE INSERT INTO t VALUES (1)", "WITH t AS (SELECT 1) INSERT INTO x SELECT * FROM t", ""} {
+		assert.False(t, isReadOnlyStatement(query, nil), query)
+	}
+
+	testConf := NewNoOpsConfig()
+	testConf.SetStatementReadOnly("msck", true)
+	testConf.SetStatementReadOnly("SHOW", false)
+	assert.True(t, isReadOnlyStatement("MSCK REPAIR TABLE t", testConf))
+	assert.False(t, isReadOnlyStatement("SHOW TABLES", testConf))
+	assert.True(t, isReadOnlyStatement("SELECT 1", testConf))
+}
+
+func TestCountPlaceholders(t *testing.T) {
+	tests := map[string]int{
+		"":                        0,
+		"SELECT ?":                1,
+		"SELECT ?, ?":             2,
+		"SELECT 'why?', ?":        1,
+		"SELECT 'it''s ?', ?":     1,
+		`SELECT "a?" FROM t`:      0,
+		"SELECT `a?` FROM t":      0,
+		"SELECT ? -- why?\n, ?":   2,
+		"SELECT /* ? */ ?":        1,
+		"SELECT 'unterminated ?":  0,
+		"SELECT a?b":              1,
+		"SELECT ?/* unterminated": 1,
+	}
+	for query, expected := range tests {
+		assert.Equal(t, expected, countPlaceholders(query), query)
+	}
+}
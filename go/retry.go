@@ -0,0 +1,143 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"go.uber.org/zap"
+)
+
+// athenaErrorCategorySystem is the ErrorCategory of AthenaError for failures of Athena itself, as opposed to
+// failures caused by the query (2) or by something else (3).
+const athenaErrorCategorySystem = 1
+
+// retryableFailureReasons are the reasons of query failures which are transient, but which Athena does not always
+// mark as retryable.
+var retryableFailureReasons = []string{
+	"HIVE_CURSOR_ERROR",
+	"INTERNAL_ERROR_QUERY_ENGINE",
+	"experienced an internal error",
+	"Please reduce your request rate",
+}
+
+// QueryFailedError is returned when Athena fails a query execution. Its message is the reason Athena gives.
+type QueryFailedError struct {
+	QueryID string
+	Reason  string
+	// AthenaError is the error of the query execution, if Athena gave one.
+	AthenaError *athenatypes.AthenaError
+	// Retryable is whether the failure is transient, so executing the query again may succeed, see
+	// Config.SetQueryRetries.
+	Retryable bool
+}
+
+// Error is to implement interface error.
+func (e *QueryFailedError) Error() string {
+	return e.Reason
+}
+
+// newQueryFailedError returns the *QueryFailedError of a failed query execution.
+func newQueryFailedError(qe *athenatypes.QueryExecution, reason string) *QueryFailedError {
+	e := &QueryFailedError{QueryID: aws.ToString(qe.QueryExecutionId), Reason: reason,
+		AthenaError: qe.Status.AthenaError}
+	if athenaErr := qe.Status.AthenaError; athenaErr != nil {
+		e.Retryable = athenaErr.Retryable || athenaErr.ErrorCategory != nil &&
+			*athenaErr.ErrorCategory == athenaErrorCategorySystem
+	}
+	for _, r := range retryableFailureReasons {
+		e.Retryable = e.Retryable || strings.Contains(reason, r)
+	}
+	return e
+}
+
+// RetriedQueryError is returned when a query still fails after it has been executed again, see
+// Config.SetQueryRetries. It unwraps to the error of the last execution.
+type RetriedQueryError struct {
+	// QueryIDs are the QIDs of the executions of the query, in order.
+	QueryIDs []string
+	Err      error
+}
+
+// Error is to implement interface error.
+func (e *RetriedQueryError) Error() string {
+	return fmt.Sprintf("%v (executed %d times as %s)", e.Err, len(e.QueryIDs), strings.Join(e.QueryIDs, ", "))
+}
+
+// Unwrap is to get the error of the last execution.
+func (e *RetriedQueryError) Unwrap() error {
+	return e.Err
+}
+
+// executeQueryWithRetries executes a query with execute, which returns the QID of the execution if it started, and
+// executes read-only queries again after a backoff when they fail with a retryable QueryFailedError, as many times as
+// the config allows.
+func (c *Connection) executeQueryWithRetries(ctx context.Context, query string,
+	execute func() (string, *athenatypes.QueryExecution, error)) (*athenatypes.QueryExecution, error) {
+	obs := c.tracer(ctx)
+	retries := 0
+	if isReadOnlyStatement(query, c.connector.config) {
+		retries = c.connector.config.GetQueryRetries()
+	}
+	backoff := c.connector.config.GetQueryRetryBackoff()
+	var queryIDs []string
+	for attempt := 0; ; attempt++ {
+		queryID, qe, err := execute()
+		if queryID != "" {
+			queryIDs = append(queryIDs, queryID)
+		}
+		var failed *QueryFailedError
+		if err == nil || attempt == retries || !errors.As(err, &failed) || !failed.Retryable {
+			if len(queryIDs) < 2 {
+				return qe, err
+			}
+			obs.Log(InfoLevel, "retried query finished",
+				zap.Strings("queryIDs", queryIDs),
+				zap.Bool("succeeded", err == nil))
+			if err != nil {
+				obs.Scope().Counter(DriverName + ".failure.query.retry.exhausted").Inc(1)
+				return nil, &RetriedQueryError{QueryIDs: queryIDs, Err: err}
+			}
+			obs.Scope().Counter(DriverName + ".query.retry.succeeded").Inc(1)
+			return qe, nil
+		}
+		obs.Log(WarnLevel, "retrying failed query",
+			zap.String("queryID", failed.QueryID),
+			zap.String("reason", failed.Reason),
+			zap.Int("attempt", attempt+1),
+			zap.Duration("backoff", backoff))
+		obs.Scope().Counter(DriverName + ".query.retry").Inc(1)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, &RetriedQueryError{QueryIDs: queryIDs, Err: ctx.Err()}
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewQueryFailedError(t *testing.T) {
+	qe := &athenatypes.QueryExecution{
+		QueryExecutionId: aws.String("qid"),
+		Status:           &athenatypes.QueryExecutionStatus{},
+	}
+	e := newQueryFailedError(qe, "SYNTAX_ERROR: line 1:8: Column 'x' cannot be resolved")
+	assert.Equal(t, "qid", e.QueryID)
+	assert.Equal(t, "SYNTAX_ERROR: line 1:8: Column 'x' cannot be resolved", e.Error())
+	assert.False(t, e.Retryable)
+
+	assert.True(t, newQueryFailedError(qe, "HIVE_CURSOR_ERROR: Failed to read Parquet file").Retryable)
+
+	qe.Status.AthenaError = &athenatypes.AthenaError{ErrorCategory: aws.Int32(2)}
+	assert.False(t, newQueryFailedError(qe, "USER_ERROR").Retryable)
+	qe.Status.AthenaError = &athenatypes.AthenaError{ErrorCategory: aws.Int32(1)}
+	assert.True(t, newQueryFailedError(qe, "INTERNAL_ERROR").Retryable)
+	qe.Status.AthenaError = &athenatypes.AthenaError{ErrorCategory: aws.Int32(3), Retryable: true}
+	assert.True(t, newQueryFailedError(qe, "OTHER").Retryable)
+}
+
+func TestConnection_QueryContextRetries(t *testing.T) {
+	c := createConnectionFixture()
+	nm := c.athenaClient.(*mockAthenaClient)
+	nm.flakyFailures = 2
+	c.connector.config.SetQueryRetryBackoff(time.Millisecond)
+
+	// without retries, the failure is returned
+	_, err := c.QueryContext(context.Background(), "SELECT flaky", []driver.NamedValue{})
+	var failed *QueryFailedError
+	assert.True(t, errors.As(err, &failed))
+	assert.Equal(t, "SELECT_FLAKY_QID_1", failed.QueryID)
+	assert.True(t, failed.Retryable)
+
+	// the second execution fails as well, and the third one succeeds
+	c.connector.config.SetQueryRetries(3)
+	rows, err := c.QueryContext(context.Background(), "SELECT flaky", []driver.NamedValue{})
+	assert.Nil(t, err)
+	assert.NotNil(t, rows)
+	assert.Equal(t, 3, nm.callCount("StartQueryExecution"))
+
+	nm.flakyFailures = 10
+	c.connector.config.SetQueryRetries(1)
+	_, err = c.QueryContext(context.Background(), "SELECT flaky", []driver.NamedValue{})
+	var retried *RetriedQueryError
+	assert.True(t, errors.As(err, &retried))
+	assert.Equal(t, []string{"SELECT_FLAKY_QID_4", "SELECT_FLAKY_QID_5"}, retried.QueryIDs)
+	assert.True(t, errors.As(err, &failed))
+	assert.Equal(t, "SELECT_FLAKY_QID_5", failed.QueryID)
+
+	// failures which are not retryable are not executed again
+	_, err = c.QueryContext(context.Background(), "SELECTQueryContext_AWS_FAIL", []driver.NamedValue{})
+	assert.EqualError(t, err, "something_broken")
+	assert.Equal(t, 6, nm.callCount("StartQueryExecution"))
+}
@@ -23,8 +23,12 @@ package athenadriver
 import (
 	"context"
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"io"
+	"math/big"
+	"net/netip"
+	"reflect"
 	"strconv"
 	"strings"
 	"time"
@@ -37,6 +41,7 @@ import (
 )
 
 // Rows defines rows in AWS Athena ResultSet.
+// The rows of a page are read column by column from a resultPage, built from ResultOutput when its first row is read.
 type Rows struct {
 	athena          AthenaClient
 	ctx             context.Context
@@ -46,6 +51,23 @@ type Rows struct {
 	config          *Config
 	tracer          *DriverTracer
 	pageCount       int64
+	queryExecution  *athenatypes.QueryExecution
+	pages           pageSource
+	pageToken       *string
+	pageOffset      int
+	// onClose is called once when the rows are closed.
+	onClose func()
+	// fetchTimeout is the Fetch timeout of the TimeoutPolicy, after which no more pages are fetched, and
+	// fetchDeadline is when it is reached. Both are zero without the timeout.
+	fetchTimeout  time.Duration
+	fetchDeadline time.Time
+	// decoders are the column decoders of decodedColumns with decoderConfig, see columnDecoders.
+	decoders       []columnDecoder
+	decodedColumns []athenatypes.ColumnInfo
+	decoderConfig  *Config
+	// page is the current page column by column, built from pageOutput, see currentPage.
+	page       resultPage
+	pageOutput *athena.GetQueryResultsOutput
 }
 
 // NewNonOpsRows is to create a new Rows.
@@ -63,22 +85,78 @@ func NewNonOpsRows(ctx context.Context, client AthenaClient, queryID string, dri
 }
 
 // NewRows is to create a new Rows.
+// Without the query execution, whether the first row is a header is guessed by comparing it with the column names.
 func NewRows(ctx context.Context, client AthenaClient, queryID string, driverConfig *Config,
 	obs *DriverTracer) (*Rows, error) {
+	return newRows(ctx, client, queryID, nil, driverConfig, obs)
+}
+
+// newQueryExecutionRows is to create a new Rows for a finished query execution, whose statement type decides
+// whether the first row is a header.
+func newQueryExecutionRows(ctx context.Context, client AthenaClient, queryExecution *athenatypes.QueryExecution,
+	driverConfig *Config, obs *DriverTracer) (*Rows, error) {
+	return newRows(ctx, client, *queryExecution.QueryExecutionId, queryExecution, driverConfig, obs)
+}
+
+func newRows(ctx context.Context, client AthenaClient, queryID string, queryExecution *athenatypes.QueryExecution,
+	driverConfig *Config, obs *DriverTracer) (*Rows, error) {
 	r := Rows{
-		athena:    client,
-		ctx:       ctx,
-		queryID:   queryID,
-		config:    driverConfig,
-		tracer:    obs,
-		pageCount: -1,
+		athena:         client,
+		ctx:            ctx,
+		queryID:        queryID,
+		queryExecution: queryExecution,
+		config:         driverConfig,
+		tracer:         obs,
+		pageCount:      -1,
 	}
+	r.setFetchTimeout()
 	if err := r.fetchNextPage(nil); err != nil {
 		return nil, err
 	}
+	r.startPrefetch()
 	return &r, nil
 }
 
+// setFetchTimeout is to start the Fetch timeout of the config's TimeoutPolicy, or of the one in the context.
+func (r *Rows) setFetchTimeout() {
+	policy := r.config.GetTimeoutPolicy()
+	if override, ok := r.ctx.Value(TimeoutPolicyKey).(TimeoutPolicy); ok {
+		policy = policy.merge(override)
+	}
+	if policy.Fetch > 0 {
+		r.fetchTimeout = policy.Fetch
+		r.fetchDeadline = time.Now().Add(policy.Fetch)
+	}
+}
+
+// startPrefetch is to start prefetching the pages after the current one if it is enabled.
+func (r *Rows) startPrefetch() {
+	if r.reachedLastPage || r.ResultOutput.NextToken == nil || *r.ResultOutput.NextToken == "" {
+		return
+	}
+	if r.config.IsSpillToDisk() {
+		r.pages = newSpillingPrefetcher(r.ctx, r.athena, r.queryID, r.ResultOutput.NextToken,
+			r.config.GetSpillMemoryLimit(), r.config.GetSpillDir())
+	} else if depth := r.config.GetPrefetchPages(); depth > 0 {
+		r.pages = newPagePrefetcher(r.ctx, r.athena, r.queryID, r.ResultOutput.NextToken, depth)
+	}
+}
+
+// QueryExecution returns the final status of the query execution, including its statistics, as it was when
+// the query succeeded. It is nil for rows which are not from a query execution polled by the driver, e.g. a query
+// by QID.
+func (r *Rows) QueryExecution() *athenatypes.QueryExecution {
+	return r.queryExecution
+}
+
+// statementType is the type of the statement behind the Rows, or empty if it is unknown.
+func (r *Rows) statementType() athenatypes.StatementType {
+	if r.queryExecution == nil {
+		return ""
+	}
+	return r.queryExecution.StatementType
+}
+
 // Columns return Columns metadata.
 func (r *Rows) Columns() []string {
 	var columns []string
@@ -99,12 +177,90 @@ func (r *Rows) ColumnTypeDatabaseTypeName(index int) string {
 	return ""
 }
 
+// ColumnTypeScanType returns the Go type of the values returned for a column, which is suitable
+// for scanning into.
+func (r *Rows) ColumnTypeScanType(index int) reflect.Type {
+	colInfo := r.ResultOutput.ResultSet.ResultSetMetadata.ColumnInfo[index]
+	if r.config.IsRawStringMode() {
+		return reflect.TypeOf((*string)(nil))
+	}
+	if colInfo.Type == nil {
+		return reflect.TypeOf("")
+	}
+	if _, ok := getTypeConverter(*colInfo.Type); ok {
+		// registered converters can return any type
+		return reflect.TypeOf((*interface{})(nil)).Elem()
+	}
+	switch baseTypeName(*colInfo.Type) {
+	case "tinyint":
+		return reflect.TypeOf(int8(0))
+	case "smallint":
+		return reflect.TypeOf(int16(0))
+	case "integer":
+		return reflect.TypeOf(int32(0))
+	case "bigint":
+		return reflect.TypeOf(int64(0))
+	case "float", "real":
+		return reflect.TypeOf(float32(0))
+	case "double":
+		return reflect.TypeOf(float64(0))
+	case "boolean":
+		return reflect.TypeOf(false)
+	case "date", "time", "time with time zone", "timestamp", "timestamp with time zone":
+		return reflect.TypeOf(time.Time{})
+	case "json":
+		if r.config.IsJSONAsRawMessage() {
+			return reflect.TypeOf(json.RawMessage{})
+		}
+		return reflect.TypeOf("")
+	case "varbinary":
+		if mode := r.config.GetGeometryMode(); mode == GeometryAsWKT || mode == GeometryAsGeometry {
+			// depends on whether the value is a geometry
+			return reflect.TypeOf((*interface{})(nil)).Elem()
+		}
+		return reflect.TypeOf([]byte{})
+	case "geometry":
+		if r.config.GetGeometryMode() == GeometryAsGeometry {
+			return reflect.TypeOf((*Geometry)(nil)).Elem()
+		}
+		return reflect.TypeOf("")
+	case "ipaddress":
+		if r.config.IsTypedIPAddressAndUUID() {
+			return reflect.TypeOf(netip.Addr{})
+		}
+		return reflect.TypeOf("")
+	case "uuid":
+		if r.config.IsTypedIPAddressAndUUID() {
+			return reflect.TypeOf(UUID{})
+		}
+		return reflect.TypeOf("")
+	case "interval year to month":
+		return reflect.TypeOf(YearMonthInterval{})
+	case "interval day to second":
+		return reflect.TypeOf(time.Duration(0))
+	case "decimal":
+		switch r.config.GetDecimalMode() {
+		case DecimalAsString:
+			return reflect.TypeOf("")
+		case DecimalAsRat:
+			return reflect.TypeOf(&big.Rat{})
+		case DecimalAsFloat64:
+			return reflect.TypeOf(float64(0))
+		}
+		// registered converters can return any type
+		return reflect.TypeOf((*interface{})(nil)).Elem()
+	default:
+		return reflect.TypeOf("")
+	}
+}
+
 // Next is to get next result set page.
 func (r *Rows) Next(dest []driver.Value) error {
 	if r.reachedLastPage {
 		return io.EOF
 	}
-	if len(r.ResultOutput.ResultSet.Rows) == 0 {
+	page := r.currentPage()
+	if page.remaining() == 0 {
 		if r.ResultOutput.NextToken == nil || *r.ResultOutput.NextToken == "" {
 			// this means we reach the last page - no token and no rows
 			r.reachedLastPage = true
@@ -117,26 +273,107 @@ func (r *Rows) Next(dest []driver.Value) error {
 		if r.reachedLastPage {
 			return io.EOF
 		}
+		page = r.currentPage()
 	}
 
 	// Shift to next row
-	cur := r.ResultOutput.ResultSet.Rows[0]
 	columns := r.ResultOutput.ResultSet.ResultSetMetadata.ColumnInfo
-	if err := r.convertRow(columns, cur.Data, dest, r.config); err != nil {
+	if err := r.convertRow(columns, page, page.next, dest, r.config); err != nil {
 		return err
 	}
-	r.ResultOutput.ResultSet.Rows = r.ResultOutput.ResultSet.Rows[1:]
+	page.next++
+	r.pageOffset++
 	return nil
 }
 
+// NextBatch is to get up to n rows at once into dest, which must have room for n rows. A row slice in dest is
+// reused when it has as many elements as there are columns, and allocated otherwise. It returns the number of
+// rows read, and io.EOF with 0 rows after the last row. Rows.Next and NextBatch can be mixed.
+func (r *Rows) NextBatch(dest [][]driver.Value, n int) (int, error) {
+	if n > len(dest) {
+		n = len(dest)
+	}
+	read := 0
+	for read < n {
+		if r.reachedLastPage {
+			break
+		}
+		page := r.currentPage()
+		if page.remaining() == 0 {
+			if r.ResultOutput.NextToken == nil || *r.ResultOutput.NextToken == "" {
+				r.reachedLastPage = true
+				break
+			}
+			if err := r.fetchNextPage(r.ResultOutput.NextToken); err != nil {
+				return read, err
+			}
+			continue
+		}
+
+		columns := r.ResultOutput.ResultSet.ResultSetMetadata.ColumnInfo
+		for ; page.next < page.rows && read < n; page.next++ {
+			if len(dest[read]) != len(columns) {
+				dest[read] = make([]driver.Value, len(columns))
+			}
+			if err := r.convertRow(columns, page, page.next, dest[read], r.config); err != nil {
+				return read, err
+			}
+			read++
+			r.pageOffset++
+		}
+	}
+	if read == 0 && n > 0 {
+		return 0, io.EOF
+	}
+	return read, nil
+}
+
+// currentPage returns the current page column by column, building it from ResultOutput when it has changed since
+// the last call. The rows of ResultOutput are released then, as the page replaces them.
+func (r *Rows) currentPage() *resultPage {
+	if r.pageOutput != r.ResultOutput {
+		rs := r.ResultOutput.ResultSet
+		columnCount := 0
+		if rs.ResultSetMetadata != nil {
+			columnCount = len(rs.ResultSetMetadata.ColumnInfo)
+		}
+		r.page = newResultPage(rs.Rows, columnCount)
+		rs.Rows = nil
+		r.pageOutput = r.ResultOutput
+	}
+	return &r.page
+}
+
+// remainingRows is the number of rows of the current page left to read.
+func (r *Rows) remainingRows() int {
+	if r.ResultOutput == nil {
+		return 0
+	}
+	if r.pageOutput == r.ResultOutput {
+		return r.page.remaining()
+	}
+	return len(r.ResultOutput.ResultSet.Rows)
+}
+
 // fetchNextPage is to get next result set page with a specific token.
+// When pages come from a pageSource, e.g. a prefetcher which follows the same tokens in order, the page is taken
+// from it instead.
 func (r *Rows) fetchNextPage(token *string) error {
+	if !r.fetchDeadline.IsZero() && time.Now().After(r.fetchDeadline) {
+		r.tracer.Scope().Counter(DriverName + ".failure.fetchnextpage.timeout").Inc(1)
+		r.reachedLastPage = true
+		return &FetchTimeoutError{QueryID: r.queryID, Timeout: r.fetchTimeout}
+	}
 	var err error
-	r.ResultOutput, err = r.athena.GetQueryResults(r.ctx,
-		&athena.GetQueryResultsInput{
-			QueryExecutionId: aws.String(r.queryID),
-			NextToken:        token,
-		})
+	if r.pages != nil {
+		r.ResultOutput, err = r.pages.next()
+	} else {
+		r.ResultOutput, err = r.athena.GetQueryResults(r.ctx,
+			&athena.GetQueryResultsInput{
+				QueryExecutionId: aws.String(r.queryID),
+				NextToken:        token,
+			})
+	}
 	if err != nil {
 		r.tracer.Scope().Counter(DriverName + ".failure.fetchnextpage.getqueryresults").Inc(1)
 		r.tracer.Log(ErrorLevel, "GetQueryResults failed", zap.String("error", err.Error()))
@@ -144,6 +381,10 @@ func (r *Rows) fetchNextPage(token *string) error {
 		return err
 	}
 
+	r.pageToken = token
+	r.pageOffset = 0
+	// The page may be the same output with other rows, e.g. from a ResultTransport.
+	r.pageOutput = nil
 	r.pageCount++
 	// First row of the first page contains header if the query is not DDL.
 	// These are also available in *athenaAPI.Row.ResultSetMetadata.
@@ -156,6 +397,10 @@ func (r *Rows) fetchNextPage(token *string) error {
 	//     _col0
 	//     Partitions not in metastore:    elb_logs:2015/01/01     elb_logs:2015/01/02     elb_logs:2015/01/03
 	//       elb_logs:2015/01/04     elb_logs:2015/01/05     elb_logs:2015/01/06     elb_logs:2015/01/07
+	returnedRows := 0
+	if r.ResultOutput != nil && r.ResultOutput.ResultSet != nil {
+		returnedRows = len(r.ResultOutput.ResultSet.Rows)
+	}
 	if r.ResultOutput != nil &&
 		r.ResultOutput.ResultSet.ResultSetMetadata != nil &&
 		r.ResultOutput.ResultSet.ResultSetMetadata.ColumnInfo != nil {
@@ -195,7 +440,13 @@ func (r *Rows) fetchNextPage(token *string) error {
 		}
 	}
 	var rowOffset = 0
-	if r.pageCount == 0 {
+	if r.pageCount == 0 && r.statementType() != "" {
+		// Only the results of DML statements start with a header row. The update count row made up above for
+		// INSERT INTO is not returned by Athena, so it is never a header.
+		if r.statementType() == athenatypes.StatementTypeDml && returnedRows > 0 {
+			rowOffset = 1
+		}
+	} else if r.pageCount == 0 {
 		rs := r.ResultOutput.ResultSet
 		ci := r.ResultOutput.ResultSet.ResultSetMetadata.ColumnInfo
 		i := 0
@@ -221,6 +472,9 @@ func (r *Rows) fetchNextPage(token *string) error {
 	}
 
 	r.ResultOutput.ResultSet.Rows = r.ResultOutput.ResultSet.Rows[rowOffset:]
+	if r.config.IsParseUtilityOutput() {
+		r.parseUtilityOutput()
+	}
 	return nil
 }
 
@@ -228,27 +482,88 @@ func (r *Rows) fetchNextPage(token *string) error {
 func (r *Rows) Close() error {
 	if r.ResultOutput != nil && r.ResultOutput.NextToken != nil {
 		r.tracer.Log(WarnLevel, "rows close prematurely, queryID: "+r.queryID)
-		r.ResultOutput = nil
+		r.tracer.Scope().Counter(DriverName + ".rows.closedearly").Inc(1)
 	}
+	// The query execution has already succeeded when there are Rows, so there is nothing left to stop in Athena
+	// but the fetching of the remaining pages.
+	if r.pages != nil {
+		r.pages.stop()
+		r.pages = nil
+	}
+	if r.onClose != nil {
+		onClose := r.onClose
+		r.onClose = nil
+		onClose()
+	}
+	r.ResultOutput = nil
+	r.pageOutput = nil
+	r.page = resultPage{}
 	r.reachedLastPage = true
 	return nil
 }
 
-// convertRow is to convert data from Athena type to Golang SQL type and put them into an array of driver.Value.
-func (r *Rows) convertRow(columns []athenatypes.ColumnInfo, rdata []athenatypes.Datum, ret []driver.Value,
+// columnDecoder is how the values of a column are converted, resolved once per result page rather than per value.
+type columnDecoder struct {
+	info     athenatypes.ColumnInfo
+	baseType string
+	masked   bool
+	// maskedValue is boxed once, rather than for each value.
+	maskedValue interface{}
+	converter   TypeConverter
+	rawString   bool
+	nullAsNil   bool
+	// emptyIsNull is whether an empty cell is NULL rather than an empty value with nullAsNil.
+	emptyIsNull bool
+}
+
+// newColumnDecoder is to resolve the conversion of the values of a column with the config.
+func newColumnDecoder(columnInfo athenatypes.ColumnInfo, driverConfig *Config) columnDecoder {
+	d := columnDecoder{
+		info:        columnInfo,
+		baseType:    baseTypeName(*columnInfo.Type),
+		rawString:   driverConfig.IsRawStringMode(),
+		nullAsNil:   driverConfig.IsNullAsNil(),
+		emptyIsNull: !canBeEmptyString(*columnInfo.Type),
+	}
+	if maskedValue, masked := driverConfig.CheckColumnMasked(*columnInfo.Name); masked {
+		d.maskedValue, d.masked = maskedValue, true
+	}
+	var ok bool
+	if d.converter, ok = getTypeConverter(*columnInfo.Type); !ok && d.baseType == "decimal" {
+		d.converter, _ = getDecimalConverter(driverConfig.GetDecimalMode())
+	}
+	return d
+}
+
+// columnDecoders is to get the decoders of the columns of the current page, reusing those of the previous page
+// when the columns are the same.
+func (r *Rows) columnDecoders(columns []athenatypes.ColumnInfo, driverConfig *Config) []columnDecoder {
+	if len(r.decoders) == len(columns) && r.decoderConfig == driverConfig &&
+		(len(columns) == 0 || &r.decodedColumns[0] == &columns[0]) {
+		return r.decoders
+	}
+	r.decoders = r.decoders[:0]
+	for _, c := range columns {
+		r.decoders = append(r.decoders, newColumnDecoder(c, driverConfig))
+	}
+	r.decodedColumns = columns
+	r.decoderConfig = driverConfig
+	return r.decoders
+}
+
+// convertRow is to convert a row of a page from Athena type to Golang SQL type and put them into an array of
+// driver.Value. The values are written into ret in place, so that reading a row allocates nothing but the values
+// themselves.
+func (r *Rows) convertRow(columns []athenatypes.ColumnInfo, page *resultPage, row int, ret []driver.Value,
 	driverConfig *Config) error {
-	for i, val := range rdata {
-		value, err := r.athenaTypeToGoType(columns[i], val.VarCharValue, driverConfig)
+	decoders := r.columnDecoders(columns, driverConfig)
+	for i := range page.columns {
+		value, err := r.decodeValue(&decoders[i], page.value(i, row), driverConfig)
 		if err != nil {
 			r.tracer.Log(ErrorLevel, "convertrow failed", zap.String("error", err.Error()))
 			r.tracer.Scope().Counter(DriverName + ".failure.convertrow").Inc(1)
 			return err
 		}
-		/*r.tracer.Log(DebugLevel, "TM",
-			zap.String("athenaType", *columns[i].Type),
-			zap.String("goType", reflect.TypeOf(value).String()),
-			zap.String("str", *val.VarCharValue),
-		)*/
 		ret[i] = value
 	}
 	return nil
@@ -265,8 +580,24 @@ func (r *Rows) convertRow(columns []athenatypes.ColumnInfo, rdata []athenatypes.
 // The full list is here: https://prestodb.io/docs/0.172/language/types.html
 // Include ipaddress for forward compatibility.
 func (r *Rows) athenaTypeToGoType(columnInfo athenatypes.ColumnInfo, rawValue *string, driverConfig *Config) (interface{}, error) {
-	if maskedValue, masked := driverConfig.CheckColumnMasked(*columnInfo.Name); masked { // "comma ok" idiom
-		return maskedValue, nil
+	d := newColumnDecoder(columnInfo, driverConfig)
+	return r.decodeValue(&d, rawValue, driverConfig)
+}
+
+// decodeValue is to convert a value of a column with its decoder, see athenaTypeToGoType.
+func (r *Rows) decodeValue(d *columnDecoder, rawValue *string, driverConfig *Config) (interface{}, error) {
+	columnInfo := d.info
+	if d.masked {
+		return d.maskedValue, nil
+	}
+	if d.rawString {
+		if rawValue == nil {
+			return nil, nil
+		}
+		return *rawValue, nil
+	}
+	if d.nullAsNil && (rawValue == nil || (*rawValue == "" && d.emptyIsNull)) {
+		return nil, nil
 	}
 	if rawValue == nil {
 		r.tracer.Scope().Counter(DriverName + ".missingvalue").Inc(1)
@@ -279,19 +610,30 @@ func (r *Rows) athenaTypeToGoType(columnInfo athenatypes.ColumnInfo, rawValue *s
 		} else if driverConfig.IsMissingAsEmptyString() {
 			return "", nil
 		} else if driverConfig.IsMissingAsDefault() {
-			return r.getDefaultValueForColumnType(*columnInfo.Type), nil
+			return r.getDefaultValueForColumnType(d.baseType), nil
 		}
 		r.tracer.Scope().Counter(DriverName + ".failure.convertvalue.config").Inc(1)
 		r.tracer.Log(ErrorLevel, "missing data", zap.String("columnInfo.Name", *columnInfo.Name))
 		return nil, fmt.Errorf("Missing data at column " + *columnInfo.Name)
 	}
 	val := *rawValue
+	if d.converter != nil {
+		value, err := d.converter(val)
+		if err != nil {
+			r.tracer.Scope().Counter(DriverName + ".failure.convertvalue.converter").Inc(1)
+			r.tracer.Log(ErrorLevel, "type converter error",
+				zap.String("val", val),
+				zap.String("type", *columnInfo.Type))
+			return nil, err
+		}
+		return value, nil
+	}
 	// https://stackoverflow.com/questions/30299649/parse-string-to-specific-type-of-int-int8-int16-int32-int64
 	// https://prestodb.io/docs/current/language/types.html#integer
 	var err error
 	var i int64
 	var f float64
-	switch *columnInfo.Type {
+	switch d.baseType {
 	case "tinyint":
 		// strconv.ParseInt() behavior is to return (int64(0), err)
 		// which is not as good as just return (nil, err)
@@ -326,10 +668,66 @@ func (r *Rows) athenaTypeToGoType(columnInfo athenatypes.ColumnInfo, rawValue *s
 		return f, nil
 	// for binary, we assume all chars are 0 or 1; for json,
 	// we assume the json syntax is correct. Leave to caller to verify it.
-	case "json", "char", "varchar", "varbinary", "row", "string", "binary",
-		"struct", "interval year to month", "interval day to second", "decimal",
-		"ipaddress", "array", "map", "unknown":
+	case "char", "varchar", "row", "string", "binary",
+		"struct", "array", "map", "unknown":
+		return val, nil
+	case "json":
+		if driverConfig.IsJSONAsRawMessage() {
+			return json.RawMessage(val), nil
+		}
 		return val, nil
+	case "interval year to month":
+		iv, err := parseYearToMonthInterval(val)
+		if err != nil {
+			r.tracer.Scope().Counter(DriverName + ".failure.convertvalue.interval").Inc(1)
+			r.tracer.Log(ErrorLevel, "interval data error", zap.String("val", val))
+			return nil, err
+		}
+		return iv, nil
+	case "interval day to second":
+		d, err := parseDayToSecondInterval(val)
+		if err != nil {
+			r.tracer.Scope().Counter(DriverName + ".failure.convertvalue.interval").Inc(1)
+			r.tracer.Log(ErrorLevel, "interval data error", zap.String("val", val))
+			return nil, err
+		}
+		return d, nil
+	case "ipaddress":
+		addr, err := netip.ParseAddr(val)
+		if err != nil {
+			r.tracer.Scope().Counter(DriverName + ".failure.convertvalue.ipaddress").Inc(1)
+			r.tracer.Log(ErrorLevel, "ipaddress data error", zap.String("val", val))
+			return nil, err
+		}
+		if !driverConfig.IsTypedIPAddressAndUUID() {
+			return val, nil
+		}
+		return addr, nil
+	case "uuid":
+		u, err := parseUUID(val)
+		if err != nil {
+			r.tracer.Scope().Counter(DriverName + ".failure.convertvalue.uuid").Inc(1)
+			r.tracer.Log(ErrorLevel, "uuid data error", zap.String("val", val))
+			return nil, err
+		}
+		if !driverConfig.IsTypedIPAddressAndUUID() {
+			return val, nil
+		}
+		return u, nil
+	case "varbinary":
+		b, err := decodeVarbinary(val)
+		if err != nil {
+			r.tracer.Scope().Counter(DriverName + ".failure.convertvalue.varbinary").Inc(1)
+			r.tracer.Log(ErrorLevel, "varbinary data error", zap.String("val", val))
+			return nil, err
+		}
+		return r.convertGeometry(b, driverConfig), nil
+	case "geometry":
+		return r.convertWKT(val, driverConfig)
+	case "decimal":
+		// the converter of a known decimal mode is the decoder's
+		r.tracer.Scope().Counter(DriverName + ".failure.convertvalue.decimalmode").Inc(1)
+		return nil, fmt.Errorf("unknown decimal mode `%s`", driverConfig.GetDecimalMode())
 	case "boolean":
 		if val == "true" {
 			return true, nil
@@ -357,6 +755,46 @@ func (r *Rows) athenaTypeToGoType(columnInfo athenatypes.ColumnInfo, rawValue *s
 	}
 }
 
+// convertGeometry returns a varbinary value as WKT or Geometry if it is WKB and the geometry mode asks for it.
+func (r *Rows) convertGeometry(b []byte, driverConfig *Config) interface{} {
+	mode := driverConfig.GetGeometryMode()
+	if mode != GeometryAsWKT && mode != GeometryAsGeometry {
+		return b
+	}
+	g, err := parseWKB(b)
+	if err != nil {
+		// not every varbinary is a geometry
+		return b
+	}
+	if mode == GeometryAsWKT {
+		return g.WKT()
+	}
+	return g
+}
+
+// convertWKT returns a geometry value, which Athena sends as WKT, as Geometry if the geometry mode asks for it.
+func (r *Rows) convertWKT(val string, driverConfig *Config) (interface{}, error) {
+	if driverConfig.GetGeometryMode() != GeometryAsGeometry {
+		return val, nil
+	}
+	g, err := parseWKT(val)
+	if err != nil {
+		r.tracer.Scope().Counter(DriverName + ".failure.convertvalue.geometry").Inc(1)
+		r.tracer.Log(ErrorLevel, "geometry data error", zap.String("val", val))
+		return nil, err
+	}
+	return g, nil
+}
+
+// canBeEmptyString returns true if an empty cell of a column type is a valid value rather than NULL.
+func canBeEmptyString(athenaType string) bool {
+	switch athenaType {
+	case "char", "varchar", "string", "json", "varbinary", "binary", "unknown":
+		return true
+	}
+	return false
+}
+
 // getDefaultValueForColumnType is used internally by athenaTypeToGoType to get default value for a column type.
 // This is helpful when column has missing value and we want to display it anyway.
 func (r *Rows) getDefaultValueForColumnType(athenaType string) interface{} {
@@ -369,9 +807,26 @@ func (r *Rows) getDefaultValueForColumnType(athenaType string) interface{} {
 		return 0.0
 	case "date", "time", "time with time zone", "timestamp", "timestamp with time zone":
 		return time.Time{}
-	case "json", "char", "varchar", "varbinary", "row", "string", "binary",
-		"struct", "interval year to month", "interval day to second", "decimal",
-		"ipaddress", "array", "map", "unknown":
+	case "varbinary":
+		return []byte{}
+	case "geometry":
+		return ""
+	case "ipaddress":
+		if r.config != nil && r.config.IsTypedIPAddressAndUUID() {
+			return netip.Addr{}
+		}
+		return ""
+	case "uuid":
+		if r.config != nil && r.config.IsTypedIPAddressAndUUID() {
+			return UUID{}
+		}
+		return ""
+	case "interval year to month":
+		return YearMonthInterval{}
+	case "interval day to second":
+		return time.Duration(0)
+	case "json", "char", "varchar", "row", "string", "binary",
+		"struct", "decimal", "array", "map", "unknown":
 		return ""
 	default:
 		r.tracer.Scope().Counter(DriverName + ".failure.defaultvalueforcolumntype.type").Inc(1)
@@ -22,13 +22,19 @@ package athenadriver
 
 import (
 	"context"
+	"database/sql"
 	"database/sql/driver"
+	"encoding/json"
 	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
 	"io"
+	"math/big"
+	"net/netip"
 	"reflect"
+	"strconv"
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/athena"
 	"github.com/stretchr/testify/assert"
 )
@@ -253,11 +259,15 @@ func TestRows_GetDefaultValueForColumnType(t *testing.T) {
 		for _, v := range []string{"tinyint", "smallint", "integer", "bigint"} {
 			assert.Equal(t, r.getDefaultValueForColumnType(v), 0)
 		}
-		for _, v := range []string{"json", "char", "varchar", "varbinary", "row", "string", "binary",
-			"struct", "interval year to month", "interval day to second", "decimal",
-			"ipaddress", "array", "map", "unknown"} {
+		for _, v := range []string{"json", "char", "varchar", "row", "string", "binary",
+			"struct", "decimal", "array", "map", "unknown"} {
 			assert.Equal(t, r.getDefaultValueForColumnType(v), "")
 		}
+		assert.Equal(t, r.getDefaultValueForColumnType("interval year to month"), YearMonthInterval{})
+		assert.Equal(t, r.getDefaultValueForColumnType("interval day to second"), time.Duration(0))
+		assert.Equal(t, r.getDefaultValueForColumnType("varbinary"), []byte{})
+		assert.Equal(t, r.getDefaultValueForColumnType("ipaddress"), "")
+		assert.Equal(t, r.getDefaultValueForColumnType("uuid"), "")
 		for _, v := range []string{"float", "double", "real"} {
 			assert.Equal(t, r.getDefaultValueForColumnType(v), 0.0)
 		}
@@ -358,10 +368,9 @@ func TestRows_AthenaTypeToGoType(t *testing.T) {
 	assert.Nil(t, g)
 
 	// string-like
-	for _, s := range []string{"json", "char", "varchar", "varbinary", "row",
+	for _, s := range []string{"json", "char", "varchar", "row",
 		"string", "binary",
-		"struct", "interval year to month", "interval day to second", "decimal",
-		"ipaddress", "array", "map", "unknown"} {
+		"struct", "decimal", "array", "map", "unknown"} {
 		c = newColumnInfo("a", s)
 		rv = "012"
 		g, e = r.athenaTypeToGoType(c, &rv, testConf)
@@ -369,6 +378,90 @@ func TestRows_AthenaTypeToGoType(t *testing.T) {
 		assert.Equal(t, "012", g)
 	}
 
+	// varbinary
+	c = newColumnInfo("a", "varbinary")
+	rv = "68 65 6c 6c 6f"
+	g, e = r.athenaTypeToGoType(c, &rv, testConf)
+	assert.Nil(t, e)
+	assert.Equal(t, []byte("hello"), g)
+
+	rv = ""
+	g, e = r.athenaTypeToGoType(c, &rv, testConf)
+	assert.Nil(t, e)
+	assert.Equal(t, []byte{}, g)
+
+	rv = "012"
+	g, e = r.athenaTypeToGoType(c, &rv, testConf)
+	assert.NotNil(t, e)
+	assert.Nil(t, g)
+
+	// intervals
+	c = newColumnInfo("a", "interval year to month")
+	rv = "1-2"
+	g, e = r.athenaTypeToGoType(c, &rv, testConf)
+	assert.Nil(t, e)
+	assert.Equal(t, YearMonthInterval{Years: 1, Months: 2}, g)
+
+	c = newColumnInfo("a", "interval day to second")
+	rv = "2 00:00:00.000"
+	g, e = r.athenaTypeToGoType(c, &rv, testConf)
+	assert.Nil(t, e)
+	assert.Equal(t, 48*time.Hour, g)
+
+	rv = "012"
+	g, e = r.athenaTypeToGoType(c, &rv, testConf)
+	assert.NotNil(t, e)
+	assert.Nil(t, g)
+
+	// ipaddress, a validated string unless typed values are on
+	c = newColumnInfo("a", "ipaddress")
+	rv = "10.0.0.1"
+	g, e = r.athenaTypeToGoType(c, &rv, testConf)
+	assert.Nil(t, e)
+	assert.Equal(t, "10.0.0.1", g)
+
+	rv = "10.0"
+	_, e = r.athenaTypeToGoType(c, &rv, testConf)
+	assert.NotNil(t, e)
+
+	testConf.SetTypedIPAddressAndUUID(true)
+	rv = "10.0.0.1"
+	g, e = r.athenaTypeToGoType(c, &rv, testConf)
+	assert.Nil(t, e)
+	assert.Equal(t, netip.MustParseAddr("10.0.0.1"), g)
+
+	rv = "2001:db8::1"
+	g, e = r.athenaTypeToGoType(c, &rv, testConf)
+	assert.Nil(t, e)
+	assert.Equal(t, netip.MustParseAddr("2001:db8::1"), g)
+
+	rv = "10.0.0"
+	g, e = r.athenaTypeToGoType(c, &rv, testConf)
+	assert.NotNil(t, e)
+	assert.Nil(t, g)
+
+	// uuid
+	c = newColumnInfo("a", "uuid")
+	rv = "123e4567-e89b-12d3-a456-426614174000"
+	g, e = r.athenaTypeToGoType(c, &rv, testConf)
+	assert.Nil(t, e)
+	assert.Equal(t, UUID{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3,
+		0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}, g)
+
+	rv = "123e4567e89b12d3a456426614174000"
+	g, e = r.athenaTypeToGoType(c, &rv, testConf)
+	assert.NotNil(t, e)
+	assert.Nil(t, g)
+
+	testConf.SetTypedIPAddressAndUUID(false)
+	rv = "123e4567-e89b-12d3-a456-426614174000"
+	g, e = r.athenaTypeToGoType(c, &rv, testConf)
+	assert.Nil(t, e)
+	assert.Equal(t, rv, g)
+	rv = "123e4567e89b12d3a456426614174000"
+	_, e = r.athenaTypeToGoType(c, &rv, testConf)
+	assert.NotNil(t, e)
+
 	// boolean
 	for _, s := range []string{"boolean"} {
 		c = newColumnInfo("a", s)
@@ -572,3 +665,373 @@ func TestRows_NewRows(t *testing.T) {
 	}
 
 }
+
+func TestRows_ColumnTypeScanType(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	r, _ := NewRows(context.Background(), newMockAthenaClient(),
+		"SELECT_OK", testConf, NewDefaultObservability(testConf))
+	expected := map[string]reflect.Type{
+		"tinyint":                  reflect.TypeOf(int8(0)),
+		"integer":                  reflect.TypeOf(int32(0)),
+		"double":                   reflect.TypeOf(float64(0)),
+		"boolean":                  reflect.TypeOf(false),
+		"timestamp with time zone": reflect.TypeOf(time.Time{}),
+		"varbinary":                reflect.TypeOf([]byte{}),
+		"ipaddress":                reflect.TypeOf(""),
+		"uuid":                     reflect.TypeOf(""),
+		"interval year to month":   reflect.TypeOf(YearMonthInterval{}),
+		"interval day to second":   reflect.TypeOf(time.Duration(0)),
+		"decimal":                  reflect.TypeOf(""),
+		"array":                    reflect.TypeOf(""),
+	}
+	for ty, scanType := range expected {
+		r.ResultOutput.ResultSet.ResultSetMetadata.ColumnInfo = []athenatypes.ColumnInfo{newColumnInfo("a", ty)}
+		assert.Equal(t, scanType, r.ColumnTypeScanType(0), ty)
+	}
+
+	testConf.SetTypedIPAddressAndUUID(true)
+	r.ResultOutput.ResultSet.ResultSetMetadata.ColumnInfo = []athenatypes.ColumnInfo{newColumnInfo("a", "ipaddress")}
+	assert.Equal(t, reflect.TypeOf(netip.Addr{}), r.ColumnTypeScanType(0))
+	r.ResultOutput.ResultSet.ResultSetMetadata.ColumnInfo = []athenatypes.ColumnInfo{newColumnInfo("a", "uuid")}
+	assert.Equal(t, reflect.TypeOf(UUID{}), r.ColumnTypeScanType(0))
+
+	r.ResultOutput.ResultSet.ResultSetMetadata.ColumnInfo = []athenatypes.ColumnInfo{newColumnInfo("a", "decimal")}
+	testConf.SetDecimalMode(DecimalAsRat)
+	assert.Equal(t, reflect.TypeOf(&big.Rat{}), r.ColumnTypeScanType(0))
+	testConf.SetDecimalMode("custom")
+	assert.Equal(t, reflect.TypeOf((*interface{})(nil)).Elem(), r.ColumnTypeScanType(0))
+
+	r.ResultOutput.ResultSet.ResultSetMetadata.ColumnInfo = []athenatypes.ColumnInfo{newColumnInfo("a", "json")}
+	assert.Equal(t, reflect.TypeOf(""), r.ColumnTypeScanType(0))
+	testConf.SetJSONAsRawMessage(true)
+	assert.Equal(t, reflect.TypeOf(json.RawMessage{}), r.ColumnTypeScanType(0))
+}
+
+func TestRows_AthenaTypeToGoTypeNullAsNil(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	testConf.SetNullAsNil(true)
+	r, _ := NewRows(context.Background(), newMockAthenaClient(),
+		"SELECT_OK", testConf, NewDefaultObservability(testConf))
+
+	// NULL cells are nil for every column type, even with missingAsEmptyString on
+	assert.True(t, testConf.IsMissingAsEmptyString())
+	for _, ty := range AthenaColumnTypes {
+		g, e := r.athenaTypeToGoType(newColumnInfo("a", ty), nil, testConf)
+		assert.Nil(t, e)
+		assert.Nil(t, g)
+	}
+
+	// an empty cell is NULL unless the column type can hold an empty string
+	empty := ""
+	g, e := r.athenaTypeToGoType(newColumnInfo("a", "integer"), &empty, testConf)
+	assert.Nil(t, e)
+	assert.Nil(t, g)
+	var i sql.NullInt64
+	assert.Nil(t, i.Scan(g))
+	assert.False(t, i.Valid)
+
+	g, e = r.athenaTypeToGoType(newColumnInfo("a", "timestamp"), &empty, testConf)
+	assert.Nil(t, e)
+	assert.Nil(t, g)
+
+	g, e = r.athenaTypeToGoType(newColumnInfo("a", "varchar"), &empty, testConf)
+	assert.Nil(t, e)
+	var s sql.NullString
+	assert.Nil(t, s.Scan(g))
+	assert.True(t, s.Valid)
+	assert.Equal(t, "", s.String)
+
+	one := "1"
+	g, e = r.athenaTypeToGoType(newColumnInfo("a", "integer"), &one, testConf)
+	assert.Nil(t, e)
+	assert.Nil(t, i.Scan(g))
+	assert.True(t, i.Valid)
+	assert.Equal(t, int64(1), i.Int64)
+}
+
+func TestRows_AthenaTypeToGoTypeJSONAsRawMessage(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	r, _ := NewRows(context.Background(), newMockAthenaClient(),
+		"SELECT_OK", testConf, NewDefaultObservability(testConf))
+	c := newColumnInfo("a", "json")
+	rv := `{"a":[1,"b"]}`
+	g, e := r.athenaTypeToGoType(c, &rv, testConf)
+	assert.Nil(t, e)
+	assert.Equal(t, rv, g)
+
+	testConf.SetJSONAsRawMessage(true)
+	g, e = r.athenaTypeToGoType(c, &rv, testConf)
+	assert.Nil(t, e)
+	assert.Equal(t, json.RawMessage(rv), g)
+	var v map[string]interface{}
+	assert.Nil(t, json.Unmarshal(g.(json.RawMessage), &v))
+	assert.Equal(t, []interface{}{float64(1), "b"}, v["a"])
+}
+
+func TestRows_AthenaTypeToGoTypeRawStringMode(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	testConf.SetRawStringMode(true)
+	testConf.SetDecimalMode("unknown")
+	r, _ := NewRows(context.Background(), newMockAthenaClient(),
+		"SELECT_OK", testConf, NewDefaultObservability(testConf))
+	for ty, rv := range map[string]string{
+		"integer":   "012",
+		"boolean":   "maybe",
+		"timestamp": "2020-01-02 03:04:05.000",
+		"varbinary": "68 65",
+		"decimal":   "1.50",
+		"weird":     "x",
+	} {
+		g, e := r.athenaTypeToGoType(newColumnInfo("a", ty), &rv, testConf)
+		assert.Nil(t, e)
+		assert.Equal(t, rv, g)
+	}
+	g, e := r.athenaTypeToGoType(newColumnInfo("a", "integer"), nil, testConf)
+	assert.Nil(t, e)
+	assert.Nil(t, g)
+
+	r.ResultOutput.ResultSet.ResultSetMetadata.ColumnInfo = []athenatypes.ColumnInfo{newColumnInfo("a", "integer")}
+	assert.Equal(t, reflect.TypeOf((*string)(nil)), r.ColumnTypeScanType(0))
+}
+
+func BenchmarkRows_ConvertRow(b *testing.B) {
+	testConf := NewNoOpsConfig()
+	r, _ := NewRows(context.Background(), newMockAthenaClient(), "SELECT_OK", testConf,
+		NewDefaultObservability(testConf))
+	columns := []athenatypes.ColumnInfo{
+		newColumnInfo("active", "boolean"),
+		newColumnInfo("name", "varchar"),
+		newColumnInfo("uid", "bigint"),
+		newColumnInfo("score", "double"),
+		newColumnInfo("register_date", "date"),
+		newColumnInfo("register_ts", "timestamp"),
+		newColumnInfo("amount", "decimal"),
+	}
+	row := newRow(len(columns), []string{"true", "henry", "1024", "3.14", "2024-01-02",
+		"2024-01-02 03:04:05.678", "12.34"})
+	page := newResultPage([]athenatypes.Row{row}, len(columns))
+	dest := make([]driver.Value, len(columns))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := r.convertRow(columns, &page, 0, dest, testConf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestRows_NextBatch(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	r, err := NewRows(context.Background(), newMockAthenaClient(), "SELECT_OK", testConf,
+		NewDefaultObservability(testConf))
+	assert.Nil(t, err)
+	total := 0
+	dest := make([]driver.Value, len(r.Columns()))
+	for r.Next(dest) == nil {
+		total++
+	}
+
+	for _, size := range []int{1, 4, 7, 100} {
+		r, err = NewRows(context.Background(), newMockAthenaClient(), "SELECT_OK", testConf,
+			NewDefaultObservability(testConf))
+		assert.Nil(t, err)
+		batch := make([][]driver.Value, size)
+		got := 0
+		for {
+			n, err := r.NextBatch(batch, size)
+			if err == io.EOF {
+				assert.Equal(t, 0, n)
+				break
+			}
+			assert.Nil(t, err)
+			assert.True(t, n > 0 && n <= size)
+			for _, row := range batch[:n] {
+				assert.Len(t, row, len(r.Columns()))
+			}
+			got += n
+		}
+		assert.Equal(t, total, got, size)
+	}
+
+	// n is capped to the room in dest
+	r, err = NewRows(context.Background(), newMockAthenaClient(), "SELECT_OK", testConf,
+		NewDefaultObservability(testConf))
+	assert.Nil(t, err)
+	n, err := r.NextBatch(make([][]driver.Value, 2), 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+
+	r, err = NewRows(context.Background(), newMockAthenaClient(), "SELECT_GetQueryResults_ERR", testConf,
+		NewDefaultObservability(testConf))
+	assert.Nil(t, err)
+	batch := make([][]driver.Value, 8)
+	for err == nil {
+		_, err = r.NextBatch(batch, len(batch))
+	}
+	assert.NotEqual(t, io.EOF, err)
+}
+
+func BenchmarkRows_Next(b *testing.B) {
+	testConf := NewNoOpsConfig()
+	obs := NewDefaultObservability(testConf)
+	columnNames := []string{"active", "name", "uid", "score", "register_ts"}
+	columnTypes := []string{"boolean", "varchar", "bigint", "double", "timestamp"}
+	data := make([][]*string, 1000)
+	for i := range data {
+		data[i] = []*string{aws.String("true"), aws.String("henry"), aws.String(strconv.Itoa(i)),
+			aws.String("3.14"), aws.String("2024-01-02 03:04:05.678")}
+	}
+	dest := make([]driver.Value, len(columnNames))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r, _ := NewNonOpsRows(context.Background(), nil, "", testConf, obs)
+		r.ResultOutput = newHeaderlessResultPage(columnNames, columnTypes, data)
+		for r.Next(dest) == nil {
+		}
+	}
+}
+
+// BenchmarkRows_NextRow is the cost of reading one row, without building the result page.
+func BenchmarkRows_NextRow(b *testing.B) {
+	testConf := NewNoOpsConfig()
+	obs := NewDefaultObservability(testConf)
+	columnNames := []string{"active", "name", "uid", "score", "register_ts"}
+	columnTypes := []string{"boolean", "varchar", "bigint", "double", "timestamp"}
+	data := make([][]*string, 1000)
+	for i := range data {
+		data[i] = []*string{aws.String("true"), aws.String("henry"), aws.String(strconv.Itoa(i)),
+			aws.String("3.14"), aws.String("2024-01-02 03:04:05.678")}
+	}
+	r, _ := NewNonOpsRows(context.Background(), nil, "", testConf, obs)
+	r.ResultOutput = newHeaderlessResultPage(columnNames, columnTypes, data)
+	page := r.currentPage()
+	dest := make([]driver.Value, len(columnNames))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if page.remaining() == 0 {
+			page.next = 0
+		}
+		if err := r.Next(dest); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRows_NextBatch(b *testing.B) {
+	testConf := NewNoOpsConfig()
+	obs := NewDefaultObservability(testConf)
+	columnNames := []string{"active", "name", "uid", "score", "register_ts"}
+	columnTypes := []string{"boolean", "varchar", "bigint", "double", "timestamp"}
+	data := make([][]*string, 1000)
+	for i := range data {
+		data[i] = []*string{aws.String("true"), aws.String("henry"), aws.String(strconv.Itoa(i)),
+			aws.String("3.14"), aws.String("2024-01-02 03:04:05.678")}
+	}
+	r, _ := NewNonOpsRows(context.Background(), nil, "", testConf, obs)
+	r.ResultOutput = newHeaderlessResultPage(columnNames, columnTypes, data)
+	page := r.currentPage()
+	dest := make([][]driver.Value, 100)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i += len(dest) {
+		if page.remaining() == 0 {
+			page.next = 0
+		}
+		if _, err := r.NextBatch(dest, len(dest)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestRows_NextAllocs(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	testConf.SetMaskedColumnValue("secret", "xxx")
+	r, _ := NewNonOpsRows(context.Background(), nil, "", testConf, NewDefaultObservability(testConf))
+	data := make([][]*string, 200)
+	for i := range data {
+		data[i] = []*string{aws.String("true"), aws.String("7"), aws.String("1"), aws.String("s")}
+	}
+	r.ResultOutput = newHeaderlessResultPage([]string{"active", "level", "uid", "secret"},
+		[]string{"boolean", "tinyint", "bigint", "varchar"}, data)
+	dest := make([]driver.Value, 4)
+	// values which need no boxing of their own, e.g. booleans and small integers, are read without allocating
+	allocs := testing.AllocsPerRun(100, func() {
+		assert.Nil(t, r.Next(dest))
+	})
+	assert.Zero(t, allocs)
+	assert.Equal(t, []driver.Value{true, int8(7), int64(1), "xxx"}, dest)
+}
+
+func TestRows_ColumnDecoders(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	r, _ := NewNonOpsRows(context.Background(), nil, "", testConf, NewDefaultObservability(testConf))
+	columns := []athenatypes.ColumnInfo{newColumnInfo("a", "integer"), newColumnInfo("b", "timestamp(3)")}
+	decoders := r.columnDecoders(columns, testConf)
+	assert.Len(t, decoders, 2)
+	assert.Equal(t, "timestamp", decoders[1].baseType)
+	assert.Equal(t, &decoders[0], &r.columnDecoders(columns, testConf)[0])
+
+	// the columns of another page are resolved again
+	testConf.SetMaskedColumnValue("a", "xxx")
+	other := []athenatypes.ColumnInfo{newColumnInfo("a", "integer"), newColumnInfo("b", "timestamp(3)")}
+	decoders = r.columnDecoders(other, testConf)
+	assert.True(t, decoders[0].masked)
+	assert.Equal(t, "xxx", decoders[0].maskedValue)
+}
+
+func TestRows_HeaderRowByStatementType(t *testing.T) {
+	// A single-column result whose first row matches the column name, e.g. SHOW TABLES over a table named tab_name.
+	client := newMockAthenaClient()
+	client.queryToResultsGenMap["tab_name"] = func(_ string) (*athena.GetQueryResultsOutput, error) {
+		return &athena.GetQueryResultsOutput{
+			ResultSet: &athenatypes.ResultSet{
+				ResultSetMetadata: &athenatypes.ResultSetMetadata{
+					ColumnInfo: []athenatypes.ColumnInfo{newColumnInfo("tab_name", "string")},
+				},
+				Rows: []athenatypes.Row{newRow(1, []string{"tab_name"}), newRow(1, []string{"t1"})},
+			},
+		}, nil
+	}
+	testConf := NewNoOpsConfig()
+	obs := NewDefaultObservability(testConf)
+	for statementType, expected := range map[athenatypes.StatementType]int{
+		athenatypes.StatementTypeUtility: 2,
+		athenatypes.StatementTypeDdl:     2,
+		athenatypes.StatementTypeDml:     1,
+		"":                               1,
+	} {
+		queryExecution := &athenatypes.QueryExecution{
+			QueryExecutionId: aws.String("tab_name"),
+			StatementType:    statementType,
+		}
+		r, err := newQueryExecutionRows(context.Background(), client, queryExecution, testConf, obs)
+		assert.Nil(t, err)
+		dest := make([]driver.Value, 1)
+		n := 0
+		for r.Next(dest) == nil {
+			n++
+		}
+		assert.Equal(t, expected, n, string(statementType))
+	}
+
+	// INSERT INTO returns no rows, only the update count, so there is no header to skip.
+	client.queryToResultsGenMap["insert"] = func(_ string) (*athena.GetQueryResultsOutput, error) {
+		return &athena.GetQueryResultsOutput{
+			ResultSet: &athenatypes.ResultSet{
+				ResultSetMetadata: &athenatypes.ResultSetMetadata{
+					ColumnInfo: []athenatypes.ColumnInfo{newColumnInfo("rows", "bigint")},
+				},
+			},
+			UpdateCount: aws.Int64(3),
+		}, nil
+	}
+	queryExecution := &athenatypes.QueryExecution{
+		QueryExecutionId: aws.String("insert"),
+		StatementType:    athenatypes.StatementTypeDml,
+	}
+	r, err := newQueryExecutionRows(context.Background(), client, queryExecution, testConf, obs)
+	assert.Nil(t, err)
+	dest := make([]driver.Value, 1)
+	assert.Nil(t, r.Next(dest))
+	assert.Equal(t, int64(3), dest[0])
+}
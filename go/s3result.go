@@ -0,0 +1,240 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// s3PageSize is the number of rows in a page read from the result CSV, the same as GetQueryResults.
+const s3PageSize = 1000
+
+// s3ObjectClient is the part of the S3 client used to read query results.
+type s3ObjectClient interface {
+	HeadObject(context.Context, *s3.HeadObjectInput, ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+	GetObject(context.Context, *s3.GetObjectInput, ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// s3CSVTransport is the ResultTransport reading the pages after the first one from the CSV file Athena wrote the
+// query result to, when it is larger than threshold bytes, as streaming it is much faster than paging through
+// GetQueryResults.
+type s3CSVTransport struct {
+	client    s3ObjectClient
+	threshold int64
+}
+
+func (t *s3CSVTransport) Open(ctx context.Context, queryExecution *athenatypes.QueryExecution,
+	first *athena.GetQueryResultsOutput) (ResultPages, error) {
+	if first == nil || first.NextToken == nil || *first.NextToken == "" {
+		return nil, nil
+	}
+	if queryExecution == nil || queryExecution.ResultConfiguration == nil ||
+		queryExecution.ResultConfiguration.OutputLocation == nil {
+		return nil, nil
+	}
+	// Only the results of SELECT are CSV files, e.g. DDL statements write text files.
+	bucket, key, ok := parseS3Location(*queryExecution.ResultConfiguration.OutputLocation)
+	if !ok || !strings.HasSuffix(key, ".csv") {
+		return nil, nil
+	}
+	head, err := t.client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	if err != nil {
+		return nil, err
+	}
+	if head.ContentLength == nil || *head.ContentLength <= t.threshold {
+		return nil, nil
+	}
+	obj, err := t.client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	if err != nil {
+		return nil, err
+	}
+	pages := &s3CSVPages{
+		body:     obj.Body,
+		reader:   newAthenaCSVReader(obj.Body),
+		metadata: first.ResultSet.ResultSetMetadata,
+	}
+	// Skip the header and the rows of the first page.
+	for i := 0; i < 1+len(first.ResultSet.Rows); i++ {
+		if _, err := pages.reader.read(); err != nil {
+			_ = pages.Close()
+			return nil, err
+		}
+	}
+	return pages, nil
+}
+
+// parseS3Location is to split an s3://bucket/key location into bucket and key.
+func parseS3Location(location string) (bucket string, key string, ok bool) {
+	if !strings.HasPrefix(location, "s3://") {
+		return "", "", false
+	}
+	bucket, key, ok = strings.Cut(strings.TrimPrefix(location, "s3://"), "/")
+	return bucket, key, ok && bucket != "" && key != ""
+}
+
+// s3CSVPages is the ResultPages reading pages from a query result CSV file.
+type s3CSVPages struct {
+	body     io.ReadCloser
+	reader   *athenaCSVReader
+	metadata *athenatypes.ResultSetMetadata
+}
+
+func (p *s3CSVPages) Next() (*athena.GetQueryResultsOutput, error) {
+	rows := make([]athenatypes.Row, 0, s3PageSize)
+	var nextToken *string
+	for {
+		if len(rows) == s3PageSize {
+			// The token is only checked to be non-empty, the pages are read in order anyway.
+			nextToken = aws.String("s3")
+			break
+		}
+		record, err := p.reader.read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		data := make([]athenatypes.Datum, len(record))
+		for i, v := range record {
+			data[i] = athenatypes.Datum{VarCharValue: v}
+		}
+		rows = append(rows, athenatypes.Row{Data: data})
+	}
+	return &athena.GetQueryResultsOutput{
+		NextToken: nextToken,
+		ResultSet: &athenatypes.ResultSet{
+			ResultSetMetadata: p.metadata,
+			Rows:              rows,
+		},
+	}, nil
+}
+
+func (p *s3CSVPages) Close() error {
+	return p.body.Close()
+}
+
+// athenaCSVReader reads the CSV files of query results. Unlike encoding/csv, it tells NULL, an empty unquoted
+// field, from the empty string, which Athena quotes.
+type athenaCSVReader struct {
+	r   *bufio.Reader
+	buf bytes.Buffer
+}
+
+func newAthenaCSVReader(r io.Reader) *athenaCSVReader {
+	return &athenaCSVReader{r: bufio.NewReaderSize(r, 1<<20)}
+}
+
+// read is to read a record, with nil for NULL fields. It returns io.EOF when there is no more record.
+func (r *athenaCSVReader) read() ([]*string, error) {
+	var record []*string
+	for {
+		field, last, err := r.readField(record == nil)
+		if err != nil {
+			return nil, err
+		}
+		record = append(record, field)
+		if last {
+			return record, nil
+		}
+	}
+}
+
+// readField is to read a field, and whether it is the last one of its record.
+func (r *athenaCSVReader) readField(first bool) (*string, bool, error) {
+	r.buf.Reset()
+	b, err := r.r.ReadByte()
+	if err == io.EOF {
+		if first {
+			return nil, true, io.EOF
+		}
+		return nil, true, nil
+	} else if err != nil {
+		return nil, true, err
+	}
+
+	if b == '"' {
+		for {
+			b, err = r.r.ReadByte()
+			if err == io.EOF {
+				return nil, true, io.ErrUnexpectedEOF
+			} else if err != nil {
+				return nil, true, err
+			}
+			if b != '"' {
+				r.buf.WriteByte(b)
+				continue
+			}
+			b, err = r.r.ReadByte()
+			if err == nil && b == '"' {
+				r.buf.WriteByte('"')
+				continue
+			}
+			value := r.buf.String()
+			if err == io.EOF {
+				return &value, true, nil
+			} else if err != nil {
+				return nil, true, err
+			}
+			if b == '\r' {
+				b, err = r.r.ReadByte()
+				if err == io.EOF {
+					return &value, true, nil
+				}
+			}
+			switch {
+			case err != nil:
+				return nil, true, err
+			case b == ',':
+				return &value, false, nil
+			case b == '\n':
+				return &value, true, nil
+			default:
+				return nil, true, fmt.Errorf("unexpected %q after a quoted field in query result CSV", b)
+			}
+		}
+	}
+
+	for b != ',' && b != '\n' {
+		r.buf.WriteByte(b)
+		b, err = r.r.ReadByte()
+		if err == io.EOF {
+			b = '\n'
+		} else if err != nil {
+			return nil, true, err
+		}
+	}
+	value := strings.TrimSuffix(r.buf.String(), "\r")
+	if value == "" {
+		return nil, b == '\n', nil
+	}
+	return &value, b == '\n', nil
+}
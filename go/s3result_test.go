@@ -0,0 +1,149 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockS3Client struct {
+	objects map[string]string
+}
+
+func (m *mockS3Client) HeadObject(_ context.Context, input *s3.HeadObjectInput,
+	_ ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	obj, ok := m.objects[*input.Bucket+"/"+*input.Key]
+	if !ok {
+		return nil, ErrTestMockGeneric
+	}
+	return &s3.HeadObjectOutput{ContentLength: aws.Int64(int64(len(obj)))}, nil
+}
+
+func (m *mockS3Client) GetObject(_ context.Context, input *s3.GetObjectInput,
+	_ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	obj, ok := m.objects[*input.Bucket+"/"+*input.Key]
+	if !ok {
+		return nil, ErrTestMockGeneric
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader(obj))}, nil
+}
+
+func TestAthenaCSVReader(t *testing.T) {
+	s := func(v string) *string { return &v }
+	r := newAthenaCSVReader(strings.NewReader(
+		"\"a\",\"b\",\"c\"\n" +
+			"\"1\",,\"\"\n" +
+			"\"say \"\"hi\"\"\",\"x,y\",\"line\nbreak\"\r\n" +
+			"unquoted,\"\",\n" +
+			"\"last\",\"no newline\""))
+	expected := [][]*string{
+		{s("a"), s("b"), s("c")},
+		{s("1"), nil, s("")},
+		{s(`say "hi"`), s("x,y"), s("line\nbreak")},
+		{s("unquoted"), s(""), nil},
+		{s("last"), s("no newline")},
+	}
+	for _, want := range expected {
+		got, err := r.read()
+		assert.Nil(t, err)
+		assert.Equal(t, want, got)
+	}
+	_, err := r.read()
+	assert.Equal(t, io.EOF, err)
+
+	_, err = newAthenaCSVReader(strings.NewReader("\"open")).read()
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	_, err = newAthenaCSVReader(strings.NewReader("\"a\"b")).read()
+	assert.NotNil(t, err)
+}
+
+func TestParseS3Location(t *testing.T) {
+	bucket, key, ok := parseS3Location("s3://bucket/path/to/qid.csv")
+	assert.True(t, ok)
+	assert.Equal(t, "bucket", bucket)
+	assert.Equal(t, "path/to/qid.csv", key)
+	for _, location := range []string{"bucket/qid.csv", "s3://bucket", "s3://bucket/", "s3:///qid.csv"} {
+		_, _, ok = parseS3Location(location)
+		assert.False(t, ok, location)
+	}
+}
+
+func TestS3CSVTransport(t *testing.T) {
+	var csv strings.Builder
+	csv.WriteString(`"test_array","active","company_name","project","uid","regitser_date","regitser_ts"` + "\n")
+	const total = 2500
+	for i := 0; i < total; i++ {
+		fmt.Fprintf(&csv, `"[1, 2]","true","uber","athena","%d","2024-01-02","2024-01-02 03:04:05.000"`+"\n", i)
+	}
+	client := &mockS3Client{objects: map[string]string{"bucket/qid.csv": csv.String(), "bucket/qid.txt": "x"}}
+	qe := func(location string) *athenatypes.QueryExecution {
+		return &athenatypes.QueryExecution{
+			ResultConfiguration: &athenatypes.ResultConfiguration{OutputLocation: aws.String(location)},
+		}
+	}
+	testConf := NewNoOpsConfig()
+	obs := NewDefaultObservability(testConf)
+
+	r, err := NewRows(context.Background(), newMockAthenaClient(), "SELECT_OK", testConf, obs)
+	assert.Nil(t, err)
+	firstPage := len(r.ResultOutput.ResultSet.Rows)
+	transport := &s3CSVTransport{client: client, threshold: 100}
+	pages, err := transport.Open(context.Background(), qe("s3://bucket/qid.csv"), r.ResultOutput)
+	assert.Nil(t, err)
+	assert.NotNil(t, pages)
+	assert.Nil(t, r.readFrom(pages))
+	dest := make([]driver.Value, len(r.Columns()))
+	read := 0
+	for r.Next(dest) == nil {
+		read++
+		if read > firstPage {
+			assert.Equal(t, fmt.Sprint(read-1), fmt.Sprint(dest[4]))
+		}
+	}
+	assert.Equal(t, total, read)
+	assert.Nil(t, r.Close())
+
+	// small results, other statements and unknown objects are read with GetQueryResults
+	for _, location := range []string{"s3://bucket/qid.txt", "s3://bucket/qid.csv"} {
+		r, err = NewRows(context.Background(), newMockAthenaClient(), "SELECT_OK", testConf, obs)
+		assert.Nil(t, err)
+		transport = &s3CSVTransport{client: client, threshold: int64(csv.Len())}
+		pages, err = transport.Open(context.Background(), qe(location), r.ResultOutput)
+		assert.Nil(t, err)
+		assert.Nil(t, pages)
+	}
+	r, err = NewRows(context.Background(), newMockAthenaClient(), "SELECT_OK", testConf, obs)
+	assert.Nil(t, err)
+	transport = &s3CSVTransport{client: client, threshold: 100}
+	pages, err = transport.Open(context.Background(), qe("s3://bucket/missing.csv"), r.ResultOutput)
+	assert.NotNil(t, err)
+	assert.Nil(t, pages)
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+)
+
+// Queryer is the part of *sql.DB, *sql.Conn and *sql.Tx QuerySchema needs.
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// SchemaColumn is a column of the result of a query.
+type SchemaColumn struct {
+	Name string
+	// Type is the Athena type, e.g. varchar, decimal or array(integer).
+	Type string
+	// Precision and Scale are those of decimal columns, and 0 for the other types.
+	Precision int64
+	Scale     int64
+	// Nullable is false only for the columns Athena knows to have no NULL.
+	Nullable bool
+}
+
+// Schema is the columns of the result of a query, in order.
+type Schema struct {
+	Columns []SchemaColumn
+}
+
+// Names returns the names of the columns.
+func (s *Schema) Names() []string {
+	names := make([]string, len(s.Columns))
+	for i, c := range s.Columns {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// Column returns the column of a name, which is matched case-insensitively like Athena does.
+func (s *Schema) Column(name string) (SchemaColumn, bool) {
+	for _, c := range s.Columns {
+		if strings.EqualFold(c.Name, name) {
+			return c, true
+		}
+	}
+	return SchemaColumn{}, false
+}
+
+// QuerySchema returns the columns the result of a query would have, without reading any of its rows: the query runs
+// with a LIMIT 0, which Athena plans without scanning data. Only queries, i.e. SELECT, WITH, VALUES and TABLE
+// statements, have a schema.
+func QuerySchema(ctx context.Context, db Queryer, query string, args ...interface{}) (*Schema, error) {
+	query = strings.TrimRight(strings.TrimSpace(query), "; \t\r\n")
+	switch keyword := statementKeyword(query); keyword {
+	case "SELECT", "VALUES", "TABLE":
+	default:
+		return nil, fmt.Errorf("%w: %s statements have no result schema", ErrInvalidQuery, keyword)
+	}
+	// The query is on lines of its own so that a trailing line comment does not swallow the closing parenthesis.
+	rows, err := db.QueryContext(ctx, "SELECT * FROM (\n"+query+"\n) LIMIT 0", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	schema := &Schema{Columns: make([]SchemaColumn, len(columnTypes))}
+	for i, ct := range columnTypes {
+		precision, scale, _ := ct.DecimalSize()
+		nullable, ok := ct.Nullable()
+		schema.Columns[i] = SchemaColumn{
+			Name:      ct.Name(),
+			Type:      ct.DatabaseTypeName(),
+			Precision: precision,
+			Scale:     scale,
+			Nullable:  nullable || !ok,
+		}
+	}
+	return schema, rows.Close()
+}
+
+// ColumnTypeNullable returns whether a column may have NULL values, and whether Athena knows it.
+func (r *Rows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	switch r.ResultOutput.ResultSet.ResultSetMetadata.ColumnInfo[index].Nullable {
+	case athenatypes.ColumnNullableNotNull:
+		return false, true
+	case athenatypes.ColumnNullableNullable:
+		return true, true
+	}
+	return false, false
+}
+
+// ColumnTypePrecisionScale returns the precision and scale of decimal columns.
+func (r *Rows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+	colInfo := r.ResultOutput.ResultSet.ResultSetMetadata.ColumnInfo[index]
+	if colInfo.Type == nil || baseTypeName(*colInfo.Type) != "decimal" {
+		return 0, 0, false
+	}
+	return int64(colInfo.Precision), int64(colInfo.Scale), true
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuerySchema(t *testing.T) {
+	m := newMockAthenaClient()
+	db := sql.OpenDB(NewSQLConnectorWithClient(NewNoOpsConfig(), m))
+	defer db.Close()
+
+	schema, err := QuerySchema(context.Background(), db, "SELECT id, price, name FROM orders WHERE day = ? -- today;",
+		"2024-01-02")
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM (\nSELECT id, price, name FROM orders WHERE day = ? -- today\n) LIMIT 0",
+		*m.lastStartInput.QueryString)
+	assert.Equal(t, []string{"2024-01-02"}, m.lastStartInput.ExecutionParameters)
+	assert.Equal(t, []SchemaColumn{
+		{Name: "id", Type: "bigint"},
+		{Name: "price", Type: "decimal", Precision: 10, Scale: 2, Nullable: true},
+		{Name: "name", Type: "varchar", Nullable: true},
+	}, schema.Columns)
+	assert.Equal(t, []string{"id", "price", "name"}, schema.Names())
+	c, ok := schema.Column("PRICE")
+	assert.True(t, ok)
+	assert.Equal(t, "price", c.Name)
+	_, ok = schema.Column("missing")
+	assert.False(t, ok)
+	assert.Equal(t, 1, m.callCount("GetQueryResults"))
+
+	_, err = QuerySchema(context.Background(), db, "INSERT INTO t SELECT * FROM s")
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+	_, err = QuerySchema(context.Background(), db, "WITH a AS (SELECT 1) SELECT * FROM a")
+	assert.Nil(t, err)
+}
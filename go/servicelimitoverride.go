@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"net/url"
 	"strconv"
+	"time"
 )
 
 // ServiceLimitOverride allows users to override service limits, hardcoded in constants.go.
@@ -85,3 +86,11 @@ func (c *ServiceLimitOverride) SetFromValues(kvp url.Values) {
 	dmlQueryTimeout, _ := strconv.Atoi(kvp.Get("DMLQueryTimeout"))
 	_ = c.SetDMLQueryTimeout(dmlQueryTimeout)
 }
+
+// timeoutPolicy is to get the overrides as a TimeoutPolicy.
+func (c *ServiceLimitOverride) timeoutPolicy() TimeoutPolicy {
+	return TimeoutPolicy{
+		DML: time.Duration(c.dmlQueryTimeout) * time.Second,
+		DDL: time.Duration(c.ddlQueryTimeout) * time.Second,
+	}
+}
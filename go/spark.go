@@ -0,0 +1,228 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// SparkCalculationResult is the outcome of a calculation in an Athena for Apache Spark session.
+// Athena writes stdout, stderr and the result of a calculation to S3; the URIs point to them, and
+// Connection.ReadSparkStdOut, ReadSparkStdErr and ReadSparkResult read them.
+type SparkCalculationResult struct {
+	CalculationID string
+	State         athenatypes.CalculationExecutionState
+	ResultType    string
+	ResultS3URI   string
+	StdOutS3URI   string
+	StdErrorS3URI string
+	// DPUExecutionTime is the data processing unit execution time of the calculation.
+	DPUExecutionTime time.Duration
+}
+
+// StartSparkSession is to start a session in the Spark-enabled workgroup of this connection.
+// It blocks until the session is IDLE and ready to accept calculations.
+// https://docs.aws.amazon.com/athena/latest/ug/notebooks-spark.html
+func (c *Connection) StartSparkSession(ctx context.Context, maxConcurrentDPUs int32) (string, error) {
+	var obs = c.tracer(ctx)
+	wgName := c.workgroupName()
+	resp, err := c.athenaClient.StartSession(ctx, &athena.StartSessionInput{
+		WorkGroup: aws.String(wgName),
+		EngineConfiguration: &athenatypes.EngineConfiguration{
+			MaxConcurrentDpus: aws.Int32(maxConcurrentDPUs),
+		},
+	})
+	if err != nil {
+		obs.Scope().Counter(DriverName + ".failure.spark.startsession").Inc(1)
+		return "", err
+	}
+	sessionID := *resp.SessionId
+	for {
+		statusResp, err := c.athenaClient.GetSessionStatus(ctx, &athena.GetSessionStatusInput{
+			SessionId: aws.String(sessionID),
+		})
+		if err != nil {
+			obs.Scope().Counter(DriverName + ".failure.spark.getsessionstatus").Inc(1)
+			return sessionID, err
+		}
+		switch statusResp.Status.State {
+		case athenatypes.SessionStateIdle:
+			return sessionID, nil
+		case athenatypes.SessionStateFailed, athenatypes.SessionStateDegraded,
+			athenatypes.SessionStateTerminating, athenatypes.SessionStateTerminated:
+			reason := ""
+			if statusResp.Status.StateChangeReason != nil {
+				reason = *statusResp.Status.StateChangeReason
+			}
+			obs.Log(ErrorLevel, "spark session failed to start",
+				zap.String("workgroup", wgName),
+				zap.String("sessionID", sessionID),
+				zap.String("reason", reason))
+			return sessionID, fmt.Errorf("%w: session %s is %s %s", ErrSparkSessionNotReady, sessionID,
+				statusResp.Status.State, reason)
+		}
+		select {
+		case <-ctx.Done():
+			return sessionID, ctx.Err()
+		case <-time.After(c.connector.config.GetResultPollIntervalSeconds()):
+		}
+	}
+}
+
+// SubmitSparkCalculation is to submit a block of code, e.g. PySpark, to a Spark session
+// and return the calculation execution ID without waiting for it.
+func (c *Connection) SubmitSparkCalculation(ctx context.Context, sessionID string, code string) (string, error) {
+	resp, err := c.athenaClient.StartCalculationExecution(ctx, &athena.StartCalculationExecutionInput{
+		SessionId: aws.String(sessionID),
+		CodeBlock: aws.String(code),
+	})
+	if err != nil {
+		c.tracer(ctx).Scope().Counter(DriverName + ".failure.spark.startcalculationexecution").Inc(1)
+		return "", err
+	}
+	return *resp.CalculationExecutionId, nil
+}
+
+// GetSparkCalculation is to get the current state and result locations of a calculation.
+func (c *Connection) GetSparkCalculation(ctx context.Context, calculationID string) (*SparkCalculationResult, error) {
+	resp, err := c.athenaClient.GetCalculationExecution(ctx, &athena.GetCalculationExecutionInput{
+		CalculationExecutionId: aws.String(calculationID),
+	})
+	if err != nil {
+		c.tracer(ctx).Scope().Counter(DriverName + ".failure.spark.getcalculationexecution").Inc(1)
+		return nil, err
+	}
+	r := &SparkCalculationResult{
+		CalculationID: calculationID,
+	}
+	if resp.Status != nil {
+		r.State = resp.Status.State
+	}
+	if resp.Result != nil {
+		r.ResultType = aws.ToString(resp.Result.ResultType)
+		r.ResultS3URI = aws.ToString(resp.Result.ResultS3Uri)
+		r.StdOutS3URI = aws.ToString(resp.Result.StdOutS3Uri)
+		r.StdErrorS3URI = aws.ToString(resp.Result.StdErrorS3Uri)
+	}
+	if resp.Statistics != nil && resp.Statistics.DpuExecutionInMillis != nil {
+		r.DPUExecutionTime = time.Duration(*resp.Statistics.DpuExecutionInMillis) * time.Millisecond
+	}
+	if r.State == athenatypes.CalculationExecutionStateFailed {
+		reason := ""
+		if resp.Status.StateChangeReason != nil {
+			reason = *resp.Status.StateChangeReason
+		}
+		return r, fmt.Errorf("%w: calculation %s: %s", ErrSparkCalculationFailed, calculationID, reason)
+	}
+	return r, nil
+}
+
+// WaitSparkCalculation is to poll a calculation until it is COMPLETED, FAILED or CANCELED.
+// If ctx is done before that, the calculation is left running and ctx.Err() is returned.
+func (c *Connection) WaitSparkCalculation(ctx context.Context, calculationID string) (*SparkCalculationResult, error) {
+	for {
+		r, err := c.GetSparkCalculation(ctx, calculationID)
+		if err != nil {
+			return r, err
+		}
+		switch r.State {
+		case athenatypes.CalculationExecutionStateCompleted:
+			return r, nil
+		case athenatypes.CalculationExecutionStateCanceled:
+			return r, context.Canceled
+		}
+		select {
+		case <-ctx.Done():
+			return r, ctx.Err()
+		case <-time.After(c.connector.config.GetResultPollIntervalSeconds()):
+		}
+	}
+}
+
+// RunSparkCalculation is to submit a block of code to a Spark session and wait for its result.
+func (c *Connection) RunSparkCalculation(ctx context.Context, sessionID string, code string) (*SparkCalculationResult, error) {
+	calculationID, err := c.SubmitSparkCalculation(ctx, sessionID, code)
+	if err != nil {
+		return nil, err
+	}
+	return c.WaitSparkCalculation(ctx, calculationID)
+}
+
+// ReadSparkStdOut is to read what a calculation printed to stdout.
+func (c *Connection) ReadSparkStdOut(ctx context.Context, r *SparkCalculationResult) (string, error) {
+	b, err := c.readSparkObject(ctx, r.StdOutS3URI)
+	return string(b), err
+}
+
+// ReadSparkStdErr is to read what a calculation printed to stderr.
+func (c *Connection) ReadSparkStdErr(ctx context.Context, r *SparkCalculationResult) (string, error) {
+	b, err := c.readSparkObject(ctx, r.StdErrorS3URI)
+	return string(b), err
+}
+
+// ReadSparkResult is to read the result of a calculation, e.g. the value of its last expression, whose format is
+// given by its ResultType.
+func (c *Connection) ReadSparkResult(ctx context.Context, r *SparkCalculationResult) ([]byte, error) {
+	return c.readSparkObject(ctx, r.ResultS3URI)
+}
+
+// readSparkObject is to read an object written by a calculation with the S3 client of the connection. An empty
+// location, e.g. of a calculation which printed nothing, is read as empty.
+func (c *Connection) readSparkObject(ctx context.Context, location string) ([]byte, error) {
+	if location == "" {
+		return nil, nil
+	}
+	if c.s3Client == nil {
+		return nil, ErrS3NilClient
+	}
+	bucket, key, ok := parseS3Location(location)
+	if !ok {
+		return nil, fmt.Errorf("invalid S3 location %q", location)
+	}
+	obj, err := c.s3Client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	if err != nil {
+		c.tracer(ctx).Scope().Counter(DriverName + ".failure.spark.getobject").Inc(1)
+		return nil, err
+	}
+	defer obj.Body.Close()
+	return io.ReadAll(obj.Body)
+}
+
+// TerminateSparkSession is to terminate a Spark session.
+func (c *Connection) TerminateSparkSession(ctx context.Context, sessionID string) error {
+	_, err := c.athenaClient.TerminateSession(ctx, &athena.TerminateSessionInput{
+		SessionId: aws.String(sessionID),
+	})
+	if err != nil {
+		c.tracer(ctx).Scope().Counter(DriverName + ".failure.spark.terminatesession").Inc(1)
+	}
+	return err
+}
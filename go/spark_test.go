@@ -0,0 +1,143 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func createSparkTestConnection(nm *mockAthenaClient) *Connection {
+	c := &Connection{
+		athenaClient: nm,
+		connector:    NoopsSQLConnector(),
+	}
+	c.connector.config.SetResultPollIntervalSeconds(0)
+	return c
+}
+
+func TestConnection_StartSparkSession(t *testing.T) {
+	nm := newMockAthenaClient()
+	nm.sessionStates = []athenatypes.SessionState{
+		athenatypes.SessionStateCreating,
+		athenatypes.SessionStateCreated,
+		athenatypes.SessionStateIdle,
+	}
+	c := createSparkTestConnection(nm)
+	sessionID, err := c.StartSparkSession(context.Background(), 4)
+	assert.Nil(t, err)
+	assert.Equal(t, "session_1", sessionID)
+	assert.Empty(t, nm.sessionStates)
+	assert.Nil(t, c.TerminateSparkSession(context.Background(), sessionID))
+	assert.NotNil(t, c.TerminateSparkSession(context.Background(), "session_2"))
+}
+
+func TestConnection_StartSparkSession_Failed(t *testing.T) {
+	nm := newMockAthenaClient()
+	nm.sessionStates = []athenatypes.SessionState{athenatypes.SessionStateFailed}
+	c := createSparkTestConnection(nm)
+	_, err := c.StartSparkSession(context.Background(), 4)
+	assert.True(t, errors.Is(err, ErrSparkSessionNotReady))
+	assert.Contains(t, err.Error(), "mock reason")
+
+	_ = c.connector.config.SetWorkGroup(NewDefaultWG("StartSession_return_error", nil, nil))
+	_, err = c.StartSparkSession(context.Background(), 4)
+	assert.Equal(t, ErrTestMockGeneric, err)
+}
+
+func TestConnection_RunSparkCalculation(t *testing.T) {
+	nm := newMockAthenaClient()
+	nm.calculationStates = []athenatypes.CalculationExecutionState{
+		athenatypes.CalculationExecutionStateQueued,
+		athenatypes.CalculationExecutionStateRunning,
+		athenatypes.CalculationExecutionStateCompleted,
+	}
+	c := createSparkTestConnection(nm)
+	r, err := c.RunSparkCalculation(context.Background(), "session_1", "print(spark.range(10).count())")
+	assert.Nil(t, err)
+	assert.Equal(t, "calculation_1", r.CalculationID)
+	assert.Equal(t, athenatypes.CalculationExecutionStateCompleted, r.State)
+	assert.Equal(t, "s3://bucket/calculation_1/stdout.txt", r.StdOutS3URI)
+	assert.Equal(t, "s3://bucket/calculation_1/result.json", r.ResultS3URI)
+	assert.Equal(t, "application/json", r.ResultType)
+	assert.Equal(t, 1500*time.Millisecond, r.DPUExecutionTime)
+
+	_, err = c.RunSparkCalculation(context.Background(), "session_2", "print(1)")
+	assert.Equal(t, ErrTestMockGeneric, err)
+}
+
+func TestConnection_ReadSparkOutput(t *testing.T) {
+	nm := newMockAthenaClient()
+	c := createSparkTestConnection(nm)
+	r := &SparkCalculationResult{
+		CalculationID: "calculation_1",
+		StdOutS3URI:   "s3://bucket/calculation_1/stdout.txt",
+		ResultS3URI:   "s3://bucket/calculation_1/result.json",
+	}
+	_, err := c.ReadSparkStdOut(context.Background(), r)
+	assert.Equal(t, ErrS3NilClient, err)
+
+	c.s3Client = &mockS3Client{objects: map[string]string{
+		"bucket/calculation_1/stdout.txt":  "10\n",
+		"bucket/calculation_1/result.json": `{"text/plain":"10"}`,
+	}}
+	stdout, err := c.ReadSparkStdOut(context.Background(), r)
+	assert.Nil(t, err)
+	assert.Equal(t, "10\n", stdout)
+	result, err := c.ReadSparkResult(context.Background(), r)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"text/plain":"10"}`, string(result))
+	// nothing was printed to stderr
+	stderr, err := c.ReadSparkStdErr(context.Background(), r)
+	assert.Nil(t, err)
+	assert.Empty(t, stderr)
+
+	r.StdOutS3URI = "s3://bucket/calculation_1/missing.txt"
+	_, err = c.ReadSparkStdOut(context.Background(), r)
+	assert.Equal(t, ErrTestMockGeneric, err)
+	r.StdOutS3URI = "https://bucket/stdout.txt"
+	_, err = c.ReadSparkStdOut(context.Background(), r)
+	assert.NotNil(t, err)
+}
+
+func TestConnection_RunSparkCalculation_FailedOrCanceled(t *testing.T) {
+	nm := newMockAthenaClient()
+	nm.calculationStates = []athenatypes.CalculationExecutionState{athenatypes.CalculationExecutionStateFailed}
+	c := createSparkTestConnection(nm)
+	_, err := c.RunSparkCalculation(context.Background(), "session_1", "raise Exception()")
+	assert.True(t, errors.Is(err, ErrSparkCalculationFailed))
+
+	nm.calculationStates = []athenatypes.CalculationExecutionState{athenatypes.CalculationExecutionStateCanceled}
+	_, err = c.WaitSparkCalculation(context.Background(), "calculation_1")
+	assert.Equal(t, context.Canceled, err)
+
+	nm.calculationStates = []athenatypes.CalculationExecutionState{athenatypes.CalculationExecutionStateRunning}
+	c.connector.config.SetResultPollIntervalSeconds(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.WaitSparkCalculation(ctx, "calculation_1")
+	assert.Equal(t, context.Canceled, err)
+}
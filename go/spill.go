@@ -0,0 +1,319 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+)
+
+// spillingPrefetcher fetches all result set pages ahead of Rows.Next() in a background worker, like
+// pagePrefetcher, but keeps pages in memory only up to a limit and spools the rest to a temporary file, so huge
+// results can be fetched at full speed and iterated without running out of memory.
+type spillingPrefetcher struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	limit  int64
+	dir    string
+	notify chan struct{}
+
+	mu       sync.Mutex
+	queue    []spilledPage
+	memBytes int64
+	finished bool
+	err      error
+	stopped  bool
+	path     string
+	reader   *bufio.Reader
+	readFile *os.File
+	metadata *athenatypes.ResultSetMetadata
+}
+
+// spilledPage is a page in the queue of a spillingPrefetcher. output is nil when the page is in the file.
+type spilledPage struct {
+	output *athena.GetQueryResultsOutput
+	size   int64
+}
+
+// newSpillingPrefetcher is to start fetching pages from the one of token, keeping at most limit bytes of them in
+// memory and the rest in a temporary file in dir, or the default directory for temporary files if dir is empty.
+func newSpillingPrefetcher(ctx context.Context, client AthenaClient, queryID string, token *string, limit int64,
+	dir string) *spillingPrefetcher {
+	ctx, cancel := context.WithCancel(ctx)
+	p := &spillingPrefetcher{
+		ctx:    ctx,
+		cancel: cancel,
+		limit:  limit,
+		dir:    dir,
+		notify: make(chan struct{}, 1),
+	}
+	go p.run(client, queryID, token)
+	return p
+}
+
+func (p *spillingPrefetcher) run(client AthenaClient, queryID string, token *string) {
+	var file *os.File
+	var writer *bufio.Writer
+	defer func() {
+		if file != nil {
+			_ = file.Close()
+		}
+	}()
+	for token != nil && *token != "" && p.ctx.Err() == nil {
+		output, err := client.GetQueryResults(p.ctx,
+			&athena.GetQueryResultsInput{
+				QueryExecutionId: aws.String(queryID),
+				NextToken:        token,
+			})
+		if err != nil {
+			p.finish(err)
+			return
+		}
+		page := spilledPage{output: output, size: pageSize(output)}
+		p.mu.Lock()
+		inMemory := p.memBytes+page.size <= p.limit
+		if inMemory {
+			p.memBytes += page.size
+		}
+		if p.metadata == nil && output.ResultSet != nil {
+			p.metadata = output.ResultSet.ResultSetMetadata
+		}
+		p.mu.Unlock()
+
+		if !inMemory {
+			if file == nil {
+				if file, err = p.createFile(); err != nil {
+					p.finish(err)
+					return
+				}
+				writer = bufio.NewWriter(file)
+			}
+			if err = writeSpilledPage(writer, output); err == nil {
+				err = writer.Flush()
+			}
+			if err != nil {
+				p.finish(err)
+				return
+			}
+			page.output = nil
+		}
+
+		p.mu.Lock()
+		p.queue = append(p.queue, page)
+		p.mu.Unlock()
+		p.signal()
+		token = output.NextToken
+	}
+	p.finish(nil)
+}
+
+// createFile is to create the temporary file pages are spilled to, and open it for reading.
+func (p *spillingPrefetcher) createFile() (*os.File, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopped {
+		return nil, context.Canceled
+	}
+	file, err := os.CreateTemp(p.dir, "athenadriver-*.spill")
+	if err != nil {
+		return nil, err
+	}
+	readFile, err := os.Open(file.Name())
+	if err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return nil, err
+	}
+	p.path = file.Name()
+	p.readFile = readFile
+	p.reader = bufio.NewReader(readFile)
+	return file, nil
+}
+
+func (p *spillingPrefetcher) finish(err error) {
+	p.mu.Lock()
+	p.finished = true
+	p.err = err
+	p.mu.Unlock()
+	p.signal()
+}
+
+func (p *spillingPrefetcher) signal() {
+	select {
+	case p.notify <- struct{}{}:
+	default:
+	}
+}
+
+// next is to get the next page, waiting for the worker if it is not fetched yet.
+func (p *spillingPrefetcher) next() (*athena.GetQueryResultsOutput, error) {
+	for {
+		p.mu.Lock()
+		if len(p.queue) > 0 {
+			page := p.queue[0]
+			p.queue = p.queue[1:]
+			if page.output != nil {
+				p.memBytes -= page.size
+				p.mu.Unlock()
+				return page.output, nil
+			}
+			reader, metadata := p.reader, p.metadata
+			p.mu.Unlock()
+			rows, nextToken, err := readSpilledPage(reader)
+			if err != nil {
+				return nil, err
+			}
+			return &athena.GetQueryResultsOutput{
+				NextToken: nextToken,
+				ResultSet: &athenatypes.ResultSet{
+					ResultSetMetadata: metadata,
+					Rows:              rows,
+				},
+			}, nil
+		}
+		if p.finished {
+			err := p.err
+			p.mu.Unlock()
+			if err == nil {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		p.mu.Unlock()
+
+		select {
+		case <-p.notify:
+		case <-p.ctx.Done():
+			return nil, p.ctx.Err()
+		}
+	}
+}
+
+// stop is to stop the worker and remove the temporary file.
+func (p *spillingPrefetcher) stop() {
+	p.cancel()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stopped = true
+	p.queue = nil
+	if p.readFile != nil {
+		_ = p.readFile.Close()
+		_ = os.Remove(p.path)
+		p.readFile = nil
+	}
+}
+
+// pageSize is an estimate of the memory a page takes.
+func pageSize(output *athena.GetQueryResultsOutput) int64 {
+	if output.ResultSet == nil {
+		return 0
+	}
+	var size int64
+	for _, row := range output.ResultSet.Rows {
+		size += 24
+		for _, datum := range row.Data {
+			size += 32
+			if datum.VarCharValue != nil {
+				size += int64(len(*datum.VarCharValue))
+			}
+		}
+	}
+	return size
+}
+
+// writeSpilledPage is to write the rows and next token of a page, each value as a flag telling NULL apart,
+// followed by its length and bytes.
+func writeSpilledPage(w *bufio.Writer, output *athena.GetQueryResultsOutput) error {
+	var buf [binary.MaxVarintLen64]byte
+	writeString := func(s *string) {
+		if s == nil {
+			_ = w.WriteByte(0)
+			return
+		}
+		_ = w.WriteByte(1)
+		_, _ = w.Write(buf[:binary.PutUvarint(buf[:], uint64(len(*s)))])
+		_, _ = w.WriteString(*s)
+	}
+	writeString(output.NextToken)
+	var rows []athenatypes.Row
+	if output.ResultSet != nil {
+		rows = output.ResultSet.Rows
+	}
+	_, _ = w.Write(buf[:binary.PutUvarint(buf[:], uint64(len(rows)))])
+	for _, row := range rows {
+		_, _ = w.Write(buf[:binary.PutUvarint(buf[:], uint64(len(row.Data)))])
+		for _, datum := range row.Data {
+			writeString(datum.VarCharValue)
+		}
+	}
+	// bufio.Writer keeps the first error, which Flush returns.
+	return nil
+}
+
+// readSpilledPage is to read a page written by writeSpilledPage.
+func readSpilledPage(r *bufio.Reader) ([]athenatypes.Row, *string, error) {
+	readString := func() (*string, error) {
+		flag, err := r.ReadByte()
+		if err != nil || flag == 0 {
+			return nil, err
+		}
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		b := make([]byte, n)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		s := string(b)
+		return &s, nil
+	}
+	nextToken, err := readString()
+	if err != nil {
+		return nil, nil, err
+	}
+	rowCount, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows := make([]athenatypes.Row, rowCount)
+	for i := range rows {
+		colCount, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		rows[i].Data = make([]athenatypes.Datum, colCount)
+		for j := range rows[i].Data {
+			if rows[i].Data[j].VarCharValue, err = readString(); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return rows, nextToken, nil
+}
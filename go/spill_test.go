@@ -0,0 +1,111 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpilledPage(t *testing.T) {
+	rows := []athenatypes.Row{
+		newRow(3, []string{"a", "", "c"}),
+		{Data: []athenatypes.Datum{{VarCharValue: nil}, {VarCharValue: aws.String("ü")}}},
+		{},
+	}
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	for _, token := range []*string{aws.String("token"), nil} {
+		assert.Nil(t, writeSpilledPage(w, &athena.GetQueryResultsOutput{
+			NextToken: token,
+			ResultSet: &athenatypes.ResultSet{Rows: rows},
+		}))
+	}
+	assert.Nil(t, w.Flush())
+
+	r := bufio.NewReader(&buf)
+	got, nextToken, err := readSpilledPage(r)
+	assert.Nil(t, err)
+	assert.Equal(t, "token", *nextToken)
+	assert.Equal(t, len(rows), len(got))
+	assert.Equal(t, rows[0], got[0])
+	assert.Equal(t, rows[1], got[1])
+	assert.Empty(t, got[2].Data)
+	_, nextToken, err = readSpilledPage(r)
+	assert.Nil(t, err)
+	assert.Nil(t, nextToken)
+	_, _, err = readSpilledPage(r)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestRows_SpillToDisk(t *testing.T) {
+	want, err := countRows(t, "SELECT_OK", 0)
+	assert.Nil(t, err)
+
+	for _, limit := range []int64{0, 2000, DefaultSpillMemoryLimit} {
+		dir := t.TempDir()
+		testConf := NewNoOpsConfig()
+		testConf.SetSpillToDisk(true)
+		testConf.SetSpillMemoryLimit(limit)
+		testConf.SetSpillDir(dir)
+		r, err := NewRows(context.Background(), newMockAthenaClient(), "SELECT_OK", testConf,
+			NewDefaultObservability(testConf))
+		assert.Nil(t, err)
+		assert.IsType(t, &spillingPrefetcher{}, r.pages)
+		dest := make([]driver.Value, len(r.Columns()))
+		got := 0
+		for r.Next(dest) == nil {
+			got++
+		}
+		assert.Equal(t, want, got, limit)
+		if limit == 0 {
+			files, _ := os.ReadDir(dir)
+			assert.Len(t, files, 1)
+		}
+		assert.Nil(t, r.Close())
+		files, _ := os.ReadDir(dir)
+		assert.Empty(t, files)
+	}
+
+	testConf := NewNoOpsConfig()
+	testConf.SetSpillToDisk(true)
+	testConf.SetSpillMemoryLimit(0)
+	testConf.SetSpillDir(t.TempDir())
+	r, err := NewRows(context.Background(), newMockAthenaClient(), "SELECT_GetQueryResults_ERR", testConf,
+		NewDefaultObservability(testConf))
+	assert.Nil(t, err)
+	dest := make([]driver.Value, len(r.Columns()))
+	for err == nil {
+		err = r.Next(dest)
+	}
+	assert.NotEqual(t, io.EOF, err)
+	assert.Nil(t, r.Close())
+}
@@ -23,7 +23,6 @@ package athenadriver
 import (
 	"context"
 	"database/sql/driver"
-	"strings"
 )
 
 // Statement is to implement Go's database/sql Statement.
@@ -55,7 +54,7 @@ func (s *Statement) Close() error {
 // -- From Go `sql/driver`
 func (s *Statement) NumInput() int {
 	if s.numInput == 0 {
-		s.numInput = strings.Count(s.query, "?")
+		s.numInput = numInput(s.query)
 	}
 	return s.numInput
 }
@@ -86,3 +85,23 @@ func (s *Statement) Query(args []driver.Value) (driver.Rows, error) {
 	s.closed = true
 	return r, e
 }
+
+// ExecContext is to execute a prepared statement with named arguments, see driver.StmtExecContext.
+func (s *Statement) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	if s.closed {
+		return nil, driver.ErrBadConn
+	}
+	r, e := s.connection.ExecContext(ctx, s.query, args)
+	s.closed = true
+	return r, e
+}
+
+// QueryContext is to query based on a prepared statement with named arguments, see driver.StmtQueryContext.
+func (s *Statement) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	if s.closed {
+		return nil, driver.ErrBadConn
+	}
+	r, e := s.connection.QueryContext(ctx, s.query, args)
+	s.closed = true
+	return r, e
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"container/list"
+	"sync"
+)
+
+// preparedQuery is the analysis of a query done by Connection.Prepare, which statements of the same query share.
+type preparedQuery struct {
+	query    string
+	numInput int
+}
+
+// stmtCache keeps the prepared queries of a connection by query text, evicting the least recently used ones when
+// there are more than its size, see Config.SetStatementCacheSize.
+type stmtCache struct {
+	mu      sync.Mutex
+	size    int
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+func newStmtCache(size int) *stmtCache {
+	return &stmtCache{
+		size:    size,
+		lru:     list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+// get is to get the prepared query of a query text, analyzing it with prepare if it is not cached.
+func (s *stmtCache) get(query string, prepare func(string) *preparedQuery) *preparedQuery {
+	if s == nil || s.size <= 0 {
+		return prepare(query)
+	}
+	s.mu.Lock()
+	if e, ok := s.entries[query]; ok {
+		s.lru.MoveToFront(e)
+		s.mu.Unlock()
+		return e.Value.(*preparedQuery)
+	}
+	s.mu.Unlock()
+
+	p := prepare(query)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[query]; ok {
+		// prepared meanwhile by another statement
+		s.lru.MoveToFront(e)
+		return e.Value.(*preparedQuery)
+	}
+	s.entries[query] = s.lru.PushFront(p)
+	for s.lru.Len() > s.size {
+		delete(s.entries, s.lru.Remove(s.lru.Back()).(*preparedQuery).query)
+	}
+	return p
+}
+
+// len is the number of cached prepared queries.
+func (s *stmtCache) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lru.Len()
+}
+
+// prepareQuery is to analyze a query for its statements.
+func prepareQuery(query string) *preparedQuery {
+	return &preparedQuery{query: query, numInput: numInput(query)}
+}
+
+// statementCache is to get the statement cache of the connection, created on first use.
+func (c *Connection) statementCache() *stmtCache {
+	c.stmtsOnce.Do(func() {
+		c.stmts = newStmtCache(c.connector.config.GetStatementCacheSize())
+	})
+	return c.stmts
+}
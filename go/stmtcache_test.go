@@ -0,0 +1,85 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStmtCache(t *testing.T) {
+	prepared := 0
+	prepare := func(query string) *preparedQuery {
+		prepared++
+		return prepareQuery(query)
+	}
+	s := newStmtCache(2)
+	p := s.get("SELECT ?", prepare)
+	assert.Equal(t, 1, p.numInput)
+	assert.Same(t, p, s.get("SELECT ?", prepare))
+	assert.Equal(t, 1, prepared)
+
+	s.get("SELECT 1", prepare)
+	// SELECT ? is more recently used than SELECT 1, which is evicted
+	s.get("SELECT ?", prepare)
+	s.get("SELECT 2", prepare)
+	assert.Equal(t, 2, s.len())
+	assert.Equal(t, 3, prepared)
+	s.get("SELECT ?", prepare)
+	assert.Equal(t, 3, prepared)
+	s.get("SELECT 1", prepare)
+	assert.Equal(t, 4, prepared)
+
+	// a zero size disables the cache
+	s = newStmtCache(0)
+	s.get("SELECT ?", prepare)
+	s.get("SELECT ?", prepare)
+	assert.Equal(t, 6, prepared)
+	assert.Equal(t, 0, s.len())
+}
+
+func TestConnection_PrepareCache(t *testing.T) {
+	c := createConnectionFixture()
+	c.connector.config.SetStatementCacheSize(1)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stmt, err := c.Prepare("SELECT * FROM t WHERE a = ? AND b = ?")
+			assert.Nil(t, err)
+			assert.Equal(t, 2, stmt.NumInput())
+			assert.Nil(t, stmt.Close())
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, c.statementCache().len())
+
+	// every Prepare gets a statement of its own
+	s1, _ := c.Prepare("SELECT ?")
+	s2, _ := c.Prepare("SELECT ?")
+	assert.NotSame(t, s1, s2)
+	assert.Nil(t, s1.Close())
+	assert.Equal(t, 1, s2.NumInput())
+	assert.Equal(t, 1, c.statementCache().len())
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Ident is a template value rendered as a quoted identifier, or a qualified one with a part per element, e.g.
+// Ident{"sampledb", "elb_logs"} as "sampledb"."elb_logs", see QuoteQualifiedTable. Empty parts are skipped.
+type Ident []string
+
+// Template is a query with `{{name}}` slots for what placeholders cannot stand for, like table and partition
+// names, see Tmpl.
+type Template struct {
+	text string
+}
+
+// Tmpl is to create a Template from a query with `{{name}}` slots, e.g. `SELECT * FROM {{table}} WHERE id = {{id}}`.
+// Like `?` placeholders, a slot in a string literal, a quoted identifier or a comment is left as is.
+func Tmpl(text string) Template {
+	return Template{text: text}
+}
+
+// Render is to fill the slots of the template with the values of the same name, escaped for their type: an Ident is
+// quoted as an identifier, a RawParam, e.g. a function call, is inserted as is, a slice other than []byte as a
+// parenthesized list of literals, and any other value as a literal like BulkInsert renders it, e.g. a string with
+// its single quotes doubled. Every slot must have a value and every value must be used.
+func (t Template) Render(values map[string]interface{}) (string, error) {
+	var b strings.Builder
+	used := make(map[string]bool, len(values))
+	last := 0
+	tok := sqlTokenizer{query: t.text}
+	for {
+		start, end := tok.scan()
+		if start == end {
+			break
+		}
+		if !strings.HasPrefix(t.text[start:], "{{") {
+			continue
+		}
+		n := strings.Index(t.text[start+2:], "}}")
+		if n < 0 {
+			return "", fmt.Errorf("%w: template slot at %d is not closed", ErrInvalidQuery, start)
+		}
+		name := strings.TrimSpace(t.text[start+2 : start+2+n])
+		if !isTemplateName(name) {
+			return "", fmt.Errorf("%w: template slot name %q is not valid", ErrInvalidQuery, name)
+		}
+		value, ok := values[name]
+		if !ok {
+			return "", fmt.Errorf("%w: no template value named %q", ErrInvalidQuery, name)
+		}
+		used[name] = true
+		rendered, err := renderTemplateValue(value)
+		if err != nil {
+			return "", fmt.Errorf("template value %q: %w", name, err)
+		}
+		b.WriteString(t.text[last:start])
+		b.WriteString(rendered)
+		last = start + 2 + n + 2
+		tok.pos = last
+	}
+	for name := range values {
+		if !used[name] {
+			return "", fmt.Errorf("%w: template value %q is not used", ErrInvalidQuery, name)
+		}
+	}
+	b.WriteString(t.text[last:])
+	return b.String(), nil
+}
+
+// isTemplateName is to check if a slot name is made of letters, digits and underscores only.
+func isTemplateName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isWordChar(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// renderTemplateValue is to render a template value as an identifier, a raw fragment, a list or a literal.
+func renderTemplateValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case Ident:
+		if quoted := QuoteQualifiedTable(v...); quoted != "" {
+			return quoted, nil
+		}
+		return "", fmt.Errorf("%w: identifier is empty", ErrInvalidQuery)
+	case RawParam:
+		return string(v), nil
+	}
+	if isSliceParam(value) {
+		rv := reflect.ValueOf(value)
+		if rv.Len() == 0 {
+			return "", ErrEmptySliceParam
+		}
+		values := make([]interface{}, rv.Len())
+		for i := range values {
+			values[i] = rv.Index(i).Interface()
+		}
+		buf, err := appendLiterals([]byte{'('}, values)
+		if err != nil {
+			return "", err
+		}
+		return string(append(buf, ')')), nil
+	}
+	buf, err := appendInsertLiteral(nil, value)
+	return string(buf), err
+}
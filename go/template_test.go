@@ -0,0 +1,75 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplate_Render(t *testing.T) {
+	q, err := Tmpl("SELECT * FROM {{table}} WHERE id = {{ id }} AND name = {{name}} AND day IN {{days}} " +
+		"AND ts > {{since}} AND '{{id}}' <> \"{{id}}\" -- {{id}}").Render(map[string]interface{}{
+		"table": Ident{"", "sample\"db", "elb_logs"},
+		"id":    42,
+		"name":  "it's",
+		"days":  []string{"2024-07-01", "2024-07-02"},
+		"since": RawParam("current_date - INTERVAL '1' DAY"),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, `SELECT * FROM "sample""db"."elb_logs" WHERE id = 42 AND name = 'it''s' `+
+		`AND day IN ('2024-07-01', '2024-07-02') AND ts > current_date - INTERVAL '1' DAY `+
+		`AND '{{id}}' <> "{{id}}" -- {{id}}`, q)
+
+	q, err = Tmpl("SELECT {{t}}, {{t}}").Render(map[string]interface{}{
+		"t": time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)})
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT TIMESTAMP '2024-07-01 00:00:00.000', TIMESTAMP '2024-07-01 00:00:00.000'", q)
+
+	q, err = Tmpl("SELECT 1").Render(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT 1", q)
+}
+
+func TestTemplate_RenderInvalid(t *testing.T) {
+	testCases := []struct {
+		text   string
+		values map[string]interface{}
+	}{
+		{"SELECT {{a", map[string]interface{}{"a": 1}},
+		{"SELECT {{a b}}", map[string]interface{}{"a": 1}},
+		{"SELECT {{}}", nil},
+		{"SELECT {{a}}", nil},
+		{"SELECT {{a}}", map[string]interface{}{"a": 1, "b": 2}},
+		{"SELECT * FROM {{a}}", map[string]interface{}{"a": Ident{""}}},
+		{"SELECT {{a}}", map[string]interface{}{"a": struct{}{}}},
+	}
+	for _, tc := range testCases {
+		_, err := Tmpl(tc.text).Render(tc.values)
+		assert.NotNil(t, err, tc.text)
+	}
+
+	_, err := Tmpl("SELECT {{a}}").Render(map[string]interface{}{"a": []int{}})
+	assert.True(t, errors.Is(err, ErrEmptySliceParam))
+}
@@ -0,0 +1,235 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+)
+
+// TimeoutPolicy is how long the driver waits for a query to finish, by the type of its statement, before failing it
+// with a *QueryTimeoutError. A zero timeout falls back to the Athena service limit of the statement type,
+// DMLQueryTimeout for DML and UTILITY statements and DDLQueryTimeout otherwise. Max, if set, caps all of them.
+// It is set with Config.SetTimeoutPolicy and can be overridden for a query with a value of TimeoutPolicyKey in its
+// context, whose zero fields keep the configured timeouts.
+//
+// The other phases of a query have timeouts of their own, which are off when zero: Connect bounds dialing the AWS
+// endpoints and fails with a *ConnectTimeoutError, and is only taken from the config. Queued bounds how long a
+// query waits in the QUEUED state and fails with a *QueueTimeoutError, Running bounds how long it is RUNNING and
+// fails with a *RunningTimeoutError, and Fetch bounds reading its result and fails with a *FetchTimeoutError. A query
+// which exceeds Queued or Running is stopped.
+type TimeoutPolicy struct {
+	DML     time.Duration
+	DDL     time.Duration
+	Utility time.Duration
+	Max     time.Duration
+
+	Connect time.Duration
+	Queued  time.Duration
+	Running time.Duration
+	Fetch   time.Duration
+}
+
+// timeout is to get how long a query of a statement type can run.
+func (p TimeoutPolicy) timeout(statementType athenatypes.StatementType) time.Duration {
+	dml := p.DML
+	if dml <= 0 {
+		dml = DMLQueryTimeout * time.Second
+	}
+	timeout := p.DDL
+	if timeout <= 0 {
+		timeout = DDLQueryTimeout * time.Second
+	}
+	switch statementType {
+	case athenatypes.StatementTypeDml:
+		timeout = dml
+	case athenatypes.StatementTypeUtility:
+		timeout = dml
+		if p.Utility > 0 {
+			timeout = p.Utility
+		}
+	}
+	if p.Max > 0 && p.Max < timeout {
+		timeout = p.Max
+	}
+	return timeout
+}
+
+// merge is to override the timeouts of p with the non-zero ones of override.
+func (p TimeoutPolicy) merge(override TimeoutPolicy) TimeoutPolicy {
+	if override.DML > 0 {
+		p.DML = override.DML
+	}
+	if override.DDL > 0 {
+		p.DDL = override.DDL
+	}
+	if override.Utility > 0 {
+		p.Utility = override.Utility
+	}
+	if override.Max > 0 {
+		p.Max = override.Max
+	}
+	if override.Connect > 0 {
+		p.Connect = override.Connect
+	}
+	if override.Queued > 0 {
+		p.Queued = override.Queued
+	}
+	if override.Running > 0 {
+		p.Running = override.Running
+	}
+	if override.Fetch > 0 {
+		p.Fetch = override.Fetch
+	}
+	return p
+}
+
+// QueryTimeoutError is returned when a query runs longer than its timeout, see TimeoutPolicy. It is ErrQueryTimeout
+// for errors.Is.
+type QueryTimeoutError struct {
+	QueryID string
+	Timeout time.Duration
+}
+
+// Error is to implement interface error.
+func (e *QueryTimeoutError) Error() string {
+	return fmt.Sprintf("%v: query %s ran longer than %v", ErrQueryTimeout, e.QueryID, e.Timeout)
+}
+
+// Is is to match ErrQueryTimeout.
+func (e *QueryTimeoutError) Is(target error) bool {
+	return target == ErrQueryTimeout
+}
+
+// QueueTimeoutError is returned when a query waits in the QUEUED state longer than TimeoutPolicy.Queued. It is
+// ErrQueueTimeout and ErrQueryTimeout for errors.Is.
+type QueueTimeoutError struct {
+	QueryID string
+	Timeout time.Duration
+}
+
+// Error is to implement interface error.
+func (e *QueueTimeoutError) Error() string {
+	return fmt.Sprintf("%v: query %s was queued longer than %v", ErrQueueTimeout, e.QueryID, e.Timeout)
+}
+
+// Is is to match ErrQueueTimeout and ErrQueryTimeout.
+func (e *QueueTimeoutError) Is(target error) bool {
+	return target == ErrQueueTimeout || target == ErrQueryTimeout
+}
+
+// RunningTimeoutError is returned when a query is RUNNING longer than TimeoutPolicy.Running. It is ErrRunningTimeout
+// and ErrQueryTimeout for errors.Is.
+type RunningTimeoutError struct {
+	QueryID string
+	Timeout time.Duration
+}
+
+// Error is to implement interface error.
+func (e *RunningTimeoutError) Error() string {
+	return fmt.Sprintf("%v: query %s was running longer than %v", ErrRunningTimeout, e.QueryID, e.Timeout)
+}
+
+// Is is to match ErrRunningTimeout and ErrQueryTimeout.
+func (e *RunningTimeoutError) Is(target error) bool {
+	return target == ErrRunningTimeout || target == ErrQueryTimeout
+}
+
+// DeadlineError is returned when the deadline of the context of a query passes, or is too close for another status
+// check to finish in time, before the query finishes. The query is stopped, unless it is detached on cancel. State is
+// the last state the query was seen in. It wraps context.DeadlineExceeded.
+type DeadlineError struct {
+	QueryID  string
+	State    athenatypes.QueryExecutionState
+	Deadline time.Time
+}
+
+// Error is to implement interface error.
+func (e *DeadlineError) Error() string {
+	return fmt.Sprintf("%v: query %s was still %s at %s", context.DeadlineExceeded, e.QueryID, e.State,
+		e.Deadline.Format(time.RFC3339Nano))
+}
+
+// Unwrap is to get context.DeadlineExceeded.
+func (e *DeadlineError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// FetchTimeoutError is returned when reading the result of a query takes longer than TimeoutPolicy.Fetch. It is
+// ErrFetchTimeout for errors.Is.
+type FetchTimeoutError struct {
+	QueryID string
+	Timeout time.Duration
+}
+
+// Error is to implement interface error.
+func (e *FetchTimeoutError) Error() string {
+	return fmt.Sprintf("%v: reading the result of query %s took longer than %v", ErrFetchTimeout, e.QueryID,
+		e.Timeout)
+}
+
+// Is is to match ErrFetchTimeout.
+func (e *FetchTimeoutError) Is(target error) bool {
+	return target == ErrFetchTimeout
+}
+
+// ConnectTimeoutError is returned when dialing an AWS endpoint takes longer than TimeoutPolicy.Connect. It is
+// ErrConnectTimeout for errors.Is, and wraps the error of the dialer.
+type ConnectTimeoutError struct {
+	Address string
+	Timeout time.Duration
+	Err     error
+}
+
+// Error is to implement interface error.
+func (e *ConnectTimeoutError) Error() string {
+	return fmt.Sprintf("%v: connecting to %s took longer than %v: %v", ErrConnectTimeout, e.Address, e.Timeout,
+		e.Err)
+}
+
+// Unwrap is to get the error of the dialer.
+func (e *ConnectTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// Is is to match ErrConnectTimeout.
+func (e *ConnectTimeoutError) Is(target error) bool {
+	return target == ErrConnectTimeout
+}
+
+// timeoutDialer is to dial with the Connect timeout, failing with a *ConnectTimeoutError when it is exceeded.
+func timeoutDialer(timeout time.Duration) func(ctx context.Context, network string, address string) (net.Conn,
+	error) {
+	dialer := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}
+	return func(ctx context.Context, network string, address string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, network, address)
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return nil, &ConnectTimeoutError{Address: address, Timeout: timeout, Err: err}
+		}
+		return conn, err
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeoutPolicy(t *testing.T) {
+	var policy TimeoutPolicy
+	assert.Equal(t, DMLQueryTimeout*time.Second, policy.timeout(athenatypes.StatementTypeDml))
+	assert.Equal(t, DMLQueryTimeout*time.Second, policy.timeout(athenatypes.StatementTypeUtility))
+	assert.Equal(t, DDLQueryTimeout*time.Second, policy.timeout(athenatypes.StatementTypeDdl))
+	assert.Equal(t, DDLQueryTimeout*time.Second, policy.timeout("UNKNOWN"))
+
+	policy = TimeoutPolicy{DML: time.Hour, Utility: time.Minute}
+	assert.Equal(t, time.Hour, policy.timeout(athenatypes.StatementTypeDml))
+	assert.Equal(t, time.Minute, policy.timeout(athenatypes.StatementTypeUtility))
+
+	policy.Max = 10 * time.Minute
+	assert.Equal(t, 10*time.Minute, policy.timeout(athenatypes.StatementTypeDml))
+	assert.Equal(t, 10*time.Minute, policy.timeout(athenatypes.StatementTypeDdl))
+	assert.Equal(t, time.Minute, policy.timeout(athenatypes.StatementTypeUtility))
+
+	merged := policy.merge(TimeoutPolicy{DDL: time.Second})
+	assert.Equal(t, TimeoutPolicy{DML: time.Hour, DDL: time.Second, Utility: time.Minute, Max: 10 * time.Minute}, merged)
+
+	merged = merged.merge(TimeoutPolicy{Connect: time.Second, Queued: time.Minute, Running: time.Hour,
+		Fetch: 2 * time.Minute})
+	assert.Equal(t, time.Second, merged.Connect)
+	assert.Equal(t, time.Minute, merged.Queued)
+	assert.Equal(t, time.Hour, merged.Running)
+	assert.Equal(t, 2*time.Minute, merged.Fetch)
+	assert.Equal(t, time.Second, merged.DDL)
+}
+
+func TestConnection_QueryContextTimeout(t *testing.T) {
+	c := createConnectionFixture()
+	_, err := c.QueryContext(context.Background(), "SELECTQueryContext_TIMEOUT", []driver.NamedValue{})
+	var timeoutErr *QueryTimeoutError
+	assert.True(t, errors.As(err, &timeoutErr))
+	assert.Equal(t, "SELECTQueryContext_TIMEOUT_QID", timeoutErr.QueryID)
+	assert.True(t, errors.Is(err, ErrQueryTimeout))
+
+	// a query whose timeout is not up keeps being polled until its context is done
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), TimeoutPolicyKey,
+		TimeoutPolicy{Max: time.Hour}), 50*time.Millisecond)
+	defer cancel()
+	_, err = c.QueryContext(ctx, "SELECTQueryContext_CANCEL_OK", []driver.NamedValue{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestConnection_QueryContextQueueTimeout(t *testing.T) {
+	c := createConnectionFixture()
+	c.connector.config.SetInitialPollInterval(10 * time.Millisecond)
+	c.connector.config.SetTimeoutPolicy(TimeoutPolicy{Queued: 50 * time.Millisecond})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	m := c.athenaClient.(*mockAthenaClient)
+	_, err := c.QueryContext(ctx, "SELECTQueryContext_CANCEL_OK", []driver.NamedValue{})
+	assert.Equal(t, 1, m.callCount("StopQueryExecution"))
+	var queueErr *QueueTimeoutError
+	assert.True(t, errors.As(err, &queueErr))
+	assert.Equal(t, "SELECTQueryContext_CANCEL_OK_QID", queueErr.QueryID)
+	assert.Equal(t, 50*time.Millisecond, queueErr.Timeout)
+	assert.True(t, errors.Is(err, ErrQueueTimeout))
+	assert.True(t, errors.Is(err, ErrQueryTimeout))
+	assert.False(t, errors.Is(err, ErrFetchTimeout))
+}
+
+func TestConnection_QueryContextRunningTimeout(t *testing.T) {
+	c := createConnectionFixture()
+	c.connector.config.SetInitialPollInterval(10 * time.Millisecond)
+	// a query which is running is not bound by the Queued timeout
+	c.connector.config.SetTimeoutPolicy(TimeoutPolicy{Queued: time.Millisecond, Running: 50 * time.Millisecond})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	m := c.athenaClient.(*mockAthenaClient)
+	_, err := c.QueryContext(ctx, "SELECTQueryContext_RUNNING", []driver.NamedValue{})
+	assert.Equal(t, 1, m.callCount("StopQueryExecution"))
+	var timeoutErr *RunningTimeoutError
+	assert.True(t, errors.As(err, &timeoutErr))
+	assert.Equal(t, "SELECTQueryContext_RUNNING_QID", timeoutErr.QueryID)
+	assert.Equal(t, 50*time.Millisecond, timeoutErr.Timeout)
+	assert.True(t, errors.Is(err, ErrRunningTimeout))
+	assert.True(t, errors.Is(err, ErrQueryTimeout))
+	assert.False(t, errors.Is(err, ErrQueueTimeout))
+	var queryErr *QueryTimeoutError
+	assert.False(t, errors.As(err, &queryErr))
+}
+
+func TestRows_FetchTimeout(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	testConf.SetTimeoutPolicy(TimeoutPolicy{Fetch: time.Hour})
+	r, err := NewRows(context.Background(), newMockAthenaClient(), "1coloumn0row_valid", testConf,
+		NewDefaultObservability(testConf))
+	assert.Nil(t, err)
+	assert.NotNil(t, r)
+
+	ctx := context.WithValue(context.Background(), TimeoutPolicyKey, TimeoutPolicy{Fetch: time.Nanosecond})
+	time.Sleep(time.Millisecond)
+	_, err = NewRows(ctx, newMockAthenaClient(), "1coloumn0row_valid", testConf, NewDefaultObservability(testConf))
+	var fetchErr *FetchTimeoutError
+	assert.True(t, errors.As(err, &fetchErr))
+	assert.Equal(t, "1coloumn0row_valid", fetchErr.QueryID)
+	assert.True(t, errors.Is(err, ErrFetchTimeout))
+	assert.False(t, errors.Is(err, ErrQueryTimeout))
+}
+
+func TestTimeoutDialer(t *testing.T) {
+	err := error(&ConnectTimeoutError{Address: "athena.us-east-1.amazonaws.com:443", Timeout: time.Second,
+		Err: context.DeadlineExceeded})
+	assert.True(t, errors.Is(err, ErrConnectTimeout))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Contains(t, err.Error(), "athena.us-east-1.amazonaws.com:443")
+
+	l, e := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, e)
+	defer l.Close()
+	conn, e := timeoutDialer(time.Second)(context.Background(), "tcp", l.Addr().String())
+	assert.Nil(t, e)
+	conn.Close()
+}
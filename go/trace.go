@@ -21,6 +21,8 @@
 package athenadriver
 
 import (
+	"context"
+
 	"github.com/uber-go/tally"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -107,6 +109,35 @@ func (c *DriverTracer) SetScope(scope tally.Scope) {
 	c.scope = scope
 }
 
+// WithLogger is to log the queries run with the returned context with logger rather than the logger of the
+// connector, e.g. one with the fields of a tenant. Logging still needs to be enabled in the config.
+func WithLogger(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, LoggerKey, logger)
+}
+
+// WithMetrics is to report the metrics of the queries run with the returned context to scope rather than the scope
+// of the connector, e.g. one tagged with a tenant. Metrics still need to be enabled in the config.
+func WithMetrics(ctx context.Context, scope tally.Scope) context.Context {
+	return context.WithValue(ctx, MetricsKey, scope)
+}
+
+// withContext returns the tracer of a query: c with the logger and metrics scope of the context, if it has any.
+func (c *DriverTracer) withContext(ctx context.Context) *DriverTracer {
+	logger, hasLogger := ctx.Value(LoggerKey).(*zap.Logger)
+	scope, hasScope := ctx.Value(MetricsKey).(tally.Scope)
+	if !hasLogger && !hasScope {
+		return c
+	}
+	o := *c
+	if hasLogger && logger != nil {
+		o.logger = logger
+	}
+	if hasScope && scope != nil {
+		o.scope = scope
+	}
+	return &o
+}
+
 // Config is to get c.config
 func (c *DriverTracer) Config() *Config {
 	return c.config
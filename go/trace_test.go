@@ -21,11 +21,13 @@
 package athenadriver
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/uber-go/tally"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestObservability_Config(t *testing.T) {
@@ -84,3 +86,42 @@ func TestObservability_NewObservability(t *testing.T) {
 	assert.NotNil(t, obs.Logger())
 	assert.Equal(t, obs.Logger(), zap.NewNop())
 }
+
+func TestObservability_WithContext(t *testing.T) {
+	obs := NewNoOpsObservability()
+	assert.Same(t, obs, obs.withContext(context.Background()))
+
+	scope := tally.NewTestScope("tenant", nil)
+	logger := zap.NewExample()
+	ctxObs := obs.withContext(WithLogger(WithMetrics(context.Background(), scope), logger))
+	assert.NotSame(t, obs, ctxObs)
+	assert.Equal(t, scope, ctxObs.scope)
+	assert.Equal(t, logger, ctxObs.logger)
+	assert.Equal(t, tally.NoopScope, obs.scope)
+
+	// a nil override keeps the tracer's own
+	ctxObs = obs.withContext(WithLogger(context.Background(), nil))
+	assert.Equal(t, obs.logger, ctxObs.logger)
+}
+
+func TestConnection_QueryContextTracer(t *testing.T) {
+	c := createConnectionFixture()
+	c.connector.config.SetMetrics(true)
+	c.connector.config.SetLogging(true)
+	connectorScope := tally.NewTestScope("", nil)
+	c.connector.tracer = NewObservability(c.connector.config, zap.NewNop(), connectorScope)
+
+	scope := tally.NewTestScope("", nil)
+	core, logs := observer.New(zap.InfoLevel)
+	ctx := WithMetrics(WithLogger(context.Background(), zap.New(core)), scope)
+	_, err := c.QueryContext(ctx, "SELECTQueryContext_OK", nil)
+	assert.Nil(t, err)
+	_, err = c.QueryContext(ctx, "SELECTQueryContext_AWS_FAIL", nil)
+	assert.NotNil(t, err)
+
+	assert.NotEmpty(t, scope.Snapshot().Timers())
+	assert.NotEmpty(t, scope.Snapshot().Counters())
+	assert.Empty(t, connectorScope.Snapshot().Timers())
+	assert.Empty(t, connectorScope.Snapshot().Counters())
+	assert.NotZero(t, logs.FilterMessage("QueryExecutionStateFailed").Len())
+}
@@ -0,0 +1,194 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"go.uber.org/zap"
+)
+
+const (
+	// ResultTransportAPI is the name of the default ResultTransport, which pages through GetQueryResults.
+	ResultTransportAPI = "api"
+	// ResultTransportS3CSV is the name of the ResultTransport which streams the CSV file Athena writes the result
+	// of a SELECT to, see Config.SetS3ResultThreshold.
+	ResultTransportS3CSV = "s3csv"
+)
+
+// ResultTransport is how the rows of a query result are read once its execution has succeeded, e.g. by paging
+// through GetQueryResults, by streaming the result CSV file from S3, or by reading the Parquet files an UNLOAD
+// writes. The transport of a connection is selected with Config.SetResultTransport.
+type ResultTransport interface {
+	// Open is to open the pages of a query result after first, the first page of GetQueryResults without its
+	// header row, which is the only page read with GetQueryResults then. It returns nil pages to page through
+	// GetQueryResults instead, e.g. when the transport cannot read the result of a statement.
+	Open(ctx context.Context, queryExecution *athenatypes.QueryExecution,
+		first *athena.GetQueryResultsOutput) (ResultPages, error)
+}
+
+// ResultPages is the pages of a query result read by a ResultTransport. The pages have no header row.
+type ResultPages interface {
+	// Next is to get the next page, with a ResultSet and its ResultSetMetadata. The NextToken of the last page is
+	// nil or empty, the one of the others only needs to be non-empty.
+	Next() (*athena.GetQueryResultsOutput, error)
+	// Close is to release what the pages hold, e.g. when the rows are closed before the last page.
+	Close() error
+}
+
+// QueryRewriter is a ResultTransport which needs queries to be executed differently for their results to be read
+// by it, e.g. as UNLOAD statements writing Parquet files. Such results are only readable by the transport, so they
+// are neither cached nor coalesced.
+type QueryRewriter interface {
+	// RewriteQuery is to rewrite a query of the connection before it is executed. It returns false to execute the
+	// query as it is.
+	RewriteQuery(query string) (string, bool)
+}
+
+var (
+	resultTransportsMu sync.RWMutex
+	resultTransports   = map[string]ResultTransport{}
+)
+
+// RegisterResultTransport is to install a ResultTransport under a name, so the connections whose
+// Config.SetResultTransport names it read query results with it. The names of the built-in transports,
+// ResultTransportAPI and ResultTransportS3CSV, always select the built-in ones.
+func RegisterResultTransport(name string, t ResultTransport) {
+	resultTransportsMu.Lock()
+	defer resultTransportsMu.Unlock()
+	resultTransports[name] = t
+}
+
+// UnregisterResultTransport is to remove the ResultTransport installed under a name.
+func UnregisterResultTransport(name string) {
+	resultTransportsMu.Lock()
+	defer resultTransportsMu.Unlock()
+	delete(resultTransports, name)
+}
+
+func getResultTransport(name string) (ResultTransport, bool) {
+	resultTransportsMu.RLock()
+	defer resultTransportsMu.RUnlock()
+	t, ok := resultTransports[name]
+	return t, ok
+}
+
+// resultTransport is to get the ResultTransport named in the config, or nil to page through GetQueryResults.
+// Without a name, results are streamed from S3 when Config.SetS3ResultThreshold is set.
+func (c *Connection) resultTransport() ResultTransport {
+	config := c.connector.config
+	switch name := config.GetResultTransport(); name {
+	case "":
+		if config.GetS3ResultThreshold() > 0 && c.s3Client != nil {
+			return &s3CSVTransport{client: c.s3Client, threshold: config.GetS3ResultThreshold()}
+		}
+		return nil
+	case ResultTransportAPI:
+		return nil
+	case ResultTransportS3CSV:
+		if c.s3Client == nil {
+			return nil
+		}
+		return &s3CSVTransport{client: c.s3Client, threshold: config.GetS3ResultThreshold()}
+	default:
+		t, ok := getResultTransport(name)
+		if !ok {
+			c.connector.tracer.Log(WarnLevel, "result transport is not registered", zap.String("name", name))
+		}
+		return t
+	}
+}
+
+// rewriteQuery is to rewrite a query for the ResultTransport if it is a QueryRewriter, and whether it did.
+func rewriteQuery(t ResultTransport, query string) (string, bool) {
+	rewriter, ok := t.(QueryRewriter)
+	if !ok {
+		return query, false
+	}
+	rewritten, ok := rewriter.RewriteQuery(query)
+	if !ok {
+		return query, false
+	}
+	return rewritten, true
+}
+
+// readResultWith is to read the rows after their first page with a ResultTransport. When that fails, the rows are
+// paged through GetQueryResults instead, unless the query has been rewritten for the transport, whose result is
+// only readable by it.
+func (c *Connection) readResultWith(ctx context.Context, t ResultTransport, rows *Rows,
+	queryExecution *athenatypes.QueryExecution, rewritten bool) error {
+	obs := c.tracer(ctx)
+	pages, err := t.Open(ctx, queryExecution, rows.ResultOutput)
+	if err == nil && pages != nil {
+		if err = rows.readFrom(pages); err == nil {
+			obs.Scope().Counter(DriverName + ".query.resulttransport").Inc(1)
+		}
+	}
+	if err == nil {
+		return nil
+	}
+	obs.Scope().Counter(DriverName + ".failure.querycontext.resulttransport").Inc(1)
+	if rewritten {
+		return err
+	}
+	obs.Log(WarnLevel, "reading query result with the result transport failed, falling back to GetQueryResults",
+		zap.String("queryID", *queryExecution.QueryExecutionId),
+		zap.String("error", err.Error()))
+	return nil
+}
+
+// readFrom is to read the pages after the current one from a ResultTransport's pages. It must be called before any
+// row is read. When the current page has no rows, e.g. the GetQueryResults of an UNLOAD, the first page of the
+// transport is fetched right away for its columns.
+func (r *Rows) readFrom(pages ResultPages) error {
+	if r.pages != nil {
+		r.pages.stop()
+	}
+	r.pages = resultPagesSource{pages}
+	if !r.reachedLastPage && r.remainingRows() > 0 {
+		// The token is only checked to be non-empty, the pages are read in order anyway.
+		if r.ResultOutput.NextToken == nil || *r.ResultOutput.NextToken == "" {
+			r.ResultOutput.NextToken = aws.String("transport")
+		}
+		return nil
+	}
+	r.reachedLastPage = false
+	// The pages of a transport have no header row to skip.
+	r.pageCount = 0
+	return r.fetchNextPage(nil)
+}
+
+// resultPagesSource is a pageSource reading the pages of a ResultTransport.
+type resultPagesSource struct {
+	pages ResultPages
+}
+
+func (s resultPagesSource) next() (*athena.GetQueryResultsOutput, error) {
+	return s.pages.Next()
+}
+
+func (s resultPagesSource) stop() {
+	_ = s.pages.Close()
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockResultTransport reads pages of integers after the first page, and rewrites queries to the mock's
+// SELECTQueryContext_OK when rewrite is set.
+type mockResultTransport struct {
+	rewrite bool
+	err     error
+	pages   int
+	queries []*athenatypes.QueryExecution
+}
+
+func (t *mockResultTransport) RewriteQuery(query string) (string, bool) {
+	return "SELECTQueryContext_OK", t.rewrite
+}
+
+func (t *mockResultTransport) Open(_ context.Context, queryExecution *athenatypes.QueryExecution,
+	first *athena.GetQueryResultsOutput) (ResultPages, error) {
+	t.queries = append(t.queries, queryExecution)
+	if t.err != nil {
+		return nil, t.err
+	}
+	return &mockResultPages{left: t.pages, metadata: first.ResultSet.ResultSetMetadata}, nil
+}
+
+type mockResultPages struct {
+	left     int
+	metadata *athenatypes.ResultSetMetadata
+	closed   bool
+}
+
+func (p *mockResultPages) Next() (*athena.GetQueryResultsOutput, error) {
+	p.left--
+	var nextToken *string
+	if p.left > 0 {
+		nextToken = aws.String("more")
+	}
+	return &athena.GetQueryResultsOutput{
+		NextToken: nextToken,
+		ResultSet: &athenatypes.ResultSet{
+			ResultSetMetadata: p.metadata,
+			Rows:              []athenatypes.Row{newRow(1, []string{strconv.Itoa(p.left)})},
+		},
+	}, nil
+}
+
+func (p *mockResultPages) Close() error {
+	p.closed = true
+	return nil
+}
+
+func readAllRows(t *testing.T, rows driver.Rows) []driver.Value {
+	var values []driver.Value
+	dest := make([]driver.Value, len(rows.Columns()))
+	for {
+		err := rows.Next(dest)
+		if err == io.EOF {
+			return values
+		}
+		assert.Nil(t, err)
+		values = append(values, dest[0])
+	}
+}
+
+func TestConnection_QueryContextResultTransport(t *testing.T) {
+	transport := &mockResultTransport{pages: 2}
+	RegisterResultTransport("TestConnection_QueryContextResultTransport", transport)
+	defer UnregisterResultTransport("TestConnection_QueryContextResultTransport")
+	c := createConnectionFixture()
+	c.connector.config.SetResultTransport("TestConnection_QueryContextResultTransport")
+
+	rows, err := c.QueryContext(context.Background(), "SELECTQueryContext_OK", []driver.NamedValue{})
+	assert.Nil(t, err)
+	values := readAllRows(t, rows)
+	assert.Len(t, values, 3)
+	assert.Equal(t, []driver.Value{int32(1), int32(0)}, values[1:])
+	assert.Len(t, transport.queries, 1)
+	assert.Equal(t, "SELECTQueryContext_OK_QID", *transport.queries[0].QueryExecutionId)
+
+	// the result is paged through GetQueryResults when the transport fails
+	transport.err = ErrTestMockGeneric
+	rows, err = c.QueryContext(context.Background(), "SELECTQueryContext_OK", []driver.NamedValue{})
+	assert.Nil(t, err)
+	assert.Len(t, readAllRows(t, rows), 1)
+
+	// and so is it with an unknown transport
+	c.connector.config.SetResultTransport("unknown")
+	rows, err = c.QueryContext(context.Background(), "SELECTQueryContext_OK", []driver.NamedValue{})
+	assert.Nil(t, err)
+	assert.Len(t, readAllRows(t, rows), 1)
+	assert.Len(t, transport.queries, 2)
+}
+
+func TestConnection_QueryContextQueryRewriter(t *testing.T) {
+	transport := &mockResultTransport{rewrite: true, pages: 1}
+	RegisterResultTransport("TestConnection_QueryContextQueryRewriter", transport)
+	defer UnregisterResultTransport("TestConnection_QueryContextQueryRewriter")
+	RegisterQueryCache("TestConnection_QueryContextQueryRewriter", NewAthenaQueryCache(NewMemoryCache(10, 0)))
+	defer UnregisterQueryCache("TestConnection_QueryContextQueryRewriter")
+	c := createConnectionFixture()
+	nm := c.athenaClient.(*mockAthenaClient)
+	c.connector.config.SetResultTransport("TestConnection_QueryContextQueryRewriter")
+	c.connector.config.SetQueryCache("TestConnection_QueryContextQueryRewriter")
+
+	// the results of rewritten queries are only readable by the transport, so they are not cached
+	for i := 0; i < 2; i++ {
+		rows, err := c.QueryContext(context.Background(), "SELECT * FROM t", []driver.NamedValue{})
+		assert.Nil(t, err)
+		assert.Len(t, readAllRows(t, rows), 2)
+	}
+	assert.Equal(t, 2, nm.callCount("StartQueryExecution"))
+	assert.Equal(t, "SELECTQueryContext_OK", *nm.lastStartInput.QueryString)
+
+	// and there is nothing to fall back to when the transport fails
+	transport.err = ErrTestMockGeneric
+	_, err := c.QueryContext(context.Background(), "SELECT * FROM t", []driver.NamedValue{})
+	assert.Equal(t, ErrTestMockGeneric, err)
+}
+
+func TestRows_ReadFrom(t *testing.T) {
+	testConf := NewNoOpsConfig()
+	r, err := NewRows(context.Background(), newMockAthenaClient(), "INSERT_QID", testConf,
+		NewDefaultObservability(testConf))
+	assert.Nil(t, err)
+	// the result has no row, so the first page of the transport is read right away for its columns
+	pages := &mockResultPages{left: 2, metadata: &athenatypes.ResultSetMetadata{
+		ColumnInfo: []athenatypes.ColumnInfo{newColumnInfo("n", "bigint")},
+	}}
+	assert.Nil(t, r.readFrom(pages))
+	assert.Equal(t, []string{"n"}, r.Columns())
+	assert.Equal(t, []driver.Value{int64(1), int64(0)}, readAllRows(t, r))
+
+	pages = &mockResultPages{left: 2}
+	r, err = NewRows(context.Background(), newMockAthenaClient(), "SELECT_OK", testConf,
+		NewDefaultObservability(testConf))
+	assert.Nil(t, err)
+	pages.metadata = r.ResultOutput.ResultSet.ResultSetMetadata
+	assert.Nil(t, r.readFrom(pages))
+	assert.Nil(t, r.Close())
+	assert.True(t, pages.closed)
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// TxCommitError is returned by the Commit of a transaction whose statements did not all succeed. Each statement
+// is atomic on an Iceberg table, but the statements before the failed one stay committed, as Athena cannot roll
+// them back.
+type TxCommitError struct {
+	// Committed is the number of statements which succeeded.
+	Committed int
+	// Statement is the statement which failed.
+	Statement string
+	Err       error
+}
+
+func (e *TxCommitError) Error() string {
+	return fmt.Sprintf("transaction statement %d failed after %d committed: %s", e.Committed+1, e.Committed,
+		e.Err.Error())
+}
+
+func (e *TxCommitError) Unwrap() error {
+	return e.Err
+}
+
+// TxRollbackWarning is returned by the Rollback of a no-op transaction in which statements were executed, as they
+// are committed already, see Config.SetNoOpTransactions.
+type TxRollbackWarning struct {
+	// Executed is the number of statements which were executed in the transaction.
+	Executed int
+}
+
+func (e *TxRollbackWarning) Error() string {
+	return fmt.Sprintf("rollback is a no-op, the %d statements of the transaction are committed", e.Executed)
+}
+
+// pseudoTx is a transaction Athena doesn't know about. In an Iceberg transaction, the INSERT, UPDATE, DELETE and
+// MERGE statements executed on its connection are queued rather than run, and run one after another on Commit, see
+// Config.SetIcebergTransactions. In a no-op one, they are run right away, see Config.SetNoOpTransactions.
+type pseudoTx struct {
+	conn       *Connection
+	noOp       bool
+	mu         sync.Mutex
+	statements []string
+	executed   int
+}
+
+// Begin is from Conn interface. Athena has no transactions, so it fails with ErrAthenaTransactionUnsupported,
+// unless Config.SetIcebergTransactions or Config.SetNoOpTransactions is set.
+func (c *Connection) Begin() (driver.Tx, error) {
+	iceberg, noOp := c.connector.config.IsIcebergTransactions(), c.connector.config.IsNoOpTransactions()
+	if !iceberg && !noOp {
+		return nil, ErrAthenaTransactionUnsupported
+	}
+	if c.closed.Load() {
+		return nil, driver.ErrBadConn
+	}
+	tx := &pseudoTx{conn: c, noOp: !iceberg}
+	if !c.tx.CompareAndSwap(nil, tx) {
+		return nil, fmt.Errorf("%w: a transaction is already started", ErrAthenaTransactionUnsupported)
+	}
+	return tx, nil
+}
+
+// executedOne is to count a statement run in a no-op transaction.
+func (tx *pseudoTx) executedOne() {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	tx.executed++
+}
+
+// add is to queue a statement executed in an Iceberg transaction, whose rows affected are unknown until Commit.
+func (tx *pseudoTx) add(query string) (driver.Result, error) {
+	t := sqlTokenizer{query: query}
+	switch t.next() {
+	case "INSERT", "UPDATE", "DELETE", "MERGE":
+	default:
+		return nil, fmt.Errorf("%w: only INSERT, UPDATE, DELETE and MERGE statements can run in a transaction",
+			ErrAthenaTransactionUnsupported)
+	}
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	tx.statements = append(tx.statements, query)
+	return AthenaResult{lastInsertedID: -1}, nil
+}
+
+// Commit is to run the queued statements in order, stopping at the first which fails with a *TxCommitError. It is
+// a no-op in a no-op transaction.
+func (tx *pseudoTx) Commit() error {
+	c := tx.conn
+	if !c.tx.CompareAndSwap(tx, nil) {
+		return driver.ErrBadConn
+	}
+	tx.mu.Lock()
+	statements := tx.statements
+	tx.mu.Unlock()
+	obs := c.connector.tracer
+	if tx.noOp {
+		return nil
+	}
+	for i, query := range statements {
+		if _, err := c.ExecContext(context.Background(), query, nil); err != nil {
+			obs.Scope().Counter(DriverName + ".failure.tx.commit").Inc(1)
+			obs.Log(WarnLevel, "transaction partially committed", zap.Int("committed", i),
+				zap.Int("statements", len(statements)), zap.String("error", err.Error()))
+			return &TxCommitError{Committed: i, Statement: query, Err: err}
+		}
+	}
+	obs.Scope().Counter(DriverName + ".tx.commit").Inc(1)
+	return nil
+}
+
+// Rollback is to discard the queued statements, or, in a no-op transaction, to tell with a *TxRollbackWarning that
+// the executed statements are committed.
+func (tx *pseudoTx) Rollback() error {
+	if !tx.conn.tx.CompareAndSwap(tx, nil) {
+		return driver.ErrBadConn
+	}
+	tx.conn.connector.tracer.Scope().Counter(DriverName + ".tx.rollback").Inc(1)
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.executed > 0 {
+		return &TxRollbackWarning{Executed: tx.executed}
+	}
+	return nil
+}
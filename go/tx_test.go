@@ -0,0 +1,131 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnection_BeginIcebergTx(t *testing.T) {
+	c := createConnectionFixture()
+	_, err := c.Begin()
+	assert.Equal(t, ErrAthenaTransactionUnsupported, err)
+
+	c.connector.config.SetIcebergTransactions(true)
+	m := c.athenaClient.(*mockAthenaClient)
+	tx, err := c.Begin()
+	assert.Nil(t, err)
+	_, err = c.Begin()
+	assert.True(t, errors.Is(err, ErrAthenaTransactionUnsupported))
+
+	result, err := c.ExecContext(context.Background(), "INSERT INTO t SELECT * FROM ?",
+		[]driver.NamedValue{{Ordinal: 1, Value: RawParam("s")}})
+	assert.Nil(t, err)
+	n, _ := result.RowsAffected()
+	assert.Equal(t, int64(0), n)
+	_, err = c.ExecContext(context.Background(), "DROP TABLE t", nil)
+	assert.True(t, errors.Is(err, ErrAthenaTransactionUnsupported))
+	assert.Equal(t, 0, m.callCount("StartQueryExecution"))
+
+	assert.Nil(t, tx.Commit())
+	assert.Equal(t, 1, m.callCount("StartQueryExecution"))
+	assert.Equal(t, "INSERT INTO t SELECT * FROM s", *m.lastStartInput.QueryString)
+	assert.Equal(t, driver.ErrBadConn, tx.Commit())
+
+	tx, err = c.Begin()
+	assert.Nil(t, err)
+	_, err = c.ExecContext(context.Background(), "INSERT INTO t SELECT * FROM s", nil)
+	assert.Nil(t, err)
+	assert.Nil(t, tx.Rollback())
+	assert.Equal(t, 1, m.callCount("StartQueryExecution"))
+	assert.Equal(t, driver.ErrBadConn, tx.Rollback())
+}
+
+func TestConnection_IcebergTxCommitError(t *testing.T) {
+	c := createConnectionFixture()
+	c.connector.config.SetIcebergTransactions(true)
+	tx, err := c.Begin()
+	assert.Nil(t, err)
+	for _, query := range []string{"INSERT INTO t SELECT * FROM s", "DELETE FROM t WHERE broken",
+		"INSERT INTO t SELECT * FROM s"} {
+		_, err = c.ExecContext(context.Background(), query, nil)
+		assert.Nil(t, err)
+	}
+	err = tx.Commit()
+	var commitErr *TxCommitError
+	assert.True(t, errors.As(err, &commitErr))
+	assert.Equal(t, 1, commitErr.Committed)
+	assert.Equal(t, "DELETE FROM t WHERE broken", commitErr.Statement)
+	assert.Equal(t, 2, c.athenaClient.(*mockAthenaClient).callCount("StartQueryExecution"))
+}
+
+func TestConnection_BeginNoOpTx(t *testing.T) {
+	c := createConnectionFixture()
+	c.connector.config.SetNoOpTransactions(true)
+	m := c.athenaClient.(*mockAthenaClient)
+	tx, err := c.Begin()
+	assert.Nil(t, err)
+	assert.Nil(t, tx.Rollback())
+
+	tx, err = c.Begin()
+	assert.Nil(t, err)
+	_, err = c.ExecContext(context.Background(), "INSERT INTO t SELECT * FROM s", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, m.callCount("StartQueryExecution"))
+	err = tx.Rollback()
+	var warning *TxRollbackWarning
+	assert.True(t, errors.As(err, &warning))
+	assert.Equal(t, 1, warning.Executed)
+
+	tx, err = c.Begin()
+	assert.Nil(t, err)
+	_, err = c.ExecContext(context.Background(), "INSERT INTO t SELECT * FROM s", nil)
+	assert.Nil(t, err)
+	assert.Nil(t, tx.Commit())
+	assert.Equal(t, 2, m.callCount("StartQueryExecution"))
+}
+
+func TestConnection_BeginTxNoOp(t *testing.T) {
+	c := createConnectionFixture()
+	_, err := c.BeginTx(context.Background(), driver.TxOptions{})
+	assert.Equal(t, ErrAthenaTransactionUnsupported, err)
+
+	c.connector.config.SetNoOpTransactions(true)
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.BeginTx(canceled, driver.TxOptions{})
+	assert.Equal(t, context.Canceled, err)
+	_, err = c.BeginTx(context.Background(), driver.TxOptions{Isolation: driver.IsolationLevel(sql.LevelSerializable)})
+	assert.Equal(t, ErrAthenaTransactionUnsupported, err)
+
+	tx, err := c.BeginTx(context.Background(), driver.TxOptions{ReadOnly: true})
+	assert.Nil(t, err)
+	_, err = c.ExecContext(context.Background(), "INSERT INTO t SELECT * FROM s", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, c.athenaClient.(*mockAthenaClient).callCount("StartQueryExecution"))
+	assert.Nil(t, tx.Commit())
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"strings"
+
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+)
+
+// The statuses of partitions in the parsed output of MSCK REPAIR TABLE, see Config.SetParseUtilityOutput.
+const (
+	PartitionNotInMetastore        = "not_in_metastore"
+	PartitionMissingFromFilesystem = "missing_from_filesystem"
+	PartitionAdded                 = "added"
+	PartitionDropped               = "dropped"
+)
+
+// repairOutputPrefixes maps the lines of the MSCK REPAIR TABLE output to the status of the partitions listed in them.
+var repairOutputPrefixes = []struct {
+	prefix string
+	status string
+}{
+	{"Partitions not in metastore:", PartitionNotInMetastore},
+	{"Partitions missing from filesystem:", PartitionMissingFromFilesystem},
+	{"Repair: Added partition to metastore", PartitionAdded},
+	{"Repair: Dropped partition from metastore", PartitionDropped},
+}
+
+// parseRepairOutput is to split the text output of MSCK REPAIR TABLE into a table, partition and status per
+// partition, like:
+//
+//	Partitions not in metastore:	elb_logs:2015/01/01	elb_logs:2015/01/02
+//	Repair: Added partition to metastore elb_logs:2015/01/01
+//
+// It returns false if a line is not part of such an output.
+func parseRepairOutput(text string) ([][]string, bool) {
+	var rows [][]string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		status := ""
+		for _, p := range repairOutputPrefixes {
+			if strings.HasPrefix(line, p.prefix) {
+				status = p.status
+				line = line[len(p.prefix):]
+				break
+			}
+		}
+		if status == "" {
+			return nil, false
+		}
+		for _, item := range strings.Split(line, "\t") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			table, partition, ok := strings.Cut(item, ":")
+			if !ok {
+				table, partition = "", item
+			}
+			rows = append(rows, []string{table, partition, status})
+		}
+	}
+	return rows, true
+}
+
+// parseUtilityOutput is to replace the single column text output of a utility statement in the current page, e.g.
+// MSCK REPAIR TABLE, with a row per partition. The page is left as is if it isn't such an output.
+func (r *Rows) parseUtilityOutput() {
+	if r.statementType() == athenatypes.StatementTypeDml || r.ResultOutput == nil ||
+		r.ResultOutput.ResultSet == nil || r.ResultOutput.ResultSet.ResultSetMetadata == nil ||
+		len(r.ResultOutput.ResultSet.ResultSetMetadata.ColumnInfo) != 1 {
+		return
+	}
+	var lines []string
+	for _, row := range r.ResultOutput.ResultSet.Rows {
+		for _, d := range row.Data {
+			if d.VarCharValue != nil {
+				lines = append(lines, *d.VarCharValue)
+			}
+		}
+	}
+	parsed, ok := parseRepairOutput(strings.Join(lines, "\n"))
+	if !ok || len(lines) == 0 {
+		return
+	}
+	rows := make([]athenatypes.Row, len(parsed))
+	for i, p := range parsed {
+		rows[i] = newRow(3, p)
+	}
+	r.ResultOutput.ResultSet = &athenatypes.ResultSet{
+		ResultSetMetadata: &athenatypes.ResultSetMetadata{ColumnInfo: []athenatypes.ColumnInfo{
+			newColumnInfo("table", "varchar"),
+			newColumnInfo("partition", "varchar"),
+			newColumnInfo("status", "varchar"),
+		}},
+		Rows: rows,
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package athenadriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRepairOutput(t *testing.T) {
+	rows, ok := parseRepairOutput("Partitions not in metastore:\telb_logs:2015/01/01\telb_logs:2015/01/02\n" +
+		"Partitions missing from filesystem:\telb_logs:2014/12/31\n" +
+		"Repair: Added partition to metastore elb_logs:2015/01/01\n" +
+		"Repair: Dropped partition from metastore elb_logs:2014/12/31\n")
+	assert.True(t, ok)
+	assert.Equal(t, [][]string{
+		{"elb_logs", "2015/01/01", PartitionNotInMetastore},
+		{"elb_logs", "2015/01/02", PartitionNotInMetastore},
+		{"elb_logs", "2014/12/31", PartitionMissingFromFilesystem},
+		{"elb_logs", "2015/01/01", PartitionAdded},
+		{"elb_logs", "2014/12/31", PartitionDropped},
+	}, rows)
+
+	rows, ok = parseRepairOutput("Partitions not in metastore:\t")
+	assert.True(t, ok)
+	assert.Empty(t, rows)
+
+	_, ok = parseRepairOutput("Partitions not in metastore:\telb_logs:2015/01/01\nsomething else")
+	assert.False(t, ok)
+}
+
+func TestRows_ParseUtilityOutput(t *testing.T) {
+	client := newMockAthenaClient()
+	client.queryToResultsGenMap["msck"] = func(_ string) (*athena.GetQueryResultsOutput, error) {
+		return &athena.GetQueryResultsOutput{
+			ResultSet: &athenatypes.ResultSet{
+				ResultSetMetadata: &athenatypes.ResultSetMetadata{
+					ColumnInfo: []athenatypes.ColumnInfo{newColumnInfo("_col0", "string")},
+				},
+				Rows: []athenatypes.Row{
+					newRow(1, []string{"Partitions not in metastore:\telb_logs:2015/01/01\telb_logs:2015/01/02"}),
+					newRow(1, []string{"Repair: Added partition to metastore elb_logs:2015/01/01"}),
+				},
+			},
+		}, nil
+	}
+	testConf := NewNoOpsConfig()
+	testConf.SetParseUtilityOutput(true)
+	queryExecution := &athenatypes.QueryExecution{
+		QueryExecutionId: aws.String("msck"),
+		StatementType:    athenatypes.StatementTypeDdl,
+	}
+	r, err := newQueryExecutionRows(context.Background(), client, queryExecution, testConf,
+		NewDefaultObservability(testConf))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"table", "partition", "status"}, r.Columns())
+	var got [][]driver.Value
+	dest := make([]driver.Value, 3)
+	for r.Next(dest) == nil {
+		got = append(got, append([]driver.Value{}, dest...))
+	}
+	assert.Equal(t, [][]driver.Value{
+		{"elb_logs", "2015/01/01", PartitionNotInMetastore},
+		{"elb_logs", "2015/01/02", PartitionNotInMetastore},
+		{"elb_logs", "2015/01/01", PartitionAdded},
+	}, got)
+
+	// Other single column results, and results of DML, are left as they are.
+	testConf.SetParseUtilityOutput(false)
+	r, err = newQueryExecutionRows(context.Background(), client, queryExecution, testConf,
+		NewDefaultObservability(testConf))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"_col0"}, r.Columns())
+	testConf.SetParseUtilityOutput(true)
+	queryExecution.StatementType = athenatypes.StatementTypeDml
+	r, err = newQueryExecutionRows(context.Background(), client, queryExecution, testConf,
+		NewDefaultObservability(testConf))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"_col0"}, r.Columns())
+}
@@ -25,11 +25,14 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"encoding/csv"
+	"encoding/hex"
 	"fmt"
 	"math"
+	"math/big"
 	"math/rand"
 	"os"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -208,36 +211,55 @@ func PrettyPrintSQLRows(rows *sql.Rows, style string, render string, page int) {
 
 // PrettyPrintSQLColsRows is to print rows beautifully with header
 func PrettyPrintSQLColsRows(rows *sql.Rows, style string, render string, page int) {
-	t := table.NewWriter()
-	t.SetOutputMirror(os.Stdout)
 	if rows == nil {
 		return
 	}
+	t := newColsRowsTableWriter(rows)
+	t.SetOutputMirror(os.Stdout)
+	t.SetPageSize(page)
+	t.SetStyle(getTableStyle(style))
+	renderTable(render, t)
+}
+
+// ColsRowsToTable is to render columns and rows of sql.Rows as an aligned text table.
+func ColsRowsToTable(rows *sql.Rows) string {
+	if rows == nil {
+		return ""
+	}
+	t := newColsRowsTableWriter(rows)
+	t.SetStyle(table.StyleLight)
+	return t.Render()
+}
+
+// ColsRowsToMarkdown is to render columns and rows of sql.Rows as a Markdown table.
+func ColsRowsToMarkdown(rows *sql.Rows) string {
+	if rows == nil {
+		return ""
+	}
+	return newColsRowsTableWriter(rows).RenderMarkdown()
+}
+
+// newColsRowsTableWriter is to load columns and rows of sql.Rows into a table writer, with columns as header.
+func newColsRowsTableWriter(rows *sql.Rows) table.Writer {
+	t := table.NewWriter()
 	columns, _ := rows.Columns()
-	if columns != nil && len(columns) > 0 {
+	if len(columns) > 0 {
 		myrow := make(table.Row, len(columns))
 		for i, c := range columns {
 			myrow[i] = c
 		}
 		t.AppendHeader(myrow)
 	}
-	for rows.Next() {
-		rawResult := make([][]byte, len(columns))
-		row := make([]interface{}, len(columns))
-		for i := range rawResult {
-			row[i] = &rawResult[i] // pointers to each string in the interface slice
-		}
-		// We don't consider malformed rows
-		_ = rows.Scan(row...)
+	// We don't consider malformed rows
+	_ = scanEachRow(rows, len(columns), func(values []interface{}) error {
 		s := make(table.Row, len(columns))
-		for i, cell := range rawResult {
-			s[i] = string(cell)
+		for i, v := range values {
+			s[i] = formatValue(v)
 		}
 		t.AppendRow(s)
-	}
-	t.SetPageSize(page)
-	t.SetStyle(getTableStyle(style))
-	renderTable(render, t)
+		return nil
+	})
+	return t
 }
 
 // PrettyPrintCSV is to print rows in CSV format with default style
@@ -294,16 +316,6 @@ func colInFirstPage(query string) bool {
 		strings.Index(nQuery, "values") == 0
 }
 
-func isReadOnlyStatement(query string) bool {
-	nQuery := strings.TrimSpace(strings.ToLower(query))
-	return strings.Index(nQuery, "select") == 0 ||
-		strings.Index(nQuery, "using") == 0 ||
-		strings.Index(nQuery, "with") == 0 ||
-		strings.Index(nQuery, "desc") == 0 ||
-		strings.Index(nQuery, "show") == 0 ||
-		IsQID(query)
-}
-
 func isInsertStatement(query string) bool {
 	nQuery := strings.TrimSpace(strings.ToLower(query))
 	return strings.Index(nQuery, "insert") == 0
@@ -401,6 +413,34 @@ func randStr() *string {
 	return &s
 }
 
+func randBytes() []byte {
+	b := make([]byte, rand.Intn(10))
+	rand.Read(b)
+	return b
+}
+
+func randVarbinary() *string {
+	b := randBytes()
+	parts := make([]string, len(b))
+	for i := range b {
+		parts[i] = hex.EncodeToString(b[i : i+1])
+	}
+	s := strings.Join(parts, " ")
+	return &s
+}
+
+func randIPAddress() *string {
+	s := fmt.Sprintf("%d.%d.%d.%d", rand.Intn(256), rand.Intn(256), rand.Intn(256), rand.Intn(256))
+	return &s
+}
+
+func randUUID() *string {
+	var u UUID
+	rand.Read(u[:])
+	s := u.String()
+	return &s
+}
+
 func randBool() *string {
 	if rand.Intn(10)%2 == 0 {
 		s := "true"
@@ -463,10 +503,21 @@ func randRow(columns []athenatypes.ColumnInfo) athenatypes.Row {
 			row.Data[j] = athenatypes.Datum{VarCharValue: randFloat32()}
 		case "double":
 			row.Data[j] = athenatypes.Datum{VarCharValue: randFloat64()}
-		case "json", "char", "varchar", "varbinary", "row", "string", "binary",
-			"struct", "interval year to month", "interval day to second", "decimal",
-			"ipaddress", "array", "map", "unknown":
+		case "json", "char", "varchar", "row", "string", "binary",
+			"struct", "decimal", "array", "map", "unknown":
 			row.Data[j] = athenatypes.Datum{VarCharValue: randStr()}
+		case "interval year to month":
+			s := YearMonthInterval{Years: rand.Int31n(100), Months: rand.Int31n(12)}.String()
+			row.Data[j] = athenatypes.Datum{VarCharValue: &s}
+		case "interval day to second":
+			s := formatDayToSecondInterval(time.Duration(rand.Int63n(int64(100 * 24 * time.Hour))))
+			row.Data[j] = athenatypes.Datum{VarCharValue: &s}
+		case "varbinary":
+			row.Data[j] = athenatypes.Datum{VarCharValue: randVarbinary()}
+		case "ipaddress":
+			row.Data[j] = athenatypes.Datum{VarCharValue: randIPAddress()}
+		case "uuid":
+			row.Data[j] = athenatypes.Datum{VarCharValue: randUUID()}
 		case "boolean":
 			row.Data[j] = athenatypes.Datum{VarCharValue: randBool()}
 		case "date":
@@ -647,6 +698,42 @@ func escapeStringBackslash(buf []byte, v string) []byte {
 	return escapeBytesBackslash(buf, []byte(v))
 }
 
+// escapeBytesQuotes escapes []byte the way Athena (Presto/Trino) does: a single quote is escaped by
+// doubling it and every other byte, backslash included, is taken literally.
+// https://trino.io/docs/current/language/types.html#varchar
+func escapeBytesQuotes(buf, v []byte) []byte {
+	pos := len(buf)
+	buf = reserveBuffer(buf, len(v)*2)
+
+	for _, c := range v {
+		if c == '\'' {
+			buf[pos] = '\''
+			buf[pos+1] = '\''
+			pos += 2
+		} else {
+			buf[pos] = c
+			pos++
+		}
+	}
+
+	return buf[:pos]
+}
+
+// escapeStringQuotes is similar to escapeBytesQuotes but for string.
+func escapeStringQuotes(buf []byte, v string) []byte {
+	return escapeBytesQuotes(buf, []byte(v))
+}
+
+// appendHexBinary appends v as an Athena varbinary literal, e.g. X'0aff'.
+func appendHexBinary(buf, v []byte) []byte {
+	const hexDigits = "0123456789abcdef"
+	buf = append(buf, "X'"...)
+	for _, c := range v {
+		buf = append(buf, hexDigits[c>>4], hexDigits[c&0x0f])
+	}
+	return append(buf, '\'')
+}
+
 // reserveBuffer checks cap(buf) and expand buffer to len(buf) + appendSize.
 // If cap(buf) is not enough, reallocate new buffer.
 func reserveBuffer(buf []byte, appendSize int) []byte {
@@ -677,39 +764,24 @@ func valueToNamedValue(args []driver.Value) []driver.NamedValue {
 	return nameValues
 }
 
-func isQueryTimeOut(startOfStartQueryExecution time.Time, queryType athenatypes.StatementType, serviceLimitOverride *ServiceLimitOverride) bool {
-	ddlQueryTimeout := DDLQueryTimeout
-	dmlQueryTimeout := DMLQueryTimeout
-	if serviceLimitOverride != nil {
-		if serviceLimitOverride.GetDDLQueryTimeout() > 0 {
-			ddlQueryTimeout = serviceLimitOverride.GetDDLQueryTimeout()
-		}
-		if serviceLimitOverride.GetDMLQueryTimeout() > 0 {
-			dmlQueryTimeout = serviceLimitOverride.GetDMLQueryTimeout()
-		}
-	}
-	switch queryType {
-	case "DDL":
-		return time.Since(startOfStartQueryExecution) >
-			time.Duration(ddlQueryTimeout)*time.Second
-	case "DML":
-		return time.Since(startOfStartQueryExecution) >
-			time.Duration(dmlQueryTimeout)*time.Second
-	case "UTILITY":
-		return time.Since(startOfStartQueryExecution) >
-			time.Duration(dmlQueryTimeout)*time.Second
-	case "TIMEOUT_NOW":
+func isQueryTimeOut(startOfStartQueryExecution time.Time, queryType athenatypes.StatementType, policy TimeoutPolicy) bool {
+	if queryType == "TIMEOUT_NOW" {
 		return true
-	default:
-		return time.Since(startOfStartQueryExecution) >
-			time.Duration(ddlQueryTimeout)*time.Second
 	}
+	return time.Since(startOfStartQueryExecution) > policy.timeout(queryType)
 }
 
-// isQueryValid is to check the validity of Query, now only string length check.
+// validateQueryLength is to check the length of a query against the Athena service limit, returning ErrQueryTooLong
+// with the actual length if it is over the limit, or ErrInvalidQuery if it is too short to be a query.
 // https://docs.aws.amazon.com/athena/latest/ug/service-limits.html
-func isQueryValid(query string) bool {
-	return len(query) < MAXQueryStringLength && len(query) > 4
+func validateQueryLength(query string) error {
+	if len(query) > MAXQueryStringLength {
+		return fmt.Errorf("%w, got %d bytes", ErrQueryTooLong, len(query))
+	}
+	if len(query) <= 4 {
+		return ErrInvalidQuery
+	}
+	return nil
 }
 
 // GetFromEnvVal is to get environmental variable value by keys.
@@ -813,7 +885,8 @@ func IsQID(q string) bool {
 
 // FormatString formats a string type query argument for Athena by escaping special characters and surrounding the
 // string with single quotes. Using FormatString allows for selective formatting of the query argument, if
-// typecasting or function calls are part of the query argument.
+// typecasting or function calls are part of the query argument. The escaping is MySQL style, while Athena takes
+// backslashes literally, so QuoteLiteral is the one to use for strict parameter mode.
 //
 // Example usage:
 // query := "SELECT * FROM my_table WHERE description = ? AND created > ?"
@@ -827,10 +900,123 @@ func FormatString(v string) string {
 }
 
 // FormatBytes formats a byte slice query argument for Athena by escaping special characters and surrounding it with
-// single quotes.
+// single quotes. Athena does not accept the _binary prefix, QuoteBinary quotes a varbinary literal it does.
 func FormatBytes(v []byte) []byte {
 	buf := append([]byte{}, "_binary'"...)
 	buf = escapeBytesBackslash(buf, v)
 	buf = append(buf, '\'')
 	return buf
 }
+
+// FormatTimestamp formats a time as a TIMESTAMP literal in UTC, e.g. TIMESTAMP '2024-07-01 00:00:00.000'.
+func FormatTimestamp(t time.Time) RawParam {
+	return RawParam(t.UTC().Format("TIMESTAMP '2006-01-02 15:04:05.000'"))
+}
+
+// FormatTimestampTZ formats a time as a TIMESTAMP WITH TIME ZONE literal in its location, e.g.
+// TIMESTAMP '2024-07-01 00:00:00.000 America/New_York', or with its UTC offset if the location is time.Local.
+func FormatTimestampTZ(t time.Time) RawParam {
+	zone := t.Location().String()
+	if t.Location() == time.Local {
+		zone = t.Format("-07:00")
+	}
+	return RawParam("TIMESTAMP '" + t.Format("2006-01-02 15:04:05.000") + " " + zone + "'")
+}
+
+// FormatDate formats the date of a time in its location as a DATE literal, e.g. DATE '2024-07-01'.
+func FormatDate(t time.Time) RawParam {
+	return RawParam(t.Format("DATE '2006-01-02'"))
+}
+
+// FormatTime formats the time of day of a time in its location as a TIME literal, e.g. TIME '13:04:05.000'.
+func FormatTime(t time.Time) RawParam {
+	return RawParam(t.Format("TIME '15:04:05.000'"))
+}
+
+// FormatDecimal formats a number as a DECIMAL literal with scale digits after the decimal point, rounded, e.g.
+// DECIMAL '12.50' for 12.5 and scale 2.
+func FormatDecimal(v *big.Rat, scale int) RawParam {
+	if scale < 0 {
+		scale = 0
+	}
+	return RawParam("DECIMAL '" + v.FloatString(scale) + "'")
+}
+
+// FormatArray formats values as an ARRAY literal, e.g. ARRAY[1, 2]. Values are formatted the way BulkInsert
+// formats them, and nested arrays, maps and rows are passed as the RawParam the Format functions return.
+func FormatArray(values ...interface{}) (RawParam, error) {
+	buf, err := appendLiterals([]byte("ARRAY["), values)
+	if err != nil {
+		return "", err
+	}
+	return RawParam(append(buf, ']')), nil
+}
+
+// FormatMap formats a map as a MAP literal, e.g. MAP(ARRAY['a', 'b'], ARRAY[1, 2]), with the entries ordered by
+// their formatted keys, so the same map always gets the same literal. Keys and values are formatted like the values
+// of FormatArray.
+func FormatMap[K comparable, V any](m map[K]V) (RawParam, error) {
+	type entry struct {
+		key   string
+		value interface{}
+	}
+	entries := make([]entry, 0, len(m))
+	for k, v := range m {
+		key, err := appendInsertLiteral(nil, k)
+		if err != nil {
+			return "", err
+		}
+		entries = append(entries, entry{key: string(key), value: v})
+	}
+	if len(entries) == 0 {
+		return "MAP()", nil
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	keys := make([]interface{}, len(entries))
+	values := make([]interface{}, len(entries))
+	for i, e := range entries {
+		keys[i], values[i] = RawParam(e.key), e.value
+	}
+	buf, err := appendLiterals([]byte("MAP(ARRAY["), keys)
+	if err != nil {
+		return "", err
+	}
+	if buf, err = appendLiterals(append(buf, "], ARRAY["...), values); err != nil {
+		return "", err
+	}
+	return RawParam(append(buf, "])"...)), nil
+}
+
+// FormatRow formats values as a ROW literal, e.g. ROW(1, 'a'), whose fields are unnamed. Values are formatted like
+// the values of FormatArray.
+func FormatRow(values ...interface{}) (RawParam, error) {
+	buf, err := appendLiterals([]byte("ROW("), values)
+	if err != nil {
+		return "", err
+	}
+	return RawParam(append(buf, ')')), nil
+}
+
+// appendLiterals appends values as literals separated by commas.
+func appendLiterals(buf []byte, values []interface{}) ([]byte, error) {
+	for i, v := range values {
+		if i > 0 {
+			buf = append(buf, ", "...)
+		}
+		var err error
+		if buf, err = appendInsertLiteral(buf, v); err != nil {
+			return buf, err
+		}
+	}
+	return buf, nil
+}
+
+// decodeVarbinary decodes a varbinary value returned by Athena, which is hex encoded with
+// a space between every byte, like `68 65 6c 6c 6f`.
+func decodeVarbinary(val string) ([]byte, error) {
+	b, err := hex.DecodeString(strings.ReplaceAll(val, " ", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid varbinary value `%s`: %w", val, err)
+	}
+	return b, nil
+}
@@ -23,10 +23,13 @@ package athenadriver
 import (
 	"database/sql"
 	"database/sql/driver"
+	"errors"
 	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
 	"math"
+	"math/big"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -208,22 +211,22 @@ func TestValueToNamedValue(t *testing.T) {
 }
 
 func TestIsQueryTimeOut(t *testing.T) {
-	assert.False(t, isQueryTimeOut(time.Now(), athenatypes.StatementTypeDdl, nil))
-	assert.False(t, isQueryTimeOut(time.Now(), athenatypes.StatementTypeDml, nil))
-	assert.False(t, isQueryTimeOut(time.Now(), athenatypes.StatementTypeUtility, nil))
+	assert.False(t, isQueryTimeOut(time.Now(), athenatypes.StatementTypeDdl, TimeoutPolicy{}))
+	assert.False(t, isQueryTimeOut(time.Now(), athenatypes.StatementTypeDml, TimeoutPolicy{}))
+	assert.False(t, isQueryTimeOut(time.Now(), athenatypes.StatementTypeUtility, TimeoutPolicy{}))
 	now := time.Now()
 	OneHourAgo := now.Add(-3600 * time.Second)
-	assert.True(t, isQueryTimeOut(OneHourAgo, athenatypes.StatementTypeDml, nil))
-	assert.False(t, isQueryTimeOut(OneHourAgo, athenatypes.StatementTypeDdl, nil))
-	assert.False(t, isQueryTimeOut(OneHourAgo, "UNKNOWN", nil))
+	assert.True(t, isQueryTimeOut(OneHourAgo, athenatypes.StatementTypeDml, TimeoutPolicy{}))
+	assert.False(t, isQueryTimeOut(OneHourAgo, athenatypes.StatementTypeDdl, TimeoutPolicy{}))
+	assert.False(t, isQueryTimeOut(OneHourAgo, "UNKNOWN", TimeoutPolicy{}))
 
 	testConf := NewServiceLimitOverride()
 	testConf.SetDMLQueryTimeout(65 * 60) // 65 minutes
-	assert.False(t, isQueryTimeOut(OneHourAgo, athenatypes.StatementTypeDml, testConf))
+	assert.False(t, isQueryTimeOut(OneHourAgo, athenatypes.StatementTypeDml, testConf.timeoutPolicy()))
 
 	testConf.SetDDLQueryTimeout(30 * 60) // 30 minutes
-	assert.True(t, isQueryTimeOut(OneHourAgo, athenatypes.StatementTypeDdl, testConf))
-	assert.True(t, isQueryTimeOut(OneHourAgo, "UNKNOWN", testConf))
+	assert.True(t, isQueryTimeOut(OneHourAgo, athenatypes.StatementTypeDdl, testConf.timeoutPolicy()))
+	assert.True(t, isQueryTimeOut(OneHourAgo, "UNKNOWN", testConf.timeoutPolicy()))
 }
 
 func TestEscapeBytesBackslash(t *testing.T) {
@@ -526,3 +529,84 @@ func TestFormatBytes(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatTimestamp(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	assert.Nil(t, err)
+	ts := time.Date(2024, 7, 1, 13, 4, 5, 6e6, ny)
+	assert.Equal(t, RawParam("TIMESTAMP '2024-07-01 17:04:05.006'"), FormatTimestamp(ts))
+	assert.Equal(t, RawParam("TIMESTAMP '2024-07-01 13:04:05.006 America/New_York'"), FormatTimestampTZ(ts))
+	assert.Equal(t, RawParam("TIMESTAMP '2024-07-01 17:04:05.006 UTC'"), FormatTimestampTZ(ts.In(time.UTC)))
+	local := FormatTimestampTZ(ts.In(time.Local))
+	assert.Equal(t, RawParam("TIMESTAMP '"+ts.In(time.Local).Format("2006-01-02 15:04:05.000 -07:00")+"'"), local)
+	assert.Equal(t, RawParam("DATE '2024-07-01'"), FormatDate(ts))
+	assert.Equal(t, RawParam("TIME '13:04:05.006'"), FormatTime(ts))
+}
+
+func TestFormatDecimal(t *testing.T) {
+	assert.Equal(t, RawParam("DECIMAL '12.50'"), FormatDecimal(big.NewRat(25, 2), 2))
+	assert.Equal(t, RawParam("DECIMAL '-0.333'"), FormatDecimal(big.NewRat(-1, 3), 3))
+	assert.Equal(t, RawParam("DECIMAL '13'"), FormatDecimal(big.NewRat(25, 2), -1))
+}
+
+func TestFormatArrayMapRow(t *testing.T) {
+	array, err := FormatArray(1, "it's", nil, true, 1.5)
+	assert.Nil(t, err)
+	assert.Equal(t, RawParam("ARRAY[1, 'it''s', NULL, true, 1.5]"), array)
+	array, err = FormatArray()
+	assert.Nil(t, err)
+	assert.Equal(t, RawParam("ARRAY[]"), array)
+	_, err = FormatArray([]int{1})
+	assert.NotNil(t, err)
+
+	m, err := FormatMap(map[string]int{"b": 2, "a": 1})
+	assert.Nil(t, err)
+	assert.Equal(t, RawParam("MAP(ARRAY['a', 'b'], ARRAY[1, 2])"), m)
+	m, err = FormatMap(map[string]RawParam{"ids": array})
+	assert.Nil(t, err)
+	assert.Equal(t, RawParam("MAP(ARRAY['ids'], ARRAY[ARRAY[]])"), m)
+	m, err = FormatMap(map[int]string{})
+	assert.Nil(t, err)
+	assert.Equal(t, RawParam("MAP()"), m)
+	_, err = FormatMap(map[string]interface{}{"a": struct{}{}})
+	assert.NotNil(t, err)
+
+	day := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	row, err := FormatRow("x", FormatDate(day), day)
+	assert.Nil(t, err)
+	assert.Equal(t, RawParam("ROW('x', DATE '2024-07-01', TIMESTAMP '2024-07-01 00:00:00.000')"), row)
+}
+
+func TestColsRowsToTable(t *testing.T) {
+	sqlRows := sqlmock.NewRows([]string{"one", "two"})
+	sqlRows.AddRow("1", nil)
+	sqlRows.AddRow("a|b", int64(22))
+	assert.Equal(t, "┌─────┬─────┐\n"+
+		"│ ONE │ TWO │\n"+
+		"├─────┼─────┤\n"+
+		"│ 1   │     │\n"+
+		"│ a|b │ 22  │\n"+
+		"└─────┴─────┘", ColsRowsToTable(mockRowsToSQLRows(sqlRows)))
+	assert.Equal(t, "", ColsRowsToTable(nil))
+}
+
+func TestColsRowsToMarkdown(t *testing.T) {
+	sqlRows := sqlmock.NewRows([]string{"one", "two"})
+	sqlRows.AddRow("1", nil)
+	sqlRows.AddRow("a|b", int64(22))
+	assert.Equal(t, "| one | two |\n"+
+		"| --- | --- |\n"+
+		"| 1 |  |\n"+
+		"| a\\|b | 22 |", ColsRowsToMarkdown(mockRowsToSQLRows(sqlRows)))
+	assert.Equal(t, "", ColsRowsToMarkdown(nil))
+}
+
+func TestValidateQueryLength(t *testing.T) {
+	assert.Nil(t, validateQueryLength(strings.Repeat("a", MAXQueryStringLength)))
+	assert.Equal(t, ErrInvalidQuery, validateQueryLength("abcd"))
+
+	err := validateQueryLength(strings.Repeat("a", MAXQueryStringLength+1))
+	assert.True(t, errors.Is(err, ErrQueryTooLong))
+	assert.True(t, errors.Is(err, ErrInvalidQuery))
+	assert.Equal(t, "query is not valid: Athena allows at most 262144 bytes, got 262145 bytes", err.Error())
+}
@@ -22,17 +22,17 @@ package athenadriver
 
 import (
 	"context"
-	"time"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/athena"
 	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"time"
 
 	"github.com/shogo82148/memoize"
 )
 
 var (
-    // memoizer for GetWorkGroup results: key is workgroup name, value is *athenatypes.WorkGroup
-    getWGGroup memoize.Group[string, *athenatypes.WorkGroup]
+	// memoizer for GetWorkGroup results: key is workgroup name, value is *athenatypes.WorkGroup
+	getWGGroup memoize.Group[string, *athenatypes.WorkGroup]
 )
 
 // Workgroup is a wrapper of Athena Workgroup.
@@ -68,33 +68,42 @@ func NewWG(name string, config *athenatypes.WorkGroupConfiguration, tags *WGTags
 	}
 }
 
-// getWG retrieves an Athena WorkGroup from AWS remotely, caching the result for 10 minutes.
-// Subsequent calls with the same name within the TTL return the cached *WorkGroup.
-func getWG(ctx context.Context, client AthenaClient, name string) (*athenatypes.WorkGroup, error) {
-    if client == nil {
-        return nil, ErrAthenaNilClient
-    }
-
-    // Define the actual fetch logic: invoked on cache-miss or expired entry.
-    fetch := func(ctx context.Context, key string) (wg *athenatypes.WorkGroup, expiresAt time.Time, err error) {
-        out, err := client.GetWorkGroup(ctx, &athena.GetWorkGroupInput{
-            WorkGroup: aws.String(key),
-        })
-        if err != nil {
-            return nil, time.Time{}, err
-        }
-
-        wg = out.WorkGroup
-        // Cache this result for 10 minutes.
-        expiresAt = time.Now().Add(10 * time.Minute)
-        return wg, expiresAt, nil
-    }
-
-    wg, _, err := getWGGroup.Do(ctx, name, fetch)
-    if err != nil {
-        return nil, err
-    }
-    return wg, nil
+// getWG retrieves an Athena WorkGroup from AWS remotely, caching the result for ttl unless the workgroup is
+// disabled, so that it is used as soon as it is enabled again. Subsequent calls with the same name within the TTL
+// return the cached *WorkGroup.
+func getWG(ctx context.Context, client AthenaClient, name string, ttl time.Duration) (*athenatypes.WorkGroup, error) {
+	if client == nil {
+		return nil, ErrAthenaNilClient
+	}
+
+	// Define the actual fetch logic: invoked on cache-miss or expired entry.
+	fetch := func(ctx context.Context, key string) (wg *athenatypes.WorkGroup, expiresAt time.Time, err error) {
+		out, err := client.GetWorkGroup(ctx, &athena.GetWorkGroupInput{
+			WorkGroup: aws.String(key),
+		})
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+
+		wg = out.WorkGroup
+		expiresAt = time.Now()
+		if wg != nil && wg.State == athenatypes.WorkGroupStateEnabled {
+			expiresAt = expiresAt.Add(ttl)
+		}
+		return wg, expiresAt, nil
+	}
+
+	wg, _, err := getWGGroup.Do(ctx, name, fetch)
+	if err != nil {
+		return nil, err
+	}
+	return wg, nil
+}
+
+// InvalidateWorkgroupCache is to forget the cached state of a workgroup, e.g. after it is changed outside of the
+// driver, so that the next query gets it from Athena again.
+func InvalidateWorkgroupCache(name string) {
+	getWGGroup.Forget(name)
 }
 
 // CreateWGRemotely is to create a Workgroup remotely.
@@ -115,3 +124,107 @@ func (w *Workgroup) CreateWGRemotely(ctx context.Context, athenaClient AthenaCli
 	}
 	return err
 }
+
+// UpdateWGRemotely is to update a Workgroup remotely to its config: the bytes scanned cutoff per query, which is
+// removed if nil, the enforce, CloudWatch metrics and requester pays flags, and the result configuration if set.
+func (w *Workgroup) UpdateWGRemotely(ctx context.Context, athenaClient AthenaClient) error {
+	updates := &athenatypes.WorkGroupConfigurationUpdates{}
+	if w.Config != nil {
+		updates.BytesScannedCutoffPerQuery = w.Config.BytesScannedCutoffPerQuery
+		updates.RemoveBytesScannedCutoffPerQuery = aws.Bool(w.Config.BytesScannedCutoffPerQuery == nil)
+		updates.EnforceWorkGroupConfiguration = w.Config.EnforceWorkGroupConfiguration
+		updates.PublishCloudWatchMetricsEnabled = w.Config.PublishCloudWatchMetricsEnabled
+		updates.RequesterPaysEnabled = w.Config.RequesterPaysEnabled
+		if rc := w.Config.ResultConfiguration; rc != nil {
+			updates.ResultConfigurationUpdates = &athenatypes.ResultConfigurationUpdates{
+				OutputLocation:          rc.OutputLocation,
+				EncryptionConfiguration: rc.EncryptionConfiguration,
+				ExpectedBucketOwner:     rc.ExpectedBucketOwner,
+				AclConfiguration:        rc.AclConfiguration,
+			}
+		}
+	}
+	_, err := athenaClient.UpdateWorkGroup(ctx, &athena.UpdateWorkGroupInput{
+		WorkGroup:            aws.String(w.Name),
+		ConfigurationUpdates: updates,
+	})
+	InvalidateWorkgroupCache(w.Name)
+	return err
+}
+
+// DeleteWGRemotely is to delete a Workgroup remotely. With recursive, its named queries and prepared statements
+// are deleted too, otherwise Athena refuses to delete a workgroup which has any.
+func (w *Workgroup) DeleteWGRemotely(ctx context.Context, athenaClient AthenaClient, recursive bool) error {
+	_, err := athenaClient.DeleteWorkGroup(ctx, &athena.DeleteWorkGroupInput{
+		WorkGroup:             aws.String(w.Name),
+		RecursiveDeleteOption: aws.Bool(recursive),
+	})
+	InvalidateWorkgroupCache(w.Name)
+	return err
+}
+
+// ListWGs is to list the workgroups of the account in the region of the client.
+func ListWGs(ctx context.Context, athenaClient AthenaClient) ([]athenatypes.WorkGroupSummary, error) {
+	var workgroups []athenatypes.WorkGroupSummary
+	var token *string
+	for {
+		out, err := athenaClient.ListWorkGroups(ctx, &athena.ListWorkGroupsInput{NextToken: token})
+		if err != nil {
+			return nil, err
+		}
+		workgroups = append(workgroups, out.WorkGroups...)
+		if out.NextToken == nil || *out.NextToken == "" {
+			return workgroups, nil
+		}
+		token = out.NextToken
+	}
+}
+
+// WGARN is to get the ARN of a workgroup, which TagResource and UntagResource need, in the partition of the region.
+func WGARN(region string, accountID string, name string) string {
+	return "arn:" + AWSPartition(region) + ":athena:" + region + ":" + accountID + ":workgroup/" + name
+}
+
+// TagWGRemotely is to add the Tags of a Workgroup to it remotely, replacing the values of the existing keys.
+func (w *Workgroup) TagWGRemotely(ctx context.Context, athenaClient AthenaClient, arn string) error {
+	if w.Tags == nil || len(w.Tags.Get()) == 0 {
+		return nil
+	}
+	_, err := athenaClient.TagResource(ctx, &athena.TagResourceInput{
+		ResourceARN: aws.String(arn),
+		Tags:        w.Tags.Get(),
+	})
+	return err
+}
+
+// UntagWGRemotely is to remove tags of a Workgroup remotely by key.
+func (w *Workgroup) UntagWGRemotely(ctx context.Context, athenaClient AthenaClient, arn string, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := athenaClient.UntagResource(ctx, &athena.UntagResourceInput{
+		ResourceARN: aws.String(arn),
+		TagKeys:     keys,
+	})
+	return err
+}
+
+// GetWGTagsRemotely is to get the tags of a workgroup remotely.
+func GetWGTagsRemotely(ctx context.Context, athenaClient AthenaClient, arn string) (*WGTags, error) {
+	tags := NewWGTags()
+	var token *string
+	for {
+		out, err := athenaClient.ListTagsForResource(ctx, &athena.ListTagsForResourceInput{
+			ResourceARN: aws.String(arn),
+			NextToken:   token,
+		})
+		if err != nil {
+			return nil, err
+		}
+		tags.tags = append(tags.tags, out.Tags...)
+		if out.NextToken == nil || *out.NextToken == "" {
+			return tags, nil
+		}
+		token = out.NextToken
+	}
+}
@@ -23,7 +23,10 @@ package athenadriver
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -38,17 +41,17 @@ func TestNewWG(t *testing.T) {
 }
 
 func TestGetWG(t *testing.T) {
-	w, e := getWG(context.Background(), nil, "SELECT_OK")
+	w, e := getWG(context.Background(), nil, "SELECT_OK", time.Minute)
 	assert.Nil(t, w)
 	assert.NotNil(t, e)
 
 	athenaClient := newMockAthenaClient()
-	w, e = getWG(context.Background(), athenaClient, "SELECT_OK")
+	w, e = getWG(context.Background(), athenaClient, "SELECT_OK", time.Minute)
 	assert.Nil(t, w)
 	assert.NotNil(t, e)
 
 	athenaClient.GetWGStatus = true
-	w, e = getWG(context.Background(), athenaClient, "SELECT_OK")
+	w, e = getWG(context.Background(), athenaClient, "SELECT_OK", time.Minute)
 	assert.NotNil(t, w)
 	assert.Nil(t, e)
 }
@@ -77,3 +80,84 @@ func TestWorkgroup_CreateWGRemotely2(t *testing.T) {
 	e = wg.CreateWGRemotely(context.Background(), athenaClient)
 	assert.Nil(t, e)
 }
+
+func TestWorkgroup_UpdateWGRemotely(t *testing.T) {
+	athenaClient := newMockAthenaClient()
+	wg := NewWG("henry_wu", NewWGConfig(1024, false, true, false, &athenatypes.ResultConfiguration{
+		OutputLocation: aws.String("s3://results/"),
+	}), nil)
+	assert.Nil(t, wg.UpdateWGRemotely(context.Background(), athenaClient))
+	updates := athenaClient.lastWGUpdate.ConfigurationUpdates
+	assert.Equal(t, "henry_wu", *athenaClient.lastWGUpdate.WorkGroup)
+	assert.Equal(t, int64(1024), *updates.BytesScannedCutoffPerQuery)
+	assert.False(t, *updates.RemoveBytesScannedCutoffPerQuery)
+	assert.False(t, *updates.EnforceWorkGroupConfiguration)
+	assert.Equal(t, "s3://results/", *updates.ResultConfigurationUpdates.OutputLocation)
+
+	wg.Config.BytesScannedCutoffPerQuery = nil
+	assert.Nil(t, wg.UpdateWGRemotely(context.Background(), athenaClient))
+	assert.True(t, *athenaClient.lastWGUpdate.ConfigurationUpdates.RemoveBytesScannedCutoffPerQuery)
+}
+
+func TestWorkgroup_DeleteWGRemotely(t *testing.T) {
+	athenaClient := newMockAthenaClient()
+	assert.Nil(t, NewWG("henry_wu", nil, nil).DeleteWGRemotely(context.Background(), athenaClient, true))
+	assert.Equal(t, "henry_wu", *athenaClient.lastWGDeleted.WorkGroup)
+	assert.True(t, *athenaClient.lastWGDeleted.RecursiveDeleteOption)
+}
+
+func TestListWGs(t *testing.T) {
+	athenaClient := newMockAthenaClient()
+	athenaClient.workgroups = []athenatypes.WorkGroupSummary{
+		{Name: aws.String("primary")}, {Name: aws.String("henry_wu")},
+	}
+	workgroups, err := ListWGs(context.Background(), athenaClient)
+	assert.Nil(t, err)
+	assert.Equal(t, athenaClient.workgroups, workgroups)
+}
+
+func TestWorkgroup_TagWGRemotely(t *testing.T) {
+	athenaClient := newMockAthenaClient()
+	arn := WGARN("us-east-1", "123456789012", "henry_wu")
+	assert.Equal(t, "arn:aws:athena:us-east-1:123456789012:workgroup/henry_wu", arn)
+	assert.Equal(t, "arn:aws-cn:athena:cn-north-1:123456789012:workgroup/henry_wu",
+		WGARN("cn-north-1", "123456789012", "henry_wu"))
+	assert.Equal(t, "arn:aws-us-gov:athena:us-gov-west-1:123456789012:workgroup/henry_wu",
+		WGARN("us-gov-west-1", "123456789012", "henry_wu"))
+	_, err := GetWGTagsRemotely(context.Background(), athenaClient, arn)
+	assert.NotNil(t, err)
+
+	wgTags := NewWGTags()
+	wgTags.AddTag("Uber User", "henry.wu")
+	wgTags.AddTag("Uber Role", "SDE")
+	wg := NewWG("henry_wu", nil, wgTags)
+	assert.Nil(t, wg.TagWGRemotely(context.Background(), athenaClient, arn))
+	assert.Nil(t, wg.UntagWGRemotely(context.Background(), athenaClient, arn, "Uber Role"))
+	tags, err := GetWGTagsRemotely(context.Background(), athenaClient, arn)
+	assert.Nil(t, err)
+	if assert.Len(t, tags.Get(), 1) {
+		assert.Equal(t, "henry.wu", *tags.Get()[0].Value)
+	}
+}
+
+func TestGetWG_CacheTTL(t *testing.T) {
+	athenaClient := newMockAthenaClient()
+	athenaClient.GetWGStatus = true
+	athenaClient.WGDisabled = true
+	w, e := getWG(context.Background(), athenaClient, "TestGetWG_CacheTTL", time.Minute)
+	assert.Nil(t, e)
+	assert.Equal(t, athenatypes.WorkGroupStateDisabled, w.State)
+
+	// a disabled workgroup is not cached
+	athenaClient.WGDisabled = false
+	w, _ = getWG(context.Background(), athenaClient, "TestGetWG_CacheTTL", time.Minute)
+	assert.Equal(t, athenatypes.WorkGroupStateEnabled, w.State)
+
+	athenaClient.WGDisabled = true
+	w, _ = getWG(context.Background(), athenaClient, "TestGetWG_CacheTTL", time.Minute)
+	assert.Equal(t, athenatypes.WorkGroupStateEnabled, w.State)
+
+	InvalidateWorkgroupCache("TestGetWG_CacheTTL")
+	w, _ = getWG(context.Background(), athenaClient, "TestGetWG_CacheTTL", time.Minute)
+	assert.Equal(t, athenatypes.WorkGroupStateDisabled, w.State)
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package auditsink is drv.AuditSink implementations shipping the audit records of queries to S3 and Kinesis, for
+// compliance teams to keep and query them apart from the service which ran the queries.
+package auditsink
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	drv "github.com/prequel-co/athenadriver/go"
+	"github.com/prequel-co/athenadriver/lib/parquetexport"
+)
+
+// DefaultBatchSize is how many records an S3Sink writes per object when no batch size is given.
+const DefaultBatchSize = 100
+
+// ErrInvalidLocation is returned when the location records are written to is not like s3://bucket/prefix/.
+var ErrInvalidLocation = errors.New("audit location must be like s3://bucket/prefix/")
+
+// S3PutObjectAPI is the part of the S3 client used by S3Sink.
+type S3PutObjectAPI = parquetexport.S3PutObjectAPI
+
+// KinesisPutRecordAPI is the part of the Kinesis client used by KinesisSink.
+type KinesisPutRecordAPI interface {
+	PutRecord(ctx context.Context, params *kinesis.PutRecordInput,
+		optFns ...func(*kinesis.Options)) (*kinesis.PutRecordOutput, error)
+}
+
+// S3Sink is a drv.AuditSink writing the records in batches, as JSON lines objects under a location, in a
+// directory per day, e.g. s3://bucket/audit/2024/07/01/. The records of a batch which is not full are only written
+// by Flush.
+type S3Sink struct {
+	client    S3PutObjectAPI
+	bucket    string
+	prefix    string
+	batchSize int
+
+	mu    sync.Mutex
+	batch bytes.Buffer
+	n     int
+}
+
+var _ drv.AuditSink = (*S3Sink)(nil)
+
+// NewS3 is to create an S3Sink writing objects of batchSize records, or DefaultBatchSize if it is not positive,
+// under a location like s3://bucket/prefix/.
+func NewS3(client S3PutObjectAPI, location string, batchSize int) (*S3Sink, error) {
+	bucket, prefix, _ := strings.Cut(strings.TrimPrefix(location, "s3://"), "/")
+	if !strings.HasPrefix(location, "s3://") || bucket == "" {
+		return nil, ErrInvalidLocation
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	return &S3Sink{client: client, bucket: bucket, prefix: prefix, batchSize: batchSize}, nil
+}
+
+// Write is to add a record to the batch, and to write the batch once it is full.
+func (s *S3Sink) Write(ctx context.Context, record *drv.AuditRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batch.Write(append(line, '\n'))
+	s.n++
+	if s.n < s.batchSize {
+		return nil
+	}
+	return s.flush(ctx)
+}
+
+// Flush is to write the records of the batch, e.g. before the service exits.
+func (s *S3Sink) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.n == 0 {
+		return nil
+	}
+	return s.flush(ctx)
+}
+
+func (s *S3Sink) flush(ctx context.Context) error {
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	key := s.prefix + now.Format("2006/01/02/150405.000") + "-" + hex.EncodeToString(suffix) + ".jsonl"
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(s.batch.Bytes()),
+		ContentType: aws.String("application/x-ndjson"),
+	})
+	if err != nil {
+		// The batch is kept to be written by the next Write or Flush.
+		return err
+	}
+	s.batch.Reset()
+	s.n = 0
+	return nil
+}
+
+// KinesisSink is a drv.AuditSink putting each record into a Kinesis data stream as JSON, partitioned by QID.
+type KinesisSink struct {
+	client KinesisPutRecordAPI
+	stream string
+}
+
+var _ drv.AuditSink = (*KinesisSink)(nil)
+
+// NewKinesis is to create a KinesisSink putting the records into a stream, by name or ARN.
+func NewKinesis(client KinesisPutRecordAPI, stream string) *KinesisSink {
+	return &KinesisSink{client: client, stream: stream}
+}
+
+// Write is to put a record into the stream.
+func (s *KinesisSink) Write(ctx context.Context, record *drv.AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	input := &kinesis.PutRecordInput{
+		Data:         data,
+		PartitionKey: aws.String(record.QueryID),
+	}
+	if strings.HasPrefix(s.stream, "arn:") {
+		input.StreamARN = aws.String(s.stream)
+	} else {
+		input.StreamName = aws.String(s.stream)
+	}
+	_, err = s.client.PutRecord(ctx, input)
+	return err
+}
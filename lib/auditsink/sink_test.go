@@ -0,0 +1,114 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package auditsink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	drv "github.com/prequel-co/athenadriver/go"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockS3 struct {
+	inputs []*s3.PutObjectInput
+	bodies []string
+	err    error
+}
+
+func (m *mockS3) PutObject(_ context.Context, params *s3.PutObjectInput,
+	_ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	body, _ := io.ReadAll(params.Body)
+	m.inputs = append(m.inputs, params)
+	m.bodies = append(m.bodies, string(body))
+	return &s3.PutObjectOutput{}, nil
+}
+
+type mockKinesis struct {
+	inputs []*kinesis.PutRecordInput
+}
+
+func (m *mockKinesis) PutRecord(_ context.Context, params *kinesis.PutRecordInput,
+	_ ...func(*kinesis.Options)) (*kinesis.PutRecordOutput, error) {
+	m.inputs = append(m.inputs, params)
+	return &kinesis.PutRecordOutput{}, nil
+}
+
+func TestNewS3(t *testing.T) {
+	_, err := NewS3(&mockS3{}, "bucket/audit", 0)
+	assert.Equal(t, ErrInvalidLocation, err)
+	sink, err := NewS3(&mockS3{}, "s3://bucket/audit", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "bucket", sink.bucket)
+	assert.Equal(t, "audit/", sink.prefix)
+	assert.Equal(t, DefaultBatchSize, sink.batchSize)
+}
+
+func TestS3Sink(t *testing.T) {
+	client := &mockS3{}
+	sink, err := NewS3(client, "s3://bucket/audit/", 2)
+	assert.Nil(t, err)
+	ctx := context.Background()
+	assert.Nil(t, sink.Flush(ctx))
+	assert.Nil(t, sink.Write(ctx, &drv.AuditRecord{QueryID: "q1"}))
+	assert.Empty(t, client.inputs)
+	assert.Nil(t, sink.Write(ctx, &drv.AuditRecord{QueryID: "q2"}))
+	assert.Len(t, client.inputs, 1)
+	assert.Equal(t, "bucket", *client.inputs[0].Bucket)
+	assert.True(t, strings.HasPrefix(*client.inputs[0].Key, "audit/"))
+	assert.True(t, strings.HasSuffix(*client.inputs[0].Key, ".jsonl"))
+	lines := strings.Split(strings.TrimSpace(client.bodies[0]), "\n")
+	assert.Len(t, lines, 2)
+	var record drv.AuditRecord
+	assert.Nil(t, json.Unmarshal([]byte(lines[1]), &record))
+	assert.Equal(t, "q2", record.QueryID)
+
+	client.err = errors.New("unavailable")
+	assert.Nil(t, sink.Write(ctx, &drv.AuditRecord{QueryID: "q3"}))
+	assert.NotNil(t, sink.Flush(ctx))
+	client.err = nil
+	assert.Nil(t, sink.Flush(ctx))
+	assert.Len(t, client.inputs, 2)
+	assert.Contains(t, client.bodies[1], `"queryId":"q3"`)
+}
+
+func TestKinesisSink(t *testing.T) {
+	client := &mockKinesis{}
+	ctx := context.Background()
+	assert.Nil(t, NewKinesis(client, "audit").Write(ctx, &drv.AuditRecord{QueryID: "q1", Status: "SUCCEEDED"}))
+	assert.Nil(t, NewKinesis(client, "arn:aws:kinesis:us-east-1:123456789012:stream/audit").Write(ctx,
+		&drv.AuditRecord{QueryID: "q2"}))
+	assert.Len(t, client.inputs, 2)
+	assert.Equal(t, "audit", *client.inputs[0].StreamName)
+	assert.Equal(t, "q1", *client.inputs[0].PartitionKey)
+	assert.Contains(t, string(client.inputs[0].Data), `"status":"SUCCEEDED"`)
+	assert.Nil(t, client.inputs[1].StreamName)
+	assert.Equal(t, "arn:aws:kinesis:us-east-1:123456789012:stream/audit", *client.inputs[1].StreamARN)
+}
@@ -0,0 +1,266 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package gormathena is a GORM dialector for Athena built on athenadriver, so that models can be queried with
+// gorm.io/gorm. Athena has no transactions, so GORM's default transactions are skipped, and the migrator only
+// inspects tables, as they are usually managed outside of GORM, e.g. by Glue crawlers.
+package gormathena
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	drv "github.com/prequel-co/athenadriver/go"
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/migrator"
+	"gorm.io/gorm/schema"
+)
+
+// Config is the config of the dialector. The connection pool is Conn if set, or is opened with DSN, which is
+// DriverConfig.Stringify() if empty.
+type Config struct {
+	DSN          string
+	DriverConfig *drv.Config
+	Conn         gorm.ConnPool
+}
+
+// Dialector is the GORM dialector for Athena.
+type Dialector struct {
+	*Config
+}
+
+// Open is to create a dialector connecting to Athena with a DSN, like athenadriver.Config.Stringify() returns.
+func Open(dsn string) gorm.Dialector {
+	return &Dialector{Config: &Config{DSN: dsn}}
+}
+
+// New is to create a dialector with a config.
+func New(config Config) gorm.Dialector {
+	return &Dialector{Config: &config}
+}
+
+// Name is to implement interface gorm.Dialector.
+func (d Dialector) Name() string {
+	return "athena"
+}
+
+// Apply is to skip GORM's default transactions, which Athena doesn't support.
+func (d Dialector) Apply(config *gorm.Config) error {
+	config.SkipDefaultTransaction = true
+	return nil
+}
+
+// Initialize is to implement interface gorm.Dialector.
+func (d Dialector) Initialize(db *gorm.DB) error {
+	callbacks.RegisterDefaultCallbacks(db, &callbacks.Config{
+		CreateClauses: []string{"INSERT", "VALUES"},
+		QueryClauses:  []string{"SELECT", "FROM", "WHERE", "GROUP BY", "ORDER BY", "LIMIT"},
+		UpdateClauses: []string{"UPDATE", "SET", "WHERE"},
+		DeleteClauses: []string{"DELETE", "FROM", "WHERE"},
+	})
+	db.ClauseBuilders["LIMIT"] = buildLimit
+
+	if d.Conn != nil {
+		db.ConnPool = d.Conn
+		return nil
+	}
+	sqlDB, err := sql.Open(drv.DriverName, d.dsn())
+	if err != nil {
+		return err
+	}
+	db.ConnPool = sqlDB
+	return nil
+}
+
+func (d Dialector) dsn() string {
+	if d.DSN == "" && d.DriverConfig != nil {
+		return d.DriverConfig.Stringify()
+	}
+	return d.DSN
+}
+
+// database is to get the database of the connection, which is the schema tables are looked up in.
+func (d Dialector) database() string {
+	if d.DriverConfig != nil {
+		return d.DriverConfig.GetDB()
+	}
+	if conf, err := drv.NewConfig(d.DSN); err == nil {
+		return conf.GetDB()
+	}
+	return ""
+}
+
+// buildLimit is to build the LIMIT clause with OFFSET first, as Athena requires.
+func buildLimit(c clause.Clause, builder clause.Builder) {
+	limit, ok := c.Expression.(clause.Limit)
+	if !ok {
+		c.Build(builder)
+		return
+	}
+	if limit.Offset > 0 {
+		builder.WriteString("OFFSET ")
+		builder.AddVar(builder, limit.Offset)
+	}
+	if limit.Limit != nil && *limit.Limit >= 0 {
+		if limit.Offset > 0 {
+			builder.WriteByte(' ')
+		}
+		builder.WriteString("LIMIT ")
+		builder.AddVar(builder, *limit.Limit)
+	}
+}
+
+// Migrator is to implement interface gorm.Dialector.
+func (d Dialector) Migrator(db *gorm.DB) gorm.Migrator {
+	return Migrator{
+		Migrator: migrator.Migrator{Config: migrator.Config{
+			DB:        db,
+			Dialector: d,
+		}},
+		Dialector: d,
+	}
+}
+
+// DataTypeOf is to get the Athena DDL type of a field.
+func (d Dialector) DataTypeOf(field *schema.Field) string {
+	switch field.DataType {
+	case schema.Bool:
+		return "boolean"
+	case schema.Int, schema.Uint:
+		size := field.Size
+		if field.DataType == schema.Uint {
+			// one more bit for the sign
+			size++
+		}
+		switch {
+		case size <= 8:
+			return "tinyint"
+		case size <= 16:
+			return "smallint"
+		case size <= 32:
+			return "int"
+		}
+		return "bigint"
+	case schema.Float:
+		if field.Precision > 0 {
+			return fmt.Sprintf("decimal(%d,%d)", field.Precision, field.Scale)
+		}
+		if field.Size <= 32 {
+			return "float"
+		}
+		return "double"
+	case schema.String:
+		if field.Size > 0 {
+			return fmt.Sprintf("varchar(%d)", field.Size)
+		}
+		return "string"
+	case schema.Time:
+		return "timestamp"
+	case schema.Bytes:
+		return "binary"
+	}
+	return string(field.DataType)
+}
+
+// DefaultValueOf is to implement interface gorm.Dialector. Athena has no DEFAULT, so the value is NULL.
+func (d Dialector) DefaultValueOf(*schema.Field) clause.Expression {
+	return clause.Expr{SQL: "NULL"}
+}
+
+// BindVarTo is to write the placeholder of v, which GORM has just appended to stmt.Vars. As athenadriver passes
+// string arguments to Athena as they are, v is replaced by a literal of its value.
+func (d Dialector) BindVarTo(writer clause.Writer, stmt *gorm.Statement, v interface{}) {
+	writer.WriteByte('?')
+	if n := len(stmt.Vars); n > 0 {
+		stmt.Vars[n-1] = bindValue(v)
+	}
+}
+
+// bindValue is to convert v to a value athenadriver binds as a literal of the Athena type of v.
+func bindValue(v interface{}) interface{} {
+	switch v.(type) {
+	case drv.RawParam, time.Duration, drv.YearMonthInterval:
+		return v
+	}
+	converted, err := driver.DefaultParameterConverter.ConvertValue(v)
+	if err != nil {
+		// left to the driver to report
+		return v
+	}
+	switch c := converted.(type) {
+	case string:
+		return drv.RawParam(quoteString(c))
+	case []byte:
+		return drv.RawParam("X'" + hex.EncodeToString(c) + "'")
+	case time.Time:
+		if c.IsZero() {
+			return c
+		}
+		return drv.RawParam("TIMESTAMP '" + c.UTC().Format("2006-01-02 15:04:05.000") + "'")
+	}
+	return converted
+}
+
+// quoteString is to quote s as an Athena string literal.
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// QuoteTo is to quote an identifier, like table or table.column, with double quotes.
+func (d Dialector) QuoteTo(writer clause.Writer, str string) {
+	for i, part := range strings.Split(str, ".") {
+		if i > 0 {
+			writer.WriteByte('.')
+		}
+		writer.WriteByte('"')
+		writer.WriteString(strings.ReplaceAll(part, `"`, `""`))
+		writer.WriteByte('"')
+	}
+}
+
+// Explain is to interpolate vars into sql for logging.
+func (d Dialector) Explain(sql string, vars ...interface{}) string {
+	var b strings.Builder
+	i := 0
+	inString := false
+	for _, r := range sql {
+		switch {
+		case r == '\'':
+			inString = !inString
+		case r == '?' && !inString && i < len(vars):
+			if raw, ok := vars[i].(drv.RawParam); ok {
+				b.WriteString(string(raw))
+			} else {
+				b.WriteString(logger.ExplainSQL("?", nil, "'", vars[i]))
+			}
+			i++
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
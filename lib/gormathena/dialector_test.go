@@ -0,0 +1,115 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package gormathena
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	drv "github.com/prequel-co/athenadriver/go"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+type elbLog struct {
+	ID        int64
+	Name      string
+	Code      uint16
+	Size      float64 `gorm:"precision:10;scale:2"`
+	Body      []byte
+	CreatedAt time.Time
+	Valid     bool
+}
+
+func newTestDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	conn, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	conf := drv.NewNoOpsConfig()
+	conf.SetDB("sampledb")
+	db, err := gorm.Open(New(Config{Conn: conn, DriverConfig: conf}), &gorm.Config{})
+	assert.Nil(t, err)
+	return db, mock
+}
+
+func TestDialector_Query(t *testing.T) {
+	db, _ := newTestDB(t)
+	dry := db.Session(&gorm.Session{DryRun: true})
+
+	var logs []elbLog
+	stmt := dry.Where("name = ? AND body = ?", "O'Brien", []byte("hi")).Offset(20).Limit(10).Find(&logs).Statement
+	assert.Equal(t, `SELECT * FROM "elb_logs" WHERE name = ? AND body = ? OFFSET ? LIMIT ?`, stmt.SQL.String())
+	assert.Equal(t, []interface{}{drv.RawParam("'O''Brien'"), drv.RawParam("X'6869'"), int64(20), int64(10)},
+		stmt.Vars)
+	assert.Equal(t, `SELECT * FROM "elb_logs" WHERE name = 'O''Brien' AND body = X'6869' OFFSET 20 LIMIT 10`,
+		db.Dialector.Explain(stmt.SQL.String(), stmt.Vars...))
+
+	createdAt := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	stmt = dry.Where("created_at > ?", createdAt).Limit(1).Find(&logs).Statement
+	assert.Equal(t, `SELECT * FROM "elb_logs" WHERE created_at > ? LIMIT ?`, stmt.SQL.String())
+	assert.Equal(t, drv.RawParam("TIMESTAMP '2024-07-01 00:00:00.000'"), stmt.Vars[0])
+
+	stmt = dry.Table("sampledb.elb_logs").Find(&logs).Statement
+	assert.Equal(t, `SELECT * FROM "sampledb"."elb_logs"`, stmt.SQL.String())
+}
+
+func TestDialector_CreateWithoutTransaction(t *testing.T) {
+	db, mock := newTestDB(t)
+	mock.ExpectExec(`INSERT INTO "elb_logs"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.Nil(t, db.Create(&elbLog{ID: 1, Name: "a"}).Error)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestDialector_DataTypeOf(t *testing.T) {
+	db, _ := newTestDB(t)
+	assert.Nil(t, db.Statement.Parse(&elbLog{}))
+	types := map[string]string{}
+	for _, f := range db.Statement.Schema.Fields {
+		types[f.DBName] = db.Dialector.DataTypeOf(f)
+	}
+	assert.Equal(t, map[string]string{
+		"id":         "bigint",
+		"name":       "string",
+		"code":       "int",
+		"size":       "decimal(10,2)",
+		"body":       "binary",
+		"created_at": "timestamp",
+		"valid":      "boolean",
+	}, types)
+	assert.Equal(t, "tinyint", db.Dialector.DataTypeOf(&schema.Field{DataType: schema.Int, Size: 8}))
+}
+
+func TestMigrator(t *testing.T) {
+	db, mock := newTestDB(t)
+	m := db.Migrator()
+	assert.Equal(t, "sampledb", m.CurrentDatabase())
+	assert.Nil(t, m.AutoMigrate(&elbLog{}))
+	assert.Equal(t, ErrMigrationUnsupported, m.CreateTable(&elbLog{}))
+	assert.Equal(t, ErrMigrationUnsupported, m.DropColumn(&elbLog{}, "name"))
+	assert.False(t, m.HasIndex(&elbLog{}, "idx"))
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM information_schema.tables`).
+		WithArgs(drv.RawParam("'sampledb'"), drv.RawParam("'elb_logs'"), drv.RawParam("'BASE TABLE'")).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	assert.True(t, m.HasTable(&elbLog{}))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package gormathena
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/migrator"
+	"gorm.io/gorm/schema"
+)
+
+// ErrMigrationUnsupported is returned by the migrator for changes of the schema, which are left to the DDL of the
+// tables, e.g. CREATE EXTERNAL TABLE with the location and format of the data.
+var ErrMigrationUnsupported = errors.New("gormathena: schema changes are not supported, tables are managed by DDL")
+
+// Migrator is the GORM migrator for Athena. It looks tables and columns up in information_schema, AutoMigrate is a
+// no-op, and the other changes of the schema fail with ErrMigrationUnsupported.
+type Migrator struct {
+	migrator.Migrator
+	Dialector
+}
+
+// CurrentDatabase is to get the database of the connection.
+func (m Migrator) CurrentDatabase() string {
+	return m.Dialector.database()
+}
+
+// AutoMigrate is a no-op, so that applications calling it on start work with Athena.
+func (m Migrator) AutoMigrate(...interface{}) error {
+	return nil
+}
+
+// CreateTable is to implement interface gorm.Migrator.
+func (m Migrator) CreateTable(...interface{}) error {
+	return ErrMigrationUnsupported
+}
+
+// DropTable is to implement interface gorm.Migrator.
+func (m Migrator) DropTable(...interface{}) error {
+	return ErrMigrationUnsupported
+}
+
+// RenameTable is to implement interface gorm.Migrator.
+func (m Migrator) RenameTable(interface{}, interface{}) error {
+	return ErrMigrationUnsupported
+}
+
+// AddColumn is to implement interface gorm.Migrator.
+func (m Migrator) AddColumn(interface{}, string) error {
+	return ErrMigrationUnsupported
+}
+
+// DropColumn is to implement interface gorm.Migrator.
+func (m Migrator) DropColumn(interface{}, string) error {
+	return ErrMigrationUnsupported
+}
+
+// AlterColumn is to implement interface gorm.Migrator.
+func (m Migrator) AlterColumn(interface{}, string) error {
+	return ErrMigrationUnsupported
+}
+
+// MigrateColumn is to implement interface gorm.Migrator.
+func (m Migrator) MigrateColumn(interface{}, *schema.Field, gorm.ColumnType) error {
+	return ErrMigrationUnsupported
+}
+
+// RenameColumn is to implement interface gorm.Migrator.
+func (m Migrator) RenameColumn(interface{}, string, string) error {
+	return ErrMigrationUnsupported
+}
+
+// CreateView is to implement interface gorm.Migrator.
+func (m Migrator) CreateView(string, gorm.ViewOption) error {
+	return ErrMigrationUnsupported
+}
+
+// DropView is to implement interface gorm.Migrator.
+func (m Migrator) DropView(string) error {
+	return ErrMigrationUnsupported
+}
+
+// CreateConstraint is to implement interface gorm.Migrator. Athena has no constraints.
+func (m Migrator) CreateConstraint(interface{}, string) error {
+	return ErrMigrationUnsupported
+}
+
+// DropConstraint is to implement interface gorm.Migrator.
+func (m Migrator) DropConstraint(interface{}, string) error {
+	return ErrMigrationUnsupported
+}
+
+// HasConstraint is to implement interface gorm.Migrator. Athena has no constraints.
+func (m Migrator) HasConstraint(interface{}, string) bool {
+	return false
+}
+
+// CreateIndex is to implement interface gorm.Migrator. Athena has no indexes.
+func (m Migrator) CreateIndex(interface{}, string) error {
+	return ErrMigrationUnsupported
+}
+
+// DropIndex is to implement interface gorm.Migrator.
+func (m Migrator) DropIndex(interface{}, string) error {
+	return ErrMigrationUnsupported
+}
+
+// HasIndex is to implement interface gorm.Migrator. Athena has no indexes.
+func (m Migrator) HasIndex(interface{}, string) bool {
+	return false
+}
+
+// RenameIndex is to implement interface gorm.Migrator.
+func (m Migrator) RenameIndex(interface{}, string, string) error {
+	return ErrMigrationUnsupported
+}
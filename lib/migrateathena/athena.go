@@ -0,0 +1,293 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package migrateathena is a golang-migrate database driver for Athena built on athenadriver, so that the DDL of
+// external and Iceberg tables can be version-controlled and applied with golang-migrate. Athena tables cannot be
+// updated in place, so the schema version and the lock are kept as objects on S3.
+//
+// The URL is the athenadriver DSN with the athena scheme, and an optional x-state-location, which defaults to
+// schema_migrations/ under the output location:
+//
+//	athena://query-results-bucket/prefix?region=us-east-1&db=sampledb&x-state-location=s3%3A%2F%2Fbucket%2Fmigrations%2F
+package migrateathena
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/golang-migrate/migrate/v4/database"
+	drv "github.com/prequel-co/athenadriver/go"
+	"go.uber.org/atomic"
+)
+
+func init() {
+	database.Register("athena", &Athena{})
+}
+
+const (
+	versionKey = "version.json"
+	lockKey    = "lock"
+)
+
+// Various errors the driver might return.
+var (
+	ErrNilConfig            = errors.New("no config")
+	ErrInvalidStateLocation = errors.New("state location must be like s3://bucket/prefix/")
+)
+
+// S3API is the part of the S3 client used to keep the version and the lock.
+type S3API interface {
+	GetObject(context.Context, *s3.GetObjectInput, ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	PutObject(context.Context, *s3.PutObjectInput, ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	DeleteObject(context.Context, *s3.DeleteObjectInput, ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+}
+
+// Config is the config of the driver.
+type Config struct {
+	// StateLocation is the s3://bucket/prefix/ the version and the lock are kept under.
+	StateLocation string
+	// DatabaseName is the database Drop drops the tables and views of.
+	DatabaseName string
+}
+
+// Athena is the golang-migrate database driver for Athena.
+type Athena struct {
+	db       *sql.DB
+	s3       S3API
+	config   *Config
+	bucket   string
+	prefix   string
+	isLocked atomic.Bool
+}
+
+// state is the content of the version object.
+type state struct {
+	Version int  `json:"version"`
+	Dirty   bool `json:"dirty"`
+}
+
+// WithInstance is to create a driver with a database opened with athenadriver, and an S3 client.
+func WithInstance(db *sql.DB, client S3API, config *Config) (database.Driver, error) {
+	if config == nil {
+		return nil, ErrNilConfig
+	}
+	location := strings.TrimPrefix(config.StateLocation, "s3://")
+	bucket, prefix, _ := strings.Cut(location, "/")
+	if !strings.HasPrefix(config.StateLocation, "s3://") || bucket == "" {
+		return nil, ErrInvalidStateLocation
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return &Athena{
+		db:     db,
+		s3:     client,
+		config: config,
+		bucket: bucket,
+		prefix: prefix,
+	}, nil
+}
+
+// Open is to implement interface database.Driver.
+func (a *Athena) Open(rawURL string) (database.Driver, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	query := u.Query()
+	stateLocation := query.Get("x-state-location")
+	for k := range query {
+		if strings.HasPrefix(k, "x-") {
+			query.Del(k)
+		}
+	}
+	u.Scheme = "s3"
+	u.RawQuery = query.Encode()
+	conf, err := drv.NewConfig(u.String())
+	if err != nil {
+		return nil, err
+	}
+	if stateLocation == "" {
+		// The state must not move with the placeholders of an output location template.
+		prefix, _, _ := strings.Cut(conf.GetOutputBucket(), "{")
+		stateLocation = strings.TrimSuffix(prefix, "/") + "/schema_migrations/"
+	}
+
+	awsCfg := aws.Config{Region: conf.GetRegion()}
+	if conf.GetAccessID() != "" {
+		awsCfg.Credentials = credentials.NewStaticCredentialsProvider(conf.GetAccessID(),
+			conf.GetSecretAccessKey(), conf.GetSessionToken())
+	} else if awsCfg, err = awsconfig.LoadDefaultConfig(context.Background(),
+		awsconfig.WithRegion(conf.GetRegion())); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(drv.DriverName, conf.Stringify())
+	if err != nil {
+		return nil, err
+	}
+	d, err := WithInstance(db, s3.NewFromConfig(awsCfg), &Config{
+		StateLocation: stateLocation,
+		DatabaseName:  conf.GetDB(),
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+// Close is to implement interface database.Driver.
+func (a *Athena) Close() error {
+	return a.db.Close()
+}
+
+// Lock is to create the lock object, which fails if it exists already.
+func (a *Athena) Lock() error {
+	return database.CasRestoreOnErr(&a.isLocked, false, true, database.ErrLocked, func() error {
+		_, err := a.s3.PutObject(context.Background(), &s3.PutObjectInput{
+			Bucket:      aws.String(a.bucket),
+			Key:         aws.String(a.prefix + lockKey),
+			Body:        strings.NewReader(""),
+			IfNoneMatch: aws.String("*"),
+		})
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "PreconditionFailed" {
+			return database.ErrLocked
+		}
+		return err
+	})
+}
+
+// Unlock is to delete the lock object.
+func (a *Athena) Unlock() error {
+	return database.CasRestoreOnErr(&a.isLocked, true, false, database.ErrNotLocked, func() error {
+		_, err := a.s3.DeleteObject(context.Background(), &s3.DeleteObjectInput{
+			Bucket: aws.String(a.bucket),
+			Key:    aws.String(a.prefix + lockKey),
+		})
+		return err
+	})
+}
+
+// Run is to execute the statements of a migration one by one, as Athena runs a single statement per query.
+func (a *Athena) Run(migration io.Reader) error {
+	b, err := io.ReadAll(migration)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range splitStatements(string(b)) {
+		if _, err := a.db.Exec(stmt); err != nil {
+			return database.Error{OrigErr: err, Err: "migration failed", Query: []byte(stmt)}
+		}
+	}
+	return nil
+}
+
+// SetVersion is to write the version object.
+func (a *Athena) SetVersion(version int, dirty bool) error {
+	b, err := json.Marshal(state{Version: version, Dirty: dirty})
+	if err != nil {
+		return err
+	}
+	_, err = a.s3.PutObject(context.Background(), &s3.PutObjectInput{
+		Bucket: aws.String(a.bucket),
+		Key:    aws.String(a.prefix + versionKey),
+		Body:   bytes.NewReader(b),
+	})
+	return err
+}
+
+// Version is to read the version object, which doesn't exist before the first migration.
+func (a *Athena) Version() (int, bool, error) {
+	obj, err := a.s3.GetObject(context.Background(), &s3.GetObjectInput{
+		Bucket: aws.String(a.bucket),
+		Key:    aws.String(a.prefix + versionKey),
+	})
+	var noSuchKey *s3types.NoSuchKey
+	if errors.As(err, &noSuchKey) {
+		return database.NilVersion, false, nil
+	} else if err != nil {
+		return 0, false, err
+	}
+	defer obj.Body.Close()
+	var s state
+	if err := json.NewDecoder(obj.Body).Decode(&s); err != nil {
+		return 0, false, fmt.Errorf("invalid version object s3://%s/%s%s: %w", a.bucket, a.prefix, versionKey, err)
+	}
+	return s.Version, s.Dirty, nil
+}
+
+// Drop is to drop the views and tables of the database, and delete the version object. Dropping an external table
+// keeps its data, while dropping an Iceberg table deletes it.
+func (a *Athena) Drop() error {
+	for _, kind := range []string{"VIEW", "TABLE"} {
+		names, err := a.list("SHOW " + kind + "S IN `" + a.config.DatabaseName + "`")
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			var query string
+			if kind == "VIEW" {
+				query = fmt.Sprintf(`DROP VIEW IF EXISTS "%s"."%s"`, a.config.DatabaseName, name)
+			} else {
+				query = fmt.Sprintf("DROP TABLE IF EXISTS `%s`.`%s`", a.config.DatabaseName, name)
+			}
+			if _, err := a.db.Exec(query); err != nil {
+				return database.Error{OrigErr: err, Err: "drop failed", Query: []byte(query)}
+			}
+		}
+	}
+	_, err := a.s3.DeleteObject(context.Background(), &s3.DeleteObjectInput{
+		Bucket: aws.String(a.bucket),
+		Key:    aws.String(a.prefix + versionKey),
+	})
+	return err
+}
+
+// list is to get the names a SHOW statement returns.
+func (a *Athena) list(query string) ([]string, error) {
+	rows, err := a.db.Query(query)
+	if err != nil {
+		return nil, database.Error{OrigErr: err, Query: []byte(query)}
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
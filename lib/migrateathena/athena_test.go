@@ -0,0 +1,180 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package migrateathena
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockS3 struct {
+	mu      sync.Mutex
+	objects map[string]string
+}
+
+func newMockS3() *mockS3 {
+	return &mockS3{objects: map[string]string{}}
+}
+
+func (m *mockS3) GetObject(_ context.Context, input *s3.GetObjectInput,
+	_ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	obj, ok := m.objects[*input.Bucket+"/"+*input.Key]
+	if !ok {
+		return nil, &s3types.NoSuchKey{}
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader(obj))}, nil
+}
+
+func (m *mockS3) PutObject(_ context.Context, input *s3.PutObjectInput,
+	_ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := *input.Bucket + "/" + *input.Key
+	if _, ok := m.objects[key]; ok && input.IfNoneMatch != nil {
+		return nil, &smithy.GenericAPIError{Code: "PreconditionFailed"}
+	}
+	b, err := io.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	m.objects[key] = string(b)
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (m *mockS3) DeleteObject(_ context.Context, input *s3.DeleteObjectInput,
+	_ ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, *input.Bucket+"/"+*input.Key)
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func newTestDriver(t *testing.T, client S3API) (database.Driver, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	d, err := WithInstance(db, client, &Config{StateLocation: "s3://bucket/migrations", DatabaseName: "sampledb"})
+	assert.Nil(t, err)
+	return d, mock
+}
+
+func TestWithInstance(t *testing.T) {
+	_, err := WithInstance(nil, newMockS3(), nil)
+	assert.Equal(t, ErrNilConfig, err)
+	_, err = WithInstance(nil, newMockS3(), &Config{StateLocation: "bucket/migrations"})
+	assert.Equal(t, ErrInvalidStateLocation, err)
+}
+
+func TestOpen(t *testing.T) {
+	d, err := (&Athena{}).Open("athena://results/prefix?region=us-east-1&db=sampledb&accessID=id" +
+		"&secretAccessKey=secret&x-state-location=s3%3A%2F%2Fstate%2Fmigrations")
+	assert.Nil(t, err)
+	a := d.(*Athena)
+	assert.Equal(t, "state", a.bucket)
+	assert.Equal(t, "migrations/", a.prefix)
+	assert.Equal(t, "sampledb", a.config.DatabaseName)
+	assert.Nil(t, d.Close())
+
+	d, err = (&Athena{}).Open("athena://results/prefix?region=us-east-1&db=sampledb&accessID=id" +
+		"&secretAccessKey=secret")
+	assert.Nil(t, err)
+	assert.Equal(t, "results", d.(*Athena).bucket)
+	assert.Equal(t, "prefix/schema_migrations/", d.(*Athena).prefix)
+	assert.Nil(t, d.Close())
+
+	d, err = (&Athena{}).Open("athena://results/prefix/{yyyy}/{MM}?region=us-east-1&db=sampledb&accessID=id" +
+		"&secretAccessKey=secret")
+	assert.Nil(t, err)
+	assert.Equal(t, "prefix/schema_migrations/", d.(*Athena).prefix)
+	assert.Nil(t, d.Close())
+}
+
+func TestAthena_Lock(t *testing.T) {
+	client := newMockS3()
+	d1, _ := newTestDriver(t, client)
+	d2, _ := newTestDriver(t, client)
+
+	assert.Nil(t, d1.Lock())
+	assert.Equal(t, database.ErrLocked, d1.Lock())
+	assert.Equal(t, database.ErrLocked, d2.Lock())
+	assert.Equal(t, database.ErrNotLocked, d2.Unlock())
+	assert.Nil(t, d1.Unlock())
+	assert.Nil(t, d2.Lock())
+	assert.Nil(t, d2.Unlock())
+}
+
+func TestAthena_Version(t *testing.T) {
+	client := newMockS3()
+	d, _ := newTestDriver(t, client)
+
+	version, dirty, err := d.Version()
+	assert.Nil(t, err)
+	assert.Equal(t, database.NilVersion, version)
+	assert.False(t, dirty)
+
+	assert.Nil(t, d.SetVersion(3, true))
+	version, dirty, err = d.Version()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, version)
+	assert.True(t, dirty)
+	assert.Equal(t, `{"version":3,"dirty":true}`, client.objects["bucket/migrations/version.json"])
+}
+
+func TestAthena_Run(t *testing.T) {
+	d, mock := newTestDriver(t, newMockS3())
+	mock.ExpectExec("CREATE EXTERNAL TABLE t").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ALTER TABLE t").WillReturnError(errors.New("FAILED: SemanticException"))
+	err := d.Run(strings.NewReader("CREATE EXTERNAL TABLE t (a string) LOCATION 's3://bucket/t/';\n" +
+		"ALTER TABLE t ADD COLUMNS (b string);\nDROP TABLE t;"))
+	var dbErr database.Error
+	assert.True(t, errors.As(err, &dbErr))
+	assert.Equal(t, "ALTER TABLE t ADD COLUMNS (b string)", string(dbErr.Query))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestAthena_Drop(t *testing.T) {
+	client := newMockS3()
+	d, mock := newTestDriver(t, client)
+	assert.Nil(t, d.SetVersion(1, false))
+
+	mock.ExpectQuery("SHOW VIEWS IN `sampledb`").WillReturnRows(sqlmock.NewRows([]string{"views"}).AddRow("v"))
+	mock.ExpectExec(`DROP VIEW IF EXISTS "sampledb"."v"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SHOW TABLES IN `sampledb`").WillReturnRows(sqlmock.NewRows([]string{"tab_name"}).AddRow("t"))
+	mock.ExpectExec("DROP TABLE IF EXISTS `sampledb`.`t`").WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.Nil(t, d.Drop())
+	assert.Nil(t, mock.ExpectationsWereMet())
+
+	version, _, err := d.Version()
+	assert.Nil(t, err)
+	assert.Equal(t, database.NilVersion, version)
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package migrateathena
+
+import "strings"
+
+// splitStatements is to split a migration into its statements at the semicolons which are not in a string
+// literal, a quoted identifier or a comment. Comments are removed, as Athena fails DDL starting with one.
+func splitStatements(migration string) []string {
+	var stmts []string
+	var b strings.Builder
+	add := func() {
+		if stmt := strings.TrimSpace(b.String()); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+		b.Reset()
+	}
+	for i := 0; i < len(migration); i++ {
+		switch c := migration[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			// a doubled quote is an escaped one, so it is copied as an empty quoted part
+			end := strings.IndexByte(migration[i+1:], c)
+			if end < 0 {
+				end = len(migration) - i - 2
+			}
+			b.WriteString(migration[i : i+end+2])
+			i += end + 1
+		case c == '-' && strings.HasPrefix(migration[i:], "--"):
+			end := strings.IndexByte(migration[i:], '\n')
+			if end < 0 {
+				end = len(migration) - i
+			}
+			b.WriteByte(' ')
+			i += end - 1
+		case c == '/' && strings.HasPrefix(migration[i:], "/*"):
+			end := strings.Index(migration[i+2:], "*/")
+			if end < 0 {
+				end = len(migration) - i - 4
+			}
+			b.WriteByte(' ')
+			i += end + 3
+		case c == ';':
+			add()
+		default:
+			b.WriteByte(c)
+		}
+	}
+	add()
+	return stmts
+}
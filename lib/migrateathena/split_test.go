@@ -0,0 +1,41 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package migrateathena
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitStatements(t *testing.T) {
+	assert.Equal(t, []string{
+		"CREATE EXTERNAL TABLE `t` (a string)\nLOCATION 's3://bucket/t;'",
+		"ALTER TABLE t ADD PARTITION (dt = 'it''s')",
+		`CREATE VIEW "v;" AS SELECT 1`,
+	}, splitStatements("-- create t\nCREATE EXTERNAL TABLE `t` (a string)\nLOCATION 's3://bucket/t;';\n"+
+		"/* a; b */ ALTER TABLE t ADD PARTITION (dt = 'it''s');\n\n"+
+		`CREATE VIEW "v;" AS SELECT 1; -- done`+"\n"))
+
+	assert.Empty(t, splitStatements("-- nothing;\n/* to do */"))
+	assert.Equal(t, []string{"SELECT 'unterminated"}, splitStatements("SELECT 'unterminated"))
+	assert.Equal(t, []string{"SELECT 1"}, splitStatements("SELECT 1 /* unterminated"))
+}
@@ -0,0 +1,308 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package parquetexport writes the result of an athenadriver query to a Parquet file, locally or on S3,
+// with a schema built from the Athena column types.
+package parquetexport
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/parquet-go/parquet-go"
+)
+
+// ErrInvalidS3URI is returned when the destination of WriteS3 is not like s3://bucket/key.
+var ErrInvalidS3URI = errors.New("invalid S3 URI, it should be like s3://bucket/key")
+
+// S3PutObjectAPI is the part of the S3 client used by WriteS3.
+type S3PutObjectAPI interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+// columnWriter converts a value scanned from a column to a Parquet value.
+type columnWriter func(v interface{}) (parquet.Value, error)
+
+// column is how an Athena column is written to Parquet.
+type column struct {
+	node   parquet.Node
+	writer columnWriter
+	// stringLike is true if an empty string is a valid value of the column rather than NULL
+	stringLike bool
+}
+
+// Column is the name and Athena type of a column written by WriteValues, e.g. {"id", "bigint"}.
+type Column struct {
+	Name string
+	Type string
+}
+
+// Write writes rows to w as a Parquet file. Every column is optional, NULL values and empty strings in
+// non-string columns are written as null. Types without a Parquet counterpart, like decimal, ipaddress
+// or interval, are written as strings.
+func Write(rows *sql.Rows, w io.Writer) error {
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+	columns := make([]Column, len(columnTypes))
+	for i, ct := range columnTypes {
+		columns[i] = Column{Name: ct.Name(), Type: ct.DatabaseTypeName()}
+	}
+	values := make([]interface{}, len(columnTypes))
+	dest := make([]interface{}, len(columnTypes))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	return write(w, columns, func() ([]interface{}, error) {
+		if !rows.Next() {
+			return nil, rows.Err()
+		}
+		return values, rows.Scan(dest...)
+	})
+}
+
+// WriteValues writes rows of values in the order of columns to w as a Parquet file, the same way as Write
+// would for a result set with these columns.
+func WriteValues(w io.Writer, columns []Column, rows [][]interface{}) error {
+	i := 0
+	return write(w, columns, func() ([]interface{}, error) {
+		if i == len(rows) {
+			return nil, nil
+		}
+		i++
+		if len(rows[i-1]) != len(columns) {
+			return nil, fmt.Errorf("row %d has %d values for %d columns", i-1, len(rows[i-1]), len(columns))
+		}
+		return rows[i-1], nil
+	})
+}
+
+// write writes the rows returned by next until it returns nil.
+func write(w io.Writer, columns []Column, next func() ([]interface{}, error)) error {
+	group := parquet.Group{}
+	writers := make([]column, len(columns))
+	for i, c := range columns {
+		if _, ok := group[c.Name]; ok {
+			return fmt.Errorf("duplicate column name %s", c.Name)
+		}
+		writers[i] = columnOf(c.Type)
+		group[c.Name] = parquet.Optional(writers[i].node)
+	}
+	schema := parquet.NewSchema("athena", group)
+	pw := parquet.NewWriter(w, schema)
+
+	columnIndexes := make([]int, len(columns))
+	for i, c := range columns {
+		leaf, _ := schema.Lookup(c.Name)
+		columnIndexes[i] = leaf.ColumnIndex
+	}
+	row := make(parquet.Row, len(columns))
+	for {
+		values, err := next()
+		if err != nil {
+			return err
+		}
+		if values == nil {
+			break
+		}
+		for i, v := range values {
+			value, definitionLevel := parquet.NullValue(), 0
+			if v != nil && (writers[i].stringLike || !isEmpty(v)) {
+				if value, err = writers[i].writer(v); err != nil {
+					return fmt.Errorf("cannot write column %s: %w", columns[i].Name, err)
+				}
+				definitionLevel = 1
+			}
+			// the row must be ordered by column index, which follows the column names
+			row[columnIndexes[i]] = value.Level(0, definitionLevel, columnIndexes[i])
+		}
+		if _, err := pw.WriteRows([]parquet.Row{row}); err != nil {
+			return err
+		}
+	}
+	return pw.Close()
+}
+
+// WriteFile writes rows to a local Parquet file.
+func WriteFile(rows *sql.Rows, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := Write(rows, f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WriteS3 writes rows to a Parquet file at an S3 URI like s3://bucket/key. The file is staged in a
+// temporary local file first, so result sets larger than memory can be exported.
+func WriteS3(ctx context.Context, client S3PutObjectAPI, rows *sql.Rows, s3URI string) error {
+	u, err := url.Parse(s3URI)
+	if err != nil || u.Scheme != "s3" || u.Host == "" || strings.TrimPrefix(u.Path, "/") == "" {
+		return ErrInvalidS3URI
+	}
+	f, err := os.CreateTemp("", "athenadriver-*.parquet")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if err := Write(rows, f); err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	_, err = client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(u.Host),
+		Key:    aws.String(strings.TrimPrefix(u.Path, "/")),
+		Body:   f,
+	})
+	return err
+}
+
+// columnOf returns how a column of an Athena type is written to Parquet.
+func columnOf(athenaType string) column {
+	switch athenaType {
+	case "tinyint", "smallint", "integer":
+		bits := map[string]int{"tinyint": 8, "smallint": 16, "integer": 32}[athenaType]
+		return column{node: parquet.Int(bits), writer: func(v interface{}) (parquet.Value, error) {
+			i, err := toInt64(v)
+			return parquet.Int32Value(int32(i)), err
+		}}
+	case "bigint":
+		return column{node: parquet.Int(64), writer: func(v interface{}) (parquet.Value, error) {
+			i, err := toInt64(v)
+			return parquet.Int64Value(i), err
+		}}
+	case "float", "real":
+		return column{node: parquet.Leaf(parquet.FloatType), writer: func(v interface{}) (parquet.Value, error) {
+			f, err := toFloat64(v)
+			return parquet.FloatValue(float32(f)), err
+		}}
+	case "double":
+		return column{node: parquet.Leaf(parquet.DoubleType), writer: func(v interface{}) (parquet.Value, error) {
+			f, err := toFloat64(v)
+			return parquet.DoubleValue(f), err
+		}}
+	case "boolean":
+		return column{node: parquet.Leaf(parquet.BooleanType), writer: func(v interface{}) (parquet.Value, error) {
+			b, ok := v.(bool)
+			if !ok {
+				return parquet.NullValue(), fmt.Errorf("unexpected value %v (%T) for boolean", v, v)
+			}
+			return parquet.BooleanValue(b), nil
+		}}
+	case "date":
+		return column{node: parquet.Date(), writer: func(v interface{}) (parquet.Value, error) {
+			t, ok := v.(time.Time)
+			if !ok {
+				return parquet.NullValue(), fmt.Errorf("unexpected value %v (%T) for date", v, v)
+			}
+			days := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400
+			return parquet.Int32Value(int32(days)), nil
+		}}
+	case "timestamp", "timestamp with time zone":
+		return column{node: parquet.Timestamp(parquet.Millisecond), writer: func(v interface{}) (parquet.Value, error) {
+			t, ok := v.(time.Time)
+			if !ok {
+				return parquet.NullValue(), fmt.Errorf("unexpected value %v (%T) for timestamp", v, v)
+			}
+			return parquet.Int64Value(t.UnixMilli()), nil
+		}}
+	case "varbinary":
+		return column{node: parquet.Leaf(parquet.ByteArrayType), writer: func(v interface{}) (parquet.Value, error) {
+			if s, ok := v.(string); ok {
+				return parquet.ByteArrayValue([]byte(s)), nil
+			}
+			b, ok := v.([]byte)
+			if !ok {
+				return parquet.NullValue(), fmt.Errorf("unexpected value %v (%T) for varbinary", v, v)
+			}
+			return parquet.ByteArrayValue(b), nil
+		}, stringLike: true}
+	default:
+		return column{node: parquet.String(), writer: func(v interface{}) (parquet.Value, error) {
+			return parquet.ByteArrayValue([]byte(toString(v))), nil
+		}, stringLike: true}
+	}
+}
+
+// isEmpty returns true for an empty string, which non-string columns hold for NULL
+// when the driver is configured with missingAsEmptyString.
+func isEmpty(v interface{}) bool {
+	switch vv := v.(type) {
+	case string:
+		return vv == ""
+	case []byte:
+		return len(vv) == 0
+	}
+	return false
+}
+
+func toInt64(v interface{}) (int64, error) {
+	switch vv := v.(type) {
+	case int8:
+		return int64(vv), nil
+	case int16:
+		return int64(vv), nil
+	case int32:
+		return int64(vv), nil
+	case int64:
+		return vv, nil
+	case int:
+		return int64(vv), nil
+	}
+	return 0, fmt.Errorf("unexpected value %v (%T) for integer", v, v)
+}
+
+func toFloat64(v interface{}) (float64, error) {
+	switch vv := v.(type) {
+	case float32:
+		return float64(vv), nil
+	case float64:
+		return vv, nil
+	}
+	return 0, fmt.Errorf("unexpected value %v (%T) for floating point", v, v)
+}
+
+func toString(v interface{}) string {
+	switch vv := v.(type) {
+	case string:
+		return vv
+	case []byte:
+		return string(vv)
+	case time.Time:
+		return vv.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package parquetexport
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+)
+
+type exportedRow struct {
+	ID      *int64   `parquet:"id,optional"`
+	Small   *int32   `parquet:"small,optional"`
+	Score   *float64 `parquet:"score,optional"`
+	Active  *bool    `parquet:"active,optional"`
+	Name    *string  `parquet:"name,optional"`
+	Created *int64   `parquet:"created,optional"`
+	Day     *int32   `parquet:"day,optional"`
+}
+
+func newTestRows(t *testing.T) *sql.Rows {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	rows := sqlmock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("id").OfType("bigint", int64(0)),
+		sqlmock.NewColumn("small").OfType("smallint", int16(0)),
+		sqlmock.NewColumn("score").OfType("double", float64(0)),
+		sqlmock.NewColumn("active").OfType("boolean", false),
+		sqlmock.NewColumn("name").OfType("varchar", ""),
+		sqlmock.NewColumn("created").OfType("timestamp", time.Time{}),
+		sqlmock.NewColumn("day").OfType("date", time.Time{}),
+	)
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 6000000, time.UTC)
+	rows.AddRow(int64(1), int16(2), 1.5, true, "a", ts, ts)
+	rows.AddRow(nil, "", nil, nil, "", nil, nil)
+	mock.ExpectQuery("SELECT").WillReturnRows(rows)
+	r, err := db.Query("SELECT")
+	assert.Nil(t, err)
+	return r
+}
+
+func checkExported(t *testing.T, data []byte) {
+	result, err := parquet.Read[exportedRow](bytes.NewReader(data), int64(len(data)))
+	assert.Nil(t, err)
+	assert.Len(t, result, 2)
+
+	assert.Equal(t, int64(1), *result[0].ID)
+	assert.Equal(t, int32(2), *result[0].Small)
+	assert.Equal(t, 1.5, *result[0].Score)
+	assert.True(t, *result[0].Active)
+	assert.Equal(t, "a", *result[0].Name)
+	assert.Equal(t, time.Date(2020, 1, 2, 3, 4, 5, 6000000, time.UTC).UnixMilli(), *result[0].Created)
+	assert.Equal(t, int32(18263), *result[0].Day)
+
+	// NULL, and empty strings in non-string columns, are written as null
+	assert.Nil(t, result[1].ID)
+	assert.Nil(t, result[1].Small)
+	assert.Nil(t, result[1].Active)
+	assert.Equal(t, "", *result[1].Name)
+	assert.Nil(t, result[1].Created)
+}
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, Write(newTestRows(t), &buf))
+	checkExported(t, buf.Bytes())
+}
+
+func TestWriteValues(t *testing.T) {
+	columns := []Column{{"id", "bigint"}, {"small", "smallint"}, {"score", "double"}, {"active", "boolean"},
+		{"name", "varchar"}, {"created", "timestamp"}, {"day", "date"}}
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 6000000, time.UTC)
+	var buf bytes.Buffer
+	assert.Nil(t, WriteValues(&buf, columns, [][]interface{}{
+		{int64(1), int16(2), 1.5, true, "a", ts, ts},
+		{nil, "", nil, nil, "", nil, nil},
+	}))
+	checkExported(t, buf.Bytes())
+
+	assert.NotNil(t, WriteValues(io.Discard, columns, [][]interface{}{{int64(1)}}))
+}
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "result.parquet")
+	assert.Nil(t, WriteFile(newTestRows(t), path))
+	result, err := parquet.ReadFile[exportedRow](path)
+	assert.Nil(t, err)
+	assert.Len(t, result, 2)
+
+	assert.NotNil(t, WriteFile(newTestRows(t), filepath.Join(t.TempDir(), "missing", "result.parquet")))
+}
+
+func TestWrite_TypeMismatch(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	rows := sqlmock.NewRowsWithColumnDefinition(sqlmock.NewColumn("id").OfType("bigint", int64(0)))
+	rows.AddRow("x")
+	mock.ExpectQuery("SELECT").WillReturnRows(rows)
+	r, _ := db.Query("SELECT")
+	assert.NotNil(t, Write(r, io.Discard))
+}
+
+type mockS3 struct {
+	input *s3.PutObjectInput
+	body  []byte
+	err   error
+}
+
+func (m *mockS3) PutObject(ctx context.Context, params *s3.PutObjectInput,
+	optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	m.input = params
+	m.body, _ = io.ReadAll(params.Body)
+	return &s3.PutObjectOutput{}, m.err
+}
+
+func TestWriteS3(t *testing.T) {
+	client := &mockS3{}
+	assert.Nil(t, WriteS3(context.Background(), client, newTestRows(t), "s3://bucket/exports/result.parquet"))
+	assert.Equal(t, "bucket", *client.input.Bucket)
+	assert.Equal(t, "exports/result.parquet", *client.input.Key)
+	checkExported(t, client.body)
+
+	for _, uri := range []string{"bucket/key", "s3://bucket", "s3://bucket/", "s3:///key"} {
+		assert.Equal(t, ErrInvalidS3URI, WriteS3(context.Background(), client, newTestRows(t), uri), uri)
+	}
+
+	client.err = errors.New("access denied")
+	assert.Equal(t, client.err, WriteS3(context.Background(), client, newTestRows(t), "s3://bucket/key"))
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package rediscache is a drv.QueryCache backed by Redis, so several service instances share the QIDs of the queries
+// one of them already answered, and skip executing them again.
+package rediscache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	drv "github.com/prequel-co/athenadriver/go"
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultPrefix is the prefix of the keys of the QIDs when none is given.
+const DefaultPrefix = "athenadriver:qid:"
+
+// Cache is a drv.QueryCache keeping the QIDs of query fingerprints in Redis, each under the prefix and the
+// fingerprint, and expiring with the TTL they are put with.
+type Cache struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+var _ drv.QueryCache = (*Cache)(nil)
+
+// New is to create a Cache keeping QIDs with client under keys starting with prefix, or DefaultPrefix if it is empty.
+func New(client redis.UniversalClient, prefix string) *Cache {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	return &Cache{client: client, prefix: prefix}
+}
+
+// Get is to get the QID cached for a fingerprint, or "" if there is none.
+func (c *Cache) Get(ctx context.Context, fingerprint string) (string, error) {
+	qid, err := c.client.Get(ctx, c.prefix+fingerprint).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return qid, err
+}
+
+// Put is to cache the QID of a fingerprint for ttl.
+func (c *Cache) Put(ctx context.Context, fingerprint string, queryID string, ttl time.Duration) error {
+	return c.client.Set(ctx, c.prefix+fingerprint, queryID, ttl).Err()
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package rediscache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+
+	cache := New(client, "")
+	qid, err := cache.Get(ctx, "fp1")
+	assert.Nil(t, err)
+	assert.Equal(t, "", qid)
+
+	assert.Nil(t, cache.Put(ctx, "fp1", "qid1", time.Minute))
+	qid, err = cache.Get(ctx, "fp1")
+	assert.Nil(t, err)
+	assert.Equal(t, "qid1", qid)
+	assert.Equal(t, time.Minute, server.TTL(DefaultPrefix+"fp1"))
+
+	// instances sharing the prefix share the QIDs
+	qid, err = New(client, DefaultPrefix).Get(ctx, "fp1")
+	assert.Nil(t, err)
+	assert.Equal(t, "qid1", qid)
+	qid, err = New(client, "other:").Get(ctx, "fp1")
+	assert.Nil(t, err)
+	assert.Equal(t, "", qid)
+
+	server.FastForward(2 * time.Minute)
+	qid, err = cache.Get(ctx, "fp1")
+	assert.Nil(t, err)
+	assert.Equal(t, "", qid)
+
+	server.Close()
+	_, err = cache.Get(ctx, "fp1")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,328 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package s3loader writes in-memory data to S3 as CSV or Parquet under the location of an external table,
+// creates or updates the table definition, and registers the partition the data is written to, so data can be
+// queried with Athena in one call.
+package s3loader
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	drv "github.com/prequel-co/athenadriver/go"
+	"github.com/prequel-co/athenadriver/lib/parquetexport"
+)
+
+// Format is the file format data is written in.
+type Format string
+
+const (
+	// CSV files are read with OpenCSVSerde, which expects timestamps as milliseconds and dates as days
+	// since the epoch, and reads NULL in string columns as empty strings.
+	CSV Format = "csv"
+	// Parquet files are written like parquetexport.WriteValues writes them.
+	Parquet Format = "parquet"
+)
+
+var (
+	// ErrInvalidLocation is returned when the location of a table is not like s3://bucket/prefix/.
+	ErrInvalidLocation = errors.New("table location must be like s3://bucket/prefix/")
+	// ErrInvalidTable is returned when a table has no name, database or columns, or an unknown format.
+	ErrInvalidTable = errors.New("table must have a database, a name, columns and a format")
+	// ErrMissingPartition is returned when a value of a partition column is not given.
+	ErrMissingPartition = errors.New("missing partition value")
+)
+
+// S3PutObjectAPI is the part of the S3 client used by Loader.
+type S3PutObjectAPI = parquetexport.S3PutObjectAPI
+
+// Column is the name and Athena DDL type of a column, e.g. {"id", "bigint"}.
+type Column = parquetexport.Column
+
+// Table is an external table whose data is kept under Location.
+type Table struct {
+	Database string
+	Name     string
+	// Location is the S3 prefix managed by the loader, like s3://bucket/tables/events/.
+	Location         string
+	Columns          []Column
+	PartitionColumns []Column
+	Format           Format
+}
+
+// Options are the options of Load.
+type Options struct {
+	// Partition has the value of every partition column of the table.
+	Partition map[string]string
+	// Repair is to register partitions with MSCK REPAIR TABLE rather than ALTER TABLE ADD PARTITION.
+	Repair bool
+}
+
+// Loader loads data into external tables.
+type Loader struct {
+	db     *sql.DB
+	client S3PutObjectAPI
+}
+
+// New is to create a Loader with a database opened with athenadriver, and an S3 client.
+func New(db *sql.DB, client S3PutObjectAPI) *Loader {
+	return &Loader{db: db, client: client}
+}
+
+// Load writes rows of values, in the order of table.Columns, to a new object under the table location, or
+// the partition location in it, and returns the S3 URI of the object. Beforehand, the table is created if
+// it doesn't exist, and the columns it doesn't have are added. Existing columns not in table.Columns are
+// written as NULL. Afterwards, the partition is registered if the table is partitioned.
+func (l *Loader) Load(ctx context.Context, table Table, rows [][]interface{}, opts *Options) (string, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	bucket, prefix, err := parseLocation(table.Location)
+	if err != nil {
+		return "", err
+	}
+	if table.Database == "" || table.Name == "" || len(table.Columns) == 0 ||
+		(table.Format != CSV && table.Format != Parquet) {
+		return "", ErrInvalidTable
+	}
+	partitionValues := make([]drv.PartitionValue, len(table.PartitionColumns))
+	for i, c := range table.PartitionColumns {
+		v, ok := opts.Partition[c.Name]
+		if !ok {
+			return "", fmt.Errorf("%w of column %s", ErrMissingPartition, c.Name)
+		}
+		partitionValues[i] = drv.PartitionValue{Column: c.Name, Value: v}
+	}
+	partitionPath := drv.PartitionPath(partitionValues)
+
+	columns, err := l.ensureTable(ctx, table)
+	if err != nil {
+		return "", err
+	}
+	body, err := encode(table, columns, rows)
+	if err != nil {
+		return "", err
+	}
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	key := fmt.Sprintf("%s%spart-%s-%s.%s", prefix, partitionPath, time.Now().UTC().Format("20060102T150405Z"),
+		hex.EncodeToString(suffix), table.Format)
+	if _, err := l.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(body),
+	}); err != nil {
+		return "", err
+	}
+	uri := "s3://" + bucket + "/" + key
+
+	if len(table.PartitionColumns) == 0 {
+		return uri, nil
+	}
+	if opts.Repair {
+		_, err = l.db.ExecContext(ctx, "MSCK REPAIR TABLE "+tableName(table))
+		return uri, err
+	}
+	return uri, drv.AddPartitions(ctx, l.db, tableName(table), []drv.Partition{{
+		Values:   partitionValues,
+		Location: "s3://" + bucket + "/" + prefix + partitionPath,
+	}})
+}
+
+// ensureTable creates the table if it doesn't exist, adds the columns it misses, and returns the data
+// columns of the table in their order.
+func (l *Loader) ensureTable(ctx context.Context, table Table) ([]string, error) {
+	if _, err := l.db.ExecContext(ctx, createTableStatement(table)); err != nil {
+		return nil, err
+	}
+	rows, err := l.db.QueryContext(ctx, "SHOW COLUMNS IN "+tableName(table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	partitionColumns := map[string]bool{}
+	for _, c := range table.PartitionColumns {
+		partitionColumns[strings.ToLower(c.Name)] = true
+	}
+	var columns []string
+	existing := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		name = strings.TrimSpace(name)
+		if !partitionColumns[strings.ToLower(name)] {
+			columns = append(columns, name)
+			existing[strings.ToLower(name)] = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var added []string
+	for _, c := range table.Columns {
+		if !existing[strings.ToLower(c.Name)] {
+			added = append(added, quoteIdentifier(c.Name)+" "+c.Type)
+			columns = append(columns, c.Name)
+		}
+	}
+	if len(added) > 0 {
+		query := fmt.Sprintf("ALTER TABLE %s ADD COLUMNS (%s)", tableName(table), strings.Join(added, ", "))
+		if _, err := l.db.ExecContext(ctx, query); err != nil {
+			return nil, err
+		}
+	}
+	return columns, nil
+}
+
+// createTableStatement returns the CREATE EXTERNAL TABLE IF NOT EXISTS statement of a table.
+func createTableStatement(table Table) string {
+	var sb strings.Builder
+	sb.WriteString("CREATE EXTERNAL TABLE IF NOT EXISTS " + tableName(table) + " (")
+	sb.WriteString(columnList(table.Columns))
+	sb.WriteString(")")
+	if len(table.PartitionColumns) > 0 {
+		sb.WriteString(" PARTITIONED BY (" + columnList(table.PartitionColumns) + ")")
+	}
+	if table.Format == CSV {
+		sb.WriteString(" ROW FORMAT SERDE 'org.apache.hadoop.hive.serde2.OpenCSVSerde'")
+	} else {
+		sb.WriteString(" STORED AS PARQUET")
+	}
+	sb.WriteString(" LOCATION '" + strings.TrimSuffix(table.Location, "/") + "/'")
+	if table.Format == CSV {
+		sb.WriteString(" TBLPROPERTIES ('skip.header.line.count'='1')")
+	}
+	return sb.String()
+}
+
+// encode writes rows in the order of the table columns.
+func encode(table Table, columns []string, rows [][]interface{}) ([]byte, error) {
+	positions := map[string]int{}
+	types := map[string]string{}
+	for i, c := range table.Columns {
+		positions[strings.ToLower(c.Name)] = i
+		types[strings.ToLower(c.Name)] = c.Type
+	}
+	ordered := make([]Column, len(columns))
+	for i, name := range columns {
+		ordered[i] = Column{Name: name, Type: types[strings.ToLower(name)]}
+		if ordered[i].Type == "" {
+			ordered[i].Type = "string"
+		}
+	}
+	values := make([][]interface{}, len(rows))
+	for i, row := range rows {
+		if len(row) != len(table.Columns) {
+			return nil, fmt.Errorf("row %d has %d values for %d columns", i, len(row), len(table.Columns))
+		}
+		values[i] = make([]interface{}, len(columns))
+		for j, name := range columns {
+			if pos, ok := positions[strings.ToLower(name)]; ok {
+				values[i][j] = row[pos]
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if table.Format == Parquet {
+		err := parquetexport.WriteValues(&buf, ordered, values)
+		return buf.Bytes(), err
+	}
+	w := csv.NewWriter(&buf)
+	if err := w.Write(columns); err != nil {
+		return nil, err
+	}
+	record := make([]string, len(columns))
+	for _, row := range values {
+		for j, v := range row {
+			record[j] = csvValue(v, ordered[j].Type)
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// csvValue renders a value the way OpenCSVSerde reads it for a column type.
+func csvValue(v interface{}, columnType string) string {
+	switch vv := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return vv
+	case []byte:
+		return string(vv)
+	case time.Time:
+		if strings.EqualFold(columnType, "date") {
+			days := time.Date(vv.Year(), vv.Month(), vv.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400
+			return strconv.FormatInt(days, 10)
+		}
+		return strconv.FormatInt(vv.UnixMilli(), 10)
+	}
+	return fmt.Sprint(v)
+}
+
+// parseLocation splits a location like s3://bucket/prefix into the bucket and the prefix with a trailing slash.
+func parseLocation(location string) (string, string, error) {
+	bucket, prefix, _ := strings.Cut(strings.TrimPrefix(location, "s3://"), "/")
+	if !strings.HasPrefix(location, "s3://") || bucket == "" {
+		return "", "", ErrInvalidLocation
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return bucket, prefix, nil
+}
+
+func tableName(table Table) string {
+	return quoteIdentifier(table.Database) + "." + quoteIdentifier(table.Name)
+}
+
+func columnList(columns []Column) string {
+	list := make([]string, len(columns))
+	for i, c := range columns {
+		list[i] = quoteIdentifier(c.Name) + " " + c.Type
+	}
+	return strings.Join(list, ", ")
+}
+
+// quoteIdentifier quotes a DDL identifier with backticks.
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
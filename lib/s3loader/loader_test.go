@@ -0,0 +1,179 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package s3loader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockS3 struct {
+	input *s3.PutObjectInput
+	body  []byte
+	err   error
+}
+
+func (m *mockS3) PutObject(_ context.Context, params *s3.PutObjectInput,
+	_ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	m.input = params
+	m.body, _ = io.ReadAll(params.Body)
+	return &s3.PutObjectOutput{}, m.err
+}
+
+var events = Table{
+	Database: "mydb",
+	Name:     "events",
+	Location: "s3://bucket/tables/events",
+	Columns: []Column{{Name: "id", Type: "bigint"}, {Name: "name", Type: "string"},
+		{Name: "at", Type: "timestamp"}},
+	PartitionColumns: []Column{{Name: "dt", Type: "string"}},
+	Format:           CSV,
+}
+
+func TestLoader_Load(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	client := &mockS3{}
+	at := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mock.ExpectExec(regexp.QuoteMeta("CREATE EXTERNAL TABLE IF NOT EXISTS `mydb`.`events` (`id` bigint, " +
+		"`name` string, `at` timestamp) PARTITIONED BY (`dt` string) ROW FORMAT SERDE " +
+		"'org.apache.hadoop.hive.serde2.OpenCSVSerde' LOCATION 's3://bucket/tables/events/' " +
+		"TBLPROPERTIES ('skip.header.line.count'='1')")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SHOW COLUMNS IN `mydb`.`events`")).
+		WillReturnRows(sqlmock.NewRows([]string{"field"}).AddRow("old  ").AddRow("id").AddRow("dt"))
+	mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE `mydb`.`events` ADD COLUMNS (`name` string, `at` timestamp)")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE `mydb`.`events` ADD IF NOT EXISTS PARTITION (`dt` = " +
+		"'2020-01-02') LOCATION 's3://bucket/tables/events/dt=2020-01-02/'")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	uri, err := New(db, client).Load(context.Background(), events, [][]interface{}{
+		{int64(1), "a,b", at},
+		{int64(2), nil, nil},
+	}, &Options{Partition: map[string]string{"dt": "2020-01-02"}})
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+	assert.Equal(t, "bucket", *client.input.Bucket)
+	assert.True(t, strings.HasPrefix(*client.input.Key, "tables/events/dt=2020-01-02/part-"))
+	assert.True(t, strings.HasSuffix(*client.input.Key, ".csv"))
+	assert.Equal(t, "s3://bucket/"+*client.input.Key, uri)
+	// existing columns keep their order, and the ones missing from the data are empty
+	assert.Equal(t, "old,id,name,at\n,1,\"a,b\",1577934245000\n,2,,\n", string(client.body))
+}
+
+func TestLoader_Load_Parquet(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	client := &mockS3{}
+	table := Table{Database: "mydb", Name: "t", Location: "s3://bucket/t/",
+		Columns: []Column{{Name: "id", Type: "bigint"}}, Format: Parquet}
+
+	mock.ExpectExec(regexp.QuoteMeta("CREATE EXTERNAL TABLE IF NOT EXISTS `mydb`.`t` (`id` bigint) " +
+		"STORED AS PARQUET LOCATION 's3://bucket/t/'")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SHOW COLUMNS").WillReturnRows(sqlmock.NewRows([]string{"field"}).AddRow("id"))
+
+	_, err = New(db, client).Load(context.Background(), table, [][]interface{}{{int64(7)}}, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+	assert.True(t, strings.HasPrefix(*client.input.Key, "t/part-"))
+	type row struct {
+		ID *int64 `parquet:"id,optional"`
+	}
+	result, err := parquet.Read[row](bytes.NewReader(client.body), int64(len(client.body)))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), *result[0].ID)
+}
+
+func TestLoader_Load_Repair(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	client := &mockS3{}
+	mock.ExpectExec("CREATE EXTERNAL TABLE").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SHOW COLUMNS").WillReturnRows(sqlmock.NewRows([]string{"field"}).
+		AddRow("id").AddRow("name").AddRow("at").AddRow("dt"))
+	mock.ExpectExec(regexp.QuoteMeta("MSCK REPAIR TABLE `mydb`.`events`")).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	_, err = New(db, client).Load(context.Background(), events, nil,
+		&Options{Partition: map[string]string{"dt": "a b"}, Repair: true})
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+	assert.True(t, strings.HasPrefix(*client.input.Key, "tables/events/dt=a b/part-"))
+}
+
+func TestLoader_Load_Errors(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	client := &mockS3{}
+	l := New(db, client)
+	ctx := context.Background()
+
+	bad := events
+	bad.Location = "bucket/prefix"
+	_, err = l.Load(ctx, bad, nil, nil)
+	assert.Equal(t, ErrInvalidLocation, err)
+	bad = events
+	bad.Format = "orc"
+	_, err = l.Load(ctx, bad, nil, nil)
+	assert.Equal(t, ErrInvalidTable, err)
+	_, err = l.Load(ctx, events, nil, nil)
+	assert.True(t, errors.Is(err, ErrMissingPartition))
+
+	opts := &Options{Partition: map[string]string{"dt": "x"}}
+	mock.ExpectExec("CREATE EXTERNAL TABLE").WillReturnError(errors.New("denied"))
+	_, err = l.Load(ctx, events, nil, opts)
+	assert.EqualError(t, err, "denied")
+
+	mock.ExpectExec("CREATE EXTERNAL TABLE").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SHOW COLUMNS").WillReturnRows(sqlmock.NewRows([]string{"field"}).
+		AddRow("id").AddRow("name").AddRow("at"))
+	_, err = l.Load(ctx, events, [][]interface{}{{int64(1)}}, opts)
+	assert.NotNil(t, err)
+
+	mock.ExpectExec("CREATE EXTERNAL TABLE").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SHOW COLUMNS").WillReturnRows(sqlmock.NewRows([]string{"field"}).
+		AddRow("id").AddRow("name").AddRow("at"))
+	client.err = errors.New("no such bucket")
+	_, err = l.Load(ctx, events, nil, opts)
+	assert.Equal(t, client.err, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestCSVValue(t *testing.T) {
+	day := time.Date(2020, 1, 2, 23, 0, 0, 0, time.UTC)
+	assert.Equal(t, "18263", csvValue(day, "date"))
+	assert.Equal(t, "1578006000000", csvValue(day, "timestamp"))
+	assert.Equal(t, "1.5", csvValue(1.5, "double"))
+	assert.Equal(t, "true", csvValue(true, "boolean"))
+	assert.Equal(t, "ab", csvValue([]byte("ab"), "string"))
+	assert.Equal(t, "", csvValue(nil, "string"))
+}
@@ -0,0 +1,389 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package unload is a drv.ResultTransport executing SELECT queries as UNLOAD statements, which write their results
+// to S3 as Parquet files, and reading the rows from these files. For huge results, it is much faster than paging
+// through GetQueryResults, and it keeps the types of the columns:
+//
+//	transport, _ := unload.New(s3.NewFromConfig(awsCfg), "s3://bucket/unload/")
+//	drv.RegisterResultTransport("unload", transport)
+//	conf.SetResultTransport("unload")
+package unload
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/parquet-go/parquet-go"
+	drv "github.com/prequel-co/athenadriver/go"
+)
+
+// PageSize is the number of rows in a page read from the Parquet files, the same as GetQueryResults.
+const PageSize = 1000
+
+// ErrInvalidLocation is returned when the location results are unloaded to is not like s3://bucket/prefix/.
+var ErrInvalidLocation = errors.New("unload location must be like s3://bucket/prefix/")
+
+// S3GetObjectAPI is the part of the S3 client used by Transport.
+type S3GetObjectAPI interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// Transport is a drv.ResultTransport and drv.QueryRewriter unloading the results of the queries starting with
+// SELECT or WITH to a new directory under its location, and reading them from there. The other statements are
+// executed as they are, and their results are paged through GetQueryResults.
+//
+// Columns of arrays, maps and rows are not supported. The files are read one at a time, each of them in memory.
+type Transport struct {
+	client   S3GetObjectAPI
+	location string
+}
+
+var (
+	_ drv.ResultTransport = (*Transport)(nil)
+	_ drv.QueryRewriter   = (*Transport)(nil)
+)
+
+// New is to create a Transport unloading query results under a location like s3://bucket/prefix/, which the
+// workgroup must be able to write to, and client to read from. Nothing deletes the results, so the location
+// should have an S3 lifecycle rule expiring them.
+func New(client S3GetObjectAPI, location string) (*Transport, error) {
+	bucket, _, _ := strings.Cut(strings.TrimPrefix(location, "s3://"), "/")
+	if !strings.HasPrefix(location, "s3://") || bucket == "" {
+		return nil, ErrInvalidLocation
+	}
+	if !strings.HasSuffix(location, "/") {
+		location += "/"
+	}
+	return &Transport{client: client, location: location}, nil
+}
+
+// RewriteQuery is to rewrite a query starting with SELECT or WITH as an UNLOAD to a new directory.
+func (t *Transport) RewriteQuery(query string) (string, bool) {
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+	head := strings.ToUpper(strings.TrimLeft(query, "( \t\r\n"))
+	if !hasKeyword(head, "SELECT") && !hasKeyword(head, "WITH") {
+		return query, false
+	}
+	dir := make([]byte, 16)
+	if _, err := rand.Read(dir); err != nil {
+		return query, false
+	}
+	// The query is closed on its own line, in case it ends with a comment.
+	return fmt.Sprintf("UNLOAD (%s\n) TO '%s%s/' WITH (format = 'PARQUET')", query, t.location,
+		hex.EncodeToString(dir)), true
+}
+
+// hasKeyword returns whether s starts with keyword as a whole word.
+func hasKeyword(s string, keyword string) bool {
+	if !strings.HasPrefix(s, keyword) {
+		return false
+	}
+	rest := s[len(keyword):]
+	return rest == "" || strings.ContainsRune(" \t\r\n(", rune(rest[0]))
+}
+
+// Open is to open the Parquet files of a query rewritten by the Transport, listed by the data manifest of its query
+// execution. It returns nil pages for the other queries.
+func (t *Transport) Open(ctx context.Context, queryExecution *athenatypes.QueryExecution,
+	_ *athena.GetQueryResultsOutput) (drv.ResultPages, error) {
+	if queryExecution == nil || !strings.Contains(aws.ToString(queryExecution.Query), ") TO '"+t.location) ||
+		queryExecution.Statistics == nil || queryExecution.Statistics.DataManifestLocation == nil {
+		return nil, nil
+	}
+	manifest, err := t.get(ctx, *queryExecution.Statistics.DataManifestLocation)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the data manifest of query %s: %w",
+			aws.ToString(queryExecution.QueryExecutionId), err)
+	}
+	p := &pages{ctx: ctx, transport: t, rows: make([]parquet.Row, PageSize)}
+	for _, file := range strings.Split(string(manifest), "\n") {
+		if file = strings.TrimSpace(file); file != "" {
+			p.files = append(p.files, file)
+		}
+	}
+	// The first file is opened right away for the columns, which are the same in every file.
+	if err := p.openNextFile(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// get is to read an object at a location like s3://bucket/key.
+func (t *Transport) get(ctx context.Context, location string) ([]byte, error) {
+	bucket, key, ok := strings.Cut(strings.TrimPrefix(location, "s3://"), "/")
+	if !strings.HasPrefix(location, "s3://") || !ok || bucket == "" || key == "" {
+		return nil, fmt.Errorf("invalid S3 location %s", location)
+	}
+	obj, err := t.client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Body.Close()
+	return io.ReadAll(obj.Body)
+}
+
+// pages is the drv.ResultPages reading the rows of the Parquet files of a query result in order.
+type pages struct {
+	ctx       context.Context
+	transport *Transport
+	files     []string
+	reader    *parquet.Reader
+	columns   []column
+	metadata  *athenatypes.ResultSetMetadata
+	rows      []parquet.Row
+}
+
+// openNextFile is to open the next file, if there is one left.
+func (p *pages) openNextFile() error {
+	if len(p.files) == 0 {
+		if p.metadata == nil {
+			p.metadata = &athenatypes.ResultSetMetadata{}
+		}
+		return nil
+	}
+	file := p.files[0]
+	p.files = p.files[1:]
+	b, err := p.transport.get(p.ctx, file)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", file, err)
+	}
+	f, err := parquet.OpenFile(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", file, err)
+	}
+	if p.metadata == nil {
+		if p.columns, err = columnsOf(f.Schema()); err != nil {
+			return err
+		}
+		p.metadata = &athenatypes.ResultSetMetadata{ColumnInfo: make([]athenatypes.ColumnInfo, len(p.columns))}
+		for i, c := range p.columns {
+			p.metadata.ColumnInfo[i] = c.info
+		}
+	}
+	p.reader = parquet.NewReader(f)
+	return nil
+}
+
+// Next is to get the next page of rows.
+func (p *pages) Next() (*athena.GetQueryResultsOutput, error) {
+	rows := make([]athenatypes.Row, 0, PageSize)
+	for len(rows) < PageSize {
+		if p.reader == nil {
+			if len(p.files) == 0 {
+				return p.page(rows, nil), nil
+			}
+			if err := p.openNextFile(); err != nil {
+				return nil, err
+			}
+		}
+		n, err := p.reader.ReadRows(p.rows[:PageSize-len(rows)])
+		for _, row := range p.rows[:n] {
+			rows = append(rows, p.convert(row))
+		}
+		if err == io.EOF {
+			_ = p.reader.Close()
+			p.reader = nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	// The token is only checked to be non-empty, the pages are read in order anyway.
+	return p.page(rows, aws.String("unload")), nil
+}
+
+func (p *pages) page(rows []athenatypes.Row, nextToken *string) *athena.GetQueryResultsOutput {
+	return &athena.GetQueryResultsOutput{
+		NextToken: nextToken,
+		ResultSet: &athenatypes.ResultSet{ResultSetMetadata: p.metadata, Rows: rows},
+	}
+}
+
+// convert is to convert a row of Parquet values to the text GetQueryResults would return for them.
+func (p *pages) convert(row parquet.Row) athenatypes.Row {
+	data := make([]athenatypes.Datum, len(p.columns))
+	for _, v := range row {
+		if i := v.Column(); i < len(data) && !v.IsNull() {
+			s := p.columns[i].format(v)
+			data[i].VarCharValue = &s
+		}
+	}
+	return athenatypes.Row{Data: data}
+}
+
+// Close is to stop reading the files.
+func (p *pages) Close() error {
+	p.files = nil
+	if p.reader == nil {
+		return nil
+	}
+	err := p.reader.Close()
+	p.reader = nil
+	return err
+}
+
+// column is how a Parquet column is read.
+type column struct {
+	info   athenatypes.ColumnInfo
+	format func(v parquet.Value) string
+}
+
+// columnsOf is to get how the columns of a Parquet schema are read. Only flat schemas are supported.
+func columnsOf(schema *parquet.Schema) ([]column, error) {
+	fields := schema.Fields()
+	columns := make([]column, len(fields))
+	for i, field := range fields {
+		if !field.Leaf() || field.Repeated() {
+			return nil, fmt.Errorf("column %s is an array, a map or a row, which cannot be unloaded", field.Name())
+		}
+		athenaType, precision, scale, format := typeOf(field.Type())
+		columns[i] = column{
+			info: athenatypes.ColumnInfo{
+				Name:          aws.String(field.Name()),
+				Label:         aws.String(field.Name()),
+				Type:          aws.String(athenaType),
+				Precision:     precision,
+				Scale:         scale,
+				Nullable:      athenatypes.ColumnNullableNullable,
+				CaseSensitive: athenaType == "varchar",
+			},
+			format: format,
+		}
+	}
+	return columns, nil
+}
+
+// typeOf is to get the Athena type of a Parquet type, and how its values are formatted as text.
+func typeOf(t parquet.Type) (string, int32, int32, func(parquet.Value) string) {
+	lt := t.LogicalType()
+	switch {
+	case lt == nil:
+	case lt.UTF8 != nil, lt.Enum != nil, lt.Json != nil:
+		return "varchar", 0, 0, func(v parquet.Value) string { return string(v.ByteArray()) }
+	case lt.Date != nil:
+		return "date", 0, 0, func(v parquet.Value) string {
+			return time.Unix(int64(v.Int32())*86400, 0).UTC().Format("2006-01-02")
+		}
+	case lt.Timestamp != nil:
+		unit := lt.Timestamp.Unit
+		layout := "2006-01-02 15:04:05.000000"
+		if unit.Millis != nil {
+			layout = "2006-01-02 15:04:05.000"
+		}
+		return "timestamp", 0, 0, func(v parquet.Value) string {
+			switch {
+			case unit.Millis != nil:
+				return time.UnixMilli(v.Int64()).UTC().Format(layout)
+			case unit.Micros != nil:
+				return time.UnixMicro(v.Int64()).UTC().Format(layout)
+			}
+			return time.Unix(0, v.Int64()).UTC().Format(layout)
+		}
+	case lt.Decimal != nil:
+		scale := lt.Decimal.Scale
+		return "decimal", lt.Decimal.Precision, scale, func(v parquet.Value) string {
+			return formatDecimal(v, scale)
+		}
+	case lt.Integer != nil:
+		switch lt.Integer.BitWidth {
+		case 8:
+			return "tinyint", 3, 0, formatInt
+		case 16:
+			return "smallint", 5, 0, formatInt
+		case 32:
+			return "integer", 10, 0, formatInt
+		}
+		return "bigint", 19, 0, formatInt
+	}
+	switch t.Kind() {
+	case parquet.Boolean:
+		return "boolean", 0, 0, func(v parquet.Value) string { return strconv.FormatBool(v.Boolean()) }
+	case parquet.Int32:
+		return "integer", 10, 0, formatInt
+	case parquet.Int64:
+		return "bigint", 19, 0, formatInt
+	case parquet.Int96:
+		// Hive writes timestamps as the nanoseconds of the day and the Julian day.
+		return "timestamp", 0, 0, func(v parquet.Value) string {
+			i := v.Int96()
+			days := int64(i[2]) - 2440588
+			nanos := int64(i[1])<<32 | int64(i[0])
+			return time.Unix(days*86400, nanos).UTC().Format("2006-01-02 15:04:05.000")
+		}
+	case parquet.Float:
+		return "float", 0, 0, func(v parquet.Value) string {
+			return strconv.FormatFloat(float64(v.Float()), 'g', -1, 32)
+		}
+	case parquet.Double:
+		return "double", 0, 0, func(v parquet.Value) string {
+			return strconv.FormatFloat(v.Double(), 'g', -1, 64)
+		}
+	}
+	return "varbinary", 0, 0, func(v parquet.Value) string { return hex.EncodeToString(v.ByteArray()) }
+}
+
+func formatInt(v parquet.Value) string {
+	if v.Kind() == parquet.Int32 {
+		return strconv.FormatInt(int64(v.Int32()), 10)
+	}
+	return strconv.FormatInt(v.Int64(), 10)
+}
+
+// formatDecimal is to format the unscaled value of a decimal, an integer or big-endian two's complement bytes.
+func formatDecimal(v parquet.Value, scale int32) string {
+	unscaled := new(big.Int)
+	switch v.Kind() {
+	case parquet.Int32:
+		unscaled.SetInt64(int64(v.Int32()))
+	case parquet.Int64:
+		unscaled.SetInt64(v.Int64())
+	default:
+		b := v.ByteArray()
+		unscaled.SetBytes(b)
+		if len(b) > 0 && b[0]&0x80 != 0 {
+			unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(len(b))*8))
+		}
+	}
+	s := unscaled.String()
+	if scale <= 0 {
+		return s
+	}
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	if pad := int(scale) + 1 - len(s); pad > 0 {
+		s = strings.Repeat("0", pad) + s
+	}
+	return sign + s[:len(s)-int(scale)] + "." + s[len(s)-int(scale):]
+}
@@ -0,0 +1,220 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package unload
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockS3 struct {
+	objects map[string][]byte
+}
+
+func (m *mockS3) GetObject(_ context.Context, params *s3.GetObjectInput,
+	_ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	b, ok := m.objects[*params.Bucket+"/"+*params.Key]
+	if !ok {
+		return nil, errors.New("no such key")
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(b))}, nil
+}
+
+type testRow struct {
+	ID    int64     `parquet:"id"`
+	Name  *string   `parquet:"name,optional"`
+	Day   int32     `parquet:"day,date"`
+	TS    time.Time `parquet:"ts,timestamp(millisecond)"`
+	Price int64     `parquet:"price,decimal(2:10)"`
+	OK    bool      `parquet:"ok"`
+	Score float64   `parquet:"score"`
+	Raw   []byte    `parquet:"raw"`
+}
+
+func writeParquet(t *testing.T, rows []testRow) []byte {
+	var buf bytes.Buffer
+	w := parquet.NewGenericWriter[testRow](&buf)
+	_, err := w.Write(rows)
+	assert.Nil(t, err)
+	assert.Nil(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestNew(t *testing.T) {
+	tr, err := New(&mockS3{}, "s3://bucket/unload")
+	assert.Nil(t, err)
+	assert.Equal(t, "s3://bucket/unload/", tr.location)
+	for _, location := range []string{"", "bucket/unload/", "s3:///unload/"} {
+		_, err = New(&mockS3{}, location)
+		assert.Equal(t, ErrInvalidLocation, err, location)
+	}
+}
+
+func TestTransport_RewriteQuery(t *testing.T) {
+	tr, _ := New(&mockS3{}, "s3://bucket/unload/")
+	for _, query := range []string{"SELECT 1", " select * from t;", "WITH t AS (SELECT 1) SELECT * FROM t",
+		"(SELECT 1) UNION (SELECT 2)", "SELECT 1 -- one"} {
+		rewritten, ok := tr.RewriteQuery(query)
+		assert.True(t, ok, query)
+		assert.True(t, strings.HasPrefix(rewritten, "UNLOAD ("), rewritten)
+		assert.Regexp(t, `\n\) TO 's3://bucket/unload/[0-9a-f]{32}/' WITH \(format = 'PARQUET'\)$`, rewritten)
+		assert.NotContains(t, rewritten, ";")
+	}
+	a, _ := tr.RewriteQuery("SELECT 1")
+	b, _ := tr.RewriteQuery("SELECT 1")
+	assert.NotEqual(t, a, b)
+	for _, query := range []string{"INSERT INTO t SELECT 1", "SHOW TABLES", "SELECTION", "EXPLAIN SELECT 1"} {
+		rewritten, ok := tr.RewriteQuery(query)
+		assert.False(t, ok, query)
+		assert.Equal(t, query, rewritten)
+	}
+}
+
+func TestTransport_Open(t *testing.T) {
+	name := "uber"
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 6e6, time.UTC)
+	var second []testRow
+	for i := 0; i < PageSize; i++ {
+		second = append(second, testRow{ID: int64(i + 2), TS: ts})
+	}
+	client := &mockS3{objects: map[string][]byte{
+		"bucket/results/qid-manifest.csv": []byte("s3://bucket/unload/dir/1.parquet\ns3://bucket/unload/dir/2.parquet\n"),
+		"bucket/unload/dir/1.parquet": writeParquet(t, []testRow{
+			{ID: 1, Name: &name, Day: 19724, TS: ts, Price: -1234, OK: true, Score: 0.5, Raw: []byte{1, 255}},
+		}),
+		"bucket/unload/dir/2.parquet": writeParquet(t, second),
+	}}
+	tr, _ := New(client, "s3://bucket/unload/")
+	query, _ := tr.RewriteQuery("SELECT * FROM t")
+	qe := &athenatypes.QueryExecution{
+		QueryExecutionId: aws.String("qid"),
+		Query:            aws.String(query),
+		Statistics: &athenatypes.QueryExecutionStatistics{
+			DataManifestLocation: aws.String("s3://bucket/results/qid-manifest.csv"),
+		},
+	}
+	pages, err := tr.Open(context.Background(), qe, nil)
+	assert.Nil(t, err)
+
+	page, err := pages.Next()
+	assert.Nil(t, err)
+	assert.NotNil(t, page.NextToken)
+	columns := page.ResultSet.ResultSetMetadata.ColumnInfo
+	var types []string
+	for _, c := range columns {
+		types = append(types, *c.Name+" "+*c.Type)
+	}
+	assert.Equal(t, []string{"id bigint", "name varchar", "day date", "ts timestamp",
+		"price decimal", "ok boolean", "score double", "raw varbinary"}, types)
+	assert.Equal(t, int32(2), columns[4].Scale)
+	assert.Equal(t, int32(10), columns[4].Precision)
+	assert.Len(t, page.ResultSet.Rows, PageSize)
+	var values []string
+	for _, d := range page.ResultSet.Rows[0].Data {
+		values = append(values, aws.ToString(d.VarCharValue))
+	}
+	assert.Equal(t, []string{"1", "uber", "2024-01-02", "2024-01-02 03:04:05.006", "-12.34", "true", "0.5",
+		"01ff"}, values)
+	assert.Nil(t, page.ResultSet.Rows[1].Data[1].VarCharValue)
+	assert.Equal(t, "2", *page.ResultSet.Rows[1].Data[0].VarCharValue)
+
+	page, err = pages.Next()
+	assert.Nil(t, err)
+	assert.Nil(t, page.NextToken)
+	assert.Len(t, page.ResultSet.Rows, 1)
+	assert.Equal(t, strconv.Itoa(PageSize+1), *page.ResultSet.Rows[0].Data[0].VarCharValue)
+	assert.Nil(t, pages.Close())
+
+	// the results of other queries are read with GetQueryResults
+	qe.Query = aws.String("SELECT * FROM t")
+	pages, err = tr.Open(context.Background(), qe, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, pages)
+
+	qe.Query = aws.String(query)
+	qe.Statistics.DataManifestLocation = aws.String("s3://bucket/results/missing-manifest.csv")
+	_, err = tr.Open(context.Background(), qe, nil)
+	assert.NotNil(t, err)
+}
+
+func TestTransport_OpenEmpty(t *testing.T) {
+	client := &mockS3{objects: map[string][]byte{"bucket/results/qid-manifest.csv": {}}}
+	tr, _ := New(client, "s3://bucket/unload/")
+	query, _ := tr.RewriteQuery("SELECT 1")
+	pages, err := tr.Open(context.Background(), &athenatypes.QueryExecution{
+		Query: aws.String(query),
+		Statistics: &athenatypes.QueryExecutionStatistics{
+			DataManifestLocation: aws.String("s3://bucket/results/qid-manifest.csv"),
+		},
+	}, nil)
+	assert.Nil(t, err)
+	page, err := pages.Next()
+	assert.Nil(t, err)
+	assert.Nil(t, page.NextToken)
+	assert.Empty(t, page.ResultSet.Rows)
+}
+
+func TestTypeOf(t *testing.T) {
+	for node, athenaType := range map[parquet.Node]string{
+		parquet.Int(8):                                 "tinyint",
+		parquet.Int(16):                                "smallint",
+		parquet.Int(32):                                "integer",
+		parquet.Leaf(parquet.Int32Type):                "integer",
+		parquet.Leaf(parquet.FloatType):                "float",
+		parquet.Leaf(parquet.Int96Type):                "timestamp",
+		parquet.Timestamp(parquet.Microsecond):         "timestamp",
+		parquet.JSON():                                 "varchar",
+		parquet.Leaf(parquet.FixedLenByteArrayType(4)): "varbinary",
+	} {
+		got, _, _, _ := typeOf(node.Type())
+		assert.Equal(t, athenaType, got, node.Type().String())
+	}
+	_, _, _, format := typeOf(parquet.Timestamp(parquet.Microsecond).Type())
+	assert.Equal(t, "1970-01-01 00:00:01.000002", format(parquet.Int64Value(1000002)))
+	_, _, _, format = typeOf(parquet.Leaf(parquet.Int96Type).Type())
+	// 2440589 is the Julian day of 1970-01-02
+	assert.Equal(t, "1970-01-02 00:00:01.500", format(parquet.Int96Value([3]uint32{1500000000, 0, 2440589})))
+}
+
+func TestColumnsOf_Nested(t *testing.T) {
+	_, err := columnsOf(parquet.NewSchema("t", parquet.Group{"a": parquet.List(parquet.Int(32))}))
+	assert.NotNil(t, err)
+}
+
+func TestFormatDecimal(t *testing.T) {
+	assert.Equal(t, "0.05", formatDecimal(parquet.Int64Value(5), 2))
+	assert.Equal(t, "-0.05", formatDecimal(parquet.Int32Value(-5), 2))
+	assert.Equal(t, "12", formatDecimal(parquet.Int64Value(12), 0))
+	assert.Equal(t, "-1.28", formatDecimal(parquet.FixedLenByteArrayValue([]byte{0xff, 0x80}), 2))
+	assert.Equal(t, "2.56", formatDecimal(parquet.FixedLenByteArrayValue([]byte{0x01, 0x00}), 2))
+}